@@ -0,0 +1,52 @@
+package binding
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// ClaimConfirmation is the confirmation claim carrying proof-of-possession information (RFC 7800).
+	ClaimConfirmation = "cnf"
+	// ConfirmationX5tS256 is the cnf member holding a certificate thumbprint (RFC 8705 §3.1).
+	ConfirmationX5tS256 = "x5t#S256"
+)
+
+// CertificateThumbprint returns the base64url-encoded SHA-256 thumbprint of the first
+// certificate in pemData, as used by the cnf x5t#S256 member.
+func CertificateThumbprint(pemData []byte) (string, error) {
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			return "", fmt.Errorf("no PEM certificate found")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return "", fmt.Errorf("parsing certificate: %w", err)
+		}
+		sum := sha256.Sum256(block.Bytes)
+		return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+	}
+}
+
+// CheckCertificateBinding compares the token's cnf x5t#S256 member with the thumbprint
+// of the client certificate. It returns the thumbprint found in the token and whether it matches.
+func CheckCertificateBinding(claims jwt.MapClaims, thumbprint string) (string, bool, error) {
+	cnf, ok := claims[ClaimConfirmation].(map[string]interface{})
+	if !ok {
+		return "", false, fmt.Errorf("token has no %s claim; it is not certificate-bound", ClaimConfirmation)
+	}
+	bound, ok := cnf[ConfirmationX5tS256].(string)
+	if !ok {
+		return "", false, fmt.Errorf("%s claim has no %s member", ClaimConfirmation, ConfirmationX5tS256)
+	}
+	return bound, bound == thumbprint, nil
+}
@@ -0,0 +1,147 @@
+package checkpoint
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"jwtdecode/utils"
+)
+
+// version identifies the checkpoint file format.
+const version = 1
+
+// header is the first line of a checkpoint file.
+type header struct {
+	Version   int    `json:"version"`
+	TokenList string `json:"tokenList"`
+}
+
+// record is one processed token in a checkpoint file.
+type record struct {
+	Line   int             `json:"line"`   // Line of the token in the list
+	Offset int64           `json:"offset"` // Byte offset just past that line
+	Result json.RawMessage `json:"result"`
+}
+
+// State is the progress recorded in a checkpoint file.
+type State struct {
+	Line    int               // Line of the last processed token; zero for a new run
+	Offset  int64             // Byte offset at which the list continues
+	Results []json.RawMessage // Results of the processed tokens, in list order
+	size    int64             // Length of the valid part of the checkpoint file
+}
+
+// Path returns the default checkpoint file of an output file.
+func Path(outputFile string) string {
+	return outputFile + ".checkpoint"
+}
+
+// Load reads the progress recorded in a checkpoint file for the given token list.
+// A missing checkpoint file yields an empty state, so the run starts from the beginning.
+// A truncated last record, left by a run that was killed while writing it, is ignored.
+func Load(path, tokenList string) (State, error) {
+	var state State
+	f, err := utils.OpenFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("opening checkpoint: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 256*1024*1024)
+	if !scanner.Scan() {
+		return state, scanner.Err()
+	}
+	var h header
+	if err := json.Unmarshal(scanner.Bytes(), &h); err != nil || h.Version != version {
+		return state, fmt.Errorf("checkpoint %q is not a version %d checkpoint file", path, version)
+	}
+	if h.TokenList != tokenList {
+		return state, fmt.Errorf("checkpoint %q belongs to token list %q, not %q", path, h.TokenList, tokenList)
+	}
+	state.size = int64(len(scanner.Bytes())) + 1
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			break
+		}
+		state.Line = r.Line
+		state.Offset = r.Offset
+		state.Results = append(state.Results, r.Result)
+		state.size += int64(len(scanner.Bytes())) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return state, fmt.Errorf("reading checkpoint: %w", err)
+	}
+	return state, nil
+}
+
+// Writer appends processed tokens to a checkpoint file.
+type Writer struct {
+	file *os.File
+	enc  *json.Encoder
+}
+
+// Open opens the checkpoint file for the token list. Without recorded progress, a new
+// file is created; otherwise the file is continued after the last valid record of state.
+func Open(path, tokenList string, state State) (*Writer, error) {
+	if state.size == 0 {
+		f, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("creating checkpoint: %w", err)
+		}
+		w := &Writer{file: f, enc: json.NewEncoder(f)}
+		if err := w.enc.Encode(header{Version: version, TokenList: tokenList}); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("writing checkpoint: %w", err)
+		}
+		return w, nil
+	}
+	f, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening checkpoint: %w", err)
+	}
+	// Drop a record truncated by the interrupted run
+	if err := f.Truncate(state.size); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("truncating checkpoint: %w", err)
+	}
+	return &Writer{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Record appends the result of the token on the given line, with the offset at which
+// the list continues. Each record is written with a single system call, so a killed run
+// leaves at most the last record truncated.
+func (w *Writer) Record(line int, offset int64, result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("encoding checkpoint record: %w", err)
+	}
+	if err := w.enc.Encode(record{Line: line, Offset: offset, Result: data}); err != nil {
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Close closes the checkpoint file.
+func (w *Writer) Close() error {
+	return w.file.Close()
+}
+
+// Remove deletes a checkpoint file after a completed run. A missing file is not an error.
+func Remove(path string) error {
+	if err := os.Remove(filepath.Clean(path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package claimdoc
+
+import (
+	"sort"
+)
+
+// Claim documents a registered JWT claim.
+type Claim struct {
+	Title   string // Registered name of the claim
+	Meaning string // What the claim asserts
+	Type    string // Expected JSON type of the value
+	Spec    string // Specification and section defining the claim
+}
+
+// Value types shared by several claims.
+const (
+	typeNumericDate = "NumericDate (seconds since the Unix epoch)"
+	typeString      = "string"
+	typeBoolean     = "boolean"
+	typeURL         = "string (URL)"
+)
+
+// claims maps claim names to their documentation, following the IANA JSON Web Token
+// Claims registry.
+var claims = map[string]Claim{
+	// RFC 7519 registered claims
+	"iss": {Title: "Issuer", Type: "StringOrURI", Spec: "RFC 7519 §4.1.1",
+		Meaning: "Principal that issued the token; compared to the expected issuer before the token is trusted"},
+	"sub": {Title: "Subject", Type: "StringOrURI", Spec: "RFC 7519 §4.1.2",
+		Meaning: "Principal the token is about, unique within the issuer (or globally)"},
+	"aud": {Title: "Audience", Type: "StringOrURI, or array of StringOrURI", Spec: "RFC 7519 §4.1.3",
+		Meaning: "Recipients the token is intended for; a recipient must reject a token that does not name it"},
+	"exp": {Title: "Expiration Time", Type: typeNumericDate, Spec: "RFC 7519 §4.1.4",
+		Meaning: "Time on or after which the token must not be accepted"},
+	"nbf": {Title: "Not Before", Type: typeNumericDate, Spec: "RFC 7519 §4.1.5",
+		Meaning: "Time before which the token must not be accepted"},
+	"iat": {Title: "Issued At", Type: typeNumericDate, Spec: "RFC 7519 §4.1.6",
+		Meaning: "Time at which the token was issued, giving its age"},
+	"jti": {Title: "JWT ID", Type: typeString, Spec: "RFC 7519 §4.1.7",
+		Meaning: "Unique identifier of the token, used to prevent replay"},
+
+	// OpenID Connect ID token claims
+	"auth_time": {Title: "Authentication Time", Type: typeNumericDate, Spec: "OpenID Connect Core 1.0 §2",
+		Meaning: "Time at which the end user authenticated"},
+	"nonce": {Title: "Nonce", Type: typeString, Spec: "OpenID Connect Core 1.0 §2",
+		Meaning: "Value from the authentication request, binding the ID token to the client session against replay"},
+	"acr": {Title: "Authentication Context Class Reference", Type: typeString, Spec: "OpenID Connect Core 1.0 §2",
+		Meaning: "Authentication context class (assurance level) the authentication satisfied"},
+	"amr": {Title: "Authentication Methods References", Type: "array of strings", Spec: "OpenID Connect Core 1.0 §2, RFC 8176",
+		Meaning: "Authentication methods used, e.g. pwd, otp, mfa, hwk"},
+	"azp": {Title: "Authorized Party", Type: typeString, Spec: "OpenID Connect Core 1.0 §2",
+		Meaning: "Client ID of the party the ID token was issued to"},
+	"at_hash": {Title: "Access Token Hash", Type: "string (base64url)", Spec: "OpenID Connect Core 1.0 §3.1.3.6",
+		Meaning: "Left half of the hash of the access token issued with the ID token, binding them together"},
+	"c_hash": {Title: "Code Hash", Type: "string (base64url)", Spec: "OpenID Connect Core 1.0 §3.3.2.11",
+		Meaning: "Left half of the hash of the authorization code issued with the ID token"},
+	"s_hash": {Title: "State Hash", Type: "string (base64url)", Spec: "Financial-grade API Part 2 §5.1",
+		Meaning: "Left half of the hash of the state value, protecting it against tampering"},
+	"sid": {Title: "Session ID", Type: typeString, Spec: "OpenID Connect Front-Channel Logout 1.0 §3",
+		Meaning: "Identifier of the end user's session at the issuer, used to target logout"},
+	"sub_jwk": {Title: "Public Key for Self-Issued ID Tokens", Type: "JSON object (JWK)", Spec: "OpenID Connect Core 1.0 §7.4",
+		Meaning: "Public key that verifies the signature of a self-issued ID token"},
+
+	// OpenID Connect standard claims
+	"name": {Title: "Full Name", Type: typeString, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "End user's full name in displayable form"},
+	"given_name": {Title: "Given Name", Type: typeString, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "Given name(s) or first name(s) of the end user"},
+	"family_name": {Title: "Family Name", Type: typeString, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "Surname(s) or last name(s) of the end user"},
+	"middle_name": {Title: "Middle Name", Type: typeString, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "Middle name(s) of the end user"},
+	"nickname": {Title: "Casual Name", Type: typeString, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "Casual name of the end user"},
+	"preferred_username": {Title: "Preferred Username", Type: typeString, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "Shorthand name the end user wishes to be referred to by; not guaranteed to be unique or stable"},
+	"profile": {Title: "Profile Page URL", Type: typeURL, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "URL of the end user's profile page"},
+	"picture": {Title: "Profile Picture URL", Type: typeURL, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "URL of the end user's profile picture"},
+	"website": {Title: "Web Page or Blog URL", Type: typeURL, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "URL of the end user's web page or blog"},
+	"email": {Title: "Preferred Email Address", Type: typeString, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "End user's preferred email address; not guaranteed to be unique or verified"},
+	"email_verified": {Title: "Email Verified", Type: typeBoolean, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "Whether the issuer verified that the end user controls the email address"},
+	"gender": {Title: "Gender", Type: typeString, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "End user's gender"},
+	"birthdate": {Title: "Birthday", Type: "string (YYYY-MM-DD, or YYYY)", Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "End user's birthday"},
+	"zoneinfo": {Title: "Time Zone", Type: "string (IANA time zone)", Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "End user's time zone, e.g. Europe/Paris"},
+	"locale": {Title: "Locale", Type: "string (BCP 47 language tag)", Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "End user's locale, e.g. en-US"},
+	"phone_number": {Title: "Preferred Telephone Number", Type: "string (E.164 recommended)", Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "End user's preferred telephone number"},
+	"phone_number_verified": {Title: "Phone Number Verified", Type: typeBoolean, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "Whether the issuer verified that the end user controls the phone number"},
+	"address": {Title: "Preferred Postal Address", Type: "JSON object", Spec: "OpenID Connect Core 1.0 §5.1.1",
+		Meaning: "End user's preferred postal address"},
+	"updated_at": {Title: "Time Last Updated", Type: typeNumericDate, Spec: "OpenID Connect Core 1.0 §5.1",
+		Meaning: "Time the end user's information was last updated"},
+
+	// OAuth 2.0 claims
+	"client_id": {Title: "Client Identifier", Type: typeString, Spec: "RFC 8693 §4.3",
+		Meaning: "OAuth client the token was issued to"},
+	"scope": {Title: "Scope Values", Type: "string (space-separated)", Spec: "RFC 8693 §4.2",
+		Meaning: "Scopes granted to the client by the token"},
+	"act": {Title: "Actor", Type: "JSON object", Spec: "RFC 8693 §4.1",
+		Meaning: "Party acting on behalf of the subject (delegation); nested act members record prior actors"},
+	"may_act": {Title: "Authorized Actor", Type: "JSON object", Spec: "RFC 8693 §4.4",
+		Meaning: "Party authorized to act on behalf of the subject"},
+	"cnf": {Title: "Confirmation", Type: "JSON object", Spec: "RFC 7800 §3.1, RFC 8705 §3.1, RFC 9449 §6",
+		Meaning: "Key the presenter must prove possession of (jwk, jkt, or x5t#S256 thumbprint)"},
+	"roles": {Title: "Roles", Type: "array of strings", Spec: "RFC 9068 §2.2.3.1, RFC 7643 §4.1.2",
+		Meaning: "Roles of the subject"},
+	"groups": {Title: "Groups", Type: "array of strings", Spec: "RFC 9068 §2.2.3.1, RFC 7643 §4.1.2",
+		Meaning: "Groups the subject belongs to"},
+	"entitlements": {Title: "Entitlements", Type: "array of strings", Spec: "RFC 9068 §2.2.3.1, RFC 7643 §4.1.2",
+		Meaning: "Entitlements of the subject"},
+	"authorization_details": {Title: "Authorization Details", Type: "array of JSON objects", Spec: "RFC 9396 §9.1",
+		Meaning: "Fine-grained authorization data granted by the token"},
+
+	// DPoP proof claims
+	"htm": {Title: "HTTP Method", Type: typeString, Spec: "RFC 9449 §4.2",
+		Meaning: "HTTP method of the request the DPoP proof is bound to"},
+	"htu": {Title: "HTTP URI", Type: typeURL, Spec: "RFC 9449 §4.2",
+		Meaning: "HTTP URI (without query and fragment) of the request the DPoP proof is bound to"},
+	"ath": {Title: "Access Token Hash", Type: "string (base64url)", Spec: "RFC 9449 §4.2",
+		Meaning: "Hash of the access token presented with the DPoP proof"},
+
+	// Security Event Tokens and Verifiable Credentials
+	"events": {Title: "Security Events", Type: "JSON object", Spec: "RFC 8417 §2.2",
+		Meaning: "Security events the token conveys, keyed by event type URI"},
+	"toe": {Title: "Time of Event", Type: typeNumericDate, Spec: "RFC 8417 §2.2",
+		Meaning: "Time at which the security event occurred"},
+	"txn": {Title: "Transaction Identifier", Type: typeString, Spec: "RFC 8417 §2.2",
+		Meaning: "Identifier correlating security events of the same transaction"},
+	"vc": {Title: "Verifiable Credential", Type: "JSON object", Spec: "W3C Verifiable Credentials Data Model 1.1 §6.3.1",
+		Meaning: "Verifiable credential carried by the token"},
+	"vp": {Title: "Verifiable Presentation", Type: "JSON object", Spec: "W3C Verifiable Credentials Data Model 1.1 §6.3.1",
+		Meaning: "Verifiable presentation carried by the token"},
+}
+
+// Lookup returns the documentation of a registered claim.
+func Lookup(name string) (Claim, bool) {
+	claim, ok := claims[name]
+	return claim, ok
+}
+
+// Names returns the names of the documented claims in sorted order.
+func Names() []string {
+	names := make([]string, 0, len(claims))
+	for name := range claims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,50 @@
+package claimdoc
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"jwtdecode/provider"
+)
+
+// Main runs the explain subcommand with its command-line arguments, printing the
+// documentation of a claim to w.
+func Main(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	providerName := fs.String("provider", "", "Adds the notes of an issuer on the claim ("+strings.Join(provider.Names(), ", ")+")")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: jwtdecode explain [-provider <name>] <claim>")
+	}
+	name := fs.Arg(0)
+
+	var note string
+	if *providerName != "" {
+		p, err := provider.Get(*providerName, provider.Options{})
+		if err != nil {
+			return err
+		}
+		note = provider.ClaimNote(p, name)
+	}
+	claim, registered := Lookup(name)
+	if !registered && note == "" {
+		return fmt.Errorf("no documentation for claim %q", name)
+	}
+
+	if registered {
+		fmt.Fprintf(w, "%s: %s\n", name, claim.Title)
+		fmt.Fprintf(w, "  Meaning: %s\n", claim.Meaning)
+		fmt.Fprintf(w, "  Type:    %s\n", claim.Type)
+		fmt.Fprintf(w, "  Spec:    %s\n", claim.Spec)
+	} else {
+		fmt.Fprintf(w, "%s: not a registered claim\n", name)
+	}
+	if note != "" {
+		fmt.Fprintf(w, "  %s: %s\n", strings.ToLower(*providerName), note)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package claimenv
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/token"
+)
+
+// DefaultPrefix is prepended to the names of the claim environment variables.
+const DefaultPrefix = "JWT_"
+
+// usage describes the command line of the exec subcommand.
+const usage = "usage: jwtdecode exec [-token-file <file> | -token-env | -token-keychain <ref> | -token-ref <ref>] [-claims <list>] [-prefix <prefix>] -- <command> [args...]"
+
+// Main runs the exec subcommand: it decodes the token, exports its claims as environment
+// variables, and runs the command after "--" with them, replacing the jwtdecode process
+// where the platform allows it.
+func Main(args []string) error {
+	fs := flag.NewFlagSet("exec", flag.ContinueOnError)
+	tokenFile := fs.String("token-file", "", "File containing the JWT token")
+	tokenEnv := fs.Bool("token-env", false, "Get the token from the environment variable JWT_TOKEN")
+	tokenKeychain := fs.String("token-keychain", "", "Get the token from the platform secret store, as <service>/<account>")
+	tokenRef := fs.String("token-ref", "", "Get the token from a password manager CLI, e.g. op://vault/item/field")
+	claims := fs.String("claims", "", "Comma-separated claims to export (default: all top-level claims)")
+	prefix := fs.String("prefix", DefaultPrefix, "Prefix of the environment variable names")
+	exportToken := fs.Bool("export-token", false, "Also export the token itself as <prefix>TOKEN")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	command := fs.Args()
+	if len(command) == 0 {
+		return errors.New(usage)
+	}
+
+	var tokenType, tokenValue string
+	sources := 0
+	if *tokenFile != "" {
+		tokenType, tokenValue = "file", *tokenFile
+		sources++
+	}
+	if *tokenEnv {
+		tokenType = "environment"
+		sources++
+	}
+	if *tokenKeychain != "" {
+		tokenType, tokenValue = "keychain", *tokenKeychain
+		sources++
+	}
+	if *tokenRef != "" {
+		tokenType, tokenValue = "reference", *tokenRef
+		sources++
+	}
+	if sources != 1 {
+		return fmt.Errorf("exactly one token source is required (-token-file, -token-env, -token-keychain, or -token-ref)")
+	}
+	jwtToken, err := token.GetToken(tokenType, tokenValue, token.Options{
+		Warn:    func(msg string) { fmt.Fprintf(os.Stderr, "Warning: %s\n", msg) },
+		MaxSize: 1 << 20,
+	})
+	if err != nil {
+		return err
+	}
+
+	var selected []string
+	for _, c := range strings.Split(*claims, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			selected = append(selected, c)
+		}
+	}
+	vars, err := Variables(jwtToken, selected, *prefix)
+	if err != nil {
+		return err
+	}
+	if *exportToken {
+		vars[*prefix+"TOKEN"] = jwtToken
+	}
+
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		return fmt.Errorf("finding command: %w", err)
+	}
+	env := os.Environ()
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+vars[name])
+	}
+	return run(path, command, env)
+}
+
+// Variables decodes a token, without verifying its signature, and returns its claims as
+// environment variables named <prefix><CLAIM>. Only the selected claims are returned, or
+// all top-level claims if none is selected; selected claims missing from the token are skipped.
+func Variables(jwtToken string, selected []string, prefix string) (map[string]string, error) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser(jwt.WithJSONNumber()).ParseUnverified(jwtToken, claims); err != nil {
+		return nil, fmt.Errorf("parsing JWT token: %w", err)
+	}
+	if len(selected) == 0 {
+		for name := range claims {
+			selected = append(selected, name)
+		}
+	}
+	vars := make(map[string]string, len(selected))
+	for _, name := range selected {
+		value, ok := claims[name]
+		if !ok {
+			continue
+		}
+		s, err := format(value)
+		if err != nil {
+			return nil, fmt.Errorf("formatting claim %q: %w", name, err)
+		}
+		vars[VariableName(prefix, name)] = s
+	}
+	return vars, nil
+}
+
+// VariableName returns the environment variable name of a claim: the prefix followed by
+// the claim name in upper case, with characters other than letters and digits replaced by "_".
+func VariableName(prefix, claim string) string {
+	return prefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		default:
+			return '_'
+		}
+	}, claim)
+}
+
+// format renders a claim value for the environment: strings and numbers as they are,
+// lists of strings separated by spaces (like the scope claim), and other values as JSON.
+func format(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return formatJSON(v)
+			}
+			items[i] = s
+		}
+		return strings.Join(items, " "), nil
+	default:
+		return formatJSON(v)
+	}
+}
+
+// formatJSON renders a value as compact JSON.
+func formatJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,19 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package claimenv
+
+import (
+	"os"
+	"os/exec"
+)
+
+// run runs the command as a child process, as processes cannot be replaced on this
+// platform. A non-zero exit status is returned as an *exec.ExitError.
+func run(path string, command, env []string) error {
+	cmd := exec.Command(path, command[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
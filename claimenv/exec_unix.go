@@ -0,0 +1,17 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package claimenv
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// run replaces the jwtdecode process with the command, so that the command receives
+// signals directly and its exit status is the exit status of jwtdecode exec.
+func run(path string, command, env []string) error {
+	if err := syscall.Exec(path, command, env); err != nil {
+		return fmt.Errorf("running %s: %w", command[0], err)
+	}
+	return nil
+}
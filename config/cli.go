@@ -0,0 +1,39 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"jwtdecode/jwterrors"
+)
+
+// ErrInvalid is returned by Main when the configuration breaks validation rules.
+var ErrInvalid = jwterrors.ErrInvalidConfig
+
+// Main runs the config subcommand with its command-line arguments, reporting to w.
+// The only action is "validate", which takes the options of a decoding run (or -config
+// with a config file) and lists every violation of the validation rules, without
+// reading the token or any secret.
+func Main(args []string, w io.Writer) error {
+	if len(args) == 0 || args[0] != "validate" {
+		return fmt.Errorf("usage: jwtdecode config validate [-config <file> | <options>]")
+	}
+	fs := flag.NewFlagSet("jwtdecode config validate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	_, err := load(fs, "", args[1:], true)
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		for _, v := range invalid.Violations {
+			fmt.Fprintln(w, v)
+		}
+		return fmt.Errorf("%w (%d problems)", ErrInvalid, len(invalid.Violations))
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "Configuration is valid")
+	return nil
+}
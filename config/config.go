@@ -1,14 +1,34 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"jwtdecode/checkpoint"
+	"jwtdecode/conformance"
+	"jwtdecode/cookie"
+	"jwtdecode/formatter"
+	"jwtdecode/hook"
+	"jwtdecode/inputformat"
+	"jwtdecode/jwterrors"
+	"jwtdecode/output"
+	"jwtdecode/process"
+	"jwtdecode/provider"
+	"jwtdecode/secretref"
+	"jwtdecode/secure"
+	"jwtdecode/snapshot"
+	"jwtdecode/terminal"
 	"jwtdecode/token"
+	"jwtdecode/usagestats"
 	"jwtdecode/utils"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Constants for TokenType and OutputFormat
@@ -16,60 +36,473 @@ const (
 	TokenTypeString      = "string"
 	TokenTypeFile        = "file"
 	TokenTypeEnvironment = "environment"
+	TokenTypeEnvChain    = "environment-chain"
+	TokenTypeKeychain    = "keychain"
+	TokenTypeReference   = "reference"
+	TokenTypeCookie      = "browser-cookie"
+	TokenTypeStdin       = "stdin"
 	OutputFormatJSON     = "JSON"
 	OutputFormatCSV      = "CSV"
 	OutputFormatXML      = "XML"
+	OutputFormatTree     = "TREE"
+	OutputFormatTable    = "TABLE"
+	QueuePolicyBlock     = "block"
+	QueuePolicyDrop      = "drop"
+	ErrorFormatText      = "text"
+	ErrorFormatJSON      = "json"
 
 	defaultMaxTokenSizeMB  = 1
+	defaultTokenPattern    = "*.jwt"
 	defaultMaxOutputSizeMB = 100
+	defaultSnippetLength   = 15
+	defaultCacheSize       = 1000
+	defaultQueueSize       = 64
+	defaultMaxAttempts     = 100000
+	defaultJWKSTimeout     = 10 * time.Second
+	defaultJWKSCacheTTL    = time.Hour
+)
+
+// OutputFormats are the accepted output formats.
+var OutputFormats = []string{OutputFormatJSON, OutputFormatCSV, OutputFormatXML, OutputFormatTree, OutputFormatTable}
+
+// QueuePolicies are the policies of -queue-policy.
+var QueuePolicies = []string{QueuePolicyBlock, QueuePolicyDrop}
+
+// ErrorFormats are the formats of -error-format.
+var ErrorFormats = []string{ErrorFormatText, ErrorFormatJSON}
+
+// Events of a run on which the -exec command can run, and the placeholders replaced in it.
+var (
+	ExecEvents       = []string{"success", "invalid", "expired"}
+	ExecPlaceholders = []string{"event", "output_file", "error", "count", "sub", "iss", "exp"}
 )
 
 // FileConfig defines the structure for the JSON configuration file.
 type FileConfig struct {
-	JWTToken        string `json:"jwtToken"`
-	TokenType       string `json:"tokenType"`
-	OutputFormat    string `json:"outputFormat"`
-	OutputFile      string `json:"outputFile"`
-	ConvertEpoch    bool   `json:"convertEpoch"`
-	EpochUnit       string `json:"epochUnit"` // Unit for epoch timestamps (s, ms, us, ns)
-	SilentExec      bool   `json:"silentExec"`
-	MaxTokenSizeMB  int    `json:"maxTokenSizeMB"`
-	MaxOutputSizeMB int    `json:"maxOutputSizeMB"`
+	JWTToken             string   `json:"jwtToken"`
+	TokenType            string   `json:"tokenType"`
+	TokenList            string   `json:"tokenList"`      // File with one token per line (batch mode)
+	TokenDir             string   `json:"tokenDir"`       // Directory with one token per file (batch mode)
+	TokenPattern         string   `json:"tokenPattern"`   // File name pattern of token files in the directory
+	Resume               bool     `json:"resume"`         // Record progress of a token list and continue an interrupted run
+	CheckpointFile       string   `json:"checkpointFile"` // Checkpoint file used by resume
+	KeepGoing            bool     `json:"keepGoing"`      // Leave out the tokens of a batch that cannot be decoded instead of stopping
+	NDJSON               bool     `json:"ndjson"`         // Write JSON output of a batch as newline-delimited JSON
+	InputFormat          string   `json:"inputFormat"`    // Serialization of the tokens (see the inputformat package)
+	OutputFormat         string   `json:"outputFormat"`
+	OutputFile           string   `json:"outputFile"`
+	Stdout               bool     `json:"stdout"`            // Write the output to stdout instead of a file
+	Get                  string   `json:"get"`               // Print the value of this claim instead of writing the output
+	HasClaim             string   `json:"hasClaim"`          // Exit with status 4 unless the token has this claim
+	NonEmpty             bool     `json:"nonEmpty"`          // With hasClaim, empty values count as missing
+	QuietOutput          bool     `json:"quietOutput"`       // Write no output, reporting through the exit status only
+	NoPager              bool     `json:"noPager"`           // Never page the output to a terminal
+	PartitionBy          []string `json:"partitionBy"`       // Claims splitting batch output into partition files
+	PartitionTemplate    string   `json:"partitionTemplate"` // Path template of the partition files
+	Query                []string `json:"query"`             // Path expressions selecting the claims output
+	ConvertEpoch         bool     `json:"convertEpoch"`
+	EpochUnit            string   `json:"epochUnit"`       // Unit for epoch timestamps (s, ms, us, ns)
+	EpochClaims          []string `json:"epochClaims"`     // Names or glob patterns of custom timestamp claims converted by convertEpoch
+	Timezone             string   `json:"timezone"`        // Time zone of datestamps: an IANA name, local, or UTC
+	TimeFormat           string   `json:"timeFormat"`      // Go time layout, or layout name (e.g., RFC3339), of datestamps
+	PreserveOrder        bool     `json:"preserveOrder"`   // Keep claims in payload order in JSON output
+	XMLMultidoc          bool     `json:"xmlMultidoc"`     // Emit one XML document per token in batch mode
+	TreeStyle            string   `json:"treeStyle"`       // Branches of TREE output (ascii, unicode)
+	Full                 bool     `json:"full"`            // Print the header, payload, signature, and timing of the token as labeled sections
+	JSONRPC              bool     `json:"jsonrpc"`         // Serve decode and verify requests over stdio instead of decoding a token
+	Serve                string   `json:"serve"`           // Address the HTTP API listens on (e.g., ":8080") instead of decoding a token
+	ServeAPIKey          string   `json:"serveApiKey"`     // API key of the HTTP API: @<file> or a password manager reference
+	CacheSize            *int     `json:"cacheSize"`       // Decoded tokens cached by token hash in -jsonrpc, -serve, and -token-list runs
+	QueueSize            *int     `json:"queueSize"`       // Decode and verify requests of -serve waiting for a worker
+	QueuePolicy          string   `json:"queuePolicy"`     // What happens to requests once the queue is full (block, drop)
+	Exec                 string   `json:"exec"`            // Command run after decoding, without a shell
+	ExecOn               []string `json:"execOn"`          // Events on which the command runs (success, invalid, expired)
+	Provenance           bool     `json:"provenance"`      // Record the source of each claim in the output
+	VerifyRoundtrip      bool     `json:"verifyRoundtrip"` // Read the output back and fail on lossy conversions
+	BinaryValues         string   `json:"binaryValues"`    // Rendering of non-printable values (base64, hex, escape)
+	ASCIIOnly            bool     `json:"asciiOnly"`       // Escape non-ASCII characters in JSON output
+	StripControl         bool     `json:"stripControl"`    // Remove control characters from CSV and XML values
+	MissingValue         string   `json:"missingValue"`    // CSV cell written for claims a token does not have
+	OmitNull             bool     `json:"omitNull"`        // Remove null claims from the output
+	Redact               []string `json:"redact"`          // Claims whose values are masked in the output
+	RedactPII            bool     `json:"redactPii"`       // Mask the personal data claims (email, phone_number, ssn, address)
+	DecodeNested         bool     `json:"decodeNested"`    // Decode the JWTs held by claims (id_token, act, ...) into objects
+	TruncateValues       string   `json:"truncateValues"`  // Length limit of string values, for every format or per format (CSV=512,XML=1024)
+	Warnings             bool     `json:"warnings"`        // Add a warnings array of soft issues to the output
+	NoWatermark          bool     `json:"noWatermark"`     // Do not stamp the output of unverified tokens as NOT_VERIFIED
+	Envelope             bool     `json:"envelope"`        // Wrap each token of JSON output in the versioned response envelope
+	ErrorFormat          string   `json:"errorFormat"`     // Format of the error of a failed run and of -serve error responses (text, json)
+	IncludeHeader        bool     `json:"includeHeader"`   // Add the decoded JOSE header to the output, in the header section
+	HeaderOnly           bool     `json:"headerOnly"`      // Output the decoded JOSE header instead of the claims
+	Silent               bool     `json:"silent"`          // Suppress all output messages
+	SilentExec           bool     `json:"silentExec"`      // Deprecated: use silent
+	StrictFlags          bool     `json:"strictFlags"`     // Fail on deprecated flags and fields instead of warning
+	NoAutoSilent         bool     `json:"noAutoSilent"`    // Keep status messages when stdout is not a terminal
+	UsageStats           bool     `json:"usageStats"`      // Record the run in the local usage statistics file
+	ShowSnippet          bool     `json:"showTokenSnippet"`
+	SnippetLength        int      `json:"snippetLength"`
+	MaxTokenSizeMB       int      `json:"maxTokenSizeMB"`
+	MaxOutputSizeMB      int      `json:"maxOutputSizeMB"`
+	Harden               bool     `json:"harden"` // Lock and zero token memory, disable core dumps, scrub errors
+	StrictPerms          bool     `json:"strictPermissions"`
+	Provider             string   `json:"provider"`             // Issuer-specific handling (e.g., aws-alb)
+	SkipVerify           bool     `json:"skipVerify"`           // Skip provider signature verification
+	Audience             string   `json:"audience"`             // Expected audience validated by providers
+	Issuer               string   `json:"issuer"`               // Expected issuer validated by providers
+	StripPrefixes        []string `json:"stripClaimPrefixes"`   // Namespace prefixes removed from claim keys
+	ClientCert           string   `json:"clientCert"`           // PEM certificate checked against cnf x5t#S256
+	VerifyKey            string   `json:"verifyKey"`            // PEM public key or certificate verifying the signature
+	VerifyAlgs           []string `json:"verifyAlgs"`           // Algorithms allowed with verifyKey
+	DecryptKey           string   `json:"decryptKey"`           // Private or symmetric key decrypting JWE tokens
+	Conformance          string   `json:"conformance"`          // Conformance profile (rfc9068, oidc-id-token, logout-token, set)
+	GeoIPDB              []string `json:"geoipDB"`              // MaxMind databases locating the IP addresses held by claims
+	Nonce                string   `json:"nonce"`                // Expected ID token nonce
+	AccessToken          string   `json:"accessToken"`          // Access token checked against at_hash
+	AuthCode             string   `json:"authorizationCode"`    // Authorization code checked against c_hash
+	ResolveDID           bool     `json:"resolveDid"`           // Verify DID issuers with keys from their DID documents
+	TrustFile            string   `json:"trustFile"`            // Multi-issuer trust configuration (YAML)
+	PinnedKeys           []string `json:"pinnedKeys"`           // RFC 7638 thumbprints of accepted verification keys
+	AllowEmbeddedJWK     bool     `json:"allowEmbeddedJwk"`     // Verify with the key embedded in the jwk header
+	JKUAllowlist         []string `json:"jkuAllowlist"`         // HTTPS URL prefixes from which jku key sets may be fetched
+	JWKSURL              string   `json:"jwksUrl"`              // HTTPS URL of the JWKS verifying the signature
+	JWKSTimeout          string   `json:"jwksTimeout"`          // Timeout of the JWKS request (e.g., "10s")
+	JWKSCAFile           string   `json:"jwksCaFile"`           // PEM CA bundle trusted for the JWKS server
+	JWKSCacheDir         string   `json:"jwksCacheDir"`         // Directory of the on-disk JWKS cache
+	JWKSCacheTTL         string   `json:"jwksCacheTtl"`         // Age until which a cached JWKS is used (e.g., "1h"; "0" disables the cache)
+	IssuerDiscovery      string   `json:"issuerDiscovery"`      // Issuer URL whose discovery document gives the JWKS verifying the signature
+	AllowUnsupportedCrit bool     `json:"allowUnsupportedCrit"` // Warn instead of failing on unsupported crit extensions
+	SnapshotDir          string   `json:"snapshotDir"`          // Directory receiving canonical snapshots for golden-file testing
+	ValidateAt           string   `json:"validateAt"`           // Reference time (RFC 3339 or epoch seconds) instead of the current clock
+	Validate             bool     `json:"validate"`             // Check exp, nbf, and iat against the clock and fail on an expired or not yet valid token
+	ClockSkew            string   `json:"clockSkew"`            // Tolerance of validate for clock differences (e.g., "30s")
+
+	FormatOptions FormatOptions        `json:"formatOptions"` // Settings of single output formats (CSV delimiter, XML root elements, datestamp layout)
+	Pipeline      []process.Definition `json:"pipeline"`      // Claims processing steps, in order, instead of decodeNested, convertEpoch, omitNull, redact, and stripClaimPrefixes
+	Tenants       map[string]Tenant    `json:"tenants"`       // Tenants of -serve by name, with the settings of their requests
+
+	fields map[string]json.RawMessage // Fields present in the file with their values, to find deprecated ones and show the -dry-run plan
 }
 
 // AppConfig holds the final, validated application configuration from all sources.
 type AppConfig struct {
-	JWTToken      string // The actual JWT token string
-	OutputFormat  string // JSON, CSV, or XML
-	OutputFile    string // Full path to the output file
-	ConvertEpoch  bool   // Whether to convert epoch timestamps
-	EpochUnit     string // Unit for epoch timestamps
-	IsSilent      bool   // Suppress non-error output
-	MaxTokenSize  int    // Maximum allowed token size in MB
-	MaxOutputSize int    // Maximum allowed output size in MB
-	ShowVersion   bool   // Whether to display the version and exit
+	JWTToken             string        // The actual JWT token string
+	TokenEnvVar          string        // Environment variable the token was read from with -token-env-chain
+	TokenList            string        // File with one token per line; empty for a single token
+	TokenDir             string        // Directory with one token per matching file; empty for a single token
+	TokenPattern         string        // File name pattern of token files in TokenDir
+	InputFormat          string        // Serialization of the tokens, or inputformat.Auto to detect it
+	Resume               bool          // Record progress in the checkpoint file and continue an interrupted run
+	CheckpointFile       string        // Checkpoint file of a resumable token list run
+	KeepGoing            bool          // Leave out the tokens of a batch that cannot be decoded, reporting them, instead of stopping
+	NDJSON               bool          // Write JSON output of a batch as newline-delimited JSON, one token per line
+	OutputFormat         string        // JSON, CSV, XML, TREE, or TABLE
+	OutputFile           string        // Full path to the output file, or output.Stdout
+	Get                  string        // Claim whose raw value is printed on stdout instead of writing the output, for shell scripts
+	HasClaim             string        // Claim every token must have, or the run exits with status 4
+	NonEmpty             bool          // With HasClaim, an empty string, array, or object, or null, counts as missing
+	QuietOutput          bool          // Write no output file and no messages; the exit status is the result
+	NoPager              bool          // Write output to a terminal directly, even when it does not fit on the screen
+	PartitionBy          []string      // Claims splitting batch output into partition files
+	PartitionTemplate    string        // Path template of the partition files; empty for the Hive-style default
+	Query                []string      // Path expressions (gjson-style or JSONPath) selecting the claims output; empty for all
+	ConvertEpoch         bool          // Whether to convert epoch timestamps
+	EpochUnit            string        // Unit for epoch timestamps
+	EpochClaims          []string      // Names or glob patterns of custom timestamp claims given datestamps with ConvertEpoch
+	Timezone             string        // Time zone of datestamps and dates shown: an IANA name, or local; UTC when empty
+	TimeFormat           string        // Layout of datestamps, overriding FormatOptions.Dates.Layout; a Go layout or a layout name
+	PreserveOrder        bool          // Keep claims in their original payload order in JSON output
+	XMLMultidoc          bool          // Emit one XML document per token instead of a <JWTClaimsSet> wrapper
+	TreeStyle            string        // Branches of TREE output: unicode box drawing when stdout is a terminal, ascii otherwise
+	FormatOptions        FormatOptions // Settings of single output formats, from the formatOptions section of the config file
+	Full                 bool          // Print the header, payload, signature, and timing of each token as labeled sections on stdout
+	JSONRPC              bool          // Serve JSON-RPC requests over stdio instead of decoding a token
+	Serve                string        // Address the HTTP API listens on instead of decoding a token; empty if none
+	ServeAPIKey          []byte        // Key that HTTP API requests must send in the X-API-Key header; nil if requests are not authenticated
+	CacheSize            int           // Number of decoded tokens cached by token hash in -jsonrpc, -serve, and -token-list runs; 0 disables the cache
+	QueueSize            int           // Number of -serve decode and verify requests that may wait for a worker
+	QueuePolicy          string        // Whether requests wait for room in a full queue (QueuePolicyBlock) or are refused (QueuePolicyDrop)
+	Exec                 *hook.Command // Command run after decoding; nil if none
+	ExecOn               []string      // Events on which Exec runs
+	Provenance           bool          // Record the source of each claim (sidecar, XML attribute, or CSV column)
+	VerifyRoundtrip      bool          // Read the formatted output back and report claims it does not preserve
+	BinaryValues         string        // Rendering of non-printable and non-UTF-8 string values; empty to keep them
+	ASCIIOnly            bool          // Escape non-ASCII characters as \uXXXX in JSON output
+	StripControl         bool          // Remove control characters from values in CSV and XML output
+	MissingValue         string        // CSV cell written for claims a token does not have; empty by default
+	OmitNull             bool          // Remove null claims (and null members of nested objects) from the output
+	Redact               []string      // Claims (names or dotted paths) whose values are masked, e.g. j***@example.com
+	RedactPII            bool          // Mask the claims of the pii redaction profile (email, phone_number, ssn, address)
+	DecodeNested         bool          // Replace the JWTs held by claims by objects with their decoded header and claims
+	TruncateValues       int           // Length limit of string values in the selected output format; 0 keeps full values
+	Warnings             bool          // Add the soft issues found while decoding to the output under "warnings"
+	NoWatermark          bool          // Do not stamp the output of tokens whose signature was not verified
+	Envelope             bool          // Wrap each token of JSON output (and -jsonrpc decode results) in the versioned response envelope
+	ErrorFormat          string        // Format of the error of a failed run and of -serve error responses: ErrorFormatText or ErrorFormatJSON
+	IncludeHeader        bool          // Add the decoded JOSE header (alg, kid, typ, x5c, ...) to the output as the header claim
+	HeaderOnly           bool          // Output the decoded JOSE header in place of the claims
+	IsSilent             bool          // Suppress non-error output
+	ShowSnippet          bool          // Print a token snippet instead of its fingerprint
+	SnippetLength        int           // Number of characters shown at each end of the snippet
+	MaxTokenSize         int           // Maximum allowed token size in MB
+	MaxOutputSize        int           // Maximum allowed output size in MB
+	ShowVersion          bool          // Whether to display the version and exit
+	DryRun               bool          // Print the plan of the run (see WritePlan) instead of reading the token
+	Harden               bool          // Whether secrets hygiene hardening is enabled
+	StrictPerms          bool          // Fail instead of warning on world-accessible token or output files
+	Provider             string        // Issuer-specific provider name
+	SkipVerify           bool          // Whether to skip provider signature verification
+	Audience             string        // Expected audience validated by providers
+	Issuer               string        // Expected issuer validated by providers
+	StripPrefixes        []string      // Namespace prefixes removed from claim keys
+	ClientCert           string        // PEM client certificate checked against the cnf x5t#S256 binding
+	VerifyKey            string        // PEM public key or certificate that the token must be signed with
+	VerifyAlgs           []string      // Algorithms allowed with VerifyKey (default: every algorithm of the key type)
+	DecryptKey           string        // Private key (PEM or JWK) or symmetric key decrypting JWE tokens
+	Conformance          string        // Conformance profile to check the token against
+	GeoIPDB              []string      // MaxMind databases (Country, City, ASN) locating the IP addresses held by claims
+	Nonce                string        // Expected ID token nonce
+	AccessToken          string        // Access token checked against at_hash
+	AuthCode             string        // Authorization code checked against c_hash
+	ResolveDID           bool          // Whether to verify DID issuers with keys resolved from their DID documents
+	TrustFile            string        // Multi-issuer trust configuration used to verify the token
+	PinnedKeys           []string      // RFC 7638 thumbprints that verification keys must match
+	HMACWordlist         string        // Wordlist of candidate HMAC secrets (authorized testing only)
+	HMACSecret           []byte        // Secret that HS256/384/512 tokens must be signed with
+	MaxAttempts          int           // Maximum number of wordlist candidates to try
+	AllowEmbeddedJWK     bool          // Whether the key embedded in the jwk header may be used for verification
+	JKUAllowlist         []string      // HTTPS URL prefixes from which jku key sets may be fetched
+	JWKSURL              string        // HTTPS URL of the JWKS whose key, selected by kid, verifies the signature
+	JWKSTimeout          time.Duration // Timeout of the JWKS request
+	JWKSCAFile           string        // PEM CA bundle trusted for the JWKS server instead of the system CAs
+	JWKSCacheDir         string        // Directory of the on-disk JWKS cache
+	JWKSCacheTTL         time.Duration // Age until which a cached JWKS is used; 0 disables the cache
+	IssuerDiscovery      string        // HTTPS issuer URL whose discovery document (jwks_uri) gives the JWKS verifying the signature
+	AllowUnsupportedCrit bool          // Warn instead of failing when crit names unsupported extensions
+	SnapshotDir          string        // Directory receiving canonical snapshots of the output
+	SnapshotName         string        // File name of the snapshot, derived from the token file
+	ConfigFile           string        // Config file the configuration was read from; empty if none
+	UsageStats           bool          // Record the run in the local usage statistics file (see the usagestats package)
+	Deprecated           []Violation   // Deprecated flags and config file fields used
+	ValidateAt           time.Time     // Reference time for time-dependent output; zero means the current clock
+	Validate             bool          // Check exp, nbf, and iat against the reference time, adding the outcome to the output, and fail on an invalid token
+	ClockSkew            time.Duration // Tolerance of Validate for clock differences with the issuer
+
+	Pipeline []process.Definition // Claims processing steps declared in the config file; empty to use the shorthand flags
+
+	Tenants    map[string]Tenant // Tenants of -serve by name, from the config file
+	TenantKeys map[string][]byte // API keys of the tenants that have one, by name
+	Tenant     string            // Tenant whose requests the configuration serves; empty for the configuration itself
+
+	given settings // How the options were given, for the validation rules
+}
+
+// Verifies reports whether token signatures are verified: with a trust configuration,
+// DID documents, provider keys, keys from the token's own headers, or a supplied key,
+// secret, or JWKS URL (given or discovered).
+func (c *AppConfig) Verifies() bool {
+	return len(c.keySources()) > 0
+}
+
+// keySources returns the options giving the keys that verify token signatures.
+func (c *AppConfig) keySources() []string {
+	sources := []struct {
+		name string
+		set  bool
+	}{
+		{"trust", c.TrustFile != ""},
+		{"resolve-did", c.ResolveDID},
+		{"provider", c.Provider != "" && !c.SkipVerify},
+		{"allow-embedded-jwk", c.AllowEmbeddedJWK},
+		{"jku-allowlist", len(c.JKUAllowlist) > 0},
+		{"verify-hmac-secret", c.HMACSecret != nil},
+		{"verify-key", c.VerifyKey != ""},
+		{"jwks-url", c.JWKSURL != ""},
+		{"issuer-discovery", c.IssuerDiscovery != ""},
+	}
+	var names []string
+	for _, source := range sources {
+		if source.set {
+			names = append(names, source.name)
+		}
+	}
+	return names
+}
+
+// Now returns the reference time of time-dependent checks and output: ValidateAt if set,
+// otherwise the current time.
+func (c *AppConfig) Now() time.Time {
+	if c.ValidateAt.IsZero() {
+		return time.Now()
+	}
+	return c.ValidateAt
+}
+
+// Batch reports whether several tokens are decoded, from a token list or directory.
+func (c *AppConfig) Batch() bool {
+	return c.TokenList != "" || c.TokenDir != ""
+}
+
+// Serves reports whether tokens are received in requests, over JSON-RPC or HTTP, instead
+// of being read from a token source.
+func (c *AppConfig) Serves() bool {
+	return c.JSONRPC || c.Serve != ""
+}
+
+// Defaults returns the configuration of a run without options, for programs that decode
+// tokens with the library (see the pkg/jwtdecode package) instead of the command line.
+// Status messages are silenced.
+func Defaults() *AppConfig {
+	return &AppConfig{
+		InputFormat:   inputformat.Auto,
+		OutputFormat:  OutputFormatJSON,
+		ErrorFormat:   ErrorFormatText,
+		OutputFile:    output.Stdout,
+		TokenPattern:  defaultTokenPattern,
+		IsSilent:      true,
+		SnippetLength: defaultSnippetLength,
+		MaxTokenSize:  defaultMaxTokenSizeMB,
+		MaxOutputSize: defaultMaxOutputSizeMB,
+		MaxAttempts:   defaultMaxAttempts,
+		JWKSTimeout:   defaultJWKSTimeout,
+		JWKSCacheTTL:  defaultJWKSCacheTTL,
+		// Tokens are passed to the decoder, which stands for the token source of the rules
+		given: settings{tokenSources: 1},
+	}
 }
 
 // LoadConfig parses command-line flags, reads an optional config file,
 // validates the configuration, and returns the final AppConfig.
 // It follows a hierarchy: flags override config file settings, which override defaults.
+// A configuration breaking validation rules is reported with a *ValidationError.
+// An error found once the error format is known is returned with a configuration holding
+// only its ErrorFormat, so that it is reported in that format.
 func LoadConfig(version string) (*AppConfig, error) {
+	return load(flag.CommandLine, version, os.Args[1:], false)
+}
+
+// load parses the command-line arguments, merges them with the config file, and
+// validates the result. Unless validateOnly is set, it also sets the derived defaults,
+// reads secrets, and reads the token of a single-token run.
+func load(fs *flag.FlagSet, version string, args []string, validateOnly bool) (cfg *AppConfig, err error) {
 	// 1. Define and parse command-line flags
 	var (
-		tokenString   = flag.String("token-string", "", "Access token passed as a string")
-		tokenFile     = flag.String("token-file", "", "Access token passed as file")
-		tokenEnv      = flag.Bool("token-env", false, "Get the token from the environment variable JWT_TOKEN")
-		outputFormat  = flag.String("output-format", "", "Output format (JSON, CSV, or XML)")
-		outputFile    = flag.String("output-file", "", "Full path of output file")
-		configFile    = flag.String("config", "", "Full path of config.json")
-		showVersion   = flag.Bool("version", false, "Display the current application version")
-		convertEpoch  = flag.Bool("convert-epoch", false, "Convert epoch timestamps to human-readable format")
-		epochUnit     = flag.String("epoch-unit", "", "Specify epoch unit (s, ms, us, ns). Defaults to heuristic.")
-		silent        = flag.Bool("silent", false, "Suppress all output messages")
-		maxTokenSize  = flag.Int("max-token-size", 0, "Maximum JWT token size in MB")
-		maxOutputSize = flag.Int("max-output-size", 0, "Maximum formatted output size in MB")
+		tokenString   = fs.String("token-string", "", "Access token passed as a string")
+		tokenFile     = fs.String("token-file", "", "Access token passed as file")
+		tokenEnv      = fs.Bool("token-env", false, "Get the token from the environment variable JWT_TOKEN")
+		tokenStdin    = fs.Bool("token-stdin", false, "Read the token from standard input, e.g. piped from another command")
+		tokenKeychain = fs.String("token-keychain", "", "Get the token from the platform secret store, as <service>/<account>")
+		tokenRef      = fs.String("token-ref", "", "Get the token from a password manager CLI, e.g. op://vault/item/field or bw://item/field")
+		tokenEnvChain = fs.String("token-env-chain", "", "Get the token from the first set of these environment variables, e.g. ID_TOKEN,ACCESS_TOKEN,JWT_TOKEN (a Bearer prefix is removed)")
+		tokenCookie   = fs.String("token-cookie", "", "Get the token from a cookie of the local browser, as <browser>:<cookie>@<host> (browsers: "+strings.Join(cookie.Browsers(), ", ")+")")
+		tokenList     = fs.String("token-list", "", "File with one token per line, decoded in batch")
+		tokenDir      = fs.String("token-dir", "", "Directory walked for token files, each decoded in batch")
+		tokenPattern  = fs.String("token-pattern", "", "File name pattern of token files in -token-dir (default \"*.jwt\")")
+		inputFormat   = fs.String("input-format", "", "Serialization of the tokens: "+strings.Join(inputformat.Formats, ", ")+" (default: auto, detected from the input)")
+		cacheSize     = fs.Int("cache-size", defaultCacheSize, "Number of decoded tokens cached by token hash in -jsonrpc, -serve, and -token-list runs (0 disables the cache)")
+		queueSize     = fs.Int("queue-size", defaultQueueSize, "Number of -serve decode and verify requests that may wait for a worker once every worker is busy")
+		queuePolicy   = fs.String("queue-policy", QueuePolicyBlock, "What happens to -serve requests once the queue is full: block, waiting for room, or drop, answering 503")
+		jsonRPC       = fs.Bool("jsonrpc", false, "Serve decode and verify requests as JSON-RPC over stdin and stdout (for editor plugins)")
+		serve         = fs.String("serve", "", "Serve decode and verify requests over HTTP on this address, e.g. :8080")
+		serveAPIKey   = fs.String("serve-api-key", "", "API key that -serve requests must send in the X-API-Key header: @<file>, an op:// or bw:// reference, or the key itself")
+		execCommand   = fs.String("exec", "", "Command run without a shell after decoding, e.g. 'notify-send {event} {output_file}'")
+		execOn        = fs.String("exec-on", "", "Comma-separated events on which -exec runs ("+strings.Join(ExecEvents, ", ")+"; default all)")
+		resume        = fs.Bool("resume", false, "Record -token-list progress in a checkpoint file and continue an interrupted run from it")
+		keepGoing     = fs.Bool("keep-going", false, "Decode the other tokens of a -token-list or -token-dir when one cannot be decoded, and report the failures in a summary")
+		ndjson        = fs.Bool("ndjson", false, "Write JSON output of a -token-list or -token-dir as newline-delimited JSON, one token per line, instead of an array")
+		checkpointF   = fs.String("checkpoint", "", "Checkpoint file used by -resume (default <output-file>.checkpoint)")
+		outputFormat  = fs.String("output-format", "", "Output format (JSON, CSV, XML, TREE, or TABLE; default: TABLE when writing to a terminal, JSON otherwise)")
+		outputFile    = fs.String("output-file", "", "Full path of output file, or - for stdout")
+		stdoutF       = fs.Bool("stdout", false, "Write the output to stdout instead of a file (same as -output-file -)")
+		getClaim      = fs.String("get", "", "Print the raw value of this claim on stdout instead of writing the output, e.g. USER=$(jwtdecode -token-env -get sub)")
+		hasClaim      = fs.String("has-claim", "", "Exit with status 4 unless the token has this claim, e.g. if jwtdecode -token-env -has-claim groups -quiet-output; then ...")
+		nonEmpty      = fs.Bool("non-empty", false, "With -has-claim, count an empty string, array, or object, or null as missing")
+		quietOutput   = fs.Bool("quiet-output", false, "Write no output file and no messages; the exit status reports the result")
+		noPager       = fs.Bool("no-pager", false, "Do not page output to a terminal through $PAGER (default less -R) when it does not fit on the screen")
+		partitionBy   = fs.String("partition-by", "", "Comma-separated claims splitting -token-list output into partition files (e.g., iss)")
+		queryExprs    = fs.String("query", "", "Comma-separated path expressions selecting the claims output, gjson-style (realm_access.roles, items.#.id) or JSONPath ($.realm_access.roles)")
+		partitionTmpl = fs.String("partition-template", "", "Path template of partition files, e.g. '{iss_host}/{date}.ndjson'")
+		configFile    = fs.String("config", "", "Full path of config.json")
+		showVersion   = fs.Bool("version", false, "Display the current application version")
+		dryRun        = fs.Bool("dry-run", false, "Print the effective settings, token source, processing pipeline, and output destination, without reading the token or writing anything")
+		convertEpoch  = fs.Bool("convert-epoch", false, "Convert epoch timestamps to human-readable format")
+		epochUnit     = fs.String("epoch-unit", "", "Specify epoch unit (s, ms, us, ns). Defaults to heuristic.")
+		epochClaims   = fs.String("epoch-claims", "", "Comma-separated names or glob patterns of custom timestamp claims converted by -convert-epoch, e.g. created_at,*_exp")
+		timezone      = fs.String("timezone", "", "Time zone of converted timestamps and dates shown: an IANA name (e.g., America/New_York) or local (default UTC)")
+		timeFormat    = fs.String("time-format", "", "Layout of converted timestamps: a Go time layout, or a layout name such as RFC3339, RFC1123, or DateTime")
+		preserveOrder = fs.Bool("preserve-order", false, "Keep claims in their original payload order in JSON output")
+		xmlMultidoc   = fs.Bool("xml-multidoc", false, "Emit one XML document per token in batch mode instead of a <JWTClaimsSet> wrapper")
+		full          = fs.Bool("full", false, "Print the header, payload, signature, verification, and timing of the token as labeled sections (default on a terminal without -output-format)")
+		treeStyle     = fs.String("tree-style", "", "Branches of TREE output: "+strings.Join(formatter.TreeStyles, " or ")+" (default: unicode when stdout is a terminal, ascii otherwise)")
+		provenanceF   = fs.Bool("provenance", false, "Record where each claim originated (JSON sidecar, XML source attribute, or CSV _source column)")
+		binaryValues  = fs.String("binary-values", "", "Render non-printable and non-UTF-8 string values as "+strings.Join(formatter.BinaryModes, ", ")+" (default: as decoded)")
+		asciiOnly     = fs.Bool("ascii-only", false, "Escape non-ASCII characters as \\uXXXX in JSON output")
+		stripControl  = fs.Bool("strip-control", false, "Remove control characters from values in CSV and XML output")
+		missingValue  = fs.String("missing-value", "", "Value written in CSV cells of claims a token does not have (e.g., N/A; default: empty)")
+		omitNull      = fs.Bool("omit-null", false, "Remove null claims, and null members of nested objects, from the output")
+		redact        = fs.String("redact", "", "Comma-separated claims (names or dotted paths) whose values are masked in the output, e.g. email,address.street")
+		redactPII     = fs.Bool("redact-pii", false, "Mask the values of the personal data claims: email, phone_number, ssn, and address")
+		decodeNested  = fs.Bool("decode-nested", false, "Decode the JWTs held by claims (e.g., id_token, access_token, act), recursively, into objects with their header and claims")
+		truncate      = fs.String("truncate-values", "", "Truncate string values longer than a number of characters, in every format (e.g., 512) or per format (e.g., CSV=512,XML=1024)")
+		warningsF     = fs.Bool("warnings", false, "Add a severity-tagged warnings array (unverified signature, alg none, guessed epoch unit, ...) to the output")
+		noWatermark   = fs.Bool("no-watermark", false, "Do not stamp the output of tokens whose signature was not verified as NOT_VERIFIED")
+		envelopeF     = fs.Bool("envelope", false, "Wrap each token of JSON output in a versioned envelope (schema_version, token, header, claims, verification, warnings, findings)")
+		errorFormat   = fs.String("error-format", ErrorFormatText, "Format of the error of a failed run, and of -serve error responses: text, or json for the error envelope (schema_version, error code, category, and message)")
+		inclHeader    = fs.Bool("include-header", false, "Add the decoded JOSE header (alg, kid, typ, x5c, ...) to the output in a header section")
+		headerOnly    = fs.Bool("header-only", false, "Output the decoded JOSE header instead of the claims")
+		roundtrip     = fs.Bool("verify-roundtrip", false, "Read the formatted output back and fail if it does not preserve every claim value")
+		silent        = fs.Bool("silent", false, "Suppress all output messages")
+		strictFlags   = fs.Bool("strict-flags", false, "Fail instead of warning when deprecated flags or config file fields are used")
+		noAutoSilent  = fs.Bool("no-auto-silent", false, "Keep status messages even when stdout is not a terminal")
+		usageStats    = fs.Bool("usage-stats", false, "Count the output format, provider, and flags of the run (never their values) in a local statistics file, shown by jwtdecode stats")
+		showSnippet   = fs.Bool("show-token-snippet", false, "Print a snippet of the token instead of its SHA-256 fingerprint")
+		snippetLength = fs.Int("snippet-length", 0, "Number of characters shown at each end of the token snippet")
+		maxTokenSize  = fs.Int("max-token-size", 0, "Maximum JWT token size in MB")
+		maxOutputSize = fs.Int("max-output-size", 0, "Maximum formatted output size in MB")
+		harden        = fs.Bool("harden", false, "Lock and zero token memory, disable core dumps, and scrub the token from errors")
+		strictPerms   = fs.Bool("strict-permissions", false, "Fail instead of warning when token or output files are world-accessible")
+		providerName  = fs.String("provider", "", "Issuer-specific token handling ("+strings.Join(provider.Names(), ", ")+")")
+		skipVerify    = fs.Bool("skip-verify", false, "Skip provider signature verification (decode only)")
+		audience      = fs.String("audience", "", "Expected audience (client ID or bundle ID) validated by the provider")
+		issuer        = fs.String("issuer", "", "Expected issuer validated by the provider")
+		stripPrefixes = fs.String("strip-claim-prefix", "", "Comma-separated namespace prefixes to remove from claim keys")
+		verifyKey     = fs.String("verify-key", "", "PEM public key or certificate verifying the RS/PS/ES/EdDSA signature of the token, with the algorithm of its header")
+		verifyAlgs    = fs.String("verify-algs", "", "Comma-separated algorithms allowed with -verify-key, e.g. RS256,PS256 (default: every algorithm of the key type)")
+		decryptKey    = fs.String("decrypt-key", "", "Private key (PEM or JWK) or symmetric key file decrypting JWE tokens, whose claims or nested JWS are then decoded")
+		clientCert    = fs.String("client-cert", "", "PEM client certificate to check against the token's cnf x5t#S256 binding")
+		conformanceP  = fs.String("conformance", "", "Check the token against a profile ("+strings.Join(conformance.Profiles(), ", ")+")")
+		geoipDB       = fs.String("geoip-db", "", "Comma-separated MaxMind databases (e.g., GeoLite2-Country.mmdb,GeoLite2-ASN.mmdb) locating the IP addresses held by claims")
+		nonce         = fs.String("nonce", "", "Expected ID token nonce")
+		accessToken   = fs.String("access-token", "", "Access token to check against the ID token's at_hash")
+		authCode      = fs.String("auth-code", "", "Authorization code to check against the ID token's c_hash")
+		resolveDID    = fs.Bool("resolve-did", false, "Verify tokens issued by a DID (did:web, did:jwk) using keys from the DID document")
+		trustFile     = fs.String("trust", "", "Full path of a trust.yaml describing trusted issuers, keys, algorithms, and audiences")
+		hmacSecret    = fs.String("verify-hmac-secret", "", "Verify the HS256/384/512 signature of the token with this secret: @<file>, an op:// or bw:// reference, or the secret itself")
+		hmacWordlist  = fs.String("hmac-wordlist", "", "Wordlist of candidate secrets to test an HS256/384/512 token for weak secrets")
+		maxAttempts   = fs.Int("max-attempts", 0, "Maximum number of wordlist candidates to try")
+		ownToken      = fs.Bool("i-own-this-token", false, "Acknowledge that you are authorized to test this token's secret")
+		allowJWK      = fs.Bool("allow-embedded-jwk", false, "Verify the token with the public key embedded in its jwk header")
+		snapshotDir   = fs.String("snapshot-dir", "", "Directory receiving a canonical, deterministic snapshot of the output for golden-file testing")
+		validateAt    = fs.String("validate-at", "", "Reference time (RFC 3339 or epoch seconds) used instead of the current clock")
+		validateF     = fs.Bool("validate", false, "Check exp, nbf, and iat against the clock, adding expired and expires_in to the output, and exit with status 5 if a token is expired or not yet valid")
+		clockSkew     = fs.Duration("clock-skew", 0, "Tolerance of -validate for clock differences with the issuer, e.g. 30s")
+		allowCrit     = fs.Bool("allow-unsupported-crit", false, "Warn instead of failing when the crit header names unsupported extensions")
 	)
-	flag.Parse()
+	var pinnedKeys stringList
+	fs.Var(&pinnedKeys, "pin-key", "RFC 7638 thumbprint of an accepted verification key (repeatable)")
+	var jkuAllowlist stringList
+	var (
+		jwksURL      = fs.String("jwks-url", "", "HTTPS URL of a JWKS whose key, selected by the kid of the token, verifies its signature")
+		jwksTimeout  = fs.Duration("jwks-timeout", defaultJWKSTimeout, "Timeout of the -jwks-url and -issuer-discovery requests")
+		jwksCAFile   = fs.String("jwks-ca-file", "", "PEM CA bundle trusted for the -jwks-url and -issuer-discovery servers instead of the system CAs")
+		jwksCacheDir = fs.String("jwks-cache-dir", "", "Directory of the on-disk key set cache of -jwks-url and -issuer-discovery (default <user cache dir>/jwtdecode/jwks)")
+		jwksCacheTTL = fs.Duration("jwks-cache-ttl", defaultJWKSCacheTTL, "Age until which a cached key set of -jwks-url or -issuer-discovery is used; 0 disables the cache")
+		issuerDisc   = fs.String("issuer-discovery", "", "HTTPS issuer URL whose OpenID discovery document gives the JWKS (jwks_uri) verifying the signature")
+	)
+	fs.Var(&jkuAllowlist, "jku-allowlist", "HTTPS URL prefix from which the token's jku key set may be fetched (repeatable)")
+	registerDeprecatedFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
 
 	// 2. Handle immediate actions (like showing version)
 	if *showVersion {
@@ -79,12 +512,29 @@ func LoadConfig(version string) (*AppConfig, error) {
 
 	appConfig := &AppConfig{}
 	fileCfg := &FileConfig{}
+	defer func() {
+		if err != nil && appConfig.ErrorFormat != "" {
+			cfg = &AppConfig{ErrorFormat: appConfig.ErrorFormat}
+		}
+	}()
 
 	// 3. Sanitize and validate file paths from flags
 	sanitizedTokenFile, err := utils.SanitizeFilePath(*tokenFile)
 	if err != nil {
 		return nil, fmt.Errorf("sanitizing token file path: %w", err)
 	}
+	sanitizedTokenList, err := utils.SanitizeFilePath(*tokenList)
+	if err != nil {
+		return nil, fmt.Errorf("sanitizing token list path: %w", err)
+	}
+	sanitizedTokenDir, err := utils.SanitizeFilePath(*tokenDir)
+	if err != nil {
+		return nil, fmt.Errorf("sanitizing token directory: %w", err)
+	}
+	sanitizedCheckpoint, err := utils.SanitizeFilePath(*checkpointF)
+	if err != nil {
+		return nil, fmt.Errorf("sanitizing checkpoint file path: %w", err)
+	}
 	sanitizedOutputFile, err := utils.SanitizeFilePath(*outputFile)
 	if err != nil {
 		return nil, fmt.Errorf("sanitizing output file path: %w", err)
@@ -93,66 +543,496 @@ func LoadConfig(version string) (*AppConfig, error) {
 	if err != nil {
 		return nil, fmt.Errorf("sanitizing config file path: %w", err)
 	}
+	sanitizedClientCert, err := utils.SanitizeFilePath(*clientCert)
+	if err != nil {
+		return nil, fmt.Errorf("sanitizing client certificate path: %w", err)
+	}
+	sanitizedJWKSCAFile, err := utils.SanitizeFilePath(*jwksCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("sanitizing JWKS CA bundle path: %w", err)
+	}
+	sanitizedJWKSCacheDir, err := utils.SanitizeFilePath(*jwksCacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("sanitizing JWKS cache directory: %w", err)
+	}
+	sanitizedVerifyKey, err := utils.SanitizeFilePath(*verifyKey)
+	if err != nil {
+		return nil, fmt.Errorf("sanitizing verification key path: %w", err)
+	}
+	sanitizedDecryptKey, err := utils.SanitizeFilePath(*decryptKey)
+	if err != nil {
+		return nil, fmt.Errorf("sanitizing decryption key path: %w", err)
+	}
+	sanitizedTrustFile, err := utils.SanitizeFilePath(*trustFile)
+	if err != nil {
+		return nil, fmt.Errorf("sanitizing trust file path: %w", err)
+	}
+	sanitizedSnapshotDir, err := utils.SanitizeFilePath(*snapshotDir)
+	if err != nil {
+		return nil, fmt.Errorf("sanitizing snapshot directory: %w", err)
+	}
+	sanitizedWordlist, err := utils.SanitizeFilePath(*hmacWordlist)
+	if err != nil {
+		return nil, fmt.Errorf("sanitizing wordlist path: %w", err)
+	}
 
 	// 4. Load from config file if provided.
 	// Note: If -config is used, other flags are disallowed to maintain clarity.
 	if sanitizedConfigFile != "" {
-		if flag.NArg() > 0 || *tokenString != "" || *tokenFile != "" || *tokenEnv || *outputFormat != "" || *outputFile != "" || *convertEpoch || *silent || *maxTokenSize != 0 || *maxOutputSize != 0 || *epochUnit != "" {
-			return nil, fmt.Errorf("if -config is used, it must be the sole argument")
+		if fs.NArg() > 0 || otherFlagsSet(fs, "config", "dry-run") {
+			return nil, fmt.Errorf("if -config is used, it must be the sole argument (besides -dry-run)")
 		}
 		fileCfg, err = readConfigFile(sanitizedConfigFile)
 		if err != nil {
 			return nil, err
 		}
+		appConfig.ConfigFile = sanitizedConfigFile
 	}
+	appConfig.DryRun = *dryRun
 
-	// 5. Merge configuration sources (Flags > Config File > Defaults)
+	// Deprecated names keep working with a warning, unless -strict-flags makes them fail
+	// with the other violations
+	appConfig.Deprecated = deprecatedUsage(fs, fileCfg)
+	if *strictFlags || fileCfg.StrictFlags {
+		appConfig.given.problems = append(appConfig.given.problems, appConfig.Deprecated...)
+	} else {
+		for _, v := range appConfig.Deprecated {
+			Warn(v.String())
+		}
+	}
+
+	// 5. Merge configuration sources (Flags > Config File > Defaults). Values are checked
+	// by the validation rules once merged, so that every problem is reported at once.
 	appConfig.ConvertEpoch = *convertEpoch || fileCfg.ConvertEpoch
 	appConfig.EpochUnit = valueOrDefault(*epochUnit, fileCfg.EpochUnit)
-	appConfig.IsSilent = *silent || fileCfg.SilentExec
+	appConfig.EpochClaims = fileCfg.EpochClaims
+	if *epochClaims != "" {
+		appConfig.EpochClaims = splitList(*epochClaims)
+	}
+	appConfig.Timezone = valueOrDefault(*timezone, fileCfg.Timezone)
+	appConfig.TimeFormat = valueOrDefault(*timeFormat, fileCfg.TimeFormat)
+	appConfig.PreserveOrder = *preserveOrder || fileCfg.PreserveOrder
+	appConfig.XMLMultidoc = *xmlMultidoc || fileCfg.XMLMultidoc
+	appConfig.TreeStyle = strings.ToLower(valueOrDefault(*treeStyle, fileCfg.TreeStyle))
+	appConfig.FormatOptions = fileCfg.FormatOptions
+	appConfig.Pipeline = fileCfg.Pipeline
+	appConfig.Tenants = fileCfg.Tenants
+	appConfig.Provenance = *provenanceF || fileCfg.Provenance
+	appConfig.VerifyRoundtrip = *roundtrip || fileCfg.VerifyRoundtrip
+	appConfig.BinaryValues = strings.ToLower(valueOrDefault(*binaryValues, fileCfg.BinaryValues))
+	appConfig.ASCIIOnly = *asciiOnly || fileCfg.ASCIIOnly
+	appConfig.StripControl = *stripControl || fileCfg.StripControl
+	appConfig.MissingValue = valueOrDefault(*missingValue, fileCfg.MissingValue)
+	appConfig.OmitNull = *omitNull || fileCfg.OmitNull
+	appConfig.Redact = fileCfg.Redact
+	if *redact != "" {
+		appConfig.Redact = splitList(*redact)
+	}
+	appConfig.RedactPII = *redactPII || fileCfg.RedactPII
+	appConfig.DecodeNested = *decodeNested || fileCfg.DecodeNested
+	appConfig.Warnings = *warningsF || fileCfg.Warnings
+	appConfig.NoWatermark = *noWatermark || fileCfg.NoWatermark
+	appConfig.Envelope = *envelopeF || fileCfg.Envelope
+	appConfig.ErrorFormat = strings.ToLower(*errorFormat)
+	if fileCfg.ErrorFormat != "" {
+		appConfig.ErrorFormat = strings.ToLower(fileCfg.ErrorFormat)
+	}
+	appConfig.IncludeHeader = *inclHeader || fileCfg.IncludeHeader
+	appConfig.HeaderOnly = *headerOnly || fileCfg.HeaderOnly
+	appConfig.IsSilent = *silent || fileCfg.Silent || fileCfg.SilentExec
+	// Auto-silence when stdout is piped or redirected, unless explicitly overridden
+	if !appConfig.IsSilent && !*noAutoSilent && !fileCfg.NoAutoSilent && !terminal.IsTerminal(os.Stdout) {
+		appConfig.IsSilent = true
+	}
+	appConfig.ShowSnippet = *showSnippet || fileCfg.ShowSnippet
+	appConfig.SnippetLength = intValueOrDefault(*snippetLength, fileCfg.SnippetLength, defaultSnippetLength)
+	appConfig.InputFormat = strings.ToLower(valueOrDefault(*inputFormat, fileCfg.InputFormat, inputformat.Auto))
 	appConfig.MaxTokenSize = intValueOrDefault(*maxTokenSize, fileCfg.MaxTokenSizeMB, defaultMaxTokenSizeMB)
 	appConfig.MaxOutputSize = intValueOrDefault(*maxOutputSize, fileCfg.MaxOutputSizeMB, defaultMaxOutputSizeMB)
 	appConfig.OutputFormat = valueOrDefault(strings.ToUpper(*outputFormat), strings.ToUpper(fileCfg.OutputFormat))
 	appConfig.OutputFile = valueOrDefault(sanitizedOutputFile, fileCfg.OutputFile)
+	appConfig.given.outputFile = appConfig.OutputFile
+	// -stdout is the same as -output-file -
+	appConfig.given.stdout = *stdoutF || fileCfg.Stdout
+	if appConfig.given.stdout {
+		appConfig.OutputFile = output.Stdout
+	}
+	if appConfig.OutputFile == output.Stdout {
+		// Status messages would be mixed with the output
+		appConfig.IsSilent = true
+	}
+	appConfig.NoPager = *noPager || fileCfg.NoPager
+	appConfig.PartitionBy = fileCfg.PartitionBy
+	if *partitionBy != "" {
+		appConfig.PartitionBy = splitList(*partitionBy)
+	}
+	appConfig.PartitionTemplate = valueOrDefault(*partitionTmpl, fileCfg.PartitionTemplate)
+	appConfig.Query = fileCfg.Query
+	if *queryExprs != "" {
+		appConfig.Query = splitList(*queryExprs)
+	}
+	appConfig.Harden = *harden || fileCfg.Harden
+	appConfig.StrictPerms = *strictPerms || fileCfg.StrictPerms
+	appConfig.Provider = strings.ToLower(valueOrDefault(*providerName, fileCfg.Provider))
+	appConfig.SkipVerify = *skipVerify || fileCfg.SkipVerify
+	appConfig.Audience = valueOrDefault(*audience, fileCfg.Audience)
+	appConfig.Issuer = valueOrDefault(*issuer, fileCfg.Issuer)
+	appConfig.ClientCert = valueOrDefault(sanitizedClientCert, fileCfg.ClientCert)
+	appConfig.VerifyKey = valueOrDefault(sanitizedVerifyKey, fileCfg.VerifyKey)
+	appConfig.VerifyAlgs = fileCfg.VerifyAlgs
+	if *verifyAlgs != "" {
+		appConfig.VerifyAlgs = splitList(*verifyAlgs)
+	}
+	appConfig.DecryptKey = valueOrDefault(sanitizedDecryptKey, fileCfg.DecryptKey)
+	appConfig.Conformance = strings.ToLower(valueOrDefault(*conformanceP, fileCfg.Conformance))
+	appConfig.GeoIPDB = fileCfg.GeoIPDB
+	if *geoipDB != "" {
+		appConfig.GeoIPDB = splitList(*geoipDB)
+	}
+	appConfig.Nonce = valueOrDefault(*nonce, fileCfg.Nonce)
+	appConfig.AccessToken = valueOrDefault(*accessToken, fileCfg.AccessToken)
+	appConfig.AuthCode = valueOrDefault(*authCode, fileCfg.AuthCode)
+	appConfig.ResolveDID = *resolveDID || fileCfg.ResolveDID
+	appConfig.TrustFile = valueOrDefault(sanitizedTrustFile, fileCfg.TrustFile)
+	appConfig.PinnedKeys = fileCfg.PinnedKeys
+	if len(pinnedKeys) > 0 {
+		appConfig.PinnedKeys = pinnedKeys
+	}
+	appConfig.AllowEmbeddedJWK = *allowJWK || fileCfg.AllowEmbeddedJWK
+	appConfig.JKUAllowlist = fileCfg.JKUAllowlist
+	if len(jkuAllowlist) > 0 {
+		appConfig.JKUAllowlist = jkuAllowlist
+	}
+	appConfig.JWKSURL = valueOrDefault(*jwksURL, fileCfg.JWKSURL)
+	appConfig.JWKSCAFile = valueOrDefault(sanitizedJWKSCAFile, fileCfg.JWKSCAFile)
+	appConfig.JWKSCacheDir = valueOrDefault(sanitizedJWKSCacheDir, fileCfg.JWKSCacheDir)
+	appConfig.JWKSTimeout = appConfig.durationOption(fs, *jwksTimeout, fileCfg.JWKSTimeout, "jwks-timeout")
+	appConfig.JWKSCacheTTL = appConfig.durationOption(fs, *jwksCacheTTL, fileCfg.JWKSCacheTTL, "jwks-cache-ttl")
+	appConfig.given.jwksTimeout = flagPassed(fs, "jwks-timeout") || fileCfg.JWKSTimeout != ""
+	appConfig.given.jwksCacheTTL = flagPassed(fs, "jwks-cache-ttl") || fileCfg.JWKSCacheTTL != ""
+	// The key set given by the discovery document is fetched and cached like -jwks-url
+	appConfig.IssuerDiscovery = valueOrDefault(*issuerDisc, fileCfg.IssuerDiscovery)
+	appConfig.AllowUnsupportedCrit = *allowCrit || fileCfg.AllowUnsupportedCrit
+	appConfig.SnapshotDir = valueOrDefault(sanitizedSnapshotDir, fileCfg.SnapshotDir)
+	if at := valueOrDefault(*validateAt, fileCfg.ValidateAt); at != "" {
+		if appConfig.ValidateAt, err = ParseTime(at); err != nil {
+			appConfig.parseProblem("validate-at", err)
+		}
+	}
+	appConfig.Validate = *validateF || fileCfg.Validate
+	appConfig.ClockSkew = appConfig.durationOption(fs, *clockSkew, fileCfg.ClockSkew, "clock-skew")
+	appConfig.given.clockSkew = flagPassed(fs, "clock-skew") || fileCfg.ClockSkew != ""
+	// The wordlist check is command-line only and requires an explicit acknowledgment
+	appConfig.HMACWordlist = sanitizedWordlist
+	appConfig.MaxAttempts = intValueOrDefault(*maxAttempts, 0, defaultMaxAttempts)
+	appConfig.given.ownToken = *ownToken
+	// The HMAC secret is command-line only, so that it is never kept in a configuration
+	// file; it is read once the configuration is valid
+	appConfig.given.hmacSecret = *hmacSecret != ""
+	appConfig.StripPrefixes = fileCfg.StripPrefixes
+	if *stripPrefixes != "" {
+		appConfig.StripPrefixes = splitList(*stripPrefixes)
+	}
 
-	// 6. Determine token source and retrieve the token
-	tokenType, tokenValue, err := getTokenSource(tokenString, &sanitizedTokenFile, tokenEnv, fileCfg)
-	if err != nil {
-		return nil, err
+	// Token sources. The tokens of a list or directory are read token by token during
+	// decoding, and JSON-RPC requests carry their own.
+	appConfig.TokenList = valueOrDefault(sanitizedTokenList, fileCfg.TokenList)
+	appConfig.TokenDir = valueOrDefault(sanitizedTokenDir, fileCfg.TokenDir)
+	appConfig.TokenPattern = valueOrDefault(*tokenPattern, fileCfg.TokenPattern, defaultTokenPattern)
+	appConfig.given.tokenPattern = *tokenPattern != "" || fileCfg.TokenPattern != ""
+	for _, given := range []bool{*tokenString != "", sanitizedTokenFile != "", *tokenEnv, *tokenStdin, *tokenKeychain != "", *tokenRef != "", *tokenCookie != "", *tokenEnvChain != "", fileCfg.TokenType != ""} {
+		if given {
+			appConfig.given.tokenSources++
+		}
 	}
-	appConfig.JWTToken, err = token.GetToken(tokenType, tokenValue)
-	if err != nil {
-		return nil, err
+	appConfig.JSONRPC = *jsonRPC || fileCfg.JSONRPC
+	appConfig.Serve = valueOrDefault(*serve, fileCfg.Serve)
+	// The API key is read once the configuration is valid; the config file can only name it
+	appConfig.given.serveAPIKey = valueOrDefault(*serveAPIKey, fileCfg.ServeAPIKey)
+	if appConfig.Serves() {
+		// Stdout carries the protocol, so status messages would corrupt it, and a server
+		// would print them for every request
+		appConfig.IsSilent = true
 	}
+	// 0 is a valid cache size, so the file value only applies when the flag is not set
+	appConfig.CacheSize = *cacheSize
+	if fileCfg.CacheSize != nil && !flagPassed(fs, "cache-size") {
+		appConfig.CacheSize = *fileCfg.CacheSize
+	}
+	appConfig.QueueSize = *queueSize
+	if fileCfg.QueueSize != nil && !flagPassed(fs, "queue-size") {
+		appConfig.QueueSize = *fileCfg.QueueSize
+	}
+	appConfig.QueuePolicy = strings.ToLower(valueOrDefault(fileCfg.QueuePolicy, *queuePolicy))
+	appConfig.given.queue = flagPassed(fs, "queue-size") || flagPassed(fs, "queue-policy") || fileCfg.QueueSize != nil || fileCfg.QueuePolicy != ""
 
-	// 7. Validate and set defaults for output format and file
-	if appConfig.OutputFormat == "" {
-		appConfig.OutputFormat = OutputFormatJSON
+	// -get prints one claim of the token for shell substitution, in place of the output
+	appConfig.Get = valueOrDefault(*getClaim, fileCfg.Get)
+	// -has-claim and -quiet-output answer through the exit status, for shell conditionals
+	appConfig.HasClaim = valueOrDefault(*hasClaim, fileCfg.HasClaim)
+	appConfig.NonEmpty = *nonEmpty || fileCfg.NonEmpty
+	appConfig.QuietOutput = *quietOutput || fileCfg.QuietOutput
+	if appConfig.Get != "" || appConfig.QuietOutput {
+		// Stdout holds the claim value only, or nothing
+		appConfig.IsSilent = true
 	}
-	if appConfig.OutputFormat != OutputFormatJSON && appConfig.OutputFormat != OutputFormatCSV && appConfig.OutputFormat != OutputFormatXML {
-		return nil, fmt.Errorf("invalid output format; must be JSON, CSV, or XML")
+
+	// A person decoding one token in a terminal gets the sectioned report unless a format is asked for
+	appConfig.given.full = *full || fileCfg.Full
+	appConfig.Full = appConfig.given.full
+	if appConfig.OutputFormat == "" && !appConfig.IsSilent && !appConfig.Batch() && !appConfig.Serves() && terminal.IsTerminal(os.Stdout) {
+		appConfig.Full = true
+	}
+	// and claims written to a terminal are shown as a table
+	if appConfig.OutputFormat == "" && appConfig.OutputFile == output.Stdout && !appConfig.Serves() && terminal.IsTerminal(os.Stdout) {
+		appConfig.OutputFormat = OutputFormatTable
+	}
+	appConfig.OutputFormat = valueOrDefault(appConfig.OutputFormat, OutputFormatJSON)
+	if slices.Contains(OutputFormats, appConfig.OutputFormat) {
+		truncateSpec := valueOrDefault(*truncate, fileCfg.TruncateValues)
+		if appConfig.TruncateValues, err = truncateLimit(truncateSpec, appConfig.OutputFormat); err != nil {
+			appConfig.parseProblem("truncate-values", err)
+		}
+	}
+	appConfig.Resume = *resume || fileCfg.Resume
+	appConfig.KeepGoing = *keepGoing || fileCfg.KeepGoing
+	appConfig.NDJSON = *ndjson || fileCfg.NDJSON
+	appConfig.CheckpointFile = valueOrDefault(sanitizedCheckpoint, fileCfg.CheckpointFile)
+	appConfig.given.exec = valueOrDefault(*execCommand, fileCfg.Exec)
+	appConfig.UsageStats = *usageStats || fileCfg.UsageStats || usagestats.Enabled()
+	appConfig.given.features = givenFeatures(fs, fileCfg)
+	appConfig.ExecOn = fileCfg.ExecOn
+	if *execOn != "" {
+		appConfig.ExecOn = splitList(*execOn)
 	}
 
+	// 6. Validate the merged configuration, reporting every violation of the rules
+	if err := Validate(appConfig); err != nil {
+		return nil, err
+	}
+	if validateOnly {
+		return appConfig, nil
+	}
+
+	// 7. Set the defaults that depend on other options
+	jwksSource := valueOrDefault(appConfig.JWKSURL, appConfig.IssuerDiscovery)
+	if jwksSource != "" && appConfig.JWKSCacheDir == "" {
+		// Without a user cache directory, the key set is fetched at every run
+		if base, err := os.UserCacheDir(); err == nil {
+			appConfig.JWKSCacheDir = filepath.Join(base, "jwtdecode", "jwks")
+		}
+	}
+	if appConfig.OutputFormat == OutputFormatTree && appConfig.TreeStyle == "" {
+		appConfig.TreeStyle = formatter.TreeASCII
+		if terminal.IsTerminal(os.Stdout) {
+			appConfig.TreeStyle = formatter.TreeUnicode
+		}
+	}
 	if appConfig.OutputFile == "" {
-		appConfig.OutputFile = "claims." + strings.ToLower(appConfig.OutputFormat)
+		appConfig.OutputFile = "claims." + FileExtension(appConfig.OutputFormat)
 	}
 	// Sanitize the final output file path
 	appConfig.OutputFile, err = utils.SanitizeFilePath(appConfig.OutputFile)
 	if err != nil {
 		return nil, fmt.Errorf("sanitizing final output file path: %w", err)
 	}
+	if appConfig.Resume && appConfig.CheckpointFile == "" {
+		appConfig.CheckpointFile = checkpoint.Path(appConfig.OutputFile)
+	}
+	if appConfig.given.exec != "" {
+		if len(appConfig.ExecOn) == 0 {
+			appConfig.ExecOn = slices.Clone(ExecEvents)
+		}
+		for i, event := range appConfig.ExecOn {
+			appConfig.ExecOn[i] = strings.ToLower(event)
+		}
+		if appConfig.Exec, err = hook.Parse(appConfig.given.exec, ExecPlaceholders); err != nil {
+			return nil, fmt.Errorf("invalid -exec command: %w", err)
+		}
+	}
+
+	// -dry-run stops once the plan is known, before any secret or token is read
+	if appConfig.DryRun {
+		appConfig.given.options = givenOptions(fs, fileCfg)
+		if !appConfig.Serves() && !appConfig.Batch() {
+			appConfig.given.tokenType, appConfig.given.tokenValue, err = getTokenSource(tokenString, &sanitizedTokenFile, tokenEnv, tokenStdin, tokenKeychain, tokenRef, tokenCookie, tokenEnvChain, fileCfg)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return appConfig, nil
+	}
 
-	// 8. Final security and integrity validation
-	if len(appConfig.JWTToken) > appConfig.MaxTokenSize*1024*1024 {
-		return nil, fmt.Errorf("JWT token size exceeds %dMB limit", appConfig.MaxTokenSize)
+	if *hmacSecret != "" {
+		if appConfig.HMACSecret, err = readSecret("HMAC secret", *hmacSecret); err != nil {
+			return nil, err
+		}
+	}
+	if appConfig.given.serveAPIKey != "" {
+		if appConfig.ServeAPIKey, err = readSecret("API key", appConfig.given.serveAPIKey); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range appConfig.TenantNames() {
+		if value := appConfig.Tenants[name].APIKey; value != "" {
+			key, err := readSecret(fmt.Sprintf("API key of tenant %q", name), value)
+			if err != nil {
+				return nil, err
+			}
+			if appConfig.TenantKeys == nil {
+				appConfig.TenantKeys = map[string][]byte{}
+			}
+			appConfig.TenantKeys[name] = key
+		}
+	}
+
+	// Disable core dumps before the token is read so it can never be written to disk by a crash
+	if appConfig.Harden {
+		if err := secure.DisableCoreDumps(); err != nil {
+			return nil, err
+		}
 	}
-	if strings.Count(appConfig.JWTToken, ".") != 2 {
-		return nil, fmt.Errorf("invalid JWT token format; expected 2 dots")
+
+	// 8. Retrieve the token of a single-token run
+	switch {
+	case appConfig.Serves():
+		appConfig.SnapshotName = snapshot.Name("")
+	case appConfig.Batch():
+		appConfig.SnapshotName = snapshot.Name(valueOrDefault(appConfig.TokenList, appConfig.TokenDir))
+	default:
+		tokenType, tokenValue, err := getTokenSource(tokenString, &sanitizedTokenFile, tokenEnv, tokenStdin, tokenKeychain, tokenRef, tokenCookie, tokenEnvChain, fileCfg)
+		if err != nil {
+			return nil, err
+		}
+		if tokenType == TokenTypeFile {
+			appConfig.SnapshotName = snapshot.Name(tokenValue)
+		} else {
+			appConfig.SnapshotName = snapshot.Name("")
+		}
+		if tokenType == TokenTypeEnvChain {
+			// The variable used is reported, as CI images set tokens in different variables
+			appConfig.JWTToken, appConfig.TokenEnvVar, err = token.FromEnvChain(splitList(tokenValue))
+		} else {
+			appConfig.JWTToken, err = token.GetToken(tokenType, tokenValue, token.Options{
+				StrictPermissions: appConfig.StrictPerms,
+				Warn:              Warn,
+				MaxSize:           int64(appConfig.MaxTokenSize) * 1024 * 1024,
+			})
+		}
+		if err != nil {
+			return nil, err
+		}
+		// 9. Final security and integrity validation (tokens of a batch are validated as they are read)
+		if err := ValidateToken(appConfig.JWTToken, appConfig.MaxTokenSize, appConfig.InputFormat); err != nil {
+			return nil, err
+		}
 	}
 
 	return appConfig, nil
 }
 
+// FileExtension returns the file extension of output files in the output format. TREE
+// and TABLE output is plain text.
+func FileExtension(outputFormat string) string {
+	if outputFormat == OutputFormatTree || outputFormat == OutputFormatTable {
+		return "txt"
+	}
+	return strings.ToLower(outputFormat)
+}
+
+// PartitionExtension returns the file extension of partition files in the output format.
+// JSON partitions are newline-delimited, as expected by warehouse loaders.
+func PartitionExtension(outputFormat string) string {
+	if outputFormat == OutputFormatJSON {
+		return "ndjson"
+	}
+	return FileExtension(outputFormat)
+}
+
+// ValidateToken checks the size limit (in MB) of a token, and that its serialization is
+// the compact JWS for inputformat.JWS, or a recognized one for inputformat.Auto. Other
+// serializations are checked as they are parsed.
+func ValidateToken(jwtToken string, maxSizeMB int, inputFormat string) error {
+	if len(jwtToken) > maxSizeMB*1024*1024 {
+		return jwterrors.Wrap(jwterrors.ErrTokenTooLarge, fmt.Errorf("JWT token size exceeds %dMB limit", maxSizeMB))
+	}
+	switch inputFormat {
+	case inputformat.JWS:
+		if strings.Count(jwtToken, ".") != 2 {
+			return jwterrors.Wrap(jwterrors.ErrMalformedToken, fmt.Errorf("invalid JWT token format; expected 2 dots"))
+		}
+	case inputformat.Auto:
+		if inputformat.Detect(jwtToken) == "" {
+			return jwterrors.Wrap(jwterrors.ErrMalformedToken, fmt.Errorf("unrecognized token format; expected a compact JWS or JWE, JWS JSON, SD-JWT, CWT, or claims JSON (see -input-format)"))
+		}
+	}
+	return nil
+}
+
+// Warn prints a non-fatal warning to stderr. Warnings are security relevant,
+// so they are shown even in silent mode.
+func Warn(msg string) {
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+}
+
+// isSecretReference reports whether value names a secret, as @<file> or a password manager
+// reference, rather than holding it.
+func isSecretReference(value string) bool {
+	return strings.HasPrefix(value, "@") ||
+		slices.ContainsFunc(secretref.Schemes(), func(scheme string) bool { return strings.HasPrefix(value, scheme+"://") })
+}
+
+// readSecret reads the secret of -verify-hmac-secret or -serve-api-key, named by what: the
+// content of a file named with @ (without its trailing newline), the secret of a password
+// manager reference, or the value itself, which other users can see in the process list.
+func readSecret(what, value string) ([]byte, error) {
+	var secret []byte
+	switch {
+	case strings.HasPrefix(value, "@"):
+		data, err := utils.ReadFile(strings.TrimPrefix(value, "@"))
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", what, err)
+		}
+		secret = bytes.TrimRight(data, "\r\n")
+	case slices.ContainsFunc(secretref.Schemes(), func(scheme string) bool { return strings.HasPrefix(value, scheme+"://") }):
+		resolved, err := secretref.Resolve(value)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", what, err)
+		}
+		secret = []byte(resolved)
+	default:
+		Warn(fmt.Sprintf("the %s given on the command line is visible to other users in the process list; prefer @<file> or a password manager reference", what))
+		secret = []byte(value)
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("reading %s: the secret is empty", what)
+	}
+	return secret, nil
+}
+
+// ParseTime parses a reference time given as RFC 3339 or as epoch seconds.
+func ParseTime(s string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q; expected RFC 3339 (e.g., 2024-01-02T15:04:05Z) or epoch seconds", s)
+	}
+	return t, nil
+}
+
+// ReloadConfig loads the configuration again from the same command line and the current
+// contents of the config file, for long-running modes that apply changes without
+// restarting. The command line is parsed into a flag set of its own, so that ReloadConfig
+// may run alongside code reading the flags of the run. Errors are returned as by
+// LoadConfig, without printing the usage, and warnings are printed as by LoadConfig.
+func ReloadConfig(version string) (*AppConfig, error) {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return load(fs, version, os.Args[1:], false)
+}
+
 // readConfigFile reads and unmarshals the JSON configuration file using secure os.Root.
 func readConfigFile(filePath string) (*FileConfig, error) {
 	// Obtain absolute path to resolve the root directory safely
@@ -181,14 +1061,19 @@ func readConfigFile(filePath string) (*FileConfig, error) {
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file %q: %w", filePath, err)
 	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %q: %w", filePath, err)
+	}
+	cfg.fields = fields
 	return &cfg, nil
 }
 
 // getTokenSource determines the token source (type and value) from flags or config file.
 // It enforces that only one token source is provided via flags.
-func getTokenSource(tokenString *string, tokenFile *string, tokenEnv *bool, cfg *FileConfig) (string, string, error) {
+func getTokenSource(tokenString *string, tokenFile *string, tokenEnv *bool, tokenStdin *bool, tokenKeychain *string, tokenRef *string, tokenCookie *string, tokenEnvChain *string, cfg *FileConfig) (string, string, error) {
 	// 1. Check if any token source is provided via command-line flags
-	if *tokenString != "" || *tokenFile != "" || *tokenEnv {
+	if *tokenString != "" || *tokenFile != "" || *tokenEnv || *tokenStdin || *tokenKeychain != "" || *tokenRef != "" || *tokenCookie != "" || *tokenEnvChain != "" {
 		sources := 0
 		var sourceType, sourceValue string
 		if *tokenString != "" {
@@ -206,6 +1091,31 @@ func getTokenSource(tokenString *string, tokenFile *string, tokenEnv *bool, cfg
 			sourceType = TokenTypeEnvironment
 			sourceValue = "" // Default environment variable name is handled in token.GetToken
 		}
+		if *tokenStdin {
+			sources++
+			sourceType = TokenTypeStdin
+			sourceValue = ""
+		}
+		if *tokenKeychain != "" {
+			sources++
+			sourceType = TokenTypeKeychain
+			sourceValue = *tokenKeychain
+		}
+		if *tokenRef != "" {
+			sources++
+			sourceType = TokenTypeReference
+			sourceValue = *tokenRef
+		}
+		if *tokenCookie != "" {
+			sources++
+			sourceType = TokenTypeCookie
+			sourceValue = *tokenCookie
+		}
+		if *tokenEnvChain != "" {
+			sources++
+			sourceType = TokenTypeEnvChain
+			sourceValue = *tokenEnvChain
+		}
 		// Error if multiple sources are specified via flags
 		if sources > 1 {
 			return "", "", fmt.Errorf("multiple token sources provided via flags; only one is allowed")
@@ -222,6 +1132,128 @@ func getTokenSource(tokenString *string, tokenFile *string, tokenEnv *bool, cfg
 	return "", "", fmt.Errorf("no token source provided")
 }
 
+// durationOrDefault returns the value of a duration flag when it was passed, else the
+// duration of the config file when set, else the flag default.
+func durationOrDefault(fs *flag.FlagSet, flagValue time.Duration, fileValue, name string) (time.Duration, error) {
+	if flagPassed(fs, name) || fileValue == "" {
+		return flagValue, nil
+	}
+	d, err := time.ParseDuration(fileValue)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, fileValue, err)
+	}
+	return d, nil
+}
+
+// flagPassed reports whether the named command-line flag of fs was set.
+func flagPassed(fs *flag.FlagSet, name string) bool {
+	passed := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
+}
+
+// givenFeatures returns the names of the flags given on the command line, or of the flags
+// of the fields of the config file, other than the token sources.
+func givenFeatures(fs *flag.FlagSet, fileCfg *FileConfig) []string {
+	var features []string
+	if fileCfg.fields == nil {
+		fs.Visit(func(f *flag.Flag) {
+			if fileFields[f.Name] != "tokenType" {
+				features = append(features, f.Name)
+			}
+		})
+		return features
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		if name := fileField(f.Name); name != "" && name != "tokenType" && fileCfg.fields[name] != nil {
+			features = append(features, f.Name)
+		}
+	})
+	return features
+}
+
+// Features returns the options given, named by their flag (e.g., convert-epoch), without
+// their values, for the usage statistics.
+func (c *AppConfig) Features() []string {
+	return c.given.features
+}
+
+// otherFlagsSet reports whether any command-line flag of fs other than the named ones was
+// set.
+func otherFlagsSet(fs *flag.FlagSet, allowed ...string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		for _, name := range allowed {
+			if f.Name == name {
+				return
+			}
+		}
+		set = true
+	})
+	return set
+}
+
+// stringList is a repeatable flag collecting values; each value may also be comma-separated.
+type stringList []string
+
+// String returns the collected values as a comma-separated list.
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set appends the values of one flag occurrence.
+func (l *stringList) Set(value string) error {
+	*l = append(*l, splitList(value)...)
+	return nil
+}
+
+// splitList splits a comma-separated flag value, trimming whitespace and dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// truncateLimit returns the -truncate-values limit of an output format from its spec: a
+// limit for every format (e.g., 512), or comma-separated per-format limits (e.g.,
+// CSV=512,XML=1024), the formats that are not listed keeping full values.
+func truncateLimit(spec, outputFormat string) (int, error) {
+	if spec == "" {
+		return 0, nil
+	}
+	if !strings.Contains(spec, "=") {
+		limit, err := strconv.Atoi(strings.TrimSpace(spec))
+		if err != nil || limit <= 0 {
+			return 0, fmt.Errorf("invalid -truncate-values %q: a positive number of characters is required", spec)
+		}
+		return limit, nil
+	}
+	result := 0
+	for _, item := range splitList(spec) {
+		format, value, _ := strings.Cut(item, "=")
+		format = strings.ToUpper(strings.TrimSpace(format))
+		if !slices.Contains(OutputFormats, format) {
+			return 0, fmt.Errorf("invalid -truncate-values %q: unknown format %q; must be JSON, CSV, XML, TREE, or TABLE", spec, format)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit <= 0 {
+			return 0, fmt.Errorf("invalid -truncate-values %q: a positive number of characters is required for %s", spec, format)
+		}
+		if format == outputFormat {
+			result = limit
+		}
+	}
+	return result, nil
+}
+
 // valueOrDefault returns the first non-empty string.
 func valueOrDefault(values ...string) string {
 	for _, v := range values {
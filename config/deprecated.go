@@ -0,0 +1,48 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Deprecation maps a renamed flag or config file field to its replacement. The old name
+// keeps working, with a warning, until it is removed; -strict-flags makes it an error so
+// that automation can be migrated before then.
+type Deprecation struct {
+	Old   string // Deprecated name, without the leading dash of a flag
+	New   string // Name replacing it
+	Field bool   // Old and New are config file fields rather than flags
+}
+
+// Deprecations are the deprecated flags and config file fields.
+var Deprecations = []Deprecation{
+	{Old: "pattern", New: "token-pattern"},
+	{Old: "from-browser-cookie", New: "token-cookie"},
+	{Old: "silentExec", New: "silent", Field: true},
+}
+
+// registerDeprecatedFlags defines the deprecated flags as aliases of the flags replacing
+// them, which must be defined first.
+func registerDeprecatedFlags(fs *flag.FlagSet) {
+	for _, d := range Deprecations {
+		if d.Field {
+			continue
+		}
+		fs.Var(fs.Lookup(d.New).Value, d.Old, "Deprecated: use -"+d.New)
+	}
+}
+
+// deprecatedUsage returns a violation for each deprecated flag passed and each deprecated
+// field of the config file.
+func deprecatedUsage(fs *flag.FlagSet, fileCfg *FileConfig) []Violation {
+	var v []Violation
+	for _, d := range Deprecations {
+		switch {
+		case d.Field && fileCfg.fields[d.Old] != nil:
+			v = append(v, Violation{Field: d.Old, Message: "is deprecated", Fix: fmt.Sprintf("rename it to %s", d.New)})
+		case !d.Field && flagPassed(fs, d.Old):
+			v = append(v, Violation{Field: "-" + d.Old, Message: "is deprecated", Fix: fmt.Sprintf("use -%s instead", d.New)})
+		}
+	}
+	return v
+}
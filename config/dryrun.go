@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"jwtdecode/output"
+	"jwtdecode/partition"
+)
+
+// WritePlan writes the plan of a -dry-run run to w: the options given, the token source,
+// the claims processing pipeline, and the output destination. Secrets given as values,
+// such as a token string, are not shown.
+func (c *AppConfig) WritePlan(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("Dry run: no token is read and no output is written\n")
+	if c.ConfigFile != "" {
+		fmt.Fprintf(&b, "Configuration: config file %s\n", c.ConfigFile)
+	} else {
+		b.WriteString("Configuration: command line\n")
+	}
+	b.WriteString("Options:\n")
+	if len(c.given.options) == 0 {
+		b.WriteString("  none (defaults)\n")
+	}
+	for _, option := range c.given.options {
+		fmt.Fprintf(&b, "  %s\n", option)
+	}
+
+	fmt.Fprintf(&b, "Token source: %s\n", c.describeTokenSource())
+
+	b.WriteString("Pipeline:\n")
+	steps := c.Steps()
+	if len(steps) == 0 {
+		b.WriteString("  none (claims are output as decoded)\n")
+	}
+	for i, step := range steps {
+		fmt.Fprintf(&b, "  %d. %s", i+1, step.Step)
+		if options := strings.TrimSpace(string(step.Options)); options != "" && options != "null" && options != "{}" {
+			fmt.Fprintf(&b, " %s", options)
+		}
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(&b, "Output: %s\n", c.describeOutput())
+	if c.Full {
+		b.WriteString("Report: header, payload, signature, and timing sections on standard output\n")
+	}
+	if c.SnapshotDir != "" {
+		fmt.Fprintf(&b, "Snapshot: %s\n", c.SnapshotDir)
+	}
+	if c.Exec != nil {
+		fmt.Fprintf(&b, "Command run on %s: %s\n", strings.Join(c.ExecOn, ", "), c.given.exec)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// describeTokenSource describes where the tokens of the run would be read from.
+func (c *AppConfig) describeTokenSource() string {
+	switch {
+	case c.JSONRPC:
+		return "JSON-RPC requests on standard input"
+	case c.Serve != "":
+		return fmt.Sprintf("HTTP requests on %s (POST /decode and /verify)", c.Serve)
+	case c.TokenList != "":
+		return fmt.Sprintf("token list %s, one token per line", c.TokenList)
+	case c.TokenDir != "":
+		return fmt.Sprintf("token directory %s, files matching %s", c.TokenDir, c.TokenPattern)
+	}
+	value := c.given.tokenValue
+	switch c.given.tokenType {
+	case TokenTypeString:
+		return fmt.Sprintf("token string (%d characters, not shown)", len(value))
+	case TokenTypeFile:
+		return "file " + value
+	case TokenTypeEnvironment:
+		return "environment variable " + valueOrDefault(value, "JWT_TOKEN")
+	case TokenTypeEnvChain:
+		return "first set environment variable of " + strings.Join(splitList(value), ", ")
+	case TokenTypeKeychain:
+		return "platform secret store entry " + value
+	case TokenTypeReference:
+		return "password manager reference " + value
+	case TokenTypeCookie:
+		return "browser cookie " + value
+	case TokenTypeStdin:
+		return "standard input"
+	}
+	return fmt.Sprintf("%s %s", c.given.tokenType, value)
+}
+
+// describeOutput describes where the output of the run would be written.
+func (c *AppConfig) describeOutput() string {
+	switch {
+	case c.JSONRPC:
+		return "JSON-RPC responses on standard output"
+	case c.Serve != "":
+		return fmt.Sprintf("HTTP responses, in the format asked for by each request (default %s)", c.OutputFormat)
+	case c.QuietOutput:
+		return "none (-quiet-output); the exit status is the result"
+	case c.Get != "":
+		return fmt.Sprintf("raw value of claim %s on standard output", c.Get)
+	case len(c.PartitionBy) > 0:
+		template := c.PartitionTemplate
+		if template == "" {
+			template = partition.DefaultTemplate(c.PartitionBy, PartitionExtension(c.OutputFormat))
+		}
+		return fmt.Sprintf("%s partition files by %s, at %s", c.OutputFormat, strings.Join(c.PartitionBy, ", "), template)
+	case c.OutputFile == output.Stdout:
+		return c.OutputFormat + " on standard output"
+	}
+	return fmt.Sprintf("%s to %s", c.OutputFormat, c.OutputFile)
+}
+
+// givenOptions lists the options of the run as given: the command-line flags with their
+// values, or the fields of the config file with their JSON values. A token string, and an
+// HMAC secret or API key given as a value, are masked.
+func givenOptions(fs *flag.FlagSet, fileCfg *FileConfig) []string {
+	var options []string
+	if fileCfg.fields != nil {
+		names := make([]string, 0, len(fileCfg.fields))
+		for name := range fileCfg.fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			value := string(fileCfg.fields[name])
+			if name == "jwtToken" && fileCfg.TokenType == TokenTypeString {
+				value = `"(not shown)"`
+			}
+			options = append(options, name+": "+compactJSON(value))
+		}
+		return options
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "dry-run" {
+			return
+		}
+		value := f.Value.String()
+		switch {
+		case f.Name == "token-string":
+			value = "(not shown)"
+		case (f.Name == "verify-hmac-secret" || f.Name == "serve-api-key") && !isSecretReference(value):
+			value = "(not shown)"
+		}
+		if getter, ok := f.Value.(flag.Getter); ok {
+			if b, isBool := getter.Get().(bool); isBool && b {
+				options = append(options, "-"+f.Name)
+				return
+			}
+		}
+		options = append(options, fmt.Sprintf("-%s %s", f.Name, value))
+	})
+	return options
+}
+
+// compactJSON returns a JSON value on a single line, or the value itself if it does not
+// parse.
+func compactJSON(value string) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(value)); err != nil {
+		return value
+	}
+	return compact.String()
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"jwtdecode/formatter"
+)
+
+// FormatOptions is the formatOptions section of the config file: settings of single
+// output formats, which have no command-line flag.
+type FormatOptions struct {
+	CSV   CSVFormatOptions  `json:"csv"`
+	XML   XMLFormatOptions  `json:"xml"`
+	Dates DateFormatOptions `json:"dates"`
+}
+
+// CSVFormatOptions are the settings of CSV output.
+type CSVFormatOptions struct {
+	Delimiter string `json:"delimiter"` // Field delimiter, a single character (default ",")
+}
+
+// XMLFormatOptions are the settings of XML output.
+type XMLFormatOptions struct {
+	Root    string `json:"root"`    // Element holding the claims of a token (default JWTClaims)
+	SetRoot string `json:"setRoot"` // Root element of a batch (default JWTClaimsSet)
+}
+
+// DateFormatOptions are the settings of the datestamps added by convertEpoch.
+type DateFormatOptions struct {
+	Layout string `json:"layout"` // Go time layout of the datestamps (default "2006-01-02 15:04:05 UTC")
+}
+
+// DateLayout returns the layout of the datestamps added by -convert-epoch: that of
+// -time-format, or formatOptions.dates.layout.
+func (c *AppConfig) DateLayout() string {
+	if c.TimeFormat != "" {
+		return formatter.ResolveLayout(c.TimeFormat)
+	}
+	if c.FormatOptions.Dates.Layout == "" {
+		return formatter.DefaultDateLayout
+	}
+	return c.FormatOptions.Dates.Layout
+}
+
+// Location returns the time zone of -timezone, in which datestamps and dates are shown;
+// UTC when it is not set, or not valid (see the validation rules).
+func (c *AppConfig) Location() *time.Location {
+	loc, err := formatter.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// FormatterOptions returns the options passed to the formatter of the output format,
+// from the output flags and the formatOptions section of the config file.
+func (c *AppConfig) FormatterOptions() formatter.Options {
+	delimiter, _ := utf8.DecodeRuneInString(c.FormatOptions.CSV.Delimiter)
+	if c.FormatOptions.CSV.Delimiter == "" {
+		delimiter = 0
+	}
+	return formatter.Options{
+		JSON:  formatter.JSONOptions{NDJSON: c.NDJSON, ASCIIOnly: c.ASCIIOnly},
+		CSV:   formatter.CSVOptions{Delimiter: delimiter, MissingValue: c.MissingValue},
+		XML:   formatter.XMLOptions{Root: c.FormatOptions.XML.Root, SetRoot: c.FormatOptions.XML.SetRoot, Multidoc: c.XMLMultidoc},
+		Tree:  formatter.TreeOptions{Style: c.TreeStyle},
+		Table: formatter.TableOptions{Layout: c.DateLayout(), Location: c.Location()},
+	}
+}
+
+// validLayout reports whether a time layout holds at least one element of the reference
+// time, so that datestamps are not all the same text.
+func validLayout(layout string) bool {
+	return time.Unix(0, 0).UTC().Format(layout) != layout
+}
+
+// validXMLName reports whether name is a valid XML element name without a namespace
+// prefix, and does not start with the reserved "xml".
+func validXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
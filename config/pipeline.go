@@ -0,0 +1,70 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"jwtdecode/process"
+)
+
+// Steps returns the claims processing pipeline: the pipeline of the config file, or the
+// steps of the shorthand flags, in the order in which they have always run
+// (-strip-claim-prefix, -omit-null, -redact and -redact-pii, -convert-epoch with
+// -epoch-claims), after -decode-nested so that they apply to the claims of nested tokens
+// as well. The convert-epoch steps of a pipeline
+// without a timezone option get that of -timezone.
+func (c *AppConfig) Steps() []process.Definition {
+	if len(c.Pipeline) > 0 {
+		return c.pipelineInTimezone()
+	}
+	var steps []process.Definition
+	if c.DecodeNested {
+		steps = append(steps, process.Define(process.StepDecodeNested, process.DecodeNested{}))
+	}
+	if len(c.StripPrefixes) > 0 {
+		steps = append(steps, process.Define(process.StepRename, process.Rename{Prefixes: c.StripPrefixes}))
+	}
+	if c.OmitNull {
+		steps = append(steps, process.Define(process.StepFilter, process.Filter{OmitNull: true}))
+	}
+	if len(c.Redact) > 0 || c.RedactPII {
+		redact := process.Redact{Claims: c.Redact, Mask: true}
+		if c.RedactPII {
+			redact.Profile = process.RedactProfilePII
+		}
+		steps = append(steps, process.Define(process.StepRedact, redact))
+	}
+	if c.ConvertEpoch {
+		steps = append(steps, process.Define(process.StepConvertEpoch, process.ConvertEpoch{Unit: c.EpochUnit, Claims: c.EpochClaims, Timezone: c.Timezone}))
+	}
+	return steps
+}
+
+// pipelineInTimezone returns the pipeline of the config file, with the timezone of
+// -timezone set in its convert-epoch steps that have none. Steps whose options cannot be
+// decoded are left as they are, for the validation rules to report.
+func (c *AppConfig) pipelineInTimezone() []process.Definition {
+	if c.Timezone == "" {
+		return c.Pipeline
+	}
+	steps := make([]process.Definition, len(c.Pipeline))
+	copy(steps, c.Pipeline)
+	for i, def := range steps {
+		if def.Step != process.StepConvertEpoch {
+			continue
+		}
+		var opts process.ConvertEpoch
+		if len(def.Options) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(def.Options))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(&opts); err != nil {
+				continue
+			}
+		}
+		if opts.Timezone == "" {
+			opts.Timezone = c.Timezone
+			steps[i] = process.Define(process.StepConvertEpoch, opts)
+		}
+	}
+	return steps
+}
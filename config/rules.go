@@ -0,0 +1,641 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"jwtdecode/conformance"
+	"jwtdecode/formatter"
+	"jwtdecode/hook"
+	"jwtdecode/inputformat"
+	"jwtdecode/jwterrors"
+	"jwtdecode/output"
+	"jwtdecode/partition"
+	"jwtdecode/process"
+	"jwtdecode/provider"
+	"jwtdecode/query"
+)
+
+// Violation is a problem of a configuration, found by a validation rule.
+type Violation struct {
+	Field   string `json:"field"`         // Option at fault: its flag, or its config file field
+	Message string `json:"message"`       // What is wrong with the option
+	Fix     string `json:"fix,omitempty"` // Suggested fix; empty when there is no obvious one
+}
+
+// String returns the violation as "field: message (fix)".
+func (v Violation) String() string {
+	s := v.Field + ": " + v.Message
+	if v.Fix != "" {
+		s += " (" + v.Fix + ")"
+	}
+	return s
+}
+
+// ValidationError holds every violation of a configuration, so that they can all be
+// fixed at once.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error returns the violation, or the list of violations one per line.
+func (e *ValidationError) Error() string {
+	if len(e.Violations) == 1 {
+		return e.Violations[0].String()
+	}
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = "  " + v.String()
+	}
+	return fmt.Sprintf("%d configuration problems:\n%s", len(e.Violations), strings.Join(lines, "\n"))
+}
+
+// Unwrap returns jwterrors.ErrInvalidConfig, the category of validation errors.
+func (e *ValidationError) Unwrap() error {
+	return jwterrors.ErrInvalidConfig
+}
+
+// Rule checks one constraint of a merged configuration and returns its violations.
+type Rule func(c *AppConfig) []Violation
+
+// Rules are the rules Validate checks, in the order their violations are reported.
+var Rules = []Rule{
+	checkInputFormat,
+	checkStdout,
+	checkVerification,
+	checkJWKS,
+	checkWordlist,
+	checkTokenSources,
+	checkClaimQueries,
+	checkOutputFormat,
+	checkOutputOptions,
+	checkFormatOptions,
+	checkPipeline,
+	checkQuery,
+	checkPartitions,
+	checkResume,
+	checkExec,
+	checkValidate,
+	checkServe,
+	checkTenants,
+	checkLimits,
+}
+
+// settings records how options were given, for the rules that need more than their
+// merged values.
+type settings struct {
+	problems     []Violation // Values that could not be parsed when merging
+	outputFile   string      // -output-file, before -stdout applies
+	stdout       bool        // -stdout
+	full         bool        // -full, before it defaults on for a terminal
+	tokenSources int         // Single-token sources given
+	tokenPattern bool        // -token-pattern
+	jwksTimeout  bool        // -jwks-timeout
+	jwksCacheTTL bool        // -jwks-cache-ttl
+	clockSkew    bool        // -clock-skew
+	hmacSecret   bool        // -verify-hmac-secret, which is read once the configuration is valid
+	serveAPIKey  string      // -serve-api-key or serveApiKey, which is read once the configuration is valid
+	queue        bool        // -queue-size or -queue-policy
+	ownToken     bool        // --i-own-this-token
+	exec         string      // -exec command, before parsing
+	options      []string    // Options given, as listed by -dry-run
+	features     []string    // Options given, named by their flag, for the usage statistics
+	tokenType    string      // Token source of a -dry-run of a single token
+	tokenValue   string      // Value of the token source of a -dry-run
+}
+
+// Validate checks a merged configuration against every rule and returns a
+// *ValidationError holding all the violations found, or nil.
+func Validate(c *AppConfig) error {
+	violations := slices.Clone(c.given.problems)
+	for _, rule := range Rules {
+		violations = append(violations, rule(c)...)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: violations}
+}
+
+// fileFields are the config file fields of the flags whose name is not the field name
+// in camel case. Flags mapped to "" are command-line only.
+var fileFields = map[string]string{
+	"token-string":       "tokenType",
+	"token-file":         "tokenType",
+	"token-env":          "tokenType",
+	"token-env-chain":    "tokenType",
+	"token-stdin":        "tokenType",
+	"token-keychain":     "tokenType",
+	"token-ref":          "tokenType",
+	"token-cookie":       "tokenType",
+	"checkpoint":         "checkpointFile",
+	"max-token-size":     "maxTokenSizeMB",
+	"max-output-size":    "maxOutputSizeMB",
+	"strip-claim-prefix": "stripClaimPrefixes",
+	"trust":              "trustFile",
+	"pin-key":            "pinnedKeys",
+	"geoip-db":           "geoipDB",
+	"auth-code":          "authorizationCode",
+	"verify-hmac-secret": "",
+	"hmac-wordlist":      "",
+	"max-attempts":       "",
+	"i-own-this-token":   "",
+}
+
+// field returns the path of an option, named by its flag, where the configuration was
+// given: the flag on the command line, or the field of the config file.
+func (c *AppConfig) field(flagName string) string {
+	if c.ConfigFile == "" {
+		return "-" + flagName
+	}
+	if name := fileField(flagName); name != "" {
+		return name
+	}
+	return "-" + flagName
+}
+
+// fileField returns the config file field of a flag; empty for command-line only flags.
+func fileField(flagName string) string {
+	name, ok := fileFields[flagName]
+	if !ok {
+		parts := strings.Split(flagName, "-")
+		for i := 1; i < len(parts); i++ {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+		name = strings.Join(parts, "")
+	}
+	return name
+}
+
+// serveFlag returns the flag of the mode receiving tokens in requests.
+func (c *AppConfig) serveFlag() string {
+	if c.JSONRPC {
+		return "jsonrpc"
+	}
+	return "serve"
+}
+
+// violation returns a violation of the option named by its flag.
+func (c *AppConfig) violation(flagName, fix, format string, args ...interface{}) Violation {
+	return Violation{Field: c.field(flagName), Message: fmt.Sprintf(format, args...), Fix: fix}
+}
+
+// oneOf returns a violation if value is not one of the allowed values.
+func (c *AppConfig) oneOf(flagName, value string, allowed []string) []Violation {
+	if slices.Contains(allowed, value) {
+		return nil
+	}
+	return []Violation{c.violation(flagName, "", "invalid value %q; must be one of: %s", value, strings.Join(allowed, ", "))}
+}
+
+func checkInputFormat(c *AppConfig) []Violation {
+	v := c.oneOf("input-format", c.InputFormat, inputformat.Formats)
+	switch {
+	case c.DecryptKey != "" && c.InputFormat != inputformat.Auto && c.InputFormat != inputformat.JWE:
+		v = append(v, c.violation("decrypt-key", "use -input-format auto or jwe, or remove -decrypt-key", "cannot decrypt %s input", c.InputFormat))
+	case c.DecryptKey == "" && c.InputFormat == inputformat.JWE:
+		v = append(v, c.violation("input-format", "add -decrypt-key with the private or symmetric key", "jwe input requires -decrypt-key"))
+	}
+	return v
+}
+
+func checkStdout(c *AppConfig) []Violation {
+	if c.given.stdout && c.given.outputFile != "" && c.given.outputFile != output.Stdout {
+		return []Violation{c.violation("stdout", "remove -output-file, or use -output-file - alone", "cannot be combined with -output-file")}
+	}
+	return nil
+}
+
+func checkVerification(c *AppConfig) []Violation {
+	var v []Violation
+	if len(c.VerifyAlgs) > 0 && c.VerifyKey == "" {
+		v = append(v, c.violation("verify-algs", "add -verify-key with the PEM public key, or remove -verify-algs", "requires -verify-key"))
+	}
+	if c.Conformance != "" {
+		if _, err := conformance.Check(c.Conformance, nil, nil); err != nil {
+			v = append(v, c.violation("conformance", "", "%v", err))
+		}
+	}
+	for _, prefix := range c.JKUAllowlist {
+		if !strings.HasPrefix(prefix, "https://") {
+			v = append(v, c.violation("jku-allowlist", "", "entry %q must be an https:// URL", prefix))
+		}
+	}
+	if c.Provider != "" {
+		if _, err := provider.Get(c.Provider, provider.Options{}); err != nil {
+			v = append(v, c.violation("provider", "", "%v", err))
+		}
+	}
+	if len(c.PinnedKeys) > 0 && !c.Verifies() && !c.given.hmacSecret {
+		v = append(v, c.violation("pin-key", "add a verification option (-verify-key, -jwks-url, -issuer-discovery, -trust, -resolve-did, -provider, -allow-embedded-jwk, -jku-allowlist, or -verify-hmac-secret), or remove -pin-key",
+			"key pinning requires signature verification"))
+	}
+	return v
+}
+
+func checkJWKS(c *AppConfig) []Violation {
+	var v []Violation
+	if c.JWKSURL != "" && c.IssuerDiscovery != "" {
+		v = append(v, c.violation("issuer-discovery", "keep -jwks-url or -issuer-discovery", "cannot be combined with -jwks-url"))
+	}
+	if c.IssuerDiscovery != "" && !strings.HasPrefix(c.IssuerDiscovery, "https://") {
+		v = append(v, c.violation("issuer-discovery", "", "%q must be an https:// URL", c.IssuerDiscovery))
+	}
+	if c.JWKSURL != "" && !strings.HasPrefix(c.JWKSURL, "https://") {
+		v = append(v, c.violation("jwks-url", "", "%q must be an https:// URL", c.JWKSURL))
+	}
+	if c.JWKSURL == "" && c.IssuerDiscovery == "" {
+		options := []struct {
+			name string
+			set  bool
+		}{
+			{"jwks-timeout", c.given.jwksTimeout},
+			{"jwks-ca-file", c.JWKSCAFile != ""},
+			{"jwks-cache-dir", c.JWKSCacheDir != ""},
+			{"jwks-cache-ttl", c.given.jwksCacheTTL},
+		}
+		for _, option := range options {
+			if option.set {
+				v = append(v, c.violation(option.name, "add -jwks-url or -issuer-discovery, or remove -"+option.name, "requires -jwks-url or -issuer-discovery"))
+			}
+		}
+	}
+	if c.JWKSTimeout <= 0 {
+		v = append(v, c.violation("jwks-timeout", "", "must be positive"))
+	}
+	if c.JWKSCacheTTL < 0 {
+		v = append(v, c.violation("jwks-cache-ttl", "use 0 to disable the cache", "must not be negative"))
+	}
+	return v
+}
+
+func checkWordlist(c *AppConfig) []Violation {
+	var v []Violation
+	if c.HMACWordlist != "" && !c.given.ownToken {
+		v = append(v, c.violation("hmac-wordlist", "add --i-own-this-token to confirm you are authorized to test this token", "requires --i-own-this-token"))
+	}
+	if c.MaxAttempts < 1 {
+		v = append(v, c.violation("max-attempts", "", "must be positive"))
+	}
+	return v
+}
+
+func checkTokenSources(c *AppConfig) []Violation {
+	var v []Violation
+	if _, err := filepath.Match(c.TokenPattern, ""); err != nil {
+		v = append(v, c.violation("token-pattern", "", "invalid token file pattern %q: %v", c.TokenPattern, err))
+	}
+	if c.TokenDir == "" && c.given.tokenPattern {
+		v = append(v, c.violation("token-pattern", "add -token-dir, or remove -token-pattern", "applies to -token-dir only"))
+	}
+	sourceField := "token source"
+	if c.ConfigFile != "" {
+		sourceField = "tokenType"
+	}
+	switch {
+	case c.Serves():
+		if c.given.tokenSources > 0 || c.Batch() {
+			v = append(v, c.violation(c.serveFlag(), "remove the token source", "receives tokens in requests and cannot be combined with a token source"))
+		}
+		if c.Harden {
+			v = append(v, c.violation("harden", "remove -harden", "protects a single token and cannot be used with -%s", c.serveFlag()))
+		}
+	case c.Batch():
+		if c.given.tokenSources > 0 || (c.TokenList != "" && c.TokenDir != "") {
+			v = append(v, Violation{Field: sourceField, Message: "multiple token sources provided; a token list or directory cannot be combined with another token source", Fix: "keep one token source"})
+		}
+		if c.Harden {
+			v = append(v, c.violation("harden", "remove -harden", "protects a single token and cannot be used with -token-list or -token-dir"))
+		}
+	case c.given.tokenSources > 1:
+		v = append(v, Violation{Field: sourceField, Message: "multiple token sources provided; only one is allowed", Fix: "keep one token source"})
+	case c.given.tokenSources == 0:
+		v = append(v, Violation{Field: sourceField, Message: "no token source provided",
+			Fix: "add one of -token-string, -token-file, -token-env, -token-env-chain, -token-stdin, -token-keychain, -token-ref, -token-cookie, -token-list, or -token-dir"})
+	}
+	if c.CacheSize < 0 {
+		v = append(v, c.violation("cache-size", "use 0 to disable the cache", "invalid value %d; must be 0 or greater", c.CacheSize))
+	}
+	return v
+}
+
+func checkClaimQueries(c *AppConfig) []Violation {
+	var v []Violation
+	if c.Get != "" {
+		if c.Batch() || c.Serves() {
+			v = append(v, c.violation("get", "", "applies to a single token"))
+		}
+		if c.given.outputFile != "" || c.given.stdout || len(c.PartitionBy) > 0 || c.given.full {
+			v = append(v, c.violation("get", "remove -output-file, -stdout, -partition-by, and -full",
+				"prints the claim instead of the output and cannot be combined with -output-file, -stdout, -partition-by, or -full"))
+		}
+	}
+	if c.NonEmpty && c.HasClaim == "" {
+		v = append(v, c.violation("non-empty", "add -has-claim, or remove -non-empty", "requires -has-claim"))
+	}
+	if c.HasClaim != "" && c.Serves() {
+		v = append(v, c.violation("has-claim", "", "cannot be combined with -%s", c.serveFlag()))
+	}
+	if c.QuietOutput {
+		if c.Serves() {
+			v = append(v, c.violation("quiet-output", "", "cannot be combined with -%s", c.serveFlag()))
+		}
+		if c.given.outputFile != "" || c.given.stdout || len(c.PartitionBy) > 0 || c.Get != "" || c.given.full {
+			v = append(v, c.violation("quiet-output", "remove -output-file, -stdout, -partition-by, -get, and -full",
+				"writes no output and cannot be combined with -output-file, -stdout, -partition-by, -get, or -full"))
+		}
+	}
+	return v
+}
+
+func checkOutputFormat(c *AppConfig) []Violation {
+	var v []Violation
+	if c.Full && c.JSONRPC {
+		v = append(v, c.violation("full", "", "cannot be combined with -jsonrpc, whose stdout carries the protocol"))
+	}
+	if c.Full && c.Serve != "" {
+		v = append(v, c.violation("full", "", "cannot be combined with -serve, which answers requests instead of writing output"))
+	}
+	if c.Full && c.OutputFile == output.Stdout {
+		v = append(v, c.violation("full", "remove -stdout, or use -output-file with a file", "cannot be combined with output to stdout"))
+	}
+	return append(v, c.oneOf("output-format", c.OutputFormat, OutputFormats)...)
+}
+
+func checkOutputOptions(c *AppConfig) []Violation {
+	var v []Violation
+	jsonOnly := func(flagName string, set bool) {
+		if set && c.OutputFormat != OutputFormatJSON {
+			v = append(v, c.violation(flagName, "use -output-format JSON, or remove -"+flagName, "applies to JSON output only"))
+		}
+	}
+	jsonOnly("preserve-order", c.PreserveOrder)
+	jsonOnly("envelope", c.Envelope)
+	v = append(v, c.oneOf("error-format", c.ErrorFormat, ErrorFormats)...)
+	if c.Envelope && c.PreserveOrder {
+		v = append(v, c.violation("envelope", "remove -envelope or -preserve-order", "cannot be combined with -preserve-order"))
+	}
+	if c.Envelope && c.VerifyRoundtrip {
+		v = append(v, c.violation("verify-roundtrip", "remove -verify-roundtrip or -envelope", "reads claims documents back and cannot be combined with -envelope"))
+	}
+	if c.IncludeHeader && c.HeaderOnly {
+		v = append(v, c.violation("include-header", "keep -include-header or -header-only", "cannot be combined with -header-only"))
+	}
+	if (c.IncludeHeader || c.HeaderOnly) && c.Envelope {
+		name := "include-header"
+		if c.HeaderOnly {
+			name = "header-only"
+		}
+		v = append(v, c.violation(name, "remove -"+name, "cannot be combined with -envelope, which already holds the header"))
+	}
+	if c.BinaryValues != "" {
+		v = append(v, c.oneOf("binary-values", c.BinaryValues, formatter.BinaryModes)...)
+	}
+	jsonOnly("ascii-only", c.ASCIIOnly)
+	if c.StripControl && c.OutputFormat == OutputFormatJSON {
+		v = append(v, c.violation("strip-control", "use -output-format CSV or XML, or remove -strip-control", "applies to CSV and XML output only"))
+	}
+	if c.MissingValue != "" && c.OutputFormat != OutputFormatCSV {
+		v = append(v, c.violation("missing-value", "use -output-format CSV, or remove -missing-value", "applies to CSV output only"))
+	}
+	if c.TreeStyle != "" {
+		if c.OutputFormat != OutputFormatTree {
+			v = append(v, c.violation("tree-style", "use -output-format TREE, or remove -tree-style", "applies to TREE output only"))
+		}
+		v = append(v, c.oneOf("tree-style", c.TreeStyle, formatter.TreeStyles)...)
+	}
+	if (c.OutputFormat == OutputFormatTree || c.OutputFormat == OutputFormatTable) && c.VerifyRoundtrip {
+		v = append(v, c.violation("verify-roundtrip", "remove -verify-roundtrip", "does not apply to %s output, which is not read back", c.OutputFormat))
+	}
+	if c.NDJSON && (c.OutputFormat != OutputFormatJSON || !c.Batch()) {
+		v = append(v, c.violation("ndjson", "use -output-format JSON with -token-list or -token-dir, or remove -ndjson", "applies to JSON output of a -token-list or -token-dir only"))
+	}
+	if c.XMLMultidoc && (c.OutputFormat != OutputFormatXML || !c.Batch()) {
+		v = append(v, c.violation("xml-multidoc", "use -output-format XML with -token-list or -token-dir, or remove -xml-multidoc", "applies to XML output of a -token-list or -token-dir only"))
+	}
+	if c.Provenance && c.OutputFormat == OutputFormatJSON && c.OutputFile == output.Stdout {
+		v = append(v, c.violation("provenance", "write the output to a file with -output-file", "with JSON output writes a sidecar file and cannot be combined with output to stdout"))
+	}
+	return v
+}
+
+func checkFormatOptions(c *AppConfig) []Violation {
+	var v []Violation
+	opts := c.FormatOptions
+	if d := opts.CSV.Delimiter; d != "" {
+		r, _ := utf8.DecodeRuneInString(d)
+		switch {
+		case utf8.RuneCountInString(d) != 1:
+			v = append(v, Violation{Field: "formatOptions.csv.delimiter", Message: fmt.Sprintf("invalid delimiter %q; must be a single character", d), Fix: `e.g. ";" or "\t"`})
+		case r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError:
+			v = append(v, Violation{Field: "formatOptions.csv.delimiter", Message: fmt.Sprintf("invalid delimiter %q; cannot be a quote or a line break", d)})
+		}
+	}
+	for _, root := range []struct{ field, name string }{
+		{"formatOptions.xml.root", opts.XML.Root},
+		{"formatOptions.xml.setRoot", opts.XML.SetRoot},
+	} {
+		if root.name != "" && !validXMLName(root.name) {
+			v = append(v, Violation{Field: root.field, Message: fmt.Sprintf("invalid element name %q", root.name), Fix: "use letters, digits, -, _, and ., starting with a letter, and not with xml"})
+		}
+	}
+	xmlRoot, setRoot := valueOrDefault(opts.XML.Root, formatter.DefaultXMLRoot), valueOrDefault(opts.XML.SetRoot, formatter.DefaultXMLSetRoot)
+	if xmlRoot == setRoot {
+		v = append(v, Violation{Field: "formatOptions.xml.setRoot", Message: fmt.Sprintf("is the same element as formatOptions.xml.root (%s)", xmlRoot), Fix: "use different names, so that a set can be told from a single token"})
+	}
+	if opts.Dates.Layout != "" && !validLayout(opts.Dates.Layout) {
+		v = append(v, Violation{Field: "formatOptions.dates.layout", Message: fmt.Sprintf("layout %q holds no date or time element", opts.Dates.Layout), Fix: `write the reference time in the wanted layout, e.g. "2006-01-02T15:04:05Z07:00"`})
+	}
+	if c.TimeFormat != "" && !validLayout(formatter.ResolveLayout(c.TimeFormat)) {
+		v = append(v, c.violation("time-format", "use a layout name (e.g., RFC3339 or DateTime), or write the reference time in the wanted layout, e.g. 2006-01-02T15:04:05Z07:00", "layout %q holds no date or time element", c.TimeFormat))
+	}
+	if _, err := formatter.LoadLocation(c.Timezone); err != nil {
+		v = append(v, c.violation("timezone", "", "%v", err))
+	}
+	return v
+}
+
+func checkPipeline(c *AppConfig) []Violation {
+	if len(c.Pipeline) == 0 {
+		var v []Violation
+		if c.ConvertEpoch && c.EpochUnit != "" {
+			v = append(v, c.oneOf("epoch-unit", strings.ToLower(c.EpochUnit), process.EpochUnits)...)
+		}
+		if len(c.EpochClaims) > 0 && !c.ConvertEpoch {
+			v = append(v, c.violation("epoch-claims", "add -convert-epoch, or remove -epoch-claims", "applies with -convert-epoch only"))
+		}
+		if err := formatter.ValidEpochPatterns(c.EpochClaims); err != nil {
+			v = append(v, c.violation("epoch-claims", "", "%v", err))
+		}
+		return v
+	}
+	var v []Violation
+	shorthands := []struct {
+		name, step string
+		set        bool
+	}{
+		{"decode-nested", process.StepDecodeNested, c.DecodeNested},
+		{"strip-claim-prefix", process.StepRename, len(c.StripPrefixes) > 0},
+		{"omit-null", process.StepFilter, c.OmitNull},
+		{"redact", process.StepRedact, len(c.Redact) > 0},
+		{"redact-pii", process.StepRedact, c.RedactPII},
+		{"convert-epoch", process.StepConvertEpoch, c.ConvertEpoch},
+		{"epoch-unit", process.StepConvertEpoch, c.EpochUnit != ""},
+		{"epoch-claims", process.StepConvertEpoch, len(c.EpochClaims) > 0},
+	}
+	for _, shorthand := range shorthands {
+		if shorthand.set {
+			v = append(v, c.violation(shorthand.name, "add a "+shorthand.step+" step to the pipeline instead", "cannot be combined with pipeline"))
+		}
+	}
+	// An invalid formatOptions.dates.layout or -time-format is reported once, by checkFormatOptions
+	layout := c.DateLayout()
+	if !validLayout(layout) {
+		layout = formatter.DefaultDateLayout
+	}
+	for i, def := range c.Pipeline {
+		if _, err := process.New(def, layout); err != nil {
+			v = append(v, Violation{Field: fmt.Sprintf("pipeline[%d]", i), Message: err.Error()})
+		}
+	}
+	return v
+}
+
+func checkQuery(c *AppConfig) []Violation {
+	if len(c.Query) == 0 {
+		return nil
+	}
+	if _, err := query.Parse(c.Query); err != nil {
+		return []Violation{c.violation("query", "", "%v", err)}
+	}
+	return nil
+}
+
+func checkPartitions(c *AppConfig) []Violation {
+	var v []Violation
+	if c.PartitionTemplate != "" && len(c.PartitionBy) == 0 {
+		v = append(v, c.violation("partition-template", "add -partition-by, or remove -partition-template", "requires -partition-by"))
+	}
+	if len(c.PartitionBy) == 0 {
+		return v
+	}
+	if !c.Batch() {
+		v = append(v, c.violation("partition-by", "add -token-list or -token-dir, or remove -partition-by", "applies to a -token-list or -token-dir only"))
+	}
+	if c.OutputFile != "" {
+		v = append(v, c.violation("partition-by", "remove -output-file and -stdout; partition files are named by -partition-template", "cannot be combined with -output-file or -stdout"))
+	}
+	if slices.Contains(OutputFormats, c.OutputFormat) {
+		if _, err := partition.New(c.PartitionBy, c.PartitionTemplate, PartitionExtension(c.OutputFormat)); err != nil {
+			v = append(v, c.violation("partition-template", "", "%v", err))
+		}
+	}
+	return v
+}
+
+func checkResume(c *AppConfig) []Violation {
+	var v []Violation
+	if c.KeepGoing && !c.Batch() {
+		v = append(v, c.violation("keep-going", "add -token-list or -token-dir, or remove -keep-going", "applies to a -token-list or -token-dir only"))
+	}
+	if c.Resume && c.TokenList == "" {
+		v = append(v, c.violation("resume", "add -token-list, or remove -resume", "applies to a -token-list only"))
+	}
+	if c.CheckpointFile != "" && !c.Resume {
+		v = append(v, c.violation("checkpoint", "add -resume, or remove -checkpoint", "requires -resume"))
+	}
+	if c.Resume && c.CheckpointFile == "" && c.OutputFile == output.Stdout {
+		v = append(v, c.violation("resume", "add -checkpoint with the checkpoint file", "with output to stdout requires -checkpoint"))
+	}
+	return v
+}
+
+func checkExec(c *AppConfig) []Violation {
+	var v []Violation
+	if c.given.exec == "" {
+		if len(c.ExecOn) > 0 {
+			v = append(v, c.violation("exec-on", "add -exec, or remove -exec-on", "requires -exec"))
+		}
+		return v
+	}
+	if c.Serves() {
+		v = append(v, c.violation("exec", "", "runs after a decoding run and cannot be used with -%s", c.serveFlag()))
+	}
+	for _, event := range c.ExecOn {
+		if !slices.Contains(ExecEvents, strings.ToLower(event)) {
+			v = append(v, c.violation("exec-on", "", "invalid event %q; must be one of: %s", event, strings.Join(ExecEvents, ", ")))
+		}
+	}
+	if _, err := hook.Parse(c.given.exec, ExecPlaceholders); err != nil {
+		v = append(v, c.violation("exec", "", "invalid command: %v", err))
+	}
+	return v
+}
+
+func checkValidate(c *AppConfig) []Violation {
+	var v []Violation
+	if c.given.clockSkew && !c.Validate {
+		v = append(v, c.violation("clock-skew", "add -validate, or remove -clock-skew", "requires -validate"))
+	}
+	if c.ClockSkew < 0 {
+		v = append(v, c.violation("clock-skew", "", "must not be negative"))
+	}
+	return v
+}
+
+func checkServe(c *AppConfig) []Violation {
+	var v []Violation
+	if c.ConfigFile != "" && c.given.serveAPIKey != "" && !isSecretReference(c.given.serveAPIKey) {
+		v = append(v, c.violation("serve-api-key", "give the key as @<file> or a password manager reference", "must not hold the key itself"))
+	}
+	if c.Serve == "" {
+		if c.given.serveAPIKey != "" {
+			v = append(v, c.violation("serve-api-key", "add -serve, or remove -serve-api-key", "requires -serve"))
+		}
+		if c.given.queue {
+			v = append(v, c.violation("queue-size", "add -serve, or remove -queue-size and -queue-policy", "requires -serve"))
+		}
+		return v
+	}
+	if c.QueueSize < 0 {
+		v = append(v, c.violation("queue-size", "use 0 to refuse or hold every request beyond the workers", "must not be negative"))
+	}
+	v = append(v, c.oneOf("queue-policy", c.QueuePolicy, QueuePolicies)...)
+	if _, _, err := net.SplitHostPort(c.Serve); err != nil {
+		v = append(v, c.violation("serve", "use [host]:port, e.g. :8080 or 127.0.0.1:8080", "invalid address %q", c.Serve))
+	}
+	if c.JSONRPC {
+		v = append(v, c.violation("serve", "keep -serve or -jsonrpc", "cannot be combined with -jsonrpc"))
+	}
+	return v
+}
+
+func checkLimits(c *AppConfig) []Violation {
+	if c.SnippetLength < 0 {
+		return []Violation{c.violation("snippet-length", "", "must not be negative")}
+	}
+	return nil
+}
+
+// parseProblem records a value that could not be parsed when merging, to be reported
+// with the violations of the rules.
+func (c *AppConfig) parseProblem(flagName string, err error) {
+	c.given.problems = append(c.given.problems, c.violation(flagName, "", "%v", err))
+}
+
+// durationOption merges a duration option like durationOrDefault, recording a value of
+// the config file that is not a duration as a problem.
+func (c *AppConfig) durationOption(fs *flag.FlagSet, flagValue time.Duration, fileValue, name string) time.Duration {
+	d, err := durationOrDefault(fs, flagValue, fileValue, name)
+	if err != nil {
+		c.parseProblem(name, err)
+		return flagValue
+	}
+	return d
+}
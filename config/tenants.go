@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"jwtdecode/process"
+)
+
+// Tenant is a tenant of -serve, defined in the tenants section of the config file: the
+// requests it selects, and the settings that apply to them instead of those of the
+// configuration. Requests select a tenant by the prefix of their path, by the TenantHeader
+// header, or by the API key of the tenant.
+type Tenant struct {
+	PathPrefix      string               `json:"pathPrefix"`      // Prefix of the routes of the tenant, e.g. /team-a for /team-a/decode
+	APIKey          string               `json:"apiKey"`          // API key selecting the tenant: @<file> or a password manager reference
+	TrustFile       string               `json:"trustFile"`       // Multi-issuer trust configuration (YAML)
+	JWKSURL         string               `json:"jwksUrl"`         // HTTPS URL of the JWKS verifying the signature
+	IssuerDiscovery string               `json:"issuerDiscovery"` // Issuer URL whose discovery document gives the JWKS
+	PinnedKeys      []string             `json:"pinnedKeys"`      // RFC 7638 thumbprints that verification keys must match
+	OutputFormat    string               `json:"outputFormat"`    // Default output format of decode requests
+	Redact          []string             `json:"redact"`          // Claims whose values are masked
+	RedactPII       bool                 `json:"redactPii"`       // Mask the claims of the pii profile
+	Pipeline        []process.Definition `json:"pipeline"`        // Claims processing steps
+}
+
+// TenantHeader is the request header naming the tenant of a -serve request.
+const TenantHeader = "X-Tenant"
+
+// tenantName matches the names of tenants, which are sent in the TenantHeader header.
+var tenantName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// reservedPrefixes are the routes of -serve that tenant path prefixes must not shadow.
+var reservedPrefixes = []string{"/decode", "/verify", "/healthz", "/admin"}
+
+// TenantNames returns the names of the tenants, sorted.
+func (c *AppConfig) TenantNames() []string {
+	names := make([]string, 0, len(c.Tenants))
+	for name := range c.Tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ForTenant returns the configuration of the requests of a tenant: the configuration with
+// the settings the tenant gives in place of its own. A tenant giving a key source
+// (trustFile, jwksUrl, or issuerDiscovery) replaces every key source of the configuration
+// and its pinned keys, and a tenant giving redaction rules or a pipeline replaces redact,
+// redactPii, and pipeline, so that no setting of the configuration mixes with those of the
+// tenant.
+func (c *AppConfig) ForTenant(name string) *AppConfig {
+	t := c.Tenants[name]
+	tc := *c
+	tc.Tenants, tc.TenantKeys = nil, nil
+	tc.Tenant = name
+	if t.TrustFile != "" || t.JWKSURL != "" || t.IssuerDiscovery != "" {
+		tc.TrustFile, tc.JWKSURL, tc.IssuerDiscovery = t.TrustFile, t.JWKSURL, t.IssuerDiscovery
+		tc.VerifyKey, tc.VerifyAlgs, tc.HMACSecret = "", nil, nil
+		tc.Provider, tc.ResolveDID, tc.AllowEmbeddedJWK, tc.JKUAllowlist = "", false, false, nil
+		tc.PinnedKeys = t.PinnedKeys
+	}
+	if t.PinnedKeys != nil {
+		tc.PinnedKeys = t.PinnedKeys
+	}
+	if t.OutputFormat != "" {
+		tc.OutputFormat = strings.ToUpper(t.OutputFormat)
+	}
+	if len(t.Redact) > 0 || t.RedactPII || len(t.Pipeline) > 0 {
+		tc.Redact, tc.RedactPII, tc.Pipeline = t.Redact, t.RedactPII, t.Pipeline
+	}
+	return &tc
+}
+
+// tenantRules are the rules checked again on the configuration of each tenant.
+var tenantRules = []Rule{checkVerification, checkJWKS, checkOutputFormat, checkPipeline}
+
+func checkTenants(c *AppConfig) []Violation {
+	if len(c.Tenants) == 0 {
+		return nil
+	}
+	if c.Serve == "" {
+		return []Violation{{Field: "tenants", Message: "requires -serve", Fix: "add -serve, or remove tenants"}}
+	}
+	var v []Violation
+	prefixes := map[string]string{}
+	keys := map[string]string{}
+	for _, name := range c.TenantNames() {
+		t := c.Tenants[name]
+		field := "tenants." + name
+		if !tenantName.MatchString(name) {
+			v = append(v, Violation{Field: field, Message: fmt.Sprintf("invalid tenant name %q", name), Fix: "use letters, digits, dots, dashes, and underscores"})
+		}
+		if t.PathPrefix != "" {
+			switch {
+			case !strings.HasPrefix(t.PathPrefix, "/") || t.PathPrefix == "/" || path.Clean(t.PathPrefix) != t.PathPrefix:
+				v = append(v, Violation{Field: field + ".pathPrefix", Message: fmt.Sprintf("invalid path prefix %q", t.PathPrefix), Fix: "use a clean absolute path without a trailing slash, e.g. /" + name})
+			case slices.ContainsFunc(reservedPrefixes, func(route string) bool { return t.PathPrefix == route || strings.HasPrefix(t.PathPrefix, route+"/") }):
+				v = append(v, Violation{Field: field + ".pathPrefix", Message: fmt.Sprintf("path prefix %q shadows a route of -serve", t.PathPrefix)})
+			case prefixes[t.PathPrefix] != "":
+				v = append(v, Violation{Field: field + ".pathPrefix", Message: fmt.Sprintf("path prefix %q is also that of tenant %q", t.PathPrefix, prefixes[t.PathPrefix])})
+			default:
+				prefixes[t.PathPrefix] = name
+			}
+		}
+		if t.APIKey != "" {
+			switch {
+			case !isSecretReference(t.APIKey):
+				v = append(v, Violation{Field: field + ".apiKey", Message: "must not hold the key itself", Fix: "give the key as @<file> or a password manager reference"})
+			case t.APIKey == c.given.serveAPIKey:
+				v = append(v, Violation{Field: field + ".apiKey", Message: "is also the API key of -serve, which is allowed on the admin routes", Fix: "give the tenant a key of its own"})
+			case keys[t.APIKey] != "":
+				v = append(v, Violation{Field: field + ".apiKey", Message: fmt.Sprintf("is also the API key of tenant %q", keys[t.APIKey])})
+			default:
+				keys[t.APIKey] = name
+			}
+		}
+		// A tenant giving a key source verifies with its keys only
+		tc := c.ForTenant(name)
+		if t.TrustFile != "" || t.JWKSURL != "" || t.IssuerDiscovery != "" {
+			for _, source := range tc.keySources() {
+				if !slices.Contains([]string{"trust", "jwks-url", "issuer-discovery"}, source) {
+					v = append(v, Violation{Field: field, Message: fmt.Sprintf("gives a key source but still verifies with -%s of the configuration", source)})
+				}
+			}
+		}
+		// Violations of the tenant are those that the configuration does not have itself
+		for _, rule := range tenantRules {
+			own := rule(c)
+			for _, violation := range rule(tc) {
+				if !slices.Contains(own, violation) {
+					violation.Field = field + "." + violation.Field
+					v = append(v, violation)
+				}
+			}
+		}
+	}
+	return v
+}
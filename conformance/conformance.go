@@ -0,0 +1,234 @@
+package conformance
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Rule is a single conformance requirement checked against a token's header and claims.
+type Rule struct {
+	ID    string                                                          // Short identifier, e.g. "typ" or "claim.iss"
+	Check func(header map[string]interface{}, claims jwt.MapClaims) error // Returns nil when the requirement is met
+}
+
+// Result is the outcome of a single rule.
+type Result struct {
+	Rule    string
+	Passed  bool
+	Message string
+}
+
+// Report is the outcome of checking a token against a profile.
+type Report struct {
+	Profile    string
+	Conformant bool
+	Results    []Result
+}
+
+// profiles maps profile names to their rules.
+var profiles = map[string][]Rule{}
+
+// register adds a profile. It is called from each profile's init function.
+func register(name string, rules []Rule) {
+	profiles[name] = rules
+}
+
+// Profiles returns the sorted names of all conformance profiles.
+func Profiles() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check evaluates every rule of the named profile and returns the full report.
+func Check(profile string, header map[string]interface{}, claims jwt.MapClaims) (*Report, error) {
+	rules, ok := profiles[strings.ToLower(profile)]
+	if !ok {
+		return nil, fmt.Errorf("unknown conformance profile %q; must be one of: %s", profile, strings.Join(Profiles(), ", "))
+	}
+	report := &Report{Profile: strings.ToLower(profile), Conformant: true}
+	for _, rule := range rules {
+		result := Result{Rule: rule.ID, Passed: true}
+		if err := rule.Check(header, claims); err != nil {
+			result.Passed = false
+			result.Message = err.Error()
+			report.Conformant = false
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// Failures returns the number of failed rules.
+func (r *Report) Failures() int {
+	n := 0
+	for _, result := range r.Results {
+		if !result.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+// ToMap converts the report into generic values that every output formatter can render.
+func (r *Report) ToMap() map[string]interface{} {
+	results := make([]interface{}, 0, len(r.Results))
+	for _, result := range r.Results {
+		entry := map[string]interface{}{
+			"rule":   result.Rule,
+			"passed": result.Passed,
+		}
+		if result.Message != "" {
+			entry["message"] = result.Message
+		}
+		results = append(results, entry)
+	}
+	return map[string]interface{}{
+		"profile":    r.Profile,
+		"conformant": r.Conformant,
+		"results":    results,
+	}
+}
+
+// requireClaim returns a rule checking that a claim is present and satisfies check.
+func requireClaim(name string, check func(interface{}) error) Rule {
+	return Rule{
+		ID: "claim." + name,
+		Check: func(_ map[string]interface{}, claims jwt.MapClaims) error {
+			value, ok := claims[name]
+			if !ok {
+				return fmt.Errorf("required claim %q is missing", name)
+			}
+			return check(value)
+		},
+	}
+}
+
+// optionalClaim returns a rule checking that a claim, if present, satisfies check.
+func optionalClaim(name string, check func(interface{}) error) Rule {
+	return Rule{
+		ID: "claim." + name,
+		Check: func(_ map[string]interface{}, claims jwt.MapClaims) error {
+			value, ok := claims[name]
+			if !ok {
+				return nil
+			}
+			return check(value)
+		},
+	}
+}
+
+// forbidClaim returns a rule checking that a claim is absent.
+func forbidClaim(name string) Rule {
+	return Rule{
+		ID: "claim." + name,
+		Check: func(_ map[string]interface{}, claims jwt.MapClaims) error {
+			if _, ok := claims[name]; ok {
+				return fmt.Errorf("claim %q must not be present", name)
+			}
+			return nil
+		},
+	}
+}
+
+// headerTyp returns a rule checking the typ header against the accepted media types.
+// The "application/" prefix may be omitted per RFC 7515 §4.1.9.
+func headerTyp(required bool, accepted ...string) Rule {
+	return Rule{
+		ID: "header.typ",
+		Check: func(header map[string]interface{}, _ jwt.MapClaims) error {
+			typ, ok := header["typ"].(string)
+			if !ok {
+				if required {
+					return fmt.Errorf("typ header must be %q", accepted[0])
+				}
+				return nil
+			}
+			normalized := strings.TrimPrefix(strings.ToLower(typ), "application/")
+			for _, a := range accepted {
+				if normalized == a {
+					return nil
+				}
+			}
+			return fmt.Errorf("typ header %q must be %q", typ, accepted[0])
+		},
+	}
+}
+
+// headerAlgNotNone is a rule rejecting unsecured tokens.
+var headerAlgNotNone = Rule{
+	ID: "header.alg",
+	Check: func(header map[string]interface{}, _ jwt.MapClaims) error {
+		alg, _ := header["alg"].(string)
+		if alg == "" || strings.EqualFold(alg, "none") {
+			return fmt.Errorf("alg must name a signing algorithm, got %q", alg)
+		}
+		return nil
+	},
+}
+
+// isString checks that a value is a non-empty string.
+func isString(v interface{}) error {
+	if s, ok := v.(string); !ok || s == "" {
+		return fmt.Errorf("must be a non-empty string")
+	}
+	return nil
+}
+
+// isNumericDate checks that a value is a JSON number (RFC 7519 NumericDate).
+func isNumericDate(v interface{}) error {
+	if _, ok := v.(float64); !ok {
+		return fmt.Errorf("must be a NumericDate (JSON number)")
+	}
+	return nil
+}
+
+// isStringOrStringArray checks that a value is a string or an array of strings, as for aud.
+func isStringOrStringArray(v interface{}) error {
+	if isString(v) == nil {
+		return nil
+	}
+	return isStringArray(v)
+}
+
+// isStringArray checks that a value is an array of strings.
+func isStringArray(v interface{}) error {
+	items, ok := v.([]interface{})
+	if !ok {
+		return fmt.Errorf("must be an array of strings")
+	}
+	for _, item := range items {
+		if _, ok := item.(string); !ok {
+			return fmt.Errorf("must be an array of strings")
+		}
+	}
+	return nil
+}
+
+// isIssuerURL checks that a value is an https URL without query or fragment (OIDC Core §2).
+func isIssuerURL(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("must be a string")
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("must be an https URL without query or fragment")
+	}
+	return nil
+}
+
+// isObject checks that a value is a JSON object.
+func isObject(v interface{}) error {
+	if _, ok := v.(map[string]interface{}); !ok {
+		return fmt.Errorf("must be a JSON object")
+	}
+	return nil
+}
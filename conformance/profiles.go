@@ -0,0 +1,160 @@
+package conformance
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Profile names accepted by -conformance.
+const (
+	ProfileRFC9068     = "rfc9068"
+	ProfileIDToken     = "oidc-id-token"
+	ProfileLogoutToken = "logout-token"
+	ProfileSET         = "set"
+
+	// backChannelLogoutEvent is the events member identifying an OIDC logout token.
+	backChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+)
+
+func init() {
+	// JWT Profile for OAuth 2.0 Access Tokens (RFC 9068 §2)
+	register(ProfileRFC9068, []Rule{
+		headerTyp(true, "at+jwt"),
+		headerAlgNotNone,
+		requireClaim("iss", isString),
+		requireClaim("exp", isNumericDate),
+		requireClaim("aud", isStringOrStringArray),
+		requireClaim("sub", isString),
+		requireClaim("client_id", isString),
+		requireClaim("iat", isNumericDate),
+		requireClaim("jti", isString),
+		optionalClaim("auth_time", isNumericDate),
+		optionalClaim("acr", isString),
+		optionalClaim("amr", isStringArray),
+		optionalClaim("scope", isScope),
+	})
+
+	// OpenID Connect Core 1.0 ID Token (§2)
+	register(ProfileIDToken, []Rule{
+		headerAlgNotNone,
+		requireClaim("iss", isIssuerURL),
+		requireClaim("sub", isSubject),
+		requireClaim("aud", isStringOrStringArray),
+		requireClaim("exp", isNumericDate),
+		requireClaim("iat", isNumericDate),
+		optionalClaim("auth_time", isNumericDate),
+		optionalClaim("nonce", isString),
+		optionalClaim("acr", isString),
+		optionalClaim("amr", isStringArray),
+		{ID: "claim.azp", Check: checkAuthorizedParty},
+	})
+
+	// OpenID Connect Back-Channel Logout 1.0 Logout Token (§2.4)
+	register(ProfileLogoutToken, []Rule{
+		headerTyp(false, "logout+jwt"),
+		headerAlgNotNone,
+		requireClaim("iss", isIssuerURL),
+		requireClaim("aud", isStringOrStringArray),
+		requireClaim("iat", isNumericDate),
+		requireClaim("jti", isString),
+		requireClaim("events", isLogoutEvent),
+		{ID: "claim.sub_or_sid", Check: checkSubOrSid},
+		forbidClaim("nonce"),
+	})
+
+	// Security Event Token (RFC 8417 §2.2)
+	register(ProfileSET, []Rule{
+		headerTyp(false, "secevent+jwt"),
+		requireClaim("iss", isString),
+		requireClaim("iat", isNumericDate),
+		requireClaim("jti", isString),
+		requireClaim("events", isEventSet),
+	})
+}
+
+// isScope checks that scope is a space-delimited string of scope tokens (RFC 6749 §3.3).
+func isScope(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("must be a space-delimited string")
+	}
+	for _, scope := range strings.Split(s, " ") {
+		if scope == "" {
+			return fmt.Errorf("must not contain empty scope tokens")
+		}
+	}
+	return nil
+}
+
+// isSubject checks that sub is a string of at most 255 ASCII characters.
+func isSubject(v interface{}) error {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return fmt.Errorf("must be a non-empty string")
+	}
+	if len(s) > 255 {
+		return fmt.Errorf("must not exceed 255 ASCII characters")
+	}
+	for _, r := range s {
+		if r > 127 {
+			return fmt.Errorf("must contain only ASCII characters")
+		}
+	}
+	return nil
+}
+
+// isLogoutEvent checks that events holds the back-channel logout member with an object value.
+func isLogoutEvent(v interface{}) error {
+	events, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("must be a JSON object")
+	}
+	event, ok := events[backChannelLogoutEvent]
+	if !ok {
+		return fmt.Errorf("must contain the %q member", backChannelLogoutEvent)
+	}
+	if err := isObject(event); err != nil {
+		return fmt.Errorf("%q member %v", backChannelLogoutEvent, err)
+	}
+	return nil
+}
+
+// isEventSet checks that events is a non-empty object whose members are all objects.
+func isEventSet(v interface{}) error {
+	events, ok := v.(map[string]interface{})
+	if !ok || len(events) == 0 {
+		return fmt.Errorf("must be a JSON object with at least one event")
+	}
+	for uri, payload := range events {
+		if err := isObject(payload); err != nil {
+			return fmt.Errorf("event %q payload %v", uri, err)
+		}
+	}
+	return nil
+}
+
+// checkAuthorizedParty requires azp when the ID token has multiple audiences.
+func checkAuthorizedParty(_ map[string]interface{}, claims jwt.MapClaims) error {
+	azp, present := claims["azp"]
+	if present {
+		if err := isString(azp); err != nil {
+			return err
+		}
+	}
+	if aud, _ := claims["aud"].([]interface{}); len(aud) > 1 && !present {
+		return fmt.Errorf("azp is required when aud contains multiple audiences")
+	}
+	return nil
+}
+
+// checkSubOrSid requires at least one of sub and sid in a logout token.
+func checkSubOrSid(_ map[string]interface{}, claims jwt.MapClaims) error {
+	_, hasSub := claims["sub"]
+	_, hasSid := claims["sid"]
+	if !hasSub && !hasSid {
+		return fmt.Errorf("a sub or sid claim is required")
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/config"
+	"jwtdecode/formatter"
+	"jwtdecode/output"
+	"jwtdecode/terminal"
+	"jwtdecode/utils"
+)
+
+// MaxInputSize bounds the size of a claims document, like the default -max-output-size
+// of the output it was written as.
+const MaxInputSize = 10 << 20
+
+// Options configures a conversion.
+type Options struct {
+	OutputFormat string   // JSON, CSV, XML, TREE, or TABLE
+	ConvertEpoch bool     // Add _datestamp claims, as with the main -convert-epoch
+	EpochUnit    string   // Unit of epoch timestamps; empty for the heuristic
+	EpochClaims  []string // Names or glob patterns of custom timestamp claims, as with the main -epoch-claims
+	Timezone     string   // Time zone of datestamps, as with the main -timezone; UTC when empty
+	TimeFormat   string   // Layout of datestamps, as with the main -time-format
+	XMLMultidoc  bool     // One XML document per claims set instead of a <JWTClaimsSet>
+	MissingValue string   // CSV cell written for claims a claims set does not have
+	OmitNull     bool     // Remove null claims, as with the main -omit-null
+	TreeStyle    string   // Branches of TREE output, as with the main -tree-style
+}
+
+// Main runs the convert subcommand with its command-line arguments, reporting to w.
+func Main(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	in := fs.String("in", "", "Claims document to convert: a JSON object, an array of objects, or newline-delimited objects")
+	outputFormat := fs.String("output-format", config.OutputFormatJSON, "Output format (JSON, CSV, XML, TREE, TABLE)")
+	outputFile := fs.String("output-file", "", "Output file path, or - for stdout (default: claims.<format>)")
+	convertEpoch := fs.Bool("convert-epoch", false, "Add human-readable datestamps for epoch claims (iat, exp, nbf, auth_time)")
+	epochUnit := fs.String("epoch-unit", "", "Specify epoch unit (s, ms, us, ns). Defaults to heuristic.")
+	epochClaims := fs.String("epoch-claims", "", "Comma-separated names or glob patterns of custom timestamp claims converted by -convert-epoch")
+	timezone := fs.String("timezone", "", "Time zone of datestamps: an IANA name (e.g., America/New_York) or local (default UTC)")
+	timeFormat := fs.String("time-format", "", "Layout of datestamps: a Go time layout, or a layout name such as RFC3339")
+	xmlMultidoc := fs.Bool("xml-multidoc", false, "Emit one XML document per claims set instead of a <JWTClaimsSet> wrapper")
+	missingValue := fs.String("missing-value", "", "Value written in CSV cells of claims a claims set does not have (default: empty)")
+	omitNull := fs.Bool("omit-null", false, "Remove null claims, and null members of nested objects, from the output")
+	treeStyle := fs.String("tree-style", "", "Branches of TREE output: "+strings.Join(formatter.TreeStyles, " or ")+" (default: unicode when stdout is a terminal, ascii otherwise)")
+	noPager := fs.Bool("no-pager", false, "Do not page output to a terminal through $PAGER when it does not fit on the screen")
+	strictPerms := fs.Bool("strict-permissions", false, "Fail instead of warning when the output file is world-accessible")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if *in == "" {
+		return fmt.Errorf("usage: jwtdecode convert -in <claims.json> [-output-format JSON|CSV|XML|TREE|TABLE] [-output-file <file>]")
+	}
+	opts := Options{
+		OutputFormat: strings.ToUpper(*outputFormat),
+		ConvertEpoch: *convertEpoch,
+		EpochUnit:    *epochUnit,
+		Timezone:     *timezone,
+		TimeFormat:   *timeFormat,
+		XMLMultidoc:  *xmlMultidoc,
+		MissingValue: *missingValue,
+		OmitNull:     *omitNull,
+		TreeStyle:    strings.ToLower(*treeStyle),
+	}
+	if !slices.Contains(config.OutputFormats, opts.OutputFormat) {
+		return fmt.Errorf("invalid output format %q; must be JSON, CSV, XML, TREE, or TABLE", *outputFormat)
+	}
+	if opts.TreeStyle != "" && opts.OutputFormat != config.OutputFormatTree {
+		return fmt.Errorf("-tree-style applies to TREE output only")
+	}
+	if opts.OutputFormat == config.OutputFormatTree && opts.TreeStyle == "" {
+		opts.TreeStyle = formatter.TreeASCII
+		if terminal.IsTerminal(os.Stdout) {
+			opts.TreeStyle = formatter.TreeUnicode
+		}
+	}
+	for _, name := range strings.Split(*epochClaims, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opts.EpochClaims = append(opts.EpochClaims, name)
+		}
+	}
+	if len(opts.EpochClaims) > 0 && !opts.ConvertEpoch {
+		return fmt.Errorf("-epoch-claims applies with -convert-epoch only")
+	}
+	if err := formatter.ValidEpochPatterns(opts.EpochClaims); err != nil {
+		return err
+	}
+	if _, err := formatter.LoadLocation(opts.Timezone); err != nil {
+		return err
+	}
+	if layout := formatter.ResolveLayout(opts.TimeFormat); layout != "" && time.Unix(0, 0).UTC().Format(layout) == layout {
+		return fmt.Errorf("-time-format %q holds no date or time element", opts.TimeFormat)
+	}
+	if opts.MissingValue != "" && opts.OutputFormat != config.OutputFormatCSV {
+		return fmt.Errorf("-missing-value applies to CSV output only")
+	}
+	if *outputFile == "" {
+		*outputFile = "claims." + config.FileExtension(opts.OutputFormat)
+	}
+	sanitizedOutput, err := utils.SanitizeFilePath(*outputFile)
+	if err != nil {
+		return fmt.Errorf("sanitizing output file path: %w", err)
+	}
+
+	claimsList, batch, err := Read(*in)
+	if err != nil {
+		return err
+	}
+	data, err := Format(claimsList, batch, opts)
+	if err != nil {
+		return fmt.Errorf("formatting output: %w", err)
+	}
+	if err := output.WriteOutput(data, sanitizedOutput, output.Options{
+		StrictPermissions: *strictPerms,
+		Warn:              config.Warn,
+		Page:              !*noPager,
+	}); err != nil {
+		return fmt.Errorf("writing output to file: %w", err)
+	}
+	// The output itself is on stdout, with nothing appended to it
+	if sanitizedOutput != output.Stdout {
+		fmt.Fprintf(w, "Successfully wrote output to %s\n", sanitizedOutput)
+	}
+	return nil
+}
+
+// Read parses a claims document: a JSON object (a single claims set), or a JSON array of
+// objects or newline-delimited objects (a batch, as written for -token-list). batch
+// reports whether the document is a batch, even of a single claims set.
+func Read(path string) (claimsList []jwt.MapClaims, batch bool, err error) {
+	f, err := utils.OpenFile(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("opening claims document: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	data, err := io.ReadAll(io.LimitReader(f, MaxInputSize+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("reading claims document: %w", err)
+	}
+	if len(data) > MaxInputSize {
+		return nil, false, fmt.Errorf("claims document exceeds %dMB limit", MaxInputSize>>20)
+	}
+	data = bytes.TrimSpace(data)
+
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &claimsList); err != nil {
+			return nil, false, fmt.Errorf("parsing claims document: the array must hold JSON objects: %w", err)
+		}
+		batch = true
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for !batch {
+		var claims jwt.MapClaims
+		err := dec.Decode(&claims)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("parsing claims document: claims set %d must be a JSON object: %w", len(claimsList)+1, err)
+		}
+		claimsList = append(claimsList, claims)
+	}
+	if len(claimsList) == 0 {
+		return nil, false, fmt.Errorf("claims document %q holds no claims set", path)
+	}
+	return claimsList, batch || len(claimsList) > 1, nil
+}
+
+// Format runs claims sets through the formatter in the requested output format, with the
+// output shapes of the main command: a batch becomes a JSON array, one CSV row per claims
+// set, or a <JWTClaimsSet> (or, with XMLMultidoc, one XML document per claims set).
+func Format(claimsList []jwt.MapClaims, batch bool, opts Options) ([]byte, error) {
+	layout := formatter.DefaultDateLayout
+	if opts.TimeFormat != "" {
+		layout = formatter.ResolveLayout(opts.TimeFormat)
+	}
+	location, err := formatter.LoadLocation(opts.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	processed := make([]jwt.MapClaims, len(claimsList))
+	for i, claims := range claimsList {
+		if claims == nil {
+			return nil, fmt.Errorf("claims set %d is null", i+1)
+		}
+		if opts.OmitNull {
+			claims = formatter.OmitNull(claims)
+		}
+		if opts.ConvertEpoch {
+			claims = formatter.AddDatestampsIn(claims, opts.EpochClaims, opts.EpochUnit, layout, location)
+		}
+		processed[i] = claims
+	}
+
+	format, err := formatter.Get(opts.OutputFormat)
+	if err != nil {
+		return nil, err
+	}
+	b := formatter.Batch{Claims: processed, Multiple: batch}
+	if opts.OutputFormat == config.OutputFormatTree || opts.OutputFormat == config.OutputFormatTable {
+		b.Labels = make([]string, len(processed))
+		for i := range b.Labels {
+			b.Labels[i] = "claims"
+			if batch {
+				b.Labels[i] = fmt.Sprintf("claims %d", i+1)
+			}
+		}
+	}
+	return format(b, formatter.Options{
+		CSV:   formatter.CSVOptions{MissingValue: opts.MissingValue},
+		XML:   formatter.XMLOptions{Multidoc: opts.XMLMultidoc},
+		Tree:  formatter.TreeOptions{Style: opts.TreeStyle},
+		Table: formatter.TableOptions{Layout: layout, Location: location},
+	})
+}
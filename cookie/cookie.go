@@ -0,0 +1,288 @@
+package cookie
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"jwtdecode/sqlite"
+)
+
+// jwtPattern matches a compact JWS, whose header always starts with `{"` (eyJ in base64url).
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// browser describes where a browser keeps its cookies and how they are encrypted.
+type browser struct {
+	name         string
+	firefox      bool   // Firefox profiles store cookies unencrypted in cookies.sqlite
+	linux        string // Profile directory relative to the user config directory (home directory for Firefox)
+	darwin       string // Profile directory relative to ~/Library/Application Support
+	windows      string // Profile directory relative to %LOCALAPPDATA% (%APPDATA% for Firefox)
+	storage      string // Name of the Safe Storage secret holding the Chromium encryption password
+	libsecretApp string // Application attribute of the Safe Storage secret on Linux
+}
+
+// browsers lists the supported browsers by name.
+var browsers = map[string]browser{
+	"chrome":   {name: "Chrome", linux: "google-chrome", darwin: "Google/Chrome", windows: `Google\Chrome\User Data`, storage: "Chrome", libsecretApp: "chrome"},
+	"chromium": {name: "Chromium", linux: "chromium", darwin: "Chromium", windows: `Chromium\User Data`, storage: "Chromium", libsecretApp: "chromium"},
+	"edge":     {name: "Microsoft Edge", linux: "microsoft-edge", darwin: "Microsoft Edge", windows: `Microsoft\Edge\User Data`, storage: "Microsoft Edge", libsecretApp: "chromium"},
+	"brave":    {name: "Brave", linux: "BraveSoftware/Brave-Browser", darwin: "BraveSoftware/Brave-Browser", windows: `BraveSoftware\Brave-Browser\User Data`, storage: "Brave", libsecretApp: "brave"},
+	"firefox":  {name: "Firefox", firefox: true, linux: ".mozilla/firefox", darwin: "Firefox/Profiles", windows: `Mozilla\Firefox\Profiles`},
+}
+
+// Browsers returns the names of the supported browsers in sorted order.
+func Browsers() []string {
+	names := make([]string, 0, len(browsers))
+	for name := range browsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Spec names a cookie in a browser's cookie store: <browser>:<cookie>@<host>.
+type Spec struct {
+	Browser string
+	Name    string
+	Host    string
+}
+
+// ParseSpec parses a cookie specification such as chrome:session_token@app.example.com.
+func ParseSpec(s string) (Spec, error) {
+	b, rest, ok := strings.Cut(s, ":")
+	name, host, ok2 := strings.Cut(rest, "@")
+	if !ok || !ok2 || name == "" || host == "" {
+		return Spec{}, fmt.Errorf("invalid browser cookie %q; expected <browser>:<cookie>@<host>", s)
+	}
+	b = strings.ToLower(b)
+	if _, known := browsers[b]; !known {
+		return Spec{}, fmt.Errorf("unsupported browser %q (supported: %s)", b, strings.Join(Browsers(), ", "))
+	}
+	return Spec{Browser: b, Name: name, Host: strings.ToLower(strings.TrimPrefix(host, "."))}, nil
+}
+
+// Token reads the cookie named by spec from the local browser's cookie store, decrypting
+// it with the key from the OS keyring if needed, and returns the JWT it contains. All
+// profiles are searched; the cookie set for the most specific domain is used.
+func Token(spec string) (string, error) {
+	s, err := ParseSpec(spec)
+	if err != nil {
+		return "", err
+	}
+	b := browsers[s.Browser]
+	dir, err := profileRoot(b)
+	if err != nil {
+		return "", err
+	}
+	stores, err := cookieStores(b, dir)
+	if err != nil {
+		return "", err
+	}
+	if len(stores) == 0 {
+		return "", fmt.Errorf("no %s cookie store found in %s", b.name, dir)
+	}
+
+	var value string
+	bestHost := ""
+	for _, store := range stores {
+		var found bool
+		var host, v string
+		if b.firefox {
+			host, v, found, err = firefoxCookie(store, s)
+		} else {
+			host, v, found, err = chromiumCookie(b, dir, store, s)
+		}
+		if err != nil {
+			return "", fmt.Errorf("reading %s: %w", store, err)
+		}
+		if found && len(host) > len(bestHost) {
+			bestHost, value = host, v
+		}
+	}
+	if bestHost == "" {
+		return "", fmt.Errorf("no %s cookie %q for %s", b.name, s.Name, s.Host)
+	}
+	return extractJWT(value, s.Name)
+}
+
+// profileRoot returns the directory holding the browser's profiles on this platform.
+func profileRoot(b browser) (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		base := os.Getenv("LOCALAPPDATA")
+		if b.firefox {
+			base = os.Getenv("APPDATA")
+		}
+		if base == "" {
+			return "", fmt.Errorf("locating %s profiles: application data directory is not set", b.name)
+		}
+		return filepath.Join(base, b.windows), nil
+	case "darwin":
+		base, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("locating %s profiles: %w", b.name, err)
+		}
+		return filepath.Join(base, b.darwin), nil
+	default:
+		base, err := os.UserConfigDir()
+		if b.firefox {
+			base, err = os.UserHomeDir()
+		}
+		if err != nil {
+			return "", fmt.Errorf("locating %s profiles: %w", b.name, err)
+		}
+		return filepath.Join(base, b.linux), nil
+	}
+}
+
+// cookieStores returns the cookie databases of all profiles under dir.
+func cookieStores(b browser, dir string) ([]string, error) {
+	var patterns []string
+	if b.firefox {
+		patterns = []string{"*/cookies.sqlite"}
+	} else {
+		// Newer Chromium versions keep the cookies of a profile in its Network directory
+		patterns = []string{"*/Network/Cookies", "*/Cookies"}
+	}
+	var stores []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, matches...)
+	}
+	slices.Sort(stores)
+	return stores, nil
+}
+
+// domainMatch reports whether a cookie set for cookieHost is sent to host: the hosts
+// are equal, or the cookie is a domain cookie (leading dot) for a parent domain of host.
+func domainMatch(cookieHost, host string) bool {
+	cookieHost = strings.ToLower(cookieHost)
+	if strings.TrimPrefix(cookieHost, ".") == host {
+		return true
+	}
+	return strings.HasPrefix(cookieHost, ".") && strings.HasSuffix(host, cookieHost)
+}
+
+// firefoxCookie reads an unencrypted cookie from a Firefox cookies.sqlite.
+func firefoxCookie(store string, s Spec) (string, string, bool, error) {
+	db, err := sqlite.Open(store)
+	if err != nil {
+		return "", "", false, err
+	}
+	var bestHost, value string
+	err = db.Rows("moz_cookies", []string{"host", "name", "value"}, func(row []interface{}) error {
+		host, _ := row[0].(string)
+		name, _ := row[1].(string)
+		if name == s.Name && domainMatch(host, s.Host) && len(host) > len(bestHost) {
+			bestHost = host
+			value, _ = row[2].(string)
+		}
+		return nil
+	})
+	return bestHost, value, bestHost != "", err
+}
+
+// chromiumCookie reads a cookie from a Chromium Cookies database, decrypting its
+// encrypted_value when the plain value is empty.
+func chromiumCookie(b browser, dir, store string, s Spec) (string, string, bool, error) {
+	db, err := sqlite.Open(store)
+	if err != nil {
+		return "", "", false, err
+	}
+	var bestHost, value string
+	var encrypted []byte
+	err = db.Rows("cookies", []string{"host_key", "name", "value", "encrypted_value"}, func(row []interface{}) error {
+		host, _ := row[0].(string)
+		name, _ := row[1].(string)
+		if name == s.Name && domainMatch(host, s.Host) && len(host) > len(bestHost) {
+			bestHost = host
+			value, _ = row[2].(string)
+			encrypted, _ = row[3].([]byte)
+		}
+		return nil
+	})
+	if err != nil || bestHost == "" || value != "" || len(encrypted) == 0 {
+		return bestHost, value, bestHost != "", err
+	}
+
+	decrypt, err := chromiumDecrypter(b, dir)
+	if err != nil {
+		return "", "", false, err
+	}
+	plain, err := decrypt(encrypted)
+	if err != nil {
+		return "", "", false, fmt.Errorf("decrypting cookie %q: %w", s.Name, err)
+	}
+	// Since database version 24, the value is prefixed with the SHA-256 hash of the host
+	// key, binding it to its domain
+	if metaVersion(db) >= 24 && len(plain) >= sha256.Size {
+		sum := sha256.Sum256([]byte(bestHost))
+		if !bytes.Equal(plain[:sha256.Size], sum[:]) {
+			return "", "", false, fmt.Errorf("decrypting cookie %q: domain hash mismatch", s.Name)
+		}
+		plain = plain[sha256.Size:]
+	}
+	return bestHost, string(plain), true, nil
+}
+
+// metaVersion returns the schema version recorded in the meta table of a Chromium
+// cookie database, or 0 if it cannot be read.
+func metaVersion(db *sqlite.DB) int {
+	version := 0
+	_ = db.Rows("meta", []string{"key", "value"}, func(row []interface{}) error {
+		if row[0] == "version" {
+			s, _ := row[1].(string)
+			version, _ = strconv.Atoi(s)
+		}
+		return nil
+	})
+	return version
+}
+
+// decryptCBC decrypts a Chromium cookie value encrypted with AES-128-CBC, as used on
+// macOS and Linux, after its three-byte version prefix.
+func decryptCBC(key, data []byte) ([]byte, error) {
+	data = data[3:]
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid ciphertext length")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, bytes.Repeat([]byte{' '}, aes.BlockSize)).CryptBlocks(plain, data)
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize || pad > len(plain) {
+		return nil, fmt.Errorf("invalid padding (wrong key?)")
+	}
+	return plain[:len(plain)-pad], nil
+}
+
+// extractJWT finds the JWT in a cookie value, which may be URL-encoded or wrap the
+// token (e.g., "Bearer <jwt>" or a JSON document).
+func extractJWT(value, name string) (string, error) {
+	if unescaped, err := url.QueryUnescape(value); err == nil {
+		value = unescaped
+	}
+	token := jwtPattern.FindString(value)
+	if token == "" {
+		return "", fmt.Errorf("cookie %q does not contain a JWT", name)
+	}
+	return token, nil
+}
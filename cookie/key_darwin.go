@@ -0,0 +1,31 @@
+//go:build darwin
+
+package cookie
+
+import (
+	"bytes"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// chromiumDecrypter returns the decrypter of Chromium cookie values on macOS: AES-128-CBC
+// with a key derived from the Safe Storage password in the login keychain.
+func chromiumDecrypter(b browser, _ string) (func([]byte) ([]byte, error), error) {
+	password, err := keyring.Get(b.storage+" Safe Storage", b.storage)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s Safe Storage password from the keychain: %w", b.name, err)
+	}
+	key, err := pbkdf2.Key(sha1.New, password, []byte("saltysalt"), 1003, 16)
+	if err != nil {
+		return nil, fmt.Errorf("deriving cookie key: %w", err)
+	}
+	return func(data []byte) ([]byte, error) {
+		if !bytes.HasPrefix(data, []byte("v10")) {
+			return nil, fmt.Errorf("unsupported encryption version")
+		}
+		return decryptCBC(key, data)
+	}, nil
+}
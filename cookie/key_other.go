@@ -0,0 +1,74 @@
+//go:build linux || freebsd || netbsd || openbsd
+
+package cookie
+
+import (
+	"bytes"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"fmt"
+
+	ss "github.com/zalando/go-keyring/secret_service"
+)
+
+// chromiumDecrypter returns the decrypter of Chromium cookie values on Linux and the BSDs:
+// AES-128-CBC with a key derived from a fixed password ("v10" values, written without a
+// keyring) or from the Safe Storage password in the Secret Service ("v11" values).
+func chromiumDecrypter(b browser, _ string) (func([]byte) ([]byte, error), error) {
+	var v11Key []byte
+	return func(data []byte) ([]byte, error) {
+		switch {
+		case bytes.HasPrefix(data, []byte("v10")):
+			key, err := pbkdf2.Key(sha1.New, "peanuts", []byte("saltysalt"), 1, 16)
+			if err != nil {
+				return nil, err
+			}
+			return decryptCBC(key, data)
+		case bytes.HasPrefix(data, []byte("v11")):
+			if v11Key == nil {
+				password, err := safeStoragePassword(b.libsecretApp)
+				if err != nil {
+					return nil, fmt.Errorf("reading %s Safe Storage password from the keyring: %w", b.name, err)
+				}
+				if v11Key, err = pbkdf2.Key(sha1.New, password, []byte("saltysalt"), 1, 16); err != nil {
+					return nil, err
+				}
+			}
+			return decryptCBC(v11Key, data)
+		default:
+			return nil, fmt.Errorf("unsupported encryption version")
+		}
+	}, nil
+}
+
+// safeStoragePassword looks up the Safe Storage password of a Chromium browser, stored
+// in the login collection of the Secret Service (GNOME Keyring, KWallet) by application.
+func safeStoragePassword(app string) (string, error) {
+	svc, err := ss.NewSecretService()
+	if err != nil {
+		return "", err
+	}
+	session, err := svc.OpenSession()
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = svc.Close(session)
+	}()
+	collection := svc.GetLoginCollection()
+	if err := svc.Unlock(collection.Path()); err != nil {
+		return "", err
+	}
+	items, err := svc.SearchItems(collection, map[string]string{"application": app})
+	if err != nil {
+		return "", err
+	}
+	if len(items) == 0 {
+		return "", fmt.Errorf("no secret for application %q", app)
+	}
+	secret, err := svc.GetSecret(items[0], session.Path())
+	if err != nil {
+		return "", err
+	}
+	return string(secret.Value), nil
+}
@@ -0,0 +1,18 @@
+//go:build !(darwin || windows || linux || freebsd || netbsd || openbsd)
+
+package cookie
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// chromiumDecrypter reports that Chromium cookie values cannot be decrypted in
+// WebAssembly, which has no access to the platform secret store holding their key, nor
+// on platforms whose secret store is not supported.
+func chromiumDecrypter(b browser, _ string) (func([]byte) ([]byte, error), error) {
+	if runtime.GOOS == "js" || runtime.GOOS == "wasip1" {
+		return nil, fmt.Errorf("decrypting %s cookies is not supported in WebAssembly", b.name)
+	}
+	return nil, fmt.Errorf("decrypting %s cookies is not supported on %s", b.name, runtime.GOOS)
+}
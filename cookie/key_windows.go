@@ -0,0 +1,80 @@
+//go:build windows
+
+package cookie
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// chromiumDecrypter returns the decrypter of Chromium cookie values on Windows: AES-256-GCM
+// with the key stored, protected with DPAPI, in the Local State file of the profiles.
+func chromiumDecrypter(b browser, dir string) (func([]byte) ([]byte, error), error) {
+	data, err := os.ReadFile(filepath.Join(dir, "Local State"))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s Local State: %w", b.name, err)
+	}
+	var state struct {
+		OSCrypt struct {
+			EncryptedKey string `json:"encrypted_key"`
+		} `json:"os_crypt"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing %s Local State: %w", b.name, err)
+	}
+	protected, err := base64.StdEncoding.DecodeString(state.OSCrypt.EncryptedKey)
+	if err != nil || !bytes.HasPrefix(protected, []byte("DPAPI")) {
+		return nil, fmt.Errorf("parsing %s Local State: invalid encrypted key", b.name)
+	}
+	key, err := unprotect(protected[len("DPAPI"):])
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s cookie key: %w", b.name, err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return func(data []byte) ([]byte, error) {
+		switch {
+		case bytes.HasPrefix(data, []byte("v10")):
+			if len(data) < 3+gcm.NonceSize() {
+				return nil, fmt.Errorf("invalid ciphertext length")
+			}
+			return gcm.Open(nil, data[3:3+gcm.NonceSize()], data[3+gcm.NonceSize():], nil)
+		case bytes.HasPrefix(data, []byte("v20")):
+			return nil, fmt.Errorf("app-bound encryption (v20) can only be decrypted by the browser itself")
+		default:
+			// Values written before the Local State key was introduced are protected with DPAPI only
+			return unprotect(data)
+		}
+	}, nil
+}
+
+// unprotect decrypts data protected with DPAPI for the current user.
+func unprotect(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no data to decrypt")
+	}
+	in := windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	var out windows.DataBlob
+	if err := windows.CryptUnprotectData(&in, nil, nil, 0, nil, 0, &out); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+	}()
+	return bytes.Clone(unsafe.Slice(out.Data, out.Size)), nil
+}
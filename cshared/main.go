@@ -0,0 +1,74 @@
+// Command cshared is the C shared library build of the jwtdecode library, so that programs
+// in other languages (Python, Ruby, ...) decode tokens through FFI with the implementation
+// of the command line instead of running it for every token:
+//
+//	go build -buildmode=c-shared -o libjwtdecode.so ./cshared
+//
+// The build writes libjwtdecode.h next to the library, declaring:
+//
+//	char *jwtdecode_decode(char *token, char *optionsJSON);
+//	void jwtdecode_free(char *result);
+//
+// jwtdecode_decode returns the JSON response of jwtdecode.DecodeJSON, which the caller
+// releases with jwtdecode_free. Decoders are kept for the most recently used options, so
+// that keys and key sets are resolved once for a batch of tokens decoded with the same
+// options.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"jwtdecode/lru"
+	"jwtdecode/pkg/jwtdecode"
+)
+
+// decoderCacheSize is the number of option sets whose decoders are kept.
+const decoderCacheSize = 16
+
+// decoders holds the decoders of the most recently used option sets, by options JSON.
+// Decoders are safe for concurrent use, so calls from several threads share them.
+var decoders = lru.New[*jwtdecode.Decoder](decoderCacheSize)
+
+// jwtdecode_decode decodes a token with options given as JSON, with the names of the
+// config file fields, and returns the response as JSON. A NULL or empty optionsJSON
+// decodes with the default options.
+//
+//export jwtdecode_decode
+func jwtdecode_decode(token, optionsJSON *C.char) *C.char {
+	var options string
+	if optionsJSON != nil {
+		options = C.GoString(optionsJSON)
+	}
+	return C.CString(string(decode(C.GoString(token), options)))
+}
+
+// jwtdecode_free releases a response returned by jwtdecode_decode.
+//
+//export jwtdecode_free
+func jwtdecode_free(result *C.char) {
+	C.free(unsafe.Pointer(result))
+}
+
+// decode decodes a token with the decoder of its options, building it on first use.
+// Invalid options are not cached, so that every call reports them.
+func decode(token, options string) []byte {
+	dec, ok := decoders.Get(options)
+	if !ok {
+		opts, err := jwtdecode.ParseOptions([]byte(options))
+		if err != nil {
+			return jwtdecode.ErrorJSON(err)
+		}
+		if dec, err = jwtdecode.New(opts); err != nil {
+			return jwtdecode.ErrorJSON(err)
+		}
+		decoders.Add(options, dec)
+	}
+	return dec.DecodeJSON(token)
+}
+
+func main() {}
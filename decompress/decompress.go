@@ -0,0 +1,84 @@
+package decompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression formats detected by Detect.
+const (
+	FormatNone = ""
+	FormatGzip = "gzip"
+	FormatZstd = "zstd"
+)
+
+// Magic numbers of the supported formats (RFC 1952 and RFC 8878).
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Detect returns the compression format of data from its leading magic number, or
+// FormatNone for uncompressed data. At least four bytes are needed to detect zstd.
+func Detect(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		return FormatGzip
+	case bytes.HasPrefix(data, zstdMagic):
+		return FormatZstd
+	default:
+		return FormatNone
+	}
+}
+
+// NewReader returns a reader decompressing r in the given format. Concatenated gzip
+// members and zstd frames are read as one stream, as produced by appending to a log.
+func NewReader(r io.Reader, format string) (io.ReadCloser, error) {
+	switch format {
+	case FormatNone:
+		return io.NopCloser(r), nil
+	case FormatGzip:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("opening gzip stream: %w", err)
+		}
+		return gz, nil
+	case FormatZstd:
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("opening zstd stream: %w", err)
+		}
+		return d.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression format %q", format)
+	}
+}
+
+// Bytes decompresses data if it starts with a supported magic number and returns it
+// unchanged otherwise. Reading stops one byte past maxSize, so a compression bomb
+// cannot exhaust memory.
+func Bytes(data []byte, maxSize int64) ([]byte, error) {
+	format := Detect(data)
+	if format == FormatNone {
+		return data, nil
+	}
+	r, err := NewReader(bytes.NewReader(data), format)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	out, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing %s data: %w", format, err)
+	}
+	if int64(len(out)) > maxSize {
+		return nil, fmt.Errorf("decompressed %s data exceeds %d bytes", format, maxSize)
+	}
+	return out, nil
+}
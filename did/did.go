@@ -0,0 +1,179 @@
+package did
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/httpfetch"
+	"jwtdecode/jwks"
+	"jwtdecode/verify"
+)
+
+// VerificationMethod is a verification method entry of a DID document.
+// Only methods carrying a publicKeyJwk are supported.
+type VerificationMethod struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	Controller   string    `json:"controller"`
+	PublicKeyJwk *jwks.Key `json:"publicKeyJwk,omitempty"`
+}
+
+// Document is the subset of a DID document needed to resolve verification keys.
+type Document struct {
+	ID                 string               `json:"id"`
+	VerificationMethod []VerificationMethod `json:"verificationMethod"`
+}
+
+// IsDID reports whether s is a decentralized identifier.
+func IsDID(s string) bool {
+	return strings.HasPrefix(s, "did:")
+}
+
+// Resolve resolves a DID to its document. The did:web and did:jwk methods are supported.
+func Resolve(id string) (*Document, error) {
+	id = strings.SplitN(id, "#", 2)[0]
+	switch {
+	case strings.HasPrefix(id, "did:web:"):
+		return resolveWeb(id)
+	case strings.HasPrefix(id, "did:jwk:"):
+		return resolveJWK(id)
+	default:
+		return nil, fmt.Errorf("unsupported DID method in %q", id)
+	}
+}
+
+// ResolveKey resolves the public key identified by kid (a DID URL such as
+// "did:web:example.com#key-1" or a bare fragment) in the document of the DID issuer.
+// Without a kid, the document must contain exactly one usable key.
+func ResolveKey(issuer, kid string) (crypto.PublicKey, error) {
+	doc, err := Resolve(issuer)
+	if err != nil {
+		return nil, err
+	}
+	method, err := doc.findMethod(issuer, kid)
+	if err != nil {
+		return nil, err
+	}
+	if method.PublicKeyJwk == nil {
+		return nil, fmt.Errorf("verification method %q has no publicKeyJwk", method.ID)
+	}
+	key, err := method.PublicKeyJwk.PublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("decoding verification method %q: %w", method.ID, err)
+	}
+	return key, nil
+}
+
+// VerifyToken verifies a token issued by a DID: the issuer's document is resolved and
+// the key selected by the kid header, which must be among pins, is used to verify the
+// signature of the raw token.
+func VerifyToken(raw string, token *jwt.Token, pins verify.Pins) error {
+	claims, _ := token.Claims.(jwt.MapClaims)
+	issuer, _ := claims["iss"].(string)
+	if !IsDID(issuer) {
+		return fmt.Errorf("issuer %q is not a DID", issuer)
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, err := ResolveKey(issuer, kid)
+	if err != nil {
+		return err
+	}
+	return verify.Signature(raw, token, key, pins)
+}
+
+// findMethod selects the verification method matching kid.
+func (d *Document) findMethod(issuer, kid string) (*VerificationMethod, error) {
+	if kid == "" {
+		if len(d.VerificationMethod) == 1 {
+			return &d.VerificationMethod[0], nil
+		}
+		return nil, fmt.Errorf("token has no kid and DID document holds %d verification methods", len(d.VerificationMethod))
+	}
+	// Accept absolute DID URLs and fragments relative to the issuer's DID
+	want := kid
+	if strings.HasPrefix(kid, "#") {
+		want = strings.SplitN(issuer, "#", 2)[0] + kid
+	}
+	for i := range d.VerificationMethod {
+		id := d.VerificationMethod[i].ID
+		if strings.HasPrefix(id, "#") {
+			id = d.ID + id
+		}
+		if id == want {
+			return &d.VerificationMethod[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no verification method %q in DID document %q", kid, d.ID)
+}
+
+// resolveWeb fetches a did:web document over HTTPS (did:web method specification §3.2).
+func resolveWeb(id string) (*Document, error) {
+	docURL, err := webDocumentURL(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := httpfetch.Get(docURL, 0)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", id, err)
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing DID document for %s: %w", id, err)
+	}
+	if doc.ID != id {
+		return nil, fmt.Errorf("DID document id %q does not match %q", doc.ID, id)
+	}
+	return &doc, nil
+}
+
+// webDocumentURL converts a did:web identifier to the URL of its DID document:
+// did:web:example.com becomes https://example.com/.well-known/did.json and
+// did:web:example.com:user:alice becomes https://example.com/user/alice/did.json.
+func webDocumentURL(id string) (string, error) {
+	segments := strings.Split(strings.TrimPrefix(id, "did:web:"), ":")
+	host, err := url.PathUnescape(segments[0])
+	if err != nil || host == "" || strings.ContainsAny(host, "/?#@") {
+		return "", fmt.Errorf("invalid did:web host in %q", id)
+	}
+	path := "/.well-known/did.json"
+	if len(segments) > 1 {
+		for _, segment := range segments[1:] {
+			if segment == "" || segment == "." || segment == ".." || strings.ContainsAny(segment, "/?#") {
+				return "", fmt.Errorf("invalid did:web path in %q", id)
+			}
+		}
+		path = "/" + strings.Join(segments[1:], "/") + "/did.json"
+	}
+	return (&url.URL{Scheme: "https", Host: host, Path: path}).String(), nil
+}
+
+// resolveJWK decodes a did:jwk identifier into a document holding its single key
+// (did:jwk method specification §2.3). No network access is needed.
+func resolveJWK(id string) (*Document, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(id, "did:jwk:"))
+	if err != nil {
+		return nil, fmt.Errorf("decoding did:jwk identifier: %w", err)
+	}
+	var key jwks.Key
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("parsing did:jwk key: %w", err)
+	}
+	if key.D != "" {
+		return nil, fmt.Errorf("did:jwk identifier must not contain private key material")
+	}
+	return &Document{
+		ID: id,
+		VerificationMethod: []VerificationMethod{{
+			ID:           id + "#0",
+			Type:         "JsonWebKey2020",
+			Controller:   id,
+			PublicKeyJwk: &key,
+		}},
+	}, nil
+}
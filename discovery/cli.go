@@ -0,0 +1,63 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrDrift is returned by Main when the compared issuers differ.
+var ErrDrift = errors.New("issuers differ")
+
+// usage describes the actions of the issuers subcommand.
+const usage = "usage: jwtdecode issuers diff <issuer-or-snapshot> <issuer-or-snapshot> | jwtdecode issuers snapshot <issuer>"
+
+// Main runs the issuers subcommand with its command-line arguments, reporting to w.
+// The actions are "diff <a> <b>", where each side is an issuer URL or a snapshot file,
+// and "snapshot <issuer>", which prints a snapshot of the issuer to compare against later.
+func Main(args []string, w io.Writer) error {
+	switch {
+	case len(args) == 3 && args[0] == "diff":
+		return diff(args[1], args[2], w)
+	case len(args) == 2 && args[0] == "snapshot":
+		doc, err := Fetch(args[1])
+		if err != nil {
+			return err
+		}
+		data, err := doc.Snapshot()
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	default:
+		return errors.New(usage)
+	}
+}
+
+// diff reports the drift from issuer a to issuer b.
+func diff(a, b string, w io.Writer) error {
+	docA, err := Load(a)
+	if err != nil {
+		return err
+	}
+	docB, err := Load(b)
+	if err != nil {
+		return err
+	}
+	drifts, err := Diff(docA, docB)
+	if err != nil {
+		return err
+	}
+	for _, d := range drifts {
+		fmt.Fprintln(w, d)
+	}
+	if docA.Keys == nil || docB.Keys == nil {
+		fmt.Fprintln(w, "Key sets not compared: no jwks_uri or embedded jwks on both sides")
+	}
+	if len(drifts) > 0 {
+		return fmt.Errorf("%w (%d differences)", ErrDrift, len(drifts))
+	}
+	fmt.Fprintln(w, "Issuers are identical")
+	return nil
+}
@@ -0,0 +1,234 @@
+package discovery
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"jwtdecode/jwks"
+	"jwtdecode/verify"
+)
+
+// issuerPlaceholder replaces the issuer in URLs, so that endpoints at the same path
+// of two issuers (e.g., staging and production) compare equal.
+const issuerPlaceholder = "{issuer}"
+
+// Drift is one difference between the documents of two issuers.
+type Drift struct {
+	Kind   string      // "metadata" or "key"
+	Name   string      // Metadata member, or key description
+	Change string      // "added", "removed", or "changed" (from A to B)
+	A, B   interface{} // Values in A and B; nil where absent
+}
+
+// String renders the drift as a report line.
+func (d Drift) String() string {
+	switch d.Change {
+	case "added":
+		return fmt.Sprintf("%s: + %s = %s", d.Kind, d.Name, render(d.B))
+	case "removed":
+		return fmt.Sprintf("%s: - %s = %s", d.Kind, d.Name, render(d.A))
+	default:
+		return fmt.Sprintf("%s: ~ %s: %s -> %s", d.Kind, d.Name, render(d.A), render(d.B))
+	}
+}
+
+// Diff compares the metadata and key sets of two issuers. The issuer member is not
+// compared, and URLs are compared relative to their issuer. Lists of strings (supported
+// algorithms, scopes, ...) are compared as sets, reporting the values added or removed.
+// Keys are matched by RFC 7638 thumbprint. Key sets are only compared if both are known.
+func Diff(a, b *Document) ([]Drift, error) {
+	var drifts []Drift
+	names := map[string]bool{}
+	for name := range a.Metadata {
+		names[name] = true
+	}
+	for name := range b.Metadata {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		if name != "issuer" {
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		va, inA := a.Metadata[name]
+		vb, inB := b.Metadata[name]
+		va, vb = relative(va, a.Issuer()), relative(vb, b.Issuer())
+		switch {
+		case !inA:
+			drifts = append(drifts, Drift{Kind: "metadata", Name: name, Change: "added", B: vb})
+		case !inB:
+			drifts = append(drifts, Drift{Kind: "metadata", Name: name, Change: "removed", A: va})
+		case reflect.DeepEqual(va, vb):
+		default:
+			la, okA := stringList(va)
+			lb, okB := stringList(vb)
+			if !okA || !okB {
+				drifts = append(drifts, Drift{Kind: "metadata", Name: name, Change: "changed", A: va, B: vb})
+				continue
+			}
+			for _, v := range la {
+				if !slices.Contains(lb, v) {
+					drifts = append(drifts, Drift{Kind: "metadata", Name: name, Change: "removed", A: v})
+				}
+			}
+			for _, v := range lb {
+				if !slices.Contains(la, v) {
+					drifts = append(drifts, Drift{Kind: "metadata", Name: name, Change: "added", B: v})
+				}
+			}
+		}
+	}
+
+	if a.Keys == nil || b.Keys == nil {
+		return drifts, nil
+	}
+	keysA, err := keysByThumbprint(a.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("reading keys of %s: %w", a.Source, err)
+	}
+	keysB, err := keysByThumbprint(b.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("reading keys of %s: %w", b.Source, err)
+	}
+	for _, thumbprint := range sortedKeys(keysA) {
+		if _, ok := keysB[thumbprint]; !ok {
+			drifts = append(drifts, Drift{Kind: "key", Name: thumbprint, Change: "removed", A: Describe(keysA[thumbprint])})
+		}
+	}
+	for _, thumbprint := range sortedKeys(keysB) {
+		ka := keysA[thumbprint]
+		kb := keysB[thumbprint]
+		switch {
+		case ka.Kty == "":
+			drifts = append(drifts, Drift{Kind: "key", Name: thumbprint, Change: "added", B: Describe(kb)})
+		case Describe(ka) != Describe(kb):
+			drifts = append(drifts, Drift{Kind: "key", Name: thumbprint, Change: "changed", A: Describe(ka), B: Describe(kb)})
+		}
+	}
+	return drifts, nil
+}
+
+// Thumbprint returns the RFC 7638 thumbprint of a JWK.
+func Thumbprint(key jwks.Key) (string, error) {
+	pub, err := key.PublicKey()
+	if err != nil {
+		return "", err
+	}
+	return verify.Thumbprint(pub)
+}
+
+// Describe summarizes a JWK: its kid, type and size or curve, algorithm, and use.
+func Describe(key jwks.Key) string {
+	parts := []string{}
+	if key.Kid != "" {
+		parts = append(parts, "kid="+key.Kid)
+	}
+	switch key.Kty {
+	case "RSA":
+		bits := 0
+		if pub, err := key.PublicKey(); err == nil {
+			if rsaKey, ok := pub.(*rsa.PublicKey); ok {
+				bits = rsaKey.N.BitLen()
+			}
+		}
+		parts = append(parts, fmt.Sprintf("RSA-%d", bits))
+	case "EC", "OKP":
+		parts = append(parts, key.Kty+"-"+key.Crv)
+	default:
+		parts = append(parts, key.Kty)
+	}
+	if key.Alg != "" {
+		parts = append(parts, "alg="+key.Alg)
+	}
+	if key.Use != "" {
+		parts = append(parts, "use="+key.Use)
+	}
+	return strings.Join(parts, " ")
+}
+
+// keysByThumbprint indexes the keys of a set by thumbprint.
+func keysByThumbprint(set *jwks.Set) (map[string]jwks.Key, error) {
+	keys := make(map[string]jwks.Key, len(set.Keys))
+	for _, key := range set.Keys {
+		thumbprint, err := Thumbprint(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key.Kid, err)
+		}
+		keys[thumbprint] = key
+	}
+	return keys, nil
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys(m map[string]jwks.Key) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// relative replaces the issuer at the start of URL values, including within lists and objects.
+func relative(value interface{}, issuer string) interface{} {
+	issuer = strings.TrimSuffix(issuer, "/")
+	if issuer == "" {
+		return value
+	}
+	switch v := value.(type) {
+	case string:
+		if v == issuer || strings.HasPrefix(v, issuer+"/") {
+			return issuerPlaceholder + strings.TrimPrefix(v, issuer)
+		}
+		return v
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = relative(item, issuer)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = relative(item, issuer)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// stringList returns the values of a list of strings.
+func stringList(value interface{}) ([]string, bool) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, len(list))
+	for i, item := range list {
+		if out[i], ok = item.(string); !ok {
+			return nil, false
+		}
+	}
+	return out, true
+}
+
+// render formats a value compactly for the drift report.
+func render(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
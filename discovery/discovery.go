@@ -0,0 +1,118 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"jwtdecode/httpfetch"
+	"jwtdecode/jwks"
+	"jwtdecode/utils"
+)
+
+// Well-known paths of the issuer metadata: OpenID Connect Discovery, then OAuth 2.0
+// Authorization Server Metadata (RFC 8414).
+var wellKnownPaths = []string{"/.well-known/openid-configuration", "/.well-known/oauth-authorization-server"}
+
+// Document is the metadata of an issuer and its key set.
+type Document struct {
+	Source   string                 // Issuer URL or snapshot file the document was read from
+	Metadata map[string]interface{} // Discovery document, without an embedded key set
+	Keys     *jwks.Set              // Key set from jwks_uri or the snapshot; nil if unavailable
+}
+
+// Issuer returns the issuer identifier declared by the document.
+func (d *Document) Issuer() string {
+	iss, _ := d.Metadata["issuer"].(string)
+	return iss
+}
+
+// Load reads the document of an issuer URL (http:// or https://), fetching its discovery
+// document and key set, or of a snapshot file written by Snapshot.
+func Load(source string) (*Document, error) {
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		return Fetch(source)
+	}
+	data, err := utils.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("reading issuer snapshot: %w", err)
+	}
+	doc := &Document{Source: source}
+	if err := json.Unmarshal(data, &doc.Metadata); err != nil {
+		return nil, fmt.Errorf("parsing issuer snapshot %q: %w", source, err)
+	}
+	// A snapshot embeds the key set as the jwks member, as in client metadata (RFC 7591)
+	if embedded, ok := doc.Metadata["jwks"]; ok {
+		delete(doc.Metadata, "jwks")
+		raw, err := json.Marshal(embedded)
+		if err != nil {
+			return nil, fmt.Errorf("parsing issuer snapshot %q: %w", source, err)
+		}
+		if doc.Keys, err = jwks.Parse(raw); err != nil {
+			return nil, fmt.Errorf("parsing issuer snapshot %q: %w", source, err)
+		}
+	}
+	return doc, nil
+}
+
+// Fetch retrieves the discovery document of an issuer and the key set at its jwks_uri.
+func Fetch(issuer string) (*Document, error) {
+	doc, err := FetchMetadata(issuer, func(url string) ([]byte, error) {
+		return httpfetch.Get(url, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if uri := doc.JWKSURI(); uri != "" {
+		if doc.Keys, err = jwks.Fetch(uri); err != nil {
+			return nil, fmt.Errorf("fetching key set of %s: %w", issuer, err)
+		}
+	}
+	return doc, nil
+}
+
+// FetchMetadata retrieves the discovery document of an issuer with get, without its key set.
+func FetchMetadata(issuer string, get func(url string) ([]byte, error)) (*Document, error) {
+	base := strings.TrimSuffix(issuer, "/")
+	data, err := get(base + wellKnownPaths[0])
+	for _, path := range wellKnownPaths[1:] {
+		if err == nil {
+			break
+		}
+		// Report the OpenID Connect failure if no fallback document exists either
+		if fallback, fallbackErr := get(base + path); fallbackErr == nil {
+			data, err = fallback, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetching discovery document of %s: %w", issuer, err)
+	}
+	doc := &Document{Source: issuer}
+	if err := json.Unmarshal(data, &doc.Metadata); err != nil {
+		return nil, fmt.Errorf("parsing discovery document of %s: %w", issuer, err)
+	}
+	return doc, nil
+}
+
+// JWKSURI returns the URL of the key set declared by the document.
+func (d *Document) JWKSURI() string {
+	uri, _ := d.Metadata["jwks_uri"].(string)
+	return uri
+}
+
+// Snapshot returns the document as a snapshot file: the discovery document with the
+// key set embedded as its jwks member.
+func (d *Document) Snapshot() ([]byte, error) {
+	snapshot := make(map[string]interface{}, len(d.Metadata)+1)
+	for key, value := range d.Metadata {
+		snapshot[key] = value
+	}
+	if d.Keys != nil {
+		snapshot["jwks"] = d.Keys
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("formatting issuer snapshot: %w", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,138 @@
+package envelope
+
+import (
+	"errors"
+	"time"
+
+	"jwtdecode/findings"
+	"jwtdecode/formatter"
+	"jwtdecode/jwterrors"
+	"jwtdecode/warnings"
+)
+
+// SchemaVersion is the version of the envelope schema. Members may be added within a
+// version; it changes when a member is removed, renamed, or changes meaning.
+const SchemaVersion = "1"
+
+// Verification statuses of an envelope.
+const (
+	StatusVerified    = "VERIFIED"
+	StatusNotVerified = formatter.NotVerified
+)
+
+// Envelope is the stable document describing one decoded token to machine integrations:
+// -envelope JSON and NDJSON output, and -jsonrpc decode responses. Every member is always
+// present, lists being empty rather than null, so that clients need no special cases.
+type Envelope struct {
+	SchemaVersion string                 `json:"schema_version"`
+	Token         Token                  `json:"token"`
+	Header        map[string]interface{} `json:"header"`
+	Claims        map[string]interface{} `json:"claims"`
+	Verification  Verification           `json:"verification"`
+	Warnings      []warnings.Warning     `json:"warnings"`
+	Findings      []findings.Finding     `json:"findings"`
+	Failures      []string               `json:"failures"` // Failures that fail the run, e.g. a token that does not conform
+}
+
+// Token is the metadata of the token an envelope describes.
+type Token struct {
+	SHA256    string    `json:"sha256"`              // Hex SHA-256 of the token as read
+	Source    string    `json:"source,omitempty"`    // File the token was read from, in directory mode
+	ExpiresAt time.Time `json:"expires_at,omitzero"` // Expiration time (exp claim); omitted if absent
+}
+
+// Verification is the outcome of signature verification.
+type Verification struct {
+	Verified bool   `json:"verified"`
+	Status   string `json:"status"` // StatusVerified or StatusNotVerified
+}
+
+// New returns the envelope of a decoded token.
+func New(token Token, header, claims map[string]interface{}, verified bool, warns []warnings.Warning, finds []findings.Finding, failures []string) *Envelope {
+	status := StatusNotVerified
+	if verified {
+		status = StatusVerified
+	}
+	return &Envelope{
+		SchemaVersion: SchemaVersion,
+		Token:         token,
+		Header:        nonNilMap(header),
+		Claims:        nonNilMap(claims),
+		Verification:  Verification{Verified: verified, Status: status},
+		Warnings:      nonNil(warns),
+		Findings:      nonNil(finds),
+		Failures:      nonNil(failures),
+	}
+}
+
+// ErrorEnvelope is the stable document describing a failure to machine integrations: the
+// error of a run with -error-format json, and -serve error responses with it.
+type ErrorEnvelope struct {
+	SchemaVersion string      `json:"schema_version"`
+	Error         ErrorDetail `json:"error"`
+}
+
+// ErrorDetail is a failure: its code and category, stable within a schema version, and a
+// message for people, which may change between releases.
+type ErrorDetail struct {
+	Code     string `json:"code"`     // E.g. signature_invalid
+	Category string `json:"category"` // One of the Category constants
+	Message  string `json:"message"`
+}
+
+// Categories of failures.
+const (
+	CategoryToken         = "token"         // The token cannot be read
+	CategoryVerification  = "verification"  // The token cannot be decrypted or verified
+	CategoryValidity      = "validity"      // The token is expired or not yet valid
+	CategoryOutput        = "output"        // The output cannot be written
+	CategoryConfiguration = "configuration" // The options break the validation rules
+	CategoryRequest       = "request"       // The -serve request is invalid
+	CategoryInternal      = "internal"      // Any other failure
+)
+
+// errorCodes are the codes and categories of the errors of jwterrors.
+var errorCodes = []struct {
+	err      error
+	code     string
+	category string
+}{
+	{jwterrors.ErrMalformedToken, "malformed_token", CategoryToken},
+	{jwterrors.ErrTokenTooLarge, "token_too_large", CategoryToken},
+	{jwterrors.ErrDecryption, "decryption_failed", CategoryVerification},
+	{jwterrors.ErrSignatureInvalid, "signature_invalid", CategoryVerification},
+	{jwterrors.ErrKeyNotFound, "key_not_found", CategoryVerification},
+	{jwterrors.ErrExpired, "token_expired", CategoryValidity},
+	{jwterrors.ErrNotYetValid, "token_not_yet_valid", CategoryValidity},
+	{jwterrors.ErrOutputTooLarge, "output_too_large", CategoryOutput},
+	{jwterrors.ErrInvalidConfig, "invalid_configuration", CategoryConfiguration},
+}
+
+// NewError returns the envelope of a failure with the given message, coded after the
+// category of err in jwterrors; an error of no category is coded as internal_error.
+func NewError(err error, message string) *ErrorEnvelope {
+	detail := ErrorDetail{Code: "internal_error", Category: CategoryInternal, Message: message}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			detail.Code, detail.Category = c.code, c.category
+			break
+		}
+	}
+	return &ErrorEnvelope{SchemaVersion: SchemaVersion, Error: detail}
+}
+
+// nonNil returns list, or an empty list for nil, so that it is encoded as [] in JSON.
+func nonNil[T any](list []T) []T {
+	if list == nil {
+		return []T{}
+	}
+	return list
+}
+
+// nonNilMap returns m, or an empty map for nil, so that it is encoded as {} in JSON.
+func nonNilMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return map[string]interface{}{}
+	}
+	return m
+}
@@ -0,0 +1,154 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimEvents is the claim carrying security events in Security Event Tokens (RFC 8417)
+// and OpenID Connect logout tokens.
+const ClaimEvents = "events"
+
+// EventType describes a known security event type.
+type EventType struct {
+	Name        string   // Short name of the event
+	Spec        string   // Specification defining the event
+	Description string   // What the event signals
+	Required    []string // Members required in the event payload
+}
+
+// eventTypes maps event type URIs to their descriptions.
+var eventTypes = map[string]EventType{
+	"http://schemas.openid.net/event/backchannel-logout": {
+		Name: "backchannel-logout", Spec: "OpenID Connect Back-Channel Logout 1.0",
+		Description: "The user's session at the issuer ended; the relying party must log out the session identified by sub and/or sid",
+	},
+	"https://schemas.openid.net/secevent/caep/event-type/session-revoked": {
+		Name: "session-revoked", Spec: "CAEP 1.0",
+		Description: "A session of the subject was revoked",
+	},
+	"https://schemas.openid.net/secevent/caep/event-type/token-claims-change": {
+		Name: "token-claims-change", Spec: "CAEP 1.0",
+		Description: "Claims in a token previously issued for the subject changed",
+		Required:    []string{"claims"},
+	},
+	"https://schemas.openid.net/secevent/caep/event-type/credential-change": {
+		Name: "credential-change", Spec: "CAEP 1.0",
+		Description: "A credential of the subject was created, revoked, updated, or deleted",
+		Required:    []string{"credential_type", "change_type"},
+	},
+	"https://schemas.openid.net/secevent/caep/event-type/assurance-level-change": {
+		Name: "assurance-level-change", Spec: "CAEP 1.0",
+		Description: "The authentication assurance level of the subject changed",
+		Required:    []string{"namespace", "current_level"},
+	},
+	"https://schemas.openid.net/secevent/caep/event-type/device-compliance-change": {
+		Name: "device-compliance-change", Spec: "CAEP 1.0",
+		Description: "The compliance status of the subject's device changed",
+		Required:    []string{"previous_status", "current_status"},
+	},
+	"https://schemas.openid.net/secevent/risc/event-type/account-credential-change-required": {
+		Name: "account-credential-change-required", Spec: "RISC 1.0",
+		Description: "The subject must change a credential (e.g., suspected compromise)",
+	},
+	"https://schemas.openid.net/secevent/risc/event-type/account-purged": {
+		Name: "account-purged", Spec: "RISC 1.0",
+		Description: "The subject's account was permanently deleted",
+	},
+	"https://schemas.openid.net/secevent/risc/event-type/account-disabled": {
+		Name: "account-disabled", Spec: "RISC 1.0",
+		Description: "The subject's account was disabled",
+	},
+	"https://schemas.openid.net/secevent/risc/event-type/account-enabled": {
+		Name: "account-enabled", Spec: "RISC 1.0",
+		Description: "The subject's account was re-enabled",
+	},
+	"https://schemas.openid.net/secevent/risc/event-type/identifier-changed": {
+		Name: "identifier-changed", Spec: "RISC 1.0",
+		Description: "An identifier of the subject (e.g., email or phone) changed",
+	},
+	"https://schemas.openid.net/secevent/risc/event-type/identifier-recycled": {
+		Name: "identifier-recycled", Spec: "RISC 1.0",
+		Description: "An identifier of the subject was recycled and now belongs to another user",
+	},
+	"https://schemas.openid.net/secevent/risc/event-type/credential-compromise": {
+		Name: "credential-compromise", Spec: "RISC 1.0",
+		Description: "A credential of the subject was compromised",
+		Required:    []string{"credential_type"},
+	},
+	"https://schemas.openid.net/secevent/risc/event-type/sessions-revoked": {
+		Name: "sessions-revoked", Spec: "RISC 1.0",
+		Description: "All sessions of the subject were revoked",
+	},
+	"https://schemas.openid.net/secevent/ssf/event-type/verification": {
+		Name: "verification", Spec: "Shared Signals Framework 1.0",
+		Description: "Stream verification event requested by the receiver",
+	},
+	"https://schemas.openid.net/secevent/ssf/event-type/stream-updated": {
+		Name: "stream-updated", Spec: "Shared Signals Framework 1.0",
+		Description: "The status of the event stream changed",
+		Required:    []string{"status"},
+	},
+}
+
+// Annotate describes each event in the claims' events member and validates its structure
+// (RFC 8417 §2.2). It returns the annotation to add to the output, keyed by event type URI,
+// and the list of structural problems found. It returns a nil annotation when the token
+// carries no events claim.
+func Annotate(claims jwt.MapClaims) (map[string]interface{}, []string) {
+	raw, ok := claims[ClaimEvents]
+	if !ok {
+		return nil, nil
+	}
+	evts, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, []string{"events claim must be a JSON object"}
+	}
+	if len(evts) == 0 {
+		return nil, []string{"events claim must contain at least one event"}
+	}
+
+	uris := make([]string, 0, len(evts))
+	for uri := range evts {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	annotation := make(map[string]interface{}, len(evts))
+	var problems []string
+	for _, uri := range uris {
+		entry := map[string]interface{}{}
+		var eventProblems []string
+
+		payload, isObject := evts[uri].(map[string]interface{})
+		if !isObject {
+			eventProblems = append(eventProblems, "event payload must be a JSON object")
+		}
+		if eventType, known := eventTypes[uri]; known {
+			entry["name"] = eventType.Name
+			entry["spec"] = eventType.Spec
+			entry["description"] = eventType.Description
+			for _, member := range eventType.Required {
+				if _, present := payload[member]; isObject && !present {
+					eventProblems = append(eventProblems, fmt.Sprintf("required member %q is missing", member))
+				}
+			}
+		} else {
+			entry["name"] = "unknown"
+		}
+
+		entry["valid"] = len(eventProblems) == 0
+		if len(eventProblems) > 0 {
+			list := make([]interface{}, len(eventProblems))
+			for i, p := range eventProblems {
+				list[i] = p
+				problems = append(problems, fmt.Sprintf("event %s: %s", uri, p))
+			}
+			entry["problems"] = list
+		}
+		annotation[uri] = entry
+	}
+	return annotation, problems
+}
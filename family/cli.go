@@ -0,0 +1,123 @@
+package family
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/token"
+)
+
+// maxTokenSize is the size limit of the tokens read, the default of the main -max-token-size.
+const maxTokenSize = 1 << 20
+
+// Main runs the families subcommand with its command-line arguments, reporting to w.
+func Main(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("families", flag.ContinueOnError)
+	tokenList := fs.String("token-list", "", "File containing one JWT token per line (gzip and zstd are decompressed)")
+	tokenDir := fs.String("token-dir", "", "Directory (or archives) of token files")
+	pattern := fs.String("pattern", "*.jwt", "File name pattern of token files in -token-dir")
+	jsonOut := fs.Bool("json", false, "Write the report as a JSON array of families")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	var keys []Key
+	var tokens []Token
+	add := func(name, raw string) {
+		claims := jwt.MapClaims{}
+		if _, _, err := jwt.NewParser().ParseUnverified(raw, claims); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: parsing JWT token: %v\n", name, err)
+			return
+		}
+		key, t := NewToken(name, claims)
+		keys = append(keys, key)
+		tokens = append(tokens, t)
+	}
+	opts := token.Options{
+		Warn:    func(msg string) { fmt.Fprintf(os.Stderr, "Warning: %s\n", msg) },
+		MaxSize: maxTokenSize,
+	}
+	switch {
+	case *tokenList != "" && *tokenDir != "":
+		return fmt.Errorf("-token-list and -token-dir are mutually exclusive")
+	case *tokenList != "":
+		list, err := token.OpenList(*tokenList, maxTokenSize, opts)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = list.Close()
+		}()
+		for raw, ok := list.Next(); ok; raw, ok = list.Next() {
+			add(fmt.Sprintf("%s:%d", *tokenList, list.Line()), raw)
+		}
+		if err := list.Err(); err != nil {
+			return err
+		}
+	case *tokenDir != "":
+		err := token.Walk(*tokenDir, *pattern, opts, func(name, raw string) error {
+			add(name, raw)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("a token source is required (-token-list or -token-dir)")
+	}
+
+	families := Analyze(keys, tokens)
+	if *jsonOut {
+		data, err := json.MarshalIndent(families, "", "  ")
+		if err != nil {
+			return fmt.Errorf("formatting report: %w", err)
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+	for i, f := range families {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		writeFamily(w, f)
+	}
+	fmt.Fprintf(w, "%d tokens in %d families\n", len(tokens), len(families))
+	return nil
+}
+
+// writeFamily writes the report of a family as text.
+func writeFamily(w io.Writer, f *Family) {
+	fmt.Fprintf(w, "Family iss=%q sub=%q client_id=%q (%d tokens)\n", f.Issuer, f.Subject, f.ClientID, len(f.Tokens))
+	for _, t := range f.Tokens {
+		fmt.Fprintf(w, "  iat %-20s  exp %-20s  %s\n", formatTime(t.IssuedAt), formatTime(t.Expiry), t.Name)
+	}
+	if f.Cadence != nil {
+		fmt.Fprintf(w, "  Refresh cadence: min %s, median %s, max %s\n", f.Cadence.Min, f.Cadence.Median, f.Cadence.Max)
+	}
+	if f.Lifetime > 0 {
+		fmt.Fprintf(w, "  Median lifetime: %s\n", f.Lifetime)
+	}
+	if f.Overlaps > 0 {
+		fmt.Fprintf(w, "  Overlapping validity windows: %d (longest %s)\n", f.Overlaps, f.MaxOverlap)
+	}
+	for _, anomaly := range f.Anomalies {
+		fmt.Fprintf(w, "  Anomaly: %s\n", anomaly)
+	}
+}
+
+// formatTime formats a token time, or "-" for a missing claim.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,208 @@
+package family
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Lifetimes further than this factor from the median lifetime of their family are anomalies.
+const lifetimeFactor = 2
+
+// minLifetimes is the number of tokens with a lifetime a family needs for its median
+// lifetime to be a baseline.
+const minLifetimes = 3
+
+// Key identifies a token family: the tokens issued by one issuer to one subject through
+// one client.
+type Key struct {
+	Issuer   string `json:"iss"`
+	Subject  string `json:"sub"`
+	ClientID string `json:"client_id"`
+}
+
+// Token is a token of a family, with the times that order it and bound its validity.
+type Token struct {
+	Name      string    `json:"name"` // Token list line (path:line) or token directory entry
+	IssuedAt  time.Time `json:"iat,omitzero"`
+	NotBefore time.Time `json:"nbf,omitzero"`
+	Expiry    time.Time `json:"exp,omitzero"`
+}
+
+// Family is the session-behavior report of a token family.
+type Family struct {
+	Key
+	Tokens     []Token  `json:"tokens"`            // Tokens ordered by iat, those without iat last
+	Cadence    *Cadence `json:"cadence,omitempty"` // Time between consecutive issuances; nil with fewer than 2 issuance times
+	Overlaps   int      `json:"overlaps"`          // Tokens valid before an earlier token of the family expired
+	MaxOverlap Duration `json:"max_overlap"`       // Longest time two tokens of the family were valid together
+	Lifetime   Duration `json:"median_lifetime"`   // Median lifetime (exp - iat); zero if unknown
+	Anomalies  []string `json:"anomalies"`         // Lifetime anomalies of the tokens
+}
+
+// Cadence summarizes the time between consecutive issuances of a family.
+type Cadence struct {
+	Min    Duration `json:"min"`
+	Median Duration `json:"median"`
+	Max    Duration `json:"max"`
+}
+
+// Duration is a time.Duration encoded in JSON as a string, e.g. "1h30m0s".
+type Duration time.Duration
+
+// String formats the duration as time.Duration does.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText encodes the duration as its string.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// NewToken reads the family key and times of a token from its claims. The client is
+// client_id, or azp for issuers that do not set it.
+func NewToken(name string, claims jwt.MapClaims) (Key, Token) {
+	var key Key
+	key.Issuer, _ = claims.GetIssuer()
+	key.Subject, _ = claims.GetSubject()
+	if key.ClientID, _ = claims["client_id"].(string); key.ClientID == "" {
+		key.ClientID, _ = claims["azp"].(string)
+	}
+	t := Token{Name: name}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		t.IssuedAt = iat.UTC()
+	}
+	if nbf, err := claims.GetNotBefore(); err == nil && nbf != nil {
+		t.NotBefore = nbf.UTC()
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		t.Expiry = exp.UTC()
+	}
+	return key, t
+}
+
+// start returns the time from which the token is valid.
+func (t Token) start() time.Time {
+	if !t.NotBefore.IsZero() {
+		return t.NotBefore
+	}
+	return t.IssuedAt
+}
+
+// lifetime returns the validity period of the token, and false if it is unknown.
+func (t Token) lifetime() (time.Duration, bool) {
+	if t.IssuedAt.IsZero() || t.Expiry.IsZero() {
+		return 0, false
+	}
+	return t.Expiry.Sub(t.IssuedAt), true
+}
+
+// Analyze groups tokens into families and reports on each of them. Families are ordered
+// by key.
+func Analyze(keys []Key, tokens []Token) []*Family {
+	byKey := map[Key]*Family{}
+	var families []*Family
+	for i, key := range keys {
+		f, ok := byKey[key]
+		if !ok {
+			f = &Family{Key: key}
+			byKey[key] = f
+			families = append(families, f)
+		}
+		f.Tokens = append(f.Tokens, tokens[i])
+	}
+	slices.SortFunc(families, func(a, b *Family) int {
+		return cmp.Or(cmp.Compare(a.Issuer, b.Issuer), cmp.Compare(a.Subject, b.Subject), cmp.Compare(a.ClientID, b.ClientID))
+	})
+	for _, f := range families {
+		f.analyze()
+	}
+	return families
+}
+
+// analyze orders the tokens of the family and computes its report.
+func (f *Family) analyze() {
+	slices.SortStableFunc(f.Tokens, func(a, b Token) int {
+		switch {
+		case a.IssuedAt.IsZero() || b.IssuedAt.IsZero():
+			return cmp.Compare(boolInt(a.IssuedAt.IsZero()), boolInt(b.IssuedAt.IsZero()))
+		default:
+			return a.IssuedAt.Compare(b.IssuedAt)
+		}
+	})
+
+	var gaps, lifetimes []time.Duration
+	var last, validUntil time.Time
+	for _, t := range f.Tokens {
+		if !t.IssuedAt.IsZero() {
+			if !last.IsZero() {
+				gaps = append(gaps, t.IssuedAt.Sub(last))
+			}
+			last = t.IssuedAt
+		}
+		if lifetime, ok := t.lifetime(); ok && lifetime > 0 {
+			lifetimes = append(lifetimes, lifetime)
+		}
+		// Overlaps are measured against the latest expiry of the tokens issued before
+		if start := t.start(); !start.IsZero() && start.Before(validUntil) {
+			f.Overlaps++
+			end := validUntil
+			if !t.Expiry.IsZero() && t.Expiry.Before(end) {
+				end = t.Expiry
+			}
+			f.MaxOverlap = max(f.MaxOverlap, Duration(end.Sub(start)))
+		}
+		if t.Expiry.After(validUntil) {
+			validUntil = t.Expiry
+		}
+	}
+	if len(gaps) > 0 {
+		slices.Sort(gaps)
+		f.Cadence = &Cadence{Min: Duration(gaps[0]), Median: Duration(median(gaps)), Max: Duration(gaps[len(gaps)-1])}
+	}
+	if len(lifetimes) > 0 {
+		slices.Sort(lifetimes)
+		f.Lifetime = Duration(median(lifetimes))
+	}
+	baseline := time.Duration(f.Lifetime)
+
+	f.Anomalies = []string{}
+	for _, t := range f.Tokens {
+		lifetime, ok := t.lifetime()
+		switch {
+		case t.Expiry.IsZero():
+			f.Anomalies = append(f.Anomalies, fmt.Sprintf("%s has no exp claim and never expires", t.Name))
+		case !ok:
+			// Without iat, the lifetime is unknown
+		case lifetime <= 0:
+			f.Anomalies = append(f.Anomalies, fmt.Sprintf("%s expires at %s, not after it was issued at %s", t.Name, t.Expiry.Format(time.RFC3339), t.IssuedAt.Format(time.RFC3339)))
+		case len(lifetimes) < minLifetimes:
+			// Too few lifetimes for a baseline
+		case lifetime > baseline*lifetimeFactor:
+			f.Anomalies = append(f.Anomalies, fmt.Sprintf("%s lifetime of %s is more than %d times the median of %s", t.Name, lifetime, lifetimeFactor, f.Lifetime))
+		case lifetime*lifetimeFactor < baseline:
+			f.Anomalies = append(f.Anomalies, fmt.Sprintf("%s lifetime of %s is less than 1/%d of the median of %s", t.Name, lifetime, lifetimeFactor, f.Lifetime))
+		}
+	}
+}
+
+// median returns the median of sorted durations.
+func median(sorted []time.Duration) time.Duration {
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// boolInt returns 1 for true and 0 for false, to order by a condition.
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,33 @@
+package findings
+
+// Severity levels of findings.
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+)
+
+// Finding is a security-relevant observation about a token, reported in the output
+// under the "findings" key.
+type Finding struct {
+	ID       string `json:"id"`       // Stable identifier, e.g. "alg-confusion"
+	Severity string `json:"severity"` // One of the Severity constants
+	Message  string `json:"message"`  // Explanation of the finding
+}
+
+// ClaimFindings is the output key holding the list of findings.
+const ClaimFindings = "findings"
+
+// ToValue converts findings into generic values that every output formatter can render.
+func ToValue(list []Finding) []interface{} {
+	values := make([]interface{}, 0, len(list))
+	for _, f := range list {
+		values = append(values, map[string]interface{}{
+			"id":       f.ID,
+			"severity": f.Severity,
+			"message":  f.Message,
+		})
+	}
+	return values
+}
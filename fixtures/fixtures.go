@@ -0,0 +1,306 @@
+package fixtures
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/jwks"
+	"jwtdecode/utils"
+	"jwtdecode/verify"
+)
+
+const (
+	// Issuer and Audience are used in every fixture token.
+	Issuer   = "https://fixtures.jwtdecode.invalid"
+	Audience = "jwtdecode-fixtures"
+
+	// hugeClaimsSize is the approximate payload size of the huge-claims fixture,
+	// large but below the default 1MB token limit.
+	hugeClaimsSize = 512 * 1024
+)
+
+// Fixture describes one generated token in the manifest.
+type Fixture struct {
+	File        string `json:"file"`
+	Alg         string `json:"alg"`
+	Kid         string `json:"kid,omitempty"`
+	Description string `json:"description"`
+	Valid       bool   `json:"valid"` // Whether a correct validator should accept the token
+}
+
+// signer is a throwaway signing key for one algorithm.
+type signer struct {
+	method jwt.SigningMethod
+	key    interface{}      // Private key, or secret for HMAC
+	public crypto.PublicKey // Nil for HMAC
+	kid    string
+}
+
+// Main runs the fixtures subcommand with its command-line arguments, reporting to w.
+func Main(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("fixtures", flag.ContinueOnError)
+	dir := fs.String("dir", "", "Directory the fixture tokens, keys, and manifest are written to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return fmt.Errorf("-dir is required")
+	}
+	sanitizedDir, err := utils.SanitizeFilePath(*dir)
+	if err != nil {
+		return fmt.Errorf("sanitizing fixtures directory: %w", err)
+	}
+	manifest, err := Generate(sanitizedDir, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Wrote %d fixture tokens, jwks.json, hmac.key, and manifest.json to %s\n", len(manifest), sanitizedDir)
+	return nil
+}
+
+// Generate writes the fixture tokens to dir, together with the public keys needed to
+// verify them (jwks.json), the HMAC secret (hmac.key), and a manifest describing each
+// token. Keys are generated for this run only; private keys are never written.
+// Time-based fixtures are relative to now.
+func Generate(dir string, now time.Time) ([]Fixture, error) {
+	signers, err := newSigners()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating fixtures directory: %w", err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("opening fixtures directory: %w", err)
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+
+	var manifest []Fixture
+	write := func(name string, s *signer, description string, valid bool, payload []byte, header map[string]interface{}) error {
+		token, err := sign(s, payload, header)
+		if err != nil {
+			return fmt.Errorf("signing fixture %s: %w", name, err)
+		}
+		file := name + ".jwt"
+		if err := root.WriteFile(file, []byte(token), 0600); err != nil {
+			return fmt.Errorf("writing fixture %s: %w", name, err)
+		}
+		manifest = append(manifest, Fixture{File: file, Alg: s.method.Alg(), Kid: s.kid, Description: description, Valid: valid})
+		return nil
+	}
+
+	// One valid token per supported algorithm
+	for _, s := range signers {
+		name := strings.ToLower(s.method.Alg())
+		if err := write(name, s, "Valid token signed with "+s.method.Alg(), true, encodeClaims(baseClaims(now)), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	var rs256 *signer
+	for _, s := range signers {
+		if s.method == jwt.SigningMethodRS256 {
+			rs256 = s
+		}
+	}
+	expired := baseClaims(now)
+	expired["iat"] = now.Add(-2 * time.Hour).Unix()
+	expired["exp"] = now.Add(-time.Hour).Unix()
+	nbf := baseClaims(now)
+	nbf["nbf"] = now.Add(24 * time.Hour).Unix()
+	huge := baseClaims(now)
+	for i := 0; i < hugeClaimsSize/1024; i++ {
+		huge[fmt.Sprintf("claim_%04d", i)] = strings.Repeat("x", 1000)
+	}
+	unicode := baseClaims(now)
+	unicode["名前"] = "山田太郎"
+	unicode["ключ"] = "значение"
+	unicode["emoji_🔑"] = "🔐"
+	unicode["café"] = "décomposé"
+	unicode["rtl_مفتاح"] = "قيمة"
+	inner, err := sign(rs256, encodeClaims(baseClaims(now)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("signing nested fixture: %w", err)
+	}
+
+	others := []struct {
+		name        string
+		signer      *signer
+		description string
+		valid       bool
+		payload     []byte
+		header      map[string]interface{}
+	}{
+		{"expired", rs256, "Token whose exp is one hour in the past", false, encodeClaims(expired), nil},
+		{"nbf-in-future", rs256, "Token whose nbf is one day in the future", false, encodeClaims(nbf), nil},
+		{"none-alg", &signer{method: jwt.SigningMethodNone, key: jwt.UnsafeAllowNoneSignatureType}, "Unsigned token with alg none", false, encodeClaims(baseClaims(now)), nil},
+		// The payload of a nested JWT is the inner token itself, not a claims object
+		{"nested", rs256, "Nested JWT (RFC 7519 §5.2): cty JWT with a signed JWT as the payload", true, []byte(inner), map[string]interface{}{"cty": "JWT"}},
+		{"huge-claims", rs256, fmt.Sprintf("Token with a payload of about %d KB", hugeClaimsSize/1024), true, encodeClaims(huge), nil},
+		{"unicode-keys", rs256, "Token with non-ASCII claim names and values", true, encodeClaims(unicode), nil},
+	}
+	for _, f := range others {
+		if err := write(f.name, f.signer, f.description, f.valid, f.payload, f.header); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeKeys(root, signers); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding manifest: %w", err)
+	}
+	if err := root.WriteFile("manifest.json", data, 0600); err != nil {
+		return nil, fmt.Errorf("writing manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// baseClaims returns the registered claims shared by all fixtures, valid for one year.
+func baseClaims(now time.Time) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss": Issuer,
+		"sub": "fixture-subject",
+		"aud": Audience,
+		"iat": now.Unix(),
+		"exp": now.AddDate(1, 0, 0).Unix(),
+	}
+}
+
+// encodeClaims encodes claims as a JSON payload. Claims built from strings and numbers
+// always encode, so an error is impossible.
+func encodeClaims(claims jwt.MapClaims) []byte {
+	data, _ := json.Marshal(claims)
+	return data
+}
+
+// sign signs payload with s, adding the typ header, its kid, and any extra header parameters.
+func sign(s *signer, payload []byte, header map[string]interface{}) (string, error) {
+	fields := map[string]interface{}{"alg": s.method.Alg(), "typ": "JWT"}
+	if s.kid != "" {
+		fields["kid"] = s.kid
+	}
+	for k, v := range header {
+		fields[k] = v
+	}
+	headerJSON, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sig, err := s.method.Sign(signingInput, s.key)
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// newSigners generates a throwaway key for every supported signing algorithm.
+func newSigners() ([]*signer, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generating HMAC secret: %w", err)
+	}
+	// The secret is used as its hex text so that it can be passed around as a string
+	hmacSecret := []byte(hex.EncodeToString(secret))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("generating RSA key: %w", err)
+	}
+	ecKeys := make(map[string]*ecdsa.PrivateKey)
+	for alg, curve := range map[string]elliptic.Curve{"ES256": elliptic.P256(), "ES384": elliptic.P384(), "ES512": elliptic.P521()} {
+		if ecKeys[alg], err = ecdsa.GenerateKey(curve, rand.Reader); err != nil {
+			return nil, fmt.Errorf("generating %s key: %w", alg, err)
+		}
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating Ed25519 key: %w", err)
+	}
+
+	signers := []*signer{
+		{method: jwt.SigningMethodHS256, key: hmacSecret},
+		{method: jwt.SigningMethodHS384, key: hmacSecret},
+		{method: jwt.SigningMethodHS512, key: hmacSecret},
+		{method: jwt.SigningMethodRS256, key: rsaKey, public: &rsaKey.PublicKey},
+		{method: jwt.SigningMethodRS384, key: rsaKey, public: &rsaKey.PublicKey},
+		{method: jwt.SigningMethodRS512, key: rsaKey, public: &rsaKey.PublicKey},
+		{method: jwt.SigningMethodPS256, key: rsaKey, public: &rsaKey.PublicKey},
+		{method: jwt.SigningMethodPS384, key: rsaKey, public: &rsaKey.PublicKey},
+		{method: jwt.SigningMethodPS512, key: rsaKey, public: &rsaKey.PublicKey},
+		{method: jwt.SigningMethodES256, key: ecKeys["ES256"], public: &ecKeys["ES256"].PublicKey},
+		{method: jwt.SigningMethodES384, key: ecKeys["ES384"], public: &ecKeys["ES384"].PublicKey},
+		{method: jwt.SigningMethodES512, key: ecKeys["ES512"], public: &ecKeys["ES512"].PublicKey},
+		{method: jwt.SigningMethodEdDSA, key: edPrivate, public: edPublic},
+	}
+	for _, s := range signers {
+		if s.public == nil {
+			continue
+		}
+		// Keys are identified by their RFC 7638 thumbprint, so fixtures also work with -pin-key
+		thumbprint, err := verify.Thumbprint(s.public)
+		if err != nil {
+			return nil, err
+		}
+		s.kid = thumbprint
+	}
+	return signers, nil
+}
+
+// writeKeys writes the public keys as a JWKS and the HMAC secret as text.
+func writeKeys(root *os.Root, signers []*signer) error {
+	var set jwks.Set
+	seen := make(map[string]bool)
+	for _, s := range signers {
+		if s.public == nil {
+			if s.method == jwt.SigningMethodHS256 {
+				if err := root.WriteFile("hmac.key", s.key.([]byte), 0600); err != nil {
+					return fmt.Errorf("writing HMAC secret: %w", err)
+				}
+			}
+			continue
+		}
+		if seen[s.kid] {
+			continue // RS* and PS* share one RSA key
+		}
+		seen[s.kid] = true
+		alg := s.method.Alg()
+		if _, isRSA := s.public.(*rsa.PublicKey); isRSA {
+			alg = "" // The RSA key is used with several algorithms
+		}
+		key, err := jwks.FromPublicKey(s.public, s.kid, alg)
+		if err != nil {
+			return err
+		}
+		set.Keys = append(set.Keys, key)
+	}
+	data, err := json.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding JWKS: %w", err)
+	}
+	if err := root.WriteFile("jwks.json", data, 0600); err != nil {
+		return fmt.Errorf("writing JWKS: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// FormatJSONArray combines formatted JSON documents, one per token, into a single
+// pretty-printed JSON array. The documents are combined as-is, so key order and number
+// formatting of each (see FormatRawJSON and FormatOrderedJSON) are preserved.
+func FormatJSONArray(docs [][]byte) ([]byte, error) {
+	var compact bytes.Buffer
+	compact.WriteByte('[')
+	for i, doc := range docs {
+		if i > 0 {
+			compact.WriteByte(',')
+		}
+		if err := json.Compact(&compact, doc); err != nil {
+			return nil, fmt.Errorf("failed to combine JSON documents: %w", err)
+		}
+	}
+	compact.WriteByte(']')
+	var out bytes.Buffer
+	out.Grow(compact.Len() * 2)
+	if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to indent JSON array: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// FormatNDJSON combines formatted JSON documents, one per token, into newline-delimited
+// JSON: one compact document per line, each terminated by a newline.
+func FormatNDJSON(docs [][]byte) ([]byte, error) {
+	var out bytes.Buffer
+	for _, doc := range docs {
+		if err := json.Compact(&out, doc); err != nil {
+			return nil, fmt.Errorf("failed to compact JSON document: %w", err)
+		}
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// FormatXMLSet formats the claims of several tokens into a single XML document. The
+// root element is <JWTClaimsSet>, with one <Token index="N"> element per token
+// (1-based, in input order) containing the claims as in FormatXML. When sources are
+// given (one map per token, or nil), claims carry a "source" attribute. The watermark of
+// an unverified token becomes a "verification" attribute of its <Token> element.
+func FormatXMLSet(claimsList []jwt.MapClaims, sources []map[string]string) ([]byte, error) {
+	return formatXMLSet(claimsList, sources, XMLOptions{})
+}
+
+// formatXMLSet formats claims like FormatXMLSet, under the set root element of opts.
+func formatXMLSet(claimsList []jwt.MapClaims, sources []map[string]string, opts XMLOptions) ([]byte, error) {
+	root := XMLNode{
+		XMLName: xml.Name{Local: opts.setRoot()},
+		Nodes:   make([]XMLNode, len(claimsList)),
+	}
+	for i, claims := range claimsList {
+		var tokenSources map[string]string
+		if i < len(sources) {
+			tokenSources = sources[i]
+		}
+		claims, attrs := verificationAttrs(claims)
+		root.Nodes[i] = XMLNode{
+			XMLName: xml.Name{Local: "Token"},
+			Attrs:   append([]xml.Attr{{Name: xml.Name{Local: "index"}, Value: strconv.Itoa(i + 1)}}, attrs...),
+			Nodes:   claimNodes(claims, tokenSources),
+		}
+	}
+	return encodeXML(root)
+}
+
+// FormatXMLDocuments formats the claims of several tokens as a stream of complete XML
+// documents, one per token, each with its own header and <JWTClaims> root and separated
+// by a newline. This suits consumers that split the stream on the XML declaration.
+func FormatXMLDocuments(claimsList []jwt.MapClaims, sources []map[string]string) ([]byte, error) {
+	return formatXMLDocuments(claimsList, sources, XMLOptions{})
+}
+
+// formatXMLDocuments formats claims like FormatXMLDocuments, under the root element of opts.
+func formatXMLDocuments(claimsList []jwt.MapClaims, sources []map[string]string, opts XMLOptions) ([]byte, error) {
+	var out bytes.Buffer
+	for i, claims := range claimsList {
+		var tokenSources map[string]string
+		if i < len(sources) {
+			tokenSources = sources[i]
+		}
+		doc, err := formatXMLDocument(claims, tokenSources, opts)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			out.WriteByte('\n')
+		}
+		out.Write(doc)
+	}
+	return out.Bytes(), nil
+}
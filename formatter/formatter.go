@@ -6,8 +6,11 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"path"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -20,28 +23,213 @@ const (
 	ClaimAuthTime = "auth_time"
 )
 
+// epochClaims are the claims converted by PreprocessClaims.
+var epochClaims = []string{ClaimIAT, ClaimEXP, ClaimNBF, ClaimAuthTime}
+
+// DefaultDateLayout is the layout of the datestamps added by PreprocessClaims.
+const DefaultDateLayout = "2006-01-02 15:04:05 UTC"
+
+// DefaultZonedDateLayout is DefaultDateLayout in a time zone other than UTC, naming the zone
+// in place of UTC.
+const DefaultZonedDateLayout = "2006-01-02 15:04:05 MST"
+
+// namedLayouts are the layouts of the time package accepted by name by ResolveLayout.
+var namedLayouts = map[string]string{
+	"ansic":       time.ANSIC,
+	"unixdate":    time.UnixDate,
+	"rubydate":    time.RubyDate,
+	"rfc822":      time.RFC822,
+	"rfc822z":     time.RFC822Z,
+	"rfc850":      time.RFC850,
+	"rfc1123":     time.RFC1123,
+	"rfc1123z":    time.RFC1123Z,
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"kitchen":     time.Kitchen,
+	"datetime":    time.DateTime,
+	"dateonly":    time.DateOnly,
+	"timeonly":    time.TimeOnly,
+}
+
+// ResolveLayout returns the time.Format layout of a datestamp format: the name of a layout
+// of the time package (e.g., RFC3339 or DateTime, in any case), or a layout itself.
+func ResolveLayout(format string) string {
+	if layout, ok := namedLayouts[strings.ToLower(format)]; ok {
+		return layout
+	}
+	return format
+}
+
+// LoadLocation returns the time zone of datestamps: an IANA name (e.g., America/New_York),
+// "local" for the zone of the system, or UTC when name is empty.
+func LoadLocation(name string) (*time.Location, error) {
+	switch {
+	case name == "":
+		return time.UTC, nil
+	case strings.EqualFold(name, "local"):
+		return time.Local, nil
+	case strings.EqualFold(name, "utc"):
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q; use an IANA name (e.g., America/New_York), local, or UTC", name)
+	}
+	return loc, nil
+}
+
+// Pools of output buffers and writers, reused across calls so that formatting many
+// tokens (batch scans, benchmarks) does not allocate a new buffer and writer per token.
+var (
+	jsonPool = sync.Pool{New: func() interface{} {
+		e := &pooledEncoder{}
+		enc := json.NewEncoder(&e.buf)
+		enc.SetIndent("", "  ")
+		e.json = enc
+		return e
+	}}
+	csvPool = sync.Pool{New: func() interface{} {
+		e := &pooledEncoder{}
+		e.csv = csv.NewWriter(&e.buf)
+		return e
+	}}
+	xmlPool = sync.Pool{New: func() interface{} {
+		e := &pooledEncoder{}
+		e.xml = xml.NewEncoder(&e.buf)
+		e.xml.Indent("", "  ")
+		return e
+	}}
+)
+
+// pooledEncoder is an output buffer with a writer bound to it.
+type pooledEncoder struct {
+	buf  bytes.Buffer
+	json *json.Encoder
+	csv  *csv.Writer
+	xml  *xml.Encoder
+}
+
+// bytes returns a copy of the buffered output, so the buffer can be returned to its pool.
+func (e *pooledEncoder) bytes() []byte {
+	return append([]byte(nil), e.buf.Bytes()...)
+}
+
 // PreprocessClaims iterates through the claims and, if enabled, adds human-readable
 // datestamps for any epoch values it finds. This should be called once after parsing.
 // It specifically targets standard JWT epoch claims like 'iat', 'exp', 'nbf', and 'auth_time'.
+// The claims are only copied when a datestamp is actually added.
 func PreprocessClaims(claims jwt.MapClaims, convertEpoch bool, epochUnit string) jwt.MapClaims {
+	return PreprocessClaimsWithLayout(claims, convertEpoch, epochUnit, DefaultDateLayout)
+}
+
+// PreprocessClaimsWithLayout is PreprocessClaims with the datestamps formatted in a
+// time.Format layout (in UTC) instead of DefaultDateLayout.
+func PreprocessClaimsWithLayout(claims jwt.MapClaims, convertEpoch bool, epochUnit, layout string) jwt.MapClaims {
 	if !convertEpoch {
 		return claims
 	}
+	return AddDatestamps(claims, nil, epochUnit, layout)
+}
 
-	processedClaims := make(jwt.MapClaims, len(claims))
-	for key, value := range claims {
-		processedClaims[key] = value
+// AddDatestamps adds the datestamps of PreprocessClaims in layout, for the standard epoch
+// claims and the top-level claims matching patterns: names or path.Match glob patterns
+// (e.g., "*_at") of custom timestamp claims. Claims whose value is not a number are left
+// without a datestamp.
+func AddDatestamps(claims jwt.MapClaims, patterns []string, epochUnit, layout string) jwt.MapClaims {
+	return AddDatestampsIn(claims, patterns, epochUnit, layout, time.UTC)
+}
+
+// AddDatestampsIn is AddDatestamps with the datestamps in the time zone loc instead of UTC.
+// DefaultDateLayout becomes DefaultZonedDateLayout in another zone.
+func AddDatestampsIn(claims jwt.MapClaims, patterns []string, epochUnit, layout string, loc *time.Location) jwt.MapClaims {
+	var processedClaims jwt.MapClaims
+	for _, key := range EpochClaimNames(claims, patterns) {
 		// Check and add datestamp if applicable (e.g., "iat_datestamp")
-		if datestamp, ok := convertEpochToHumanReadable(key, value, epochUnit); ok {
-			processedClaims[key+"_datestamp"] = datestamp
+		datestamp, ok := epochDate(claims[key], epochUnit, layout, loc)
+		if !ok {
+			continue
 		}
+		if processedClaims == nil {
+			processedClaims = make(jwt.MapClaims, len(claims)+len(epochClaims))
+			for k, v := range claims {
+				processedClaims[k] = v
+			}
+		}
+		processedClaims[key+"_datestamp"] = datestamp
+	}
+	if processedClaims == nil {
+		return claims
 	}
 	return processedClaims
 }
 
+// EpochClaimNames returns the names of the epoch claims of claims: the standard ones
+// present, and the claims matching patterns (see AddDatestamps), in sorted order.
+func EpochClaimNames(claims jwt.MapClaims, patterns []string) []string {
+	var names []string
+	for key := range claims {
+		if slices.Contains(epochClaims, key) || matchesAny(patterns, key) {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidEpochPatterns checks the syntax of the glob patterns of custom epoch claims.
+func ValidEpochPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid epoch claim pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchesAny reports whether a claim name matches one of the glob patterns.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// StripClaimPrefixes collapses namespaced claims (e.g., "https://myapp.example.com/roles")
+// to their short names by removing the first matching prefix. The original key is
+// recorded in a "<short>_original_key" annotation. A claim is left untouched if the
+// short name would be empty or would collide with an existing claim.
+func StripClaimPrefixes(claims jwt.MapClaims, prefixes []string) jwt.MapClaims {
+	if len(prefixes) == 0 {
+		return claims
+	}
+
+	stripped := make(jwt.MapClaims, len(claims))
+	for key, value := range claims {
+		stripped[key] = value
+	}
+	for key, value := range claims {
+		for _, prefix := range prefixes {
+			if prefix == "" || !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			short := strings.TrimPrefix(key, prefix)
+			if _, exists := stripped[short]; short == "" || exists {
+				break
+			}
+			delete(stripped, key)
+			stripped[short] = value
+			stripped[short+"_original_key"] = key
+			break
+		}
+	}
+	return stripped
+}
+
 // convertEpochToHumanReadable attempts to convert a numeric value to a human-readable
-// UTC date string if the key matches a known epoch claim.
-func convertEpochToHumanReadable(key string, value interface{}, epochUnit string) (string, bool) {
+// date string in layout and the time zone loc if the key matches a known epoch claim.
+func convertEpochToHumanReadable(key string, value interface{}, epochUnit, layout string, loc *time.Location) (string, bool) {
 	// 1. Filter: Only convert claims that are commonly known to be epoch timestamps
 	isEpochKey := false
 	switch key {
@@ -51,19 +239,15 @@ func convertEpochToHumanReadable(key string, value interface{}, epochUnit string
 	if !isEpochKey {
 		return "", false
 	}
+	return epochDate(value, epochUnit, layout, loc)
+}
 
+// epochDate converts a numeric epoch timestamp to a date string in layout and the time
+// zone loc (UTC if nil).
+func epochDate(value interface{}, epochUnit, layout string, loc *time.Location) (string, bool) {
 	// 2. Extraction: Extract numeric value from interface (handles float64 and json.Number)
-	var timestamp int64
-	switch v := value.(type) {
-	case float64:
-		timestamp = int64(v)
-	case json.Number:
-		i, err := v.Int64()
-		if err != nil {
-			return "", false
-		}
-		timestamp = i
-	default:
+	timestamp, ok := epochTimestamp(value)
+	if !ok {
 		return "", false
 	}
 
@@ -80,49 +264,173 @@ func convertEpochToHumanReadable(key string, value interface{}, epochUnit string
 		tm = time.Unix(0, timestamp)
 	default:
 		// Fallback to heuristic: if timestamp is very large, assume ms; else assume seconds.
-		if timestamp > 1e11 {
+		if heuristicEpochUnit(timestamp) == "ms" {
 			tm = time.Unix(0, timestamp*int64(time.Millisecond))
 		} else {
 			tm = time.Unix(timestamp, 0)
 		}
 	}
-	// Return UTC formatted string
-	return tm.UTC().Format("2006-01-02 15:04:05 UTC"), true
+	// Return the string formatted in the time zone
+	if loc == nil {
+		loc = time.UTC
+	}
+	if loc != time.UTC && layout == DefaultDateLayout {
+		layout = DefaultZonedDateLayout
+	}
+	return tm.In(loc).Format(layout), true
+}
+
+// epochTimestamp extracts an integer timestamp from a float64 or json.Number claim value.
+func epochTimestamp(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// heuristicEpochUnit guesses the unit of a timestamp: very large values are milliseconds.
+func heuristicEpochUnit(timestamp int64) string {
+	if timestamp > 1e11 {
+		return "ms"
+	}
+	return "s"
+}
+
+// GuessedEpochUnits describes the epoch claims whose unit PreprocessClaims guesses with
+// its heuristic, as "<claim> (<unit>)", when the epoch unit is not given.
+func GuessedEpochUnits(claims jwt.MapClaims, epochUnit string) []string {
+	return GuessedEpochUnitsMatching(claims, nil, epochUnit)
+}
+
+// GuessedEpochUnitsMatching is GuessedEpochUnits for the standard epoch claims and the
+// claims matching patterns (see AddDatestamps).
+func GuessedEpochUnitsMatching(claims jwt.MapClaims, patterns []string, epochUnit string) []string {
+	switch strings.ToLower(epochUnit) {
+	case "s", "seconds", "ms", "milliseconds", "us", "microseconds", "ns", "nanoseconds":
+		return nil
+	}
+	var guessed []string
+	for _, key := range EpochClaimNames(claims, patterns) {
+		if timestamp, ok := epochTimestamp(claims[key]); ok {
+			guessed = append(guessed, fmt.Sprintf("%s (%s)", key, heuristicEpochUnit(timestamp)))
+		}
+	}
+	return guessed
 }
 
 // FormatJSON formats claims into a pretty-printed JSON byte slice.
 func FormatJSON(claims jwt.MapClaims) ([]byte, error) {
-	return json.MarshalIndent(claims, "", "  ")
+	e := jsonPool.Get().(*pooledEncoder)
+	e.buf.Reset()
+	if err := e.json.Encode(claims); err != nil {
+		return nil, err
+	}
+	// Encode terminates the document with a newline, which MarshalIndent does not
+	e.buf.Truncate(e.buf.Len() - 1)
+	out := e.bytes()
+	jsonPool.Put(e)
+	return out, nil
+}
+
+// FormatRawJSON pretty-prints a decoded JWT payload directly, without unmarshaling it
+// into claims. Key order, number formatting, and escaping are preserved exactly as the
+// issuer encoded them. It is the fast path for JSON output when claims are not modified.
+func FormatRawJSON(payload []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, fmt.Errorf("payload is not a JSON object")
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(trimmed) * 2)
+	if err := json.Indent(&buf, trimmed, "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to indent JSON payload: %w", err)
+	}
+	return buf.Bytes(), nil
 }
 
 // FormatCSV formats claims into a CSV byte slice.
 // It flattens nested structures (maps/slices) into JSON strings for CSV compatibility.
 func FormatCSV(claims jwt.MapClaims) ([]byte, error) {
+	return FormatCSVWithSources(claims, nil)
+}
+
+// FormatCSVWithSources formats claims into a CSV byte slice, recording the source of each
+// claim (see the provenance package) in an additional "<claim>_source" column.
+func FormatCSVWithSources(claims jwt.MapClaims, sources map[string]string) ([]byte, error) {
+	return FormatCSVRows([]jwt.MapClaims{claims}, []map[string]string{sources})
+}
+
+// FormatCSVRows formats the claims of several tokens into a CSV byte slice with one row
+// per token. The header is the sorted union of all claims; missing claims are empty.
+// When sources are given (one map per token, or nil), each claim gets a "<claim>_source" column.
+func FormatCSVRows(claimsList []jwt.MapClaims, sources []map[string]string) ([]byte, error) {
+	return FormatCSVRowsWithMissing(claimsList, sources, "")
+}
+
+// FormatCSVRowsWithMissing formats claims like FormatCSVRows, writing missingValue in the
+// cells of claims a token does not have, so that they can be told apart from empty-string
+// claims.
+func FormatCSVRowsWithMissing(claimsList []jwt.MapClaims, sources []map[string]string, missingValue string) ([]byte, error) {
+	return formatCSVRows(claimsList, sources, CSVOptions{MissingValue: missingValue})
+}
+
+// formatCSVRows formats claims like FormatCSVRows, with the delimiter and missing value of opts.
+func formatCSVRows(claimsList []jwt.MapClaims, sources []map[string]string, opts CSVOptions) ([]byte, error) {
 	// 1. Flatten nested maps and slices
-	flattened := flattenClaimsForCSV(claims)
+	rows := make([]map[string]interface{}, len(claimsList))
+	columns := make(map[string]bool)
+	for i, claims := range claimsList {
+		flattened := flattenClaimsForCSV(claims)
+		if i < len(sources) {
+			for key, source := range sources[i] {
+				_, claimed := flattened[key]
+				if _, exists := flattened[key+"_source"]; claimed && !exists {
+					flattened[key+"_source"] = source
+				}
+			}
+		}
+		for key := range flattened {
+			columns[key] = true
+		}
+		rows[i] = flattened
+	}
 
 	// 2. Prepare sorted headers for deterministic output
-	var headers []string
-	for key := range flattened {
+	headers := make([]string, 0, len(columns))
+	for key := range columns {
 		headers = append(headers, key)
 	}
 	sort.Strings(headers)
 
-	buf := new(bytes.Buffer)
-	writer := csv.NewWriter(buf)
+	// A writer that failed is not returned to the pool, as its error is sticky
+	e := csvPool.Get().(*pooledEncoder)
+	e.buf.Reset()
+	writer := e.csv
+	writer.Comma = opts.delimiter()
 
 	// 3. Write header row
 	if err := writer.Write(headers); err != nil {
 		return nil, fmt.Errorf("failed to write CSV header: %w", err)
 	}
 
-	// 4. Write data row with CSV injection protection
-	var row []string
-	for _, header := range headers {
-		row = append(row, escapeCSVValue(fmt.Sprintf("%v", flattened[header])))
-	}
-	if err := writer.Write(row); err != nil {
-		return nil, fmt.Errorf("failed to write CSV row: %w", err)
+	// 4. Write data rows with CSV injection protection
+	row := make([]string, len(headers))
+	missingValue := EscapeCSVValue(opts.MissingValue)
+	for _, flattened := range rows {
+		for i, header := range headers {
+			row[i] = missingValue
+			if value, ok := flattened[header]; ok {
+				row[i] = EscapeCSVValue(valueString(value))
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return nil, fmt.Errorf("failed to write CSV row: %w", err)
+		}
 	}
 
 	writer.Flush()
@@ -130,12 +438,22 @@ func FormatCSV(claims jwt.MapClaims) ([]byte, error) {
 		return nil, fmt.Errorf("CSV writer error: %w", err)
 	}
 
-	return buf.Bytes(), nil
+	out := e.bytes()
+	csvPool.Put(e)
+	return out, nil
+}
+
+// valueString renders a scalar claim value as text, avoiding fmt for the common string case.
+func valueString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", value)
 }
 
 // flattenClaimsForCSV recursively flattens claims for CSV output.
 func flattenClaimsForCSV(claims jwt.MapClaims) map[string]interface{} {
-	flattened := make(map[string]interface{})
+	flattened := make(map[string]interface{}, len(claims))
 	for key, value := range claims {
 		switch v := value.(type) {
 		case map[string]interface{}:
@@ -151,8 +469,8 @@ func flattenClaimsForCSV(claims jwt.MapClaims) map[string]interface{} {
 	return flattened
 }
 
-// escapeCSVValue prepends a single quote to values that could cause CSV injection.
-func escapeCSVValue(value string) string {
+// EscapeCSVValue prepends a single quote to values that could cause CSV injection.
+func EscapeCSVValue(value string) string {
 	if len(value) > 0 && (value[0] == '=' || value[0] == '+' || value[0] == '-' || value[0] == '@') {
 		return "'" + value
 	}
@@ -161,27 +479,69 @@ func escapeCSVValue(value string) string {
 
 // FormatXML formats claims into an XML string.
 func FormatXML(claims jwt.MapClaims) ([]byte, error) {
-	root := XMLNode{
-		XMLName: xml.Name{Local: "JWTClaims"},
-		Nodes:   mapClaimsToXMLNodes(claims),
+	return FormatXMLWithSources(claims, nil)
+}
+
+// FormatXMLWithSources formats claims into an XML string, recording the source of each
+// top-level claim (see the provenance package) in a "source" attribute. The watermark of
+// an unverified token becomes a "verification" attribute of the root element.
+func FormatXMLWithSources(claims jwt.MapClaims, sources map[string]string) ([]byte, error) {
+	return formatXMLDocument(claims, sources, XMLOptions{})
+}
+
+// formatXMLDocument formats claims like FormatXMLWithSources, under the root element of opts.
+func formatXMLDocument(claims jwt.MapClaims, sources map[string]string, opts XMLOptions) ([]byte, error) {
+	claims, attrs := verificationAttrs(claims)
+	return encodeXML(XMLNode{
+		XMLName: xml.Name{Local: opts.root()},
+		Attrs:   attrs,
+		Nodes:   claimNodes(claims, sources),
+	})
+}
+
+// claimNodes converts claims to XML nodes, adding a "source" attribute to each
+// top-level claim that has a recorded source.
+func claimNodes(claims jwt.MapClaims, sources map[string]string) []XMLNode {
+	nodes := mapClaimsToXMLNodes(claims)
+	if sources != nil {
+		for i := range nodes {
+			if source, ok := sources[nodes[i].XMLName.Local]; ok {
+				nodes[i].Attrs = []xml.Attr{{Name: xml.Name{Local: "source"}, Value: source}}
+			}
+		}
 	}
-	output, err := xml.MarshalIndent(root, "", "  ")
-	if err != nil {
+	return nodes
+}
+
+// encodeXML encodes root as an indented XML document with a header.
+func encodeXML(root XMLNode) ([]byte, error) {
+	e := xmlPool.Get().(*pooledEncoder)
+	e.buf.Reset()
+	e.buf.WriteString(xml.Header)
+	if err := e.xml.Encode(root); err != nil {
 		return nil, fmt.Errorf("failed to marshal XML: %w", err)
 	}
-	return append([]byte(xml.Header), output...), nil
+	// A reused encoder separates documents with a newline, which belongs to the previous one
+	out := e.buf.Bytes()
+	if len(out) > len(xml.Header) && out[len(xml.Header)] == '\n' {
+		out = append(out[:len(xml.Header)], out[len(xml.Header)+1:]...)
+	}
+	out = append([]byte(nil), out...)
+	xmlPool.Put(e)
+	return out, nil
 }
 
 // XMLNode represents a generic XML element.
 type XMLNode struct {
 	XMLName xml.Name
-	Content string    `xml:",chardata"`
-	Nodes   []XMLNode `xml:",any"`
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []XMLNode  `xml:",any"`
 }
 
 // mapClaimsToXMLNodes converts map claims to a slice of XMLNode.
 func mapClaimsToXMLNodes(claims jwt.MapClaims) []XMLNode {
-	var nodes []XMLNode
+	nodes := make([]XMLNode, 0, len(claims))
 	// Sort keys for consistent XML output
 	keys := make([]string, 0, len(claims))
 	for k := range claims {
@@ -190,28 +550,30 @@ func mapClaimsToXMLNodes(claims jwt.MapClaims) []XMLNode {
 	sort.Strings(keys)
 
 	for _, key := range keys {
-		value := claims[key]
-		switch v := value.(type) {
-		case map[string]interface{}:
-			nodes = append(nodes, XMLNode{
-				XMLName: xml.Name{Local: key},
-				Nodes:   mapClaimsToXMLNodes(v),
-			})
-		case []interface{}:
-			arrayNode := XMLNode{XMLName: xml.Name{Local: key}}
-			for i, item := range v {
-				arrayNode.Nodes = append(arrayNode.Nodes, XMLNode{
-					XMLName: xml.Name{Local: fmt.Sprintf("item_%d", i+1)},
-					Content: fmt.Sprintf("%v", item),
-				})
-			}
-			nodes = append(nodes, arrayNode)
-		default:
-			nodes = append(nodes, XMLNode{
-				XMLName: xml.Name{Local: key},
-				Content: fmt.Sprintf("%v", value),
-			})
-		}
+		nodes = append(nodes, valueToXMLNode(key, claims[key]))
 	}
 	return nodes
 }
+
+// valueToXMLNode converts a single claim value to an XMLNode, recursing into
+// nested objects and arrays (including arrays of objects).
+func valueToXMLNode(name string, value interface{}) XMLNode {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return XMLNode{
+			XMLName: xml.Name{Local: name},
+			Nodes:   mapClaimsToXMLNodes(v),
+		}
+	case []interface{}:
+		arrayNode := XMLNode{XMLName: xml.Name{Local: name}, Nodes: make([]XMLNode, 0, len(v))}
+		for i, item := range v {
+			arrayNode.Nodes = append(arrayNode.Nodes, valueToXMLNode(fmt.Sprintf("item_%d", i+1), item))
+		}
+		return arrayNode
+	default:
+		return XMLNode{
+			XMLName: xml.Name{Local: name},
+			Content: valueString(value),
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keyOrder records the member order of a JSON object, and of the objects nested in it,
+// as it appeared in the original document.
+type keyOrder struct {
+	keys   []string             // Object members in document order
+	fields map[string]*keyOrder // Orders of nested objects and arrays, by member name
+	items  []*keyOrder          // Orders of nested objects and arrays, by array index
+}
+
+// FormatOrderedJSON formats claims into pretty-printed JSON, keeping the keys that appear
+// in the original payload in their original order, at every nesting level. Keys added
+// after decoding (annotations) follow the original keys in sorted order.
+func FormatOrderedJSON(claims jwt.MapClaims, payload []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	order, err := decodeKeyOrder(dec)
+	if err != nil {
+		return nil, fmt.Errorf("reading payload key order: %w", err)
+	}
+	var compact bytes.Buffer
+	if err := writeOrdered(&compact, map[string]interface{}(claims), order); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	out.Grow(compact.Len() * 2)
+	if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to indent JSON: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// decodeKeyOrder reads one JSON value from dec and returns the key order of the objects
+// it contains, or nil for scalars.
+func decodeKeyOrder(dec *json.Decoder) (*keyOrder, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil, nil
+	}
+	order := &keyOrder{}
+	switch delim {
+	case '{':
+		order.fields = make(map[string]*keyOrder)
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := tok.(string)
+			nested, err := decodeKeyOrder(dec)
+			if err != nil {
+				return nil, err
+			}
+			if _, seen := order.fields[key]; !seen {
+				order.keys = append(order.keys, key)
+			}
+			order.fields[key] = nested
+		}
+	case '[':
+		for dec.More() {
+			nested, err := decodeKeyOrder(dec)
+			if err != nil {
+				return nil, err
+			}
+			order.items = append(order.items, nested)
+		}
+	}
+	// Consume the closing delimiter
+	if _, err := dec.Token(); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return order, nil
+}
+
+// writeOrdered writes value as compact JSON, ordering object members by order.
+func writeOrdered(buf *bytes.Buffer, value interface{}, order *keyOrder) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := orderedKeys(v, order)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			name, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			buf.Write(name)
+			buf.WriteByte(':')
+			var nested *keyOrder
+			if order != nil {
+				nested = order.fields[key]
+			}
+			if err := writeOrdered(buf, v[key], nested); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			var nested *keyOrder
+			if order != nil && i < len(order.items) {
+				nested = order.items[i]
+			}
+			if err := writeOrdered(buf, item, nested); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// orderedKeys returns the keys of m: those recorded in order first, in document order,
+// then the remaining keys sorted.
+func orderedKeys(m map[string]interface{}, order *keyOrder) []string {
+	keys := make([]string, 0, len(m))
+	known := make(map[string]bool, len(m))
+	if order != nil {
+		for _, key := range order.keys {
+			if _, ok := m[key]; ok && !known[key] {
+				keys = append(keys, key)
+				known[key] = true
+			}
+		}
+	}
+	var added []string
+	for key := range m {
+		if !known[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	return append(keys, added...)
+}
@@ -0,0 +1,194 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Default root element names of XML output.
+const (
+	DefaultXMLRoot    = "JWTClaims"
+	DefaultXMLSetRoot = "JWTClaimsSet"
+)
+
+// Options are the format-specific settings passed to every formatter, each reading those
+// of its own format. The zero value gives the default output of each format.
+type Options struct {
+	JSON  JSONOptions
+	CSV   CSVOptions
+	XML   XMLOptions
+	Tree  TreeOptions
+	Table TableOptions
+}
+
+// JSONOptions are the settings of JSON output.
+type JSONOptions struct {
+	NDJSON    bool // Combine the documents of a batch into newline-delimited JSON instead of an array
+	ASCIIOnly bool // Escape non-ASCII characters as \uXXXX
+}
+
+// CSVOptions are the settings of CSV output.
+type CSVOptions struct {
+	Delimiter    rune   // Field delimiter; a comma when zero
+	MissingValue string // Cell written for claims a token does not have; empty by default
+}
+
+// delimiter returns the field delimiter of CSV output.
+func (o CSVOptions) delimiter() rune {
+	if o.Delimiter == 0 {
+		return ','
+	}
+	return o.Delimiter
+}
+
+// XMLOptions are the settings of XML output.
+type XMLOptions struct {
+	Root     string // Element holding the claims of a token; DefaultXMLRoot when empty
+	SetRoot  string // Root element of a batch; DefaultXMLSetRoot when empty
+	Multidoc bool   // One XML document per token of a batch instead of a set
+}
+
+// root returns the element holding the claims of a token.
+func (o XMLOptions) root() string {
+	if o.Root == "" {
+		return DefaultXMLRoot
+	}
+	return o.Root
+}
+
+// setRoot returns the root element of a batch.
+func (o XMLOptions) setRoot() string {
+	if o.SetRoot == "" {
+		return DefaultXMLSetRoot
+	}
+	return o.SetRoot
+}
+
+// TreeOptions are the settings of TREE output.
+type TreeOptions struct {
+	Style string // Branches, one of TreeStyles; TreeASCII when empty
+}
+
+// TableOptions are the settings of TABLE output.
+type TableOptions struct {
+	Layout   string         // time.Format layout of the Date column; DefaultDateLayout when empty
+	Location *time.Location // Time zone of the Date column; UTC when nil
+}
+
+// Batch holds the claims of the tokens to format, in input order.
+type Batch struct {
+	Claims    []jwt.MapClaims
+	Sources   []map[string]string // Source of each claim of each token (see the provenance package); nil for none
+	Documents [][]byte            // JSON documents of the tokens when already formatted (see FormatRawJSON); formatted from Claims otherwise
+	Labels    []string            // Label of each token in TREE and TABLE output
+	Multiple  bool                // The tokens of a batch, formatted as such even if there is only one
+}
+
+// Formatter formats a batch of claims in one output format.
+type Formatter func(b Batch, opts Options) ([]byte, error)
+
+// registry maps output format names to their formatters.
+var registry = map[string]Formatter{}
+
+// register adds a formatter to the registry.
+func register(name string, f Formatter) {
+	registry[name] = f
+}
+
+func init() {
+	register("JSON", formatJSONBatch)
+	register("CSV", formatCSVBatch)
+	register("XML", formatXMLBatch)
+	register("TREE", formatTreeBatch)
+	register("TABLE", formatTableBatch)
+}
+
+// Get returns the formatter registered for an output format (JSON, CSV, XML, TREE, or
+// TABLE).
+func Get(name string) (Formatter, error) {
+	f, ok := registry[strings.ToUpper(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q; must be one of: %s", name, strings.Join(Names(), ", "))
+	}
+	return f, nil
+}
+
+// Names returns the registered output formats in sorted order.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatJSONBatch formats a single token as a JSON document, and a batch as a JSON array
+// or newline-delimited JSON.
+func formatJSONBatch(b Batch, opts Options) ([]byte, error) {
+	docs := b.Documents
+	if docs == nil {
+		docs = make([][]byte, len(b.Claims))
+		for i, claims := range b.Claims {
+			doc, err := FormatJSON(claims)
+			if err != nil {
+				return nil, err
+			}
+			docs[i] = doc
+		}
+	}
+	var out []byte
+	var err error
+	switch {
+	case !b.Multiple:
+		out = docs[0]
+	case opts.JSON.NDJSON:
+		out, err = FormatNDJSON(docs)
+	default:
+		out, err = FormatJSONArray(docs)
+	}
+	if err != nil || !opts.JSON.ASCIIOnly {
+		return out, err
+	}
+	return EscapeNonASCII(out), nil
+}
+
+// formatCSVBatch formats one CSV row per token.
+func formatCSVBatch(b Batch, opts Options) ([]byte, error) {
+	return formatCSVRows(b.Claims, b.Sources, opts.CSV)
+}
+
+// formatXMLBatch formats a single token as an XML document, and a batch as a set or, with
+// Multidoc, as a stream of documents.
+func formatXMLBatch(b Batch, opts Options) ([]byte, error) {
+	switch {
+	case !b.Multiple:
+		var sources map[string]string
+		if len(b.Sources) > 0 {
+			sources = b.Sources[0]
+		}
+		return formatXMLDocument(b.Claims[0], sources, opts.XML)
+	case opts.XML.Multidoc:
+		return formatXMLDocuments(b.Claims, b.Sources, opts.XML)
+	default:
+		return formatXMLSet(b.Claims, b.Sources, opts.XML)
+	}
+}
+
+// formatTreeBatch formats one tree per token.
+func formatTreeBatch(b Batch, opts Options) ([]byte, error) {
+	style := opts.Tree.Style
+	if style == "" {
+		style = TreeASCII
+	}
+	return FormatTree(b.Claims, b.Labels, b.Sources, style)
+}
+
+// formatTableBatch formats one table per token.
+func formatTableBatch(b Batch, opts Options) ([]byte, error) {
+	return FormatTable(b.Claims, b.Labels, b.Sources, b.Multiple, opts.Table)
+}
@@ -0,0 +1,159 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/text/width"
+)
+
+// tableColumnGap separates the columns of TABLE output.
+const tableColumnGap = "  "
+
+// FormatTable renders claims as an aligned table for reading in a terminal: one row per
+// top-level claim, sorted by name, with its value and, for the epoch claims (iat, exp, nbf,
+// and auth_time), the date it stands for, in the layout and time zone of opts. Objects and
+// arrays are shown as compact JSON. Values holding non-printable characters are quoted with
+// escapes, so that no control sequence reaches the terminal. A Source column is added when
+// sources are given (see the provenance package). Each claims set of a batch is a table
+// under its label; tables are separated by blank lines.
+func FormatTable(claimsList []jwt.MapClaims, labels []string, sources []map[string]string, multiple bool, opts TableOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, claims := range claimsList {
+		root, err := normalizeClaims(claims)
+		if err != nil {
+			return nil, err
+		}
+		var claimSources map[string]string
+		if sources != nil {
+			claimSources = sources[i]
+		}
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		if multiple {
+			buf.WriteString(treeLabel(labels[i]) + "\n")
+		}
+		writeTable(&buf, tableRows(root, claimSources, opts))
+	}
+	return buf.Bytes(), nil
+}
+
+// tableRows returns the header and rows of the table of a claims set, leaving out the Date
+// and Source columns when no claim has one.
+func tableRows(claims map[string]interface{}, sources map[string]string, opts TableOptions) [][]string {
+	layout := opts.Layout
+	if layout == "" {
+		layout = DefaultDateLayout
+	}
+	names := make([]string, 0, len(claims))
+	for name := range claims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rows := [][]string{{"CLAIM", "VALUE", "DATE", "SOURCE"}}
+	hasDate, hasSource := false, false
+	for _, name := range names {
+		value := claims[name]
+		date, _ := convertEpochToHumanReadable(name, value, "", layout, opts.Location)
+		source := sources[name]
+		hasDate = hasDate || date != ""
+		hasSource = hasSource || source != ""
+		rows = append(rows, []string{treeKey(name), tableValue(value), date, treeLabel(source)})
+	}
+	for i, row := range rows {
+		switch {
+		case !hasDate && !hasSource:
+			rows[i] = row[:2]
+		case !hasSource:
+			rows[i] = row[:3]
+		case !hasDate:
+			rows[i] = []string{row[0], row[1], row[3]}
+		}
+	}
+	return rows
+}
+
+// tableValue renders a claim value in a table cell: strings as is, other scalars as in
+// JSON, and objects and arrays as compact JSON, quoting those holding non-printable
+// characters.
+func tableValue(value interface{}) string {
+	var text string
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return `""`
+		}
+		text = v
+	case json.Number:
+		text = v.String()
+	case bool:
+		text = strconv.FormatBool(v)
+	case nil:
+		text = "null"
+	default:
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(v); err != nil {
+			return "(cannot be displayed)"
+		}
+		text = strings.TrimSuffix(buf.String(), "\n")
+	}
+	for _, r := range text {
+		if !unicode.IsPrint(r) {
+			return strconv.Quote(text)
+		}
+	}
+	return text
+}
+
+// writeTable writes rows with their columns aligned, under a rule beneath the header row.
+func writeTable(buf *bytes.Buffer, rows [][]string) {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+	writeRow := func(row []string) {
+		var line strings.Builder
+		for i, cell := range row {
+			line.WriteString(cell)
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-displayWidth(cell)) + tableColumnGap)
+			}
+		}
+		buf.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+	rule := make([]string, len(widths))
+	for i, width := range widths {
+		rule[i] = strings.Repeat("-", width)
+	}
+	writeRow(rows[0])
+	writeRow(rule)
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+}
+
+// displayWidth returns the number of terminal columns of s, in which East Asian wide and
+// fullwidth characters, such as CJK ideographs and most emoji, take two.
+func displayWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		switch width.LookupRune(r).Kind() {
+		case width.EastAsianWide, width.EastAsianFullwidth:
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n
+}
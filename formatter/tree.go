@@ -0,0 +1,162 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"unicode"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Styles of the branches drawn in TREE output.
+const (
+	TreeASCII   = "ascii"   // |-- and `-- branches, for any terminal or file
+	TreeUnicode = "unicode" // Box-drawing branches
+)
+
+// TreeStyles are the accepted TREE output styles.
+var TreeStyles = []string{TreeASCII, TreeUnicode}
+
+// treeBranches are the branch, last branch, continued indent, and blank indent of a style.
+var treeBranches = map[string][4]string{
+	TreeASCII:   {"|-- ", "`-- ", "|   ", "    "},
+	TreeUnicode: {"├── ", "└── ", "│   ", "    "},
+}
+
+// FormatTree renders claims as an indented tree with a type hint on every node, e.g.
+// `roles (array, 2)` or `sub: "alice" (string)`, which is easier to scan in a terminal than
+// indented JSON for deeply nested claims. Each claims set is a tree rooted at its label;
+// trees are separated by blank lines. Strings, and keys holding non-printable characters,
+// are quoted with escapes, so that no control sequence reaches the terminal. The source of
+// each top-level claim is appended when sources are given (see the provenance package).
+func FormatTree(claimsList []jwt.MapClaims, labels []string, sources []map[string]string, style string) ([]byte, error) {
+	branches, ok := treeBranches[style]
+	if !ok {
+		return nil, fmt.Errorf("unknown tree style %q", style)
+	}
+	var buf bytes.Buffer
+	for i, claims := range claimsList {
+		root, err := normalizeClaims(claims)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		var claimSources map[string]string
+		if sources != nil {
+			claimSources = sources[i]
+		}
+		fmt.Fprintf(&buf, "%s %s\n", treeLabel(labels[i]), treeHint(root))
+		writeTreeChildren(&buf, root, "", branches, claimSources)
+	}
+	return buf.Bytes(), nil
+}
+
+// normalizeClaims returns claims in their JSON form, so that annotations of any Go type
+// render alike and numbers keep their JSON formatting.
+func normalizeClaims(claims jwt.MapClaims) (map[string]interface{}, error) {
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		return nil, fmt.Errorf("formatting claims: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var root map[string]interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("formatting claims: %w", err)
+	}
+	return root, nil
+}
+
+// writeTreeChildren writes the members of an object or the items of an array under prefix.
+func writeTreeChildren(buf *bytes.Buffer, value interface{}, prefix string, branches [4]string, sources map[string]string) {
+	var keys, names []string
+	var children []interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			names = append(names, treeKey(key))
+			children = append(children, v[key])
+		}
+	case []interface{}:
+		for i, item := range v {
+			names = append(names, fmt.Sprintf("[%d]", i))
+			children = append(children, item)
+		}
+	default:
+		return
+	}
+	for i, child := range children {
+		branch, indent := branches[0], branches[2]
+		if i == len(children)-1 {
+			branch, indent = branches[1], branches[3]
+		}
+		line := names[i]
+		switch child.(type) {
+		case map[string]interface{}, []interface{}:
+			line += " " + treeHint(child)
+		default:
+			line += ": " + treeHint(child)
+		}
+		// Sources are only given for the top-level object
+		if sources != nil && sources[keys[i]] != "" {
+			line += " [" + sources[keys[i]] + "]"
+		}
+		buf.WriteString(prefix + branch + line + "\n")
+		writeTreeChildren(buf, child, prefix+indent, branches, nil)
+	}
+}
+
+// treeHint returns the rendering of a value with its type: the size of an object or
+// array, the value of a scalar.
+func treeHint(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return fmt.Sprintf("(object, %d)", len(v))
+	case []interface{}:
+		return fmt.Sprintf("(array, %d)", len(v))
+	case string:
+		return strconv.Quote(v) + " (string)"
+	case json.Number:
+		return v.String() + " (number)"
+	case bool:
+		return strconv.FormatBool(v) + " (boolean)"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// treeKey returns a key as written in the tree: as is, or quoted when it is empty or
+// holds spaces or non-printable characters.
+func treeKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for _, r := range key {
+		if !unicode.IsPrint(r) || unicode.IsSpace(r) {
+			return strconv.Quote(key)
+		}
+	}
+	return key
+}
+
+// treeLabel returns the label of a tree root (e.g., a token file name) as written in the
+// tree: as is, or quoted when it holds non-printable characters.
+func treeLabel(label string) string {
+	for _, r := range label {
+		if !unicode.IsPrint(r) {
+			return strconv.Quote(label)
+		}
+	}
+	return label
+}
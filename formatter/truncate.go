@@ -0,0 +1,74 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TruncateValues shortens the string values longer than limit characters, within nested
+// objects and arrays too, to their first limit characters followed by an ellipsis and
+// their full length, e.g. "MIIC… (4096 chars)". It returns the names of the claims that
+// were shortened, in order. The claims are only copied when a claim changes.
+func TruncateValues(claims jwt.MapClaims, limit int) (jwt.MapClaims, []string) {
+	var truncated jwt.MapClaims
+	var names []string
+	for key, value := range claims {
+		newValue, changed := truncateValue(value, limit)
+		if !changed {
+			continue
+		}
+		if truncated == nil {
+			truncated = make(jwt.MapClaims, len(claims))
+			for k, v := range claims {
+				truncated[k] = v
+			}
+		}
+		truncated[key] = newValue
+		names = append(names, key)
+	}
+	if truncated == nil {
+		return claims, nil
+	}
+	sort.Strings(names)
+	return truncated, names
+}
+
+// truncateValue shortens the long strings within a claim value, reporting whether any was found.
+func truncateValue(value interface{}, limit int) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if length <= limit {
+			return v, false
+		}
+		cut := 0
+		for i := 0; i < limit; i++ {
+			_, size := utf8.DecodeRuneInString(v[cut:])
+			cut += size
+		}
+		return fmt.Sprintf("%s… (%d chars)", v[:cut], length), true
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		changed := false
+		for key, item := range v {
+			newItem, c := truncateValue(item, limit)
+			changed = changed || c
+			out[key] = newItem
+		}
+		return out, changed
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		changed := false
+		for i, item := range v {
+			newItem, c := truncateValue(item, limit)
+			changed = changed || c
+			out[i] = newItem
+		}
+		return out, changed
+	default:
+		return value, false
+	}
+}
@@ -0,0 +1,296 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Renderings of binary claim values, selected with -binary-values.
+const (
+	BinaryBase64 = "base64" // "base64:" followed by the standard base64 encoding of the bytes
+	BinaryHex    = "hex"    // "hex:" followed by the lowercase hex encoding of the bytes
+	BinaryEscape = "escape" // Go escape sequences (e.g., \x00) for the non-printable characters and bytes
+)
+
+// BinaryModes lists the accepted -binary-values renderings.
+var BinaryModes = []string{BinaryBase64, BinaryHex, BinaryEscape}
+
+// rawByteBase is the first code point of the private-use range (U+10FF00-U+10FFFF) that
+// EscapeInvalidUTF8 maps the bytes of invalid UTF-8 sequences to, so that they survive
+// JSON decoding, which replaces them with U+FFFD.
+const rawByteBase = 0x10FF00
+
+// EscapeInvalidUTF8 replaces each byte of the invalid UTF-8 sequences in a JSON payload
+// with a private-use code point, which RenderBinaryValues turns back into the byte.
+// Valid payloads are returned unchanged.
+func EscapeInvalidUTF8(payload []byte) []byte {
+	if utf8.Valid(payload) {
+		return payload
+	}
+	out := make([]byte, 0, len(payload)+16)
+	for len(payload) > 0 {
+		r, size := utf8.DecodeRune(payload)
+		if r == utf8.RuneError && size == 1 {
+			out = utf8.AppendRune(out, rawByteBase+rune(payload[0]))
+		} else {
+			out = append(out, payload[:size]...)
+		}
+		payload = payload[size:]
+	}
+	return out
+}
+
+// RenderBinaryValues renders string values that are not printable text, at every nesting
+// level, in the given mode. A value is binary if it holds control characters other than
+// tab, newline, and carriage return, or (with escapedBytes, for claims decoded from a
+// payload passed through EscapeInvalidUTF8) bytes of invalid UTF-8 sequences. The claims
+// are only copied when a value is rendered.
+func RenderBinaryValues(claims jwt.MapClaims, mode string, escapedBytes bool) jwt.MapClaims {
+	if mode == "" {
+		return claims
+	}
+	var rendered jwt.MapClaims
+	for key, value := range claims {
+		newValue, changed := renderBinary(value, mode, escapedBytes)
+		if !changed {
+			continue
+		}
+		if rendered == nil {
+			rendered = make(jwt.MapClaims, len(claims))
+			for k, v := range claims {
+				rendered[k] = v
+			}
+		}
+		rendered[key] = newValue
+	}
+	if rendered == nil {
+		return claims
+	}
+	return rendered
+}
+
+// renderBinary renders the binary strings within a claim value, reporting whether any was found.
+func renderBinary(value interface{}, mode string, escapedBytes bool) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		raw, binary := binaryString(v, escapedBytes)
+		if !binary {
+			return v, false
+		}
+		switch mode {
+		case BinaryBase64:
+			return "base64:" + base64.StdEncoding.EncodeToString(raw), true
+		case BinaryHex:
+			return "hex:" + hex.EncodeToString(raw), true
+		default:
+			quoted := strconv.Quote(string(raw))
+			return quoted[1 : len(quoted)-1], true
+		}
+	case map[string]interface{}:
+		var out map[string]interface{}
+		for key, item := range v {
+			newItem, changed := renderBinary(item, mode, escapedBytes)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = make(map[string]interface{}, len(v))
+				for k, i := range v {
+					out[k] = i
+				}
+			}
+			out[key] = newItem
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	case []interface{}:
+		var out []interface{}
+		for i, item := range v {
+			newItem, changed := renderBinary(item, mode, escapedBytes)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = append([]interface{}(nil), v...)
+			}
+			out[i] = newItem
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	default:
+		return value, false
+	}
+}
+
+// binaryString returns the bytes of a string value, with escaped bytes restored, and
+// whether the value is binary.
+func binaryString(s string, escapedBytes bool) ([]byte, bool) {
+	binary := false
+	var raw []byte
+	for _, r := range s {
+		switch {
+		case escapedBytes && r >= rawByteBase && r <= rawByteBase+0xFF:
+			binary = true
+			raw = append(raw, byte(r-rawByteBase))
+			continue
+		case unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r':
+			binary = true
+		}
+		raw = utf8.AppendRune(raw, r)
+	}
+	return raw, binary
+}
+
+// StripControlCharacters removes control characters other than tab, newline, and carriage
+// return from string values at every nesting level, for CSV and XML output. The claims are
+// only copied when a value changes.
+func StripControlCharacters(claims jwt.MapClaims) jwt.MapClaims {
+	var stripped jwt.MapClaims
+	for key, value := range claims {
+		newValue, changed := stripControl(value)
+		if !changed {
+			continue
+		}
+		if stripped == nil {
+			stripped = make(jwt.MapClaims, len(claims))
+			for k, v := range claims {
+				stripped[k] = v
+			}
+		}
+		stripped[key] = newValue
+	}
+	if stripped == nil {
+		return claims
+	}
+	return stripped
+}
+
+// stripControl removes the control characters within a claim value, reporting whether any was found.
+func stripControl(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		out := strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+				return -1
+			}
+			return r
+		}, v)
+		return out, out != v
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		changed := false
+		for key, item := range v {
+			var c bool
+			out[key], c = stripControl(item)
+			changed = changed || c
+		}
+		return out, changed
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		changed := false
+		for i, item := range v {
+			var c bool
+			out[i], c = stripControl(item)
+			changed = changed || c
+		}
+		return out, changed
+	default:
+		return value, false
+	}
+}
+
+// EscapeNonASCII rewrites the non-ASCII characters of JSON output as \uXXXX escapes
+// (surrogate pairs beyond the Basic Multilingual Plane), for consumers that cannot read
+// UTF-8. Outside strings, JSON is ASCII, so the whole document can be rewritten.
+func EscapeNonASCII(data []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(data))
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		switch {
+		case r < utf8.RuneSelf:
+			out.WriteByte(data[0])
+		case r > 0xFFFF:
+			r -= 0x10000
+			fmt.Fprintf(&out, `\u%04x\u%04x`, 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+		default:
+			fmt.Fprintf(&out, `\u%04x`, r)
+		}
+		data = data[size:]
+	}
+	return out.Bytes()
+}
+
+// OmitNull removes null claims, and null members of nested objects, so that absent and
+// null claims are written alike. Null array items are kept, as removing them would shift
+// the positions of the items that follow. The claims are only copied when a claim changes.
+func OmitNull(claims jwt.MapClaims) jwt.MapClaims {
+	var omitted jwt.MapClaims
+	for key, value := range claims {
+		newValue, changed := omitNull(value)
+		if !changed {
+			continue
+		}
+		if omitted == nil {
+			omitted = make(jwt.MapClaims, len(claims))
+			for k, v := range claims {
+				omitted[k] = v
+			}
+		}
+		if newValue == nil {
+			delete(omitted, key)
+		} else {
+			omitted[key] = newValue
+		}
+	}
+	if omitted == nil {
+		return claims
+	}
+	return omitted
+}
+
+// omitNull removes the null members within a claim value, reporting whether any was found.
+// A null value is reported as changed to nil.
+func omitNull(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case nil:
+		return nil, true
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		changed := false
+		for key, item := range v {
+			newItem, c := omitNull(item)
+			changed = changed || c
+			if newItem != nil {
+				out[key] = newItem
+			}
+		}
+		return out, changed
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		changed := false
+		for i, item := range v {
+			if item == nil {
+				continue
+			}
+			var c bool
+			out[i], c = omitNull(item)
+			changed = changed || c
+		}
+		return out, changed
+	default:
+		return value, false
+	}
+}
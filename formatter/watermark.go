@@ -0,0 +1,63 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/xml"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Watermark stamped on the output of tokens whose signature was not verified, so that
+// their claims are not mistaken for authoritative ones.
+const (
+	ClaimVerification = "_verification" // JSON member and CSV column of the watermark
+	AttrVerification  = "verification"  // XML attribute of the watermark
+	NotVerified       = "NOT_VERIFIED"
+)
+
+// Watermark returns a copy of the claims with the _verification claim, for CSV and XML
+// output. XML output moves it to a verification attribute of the token element.
+func Watermark(claims jwt.MapClaims) jwt.MapClaims {
+	marked := make(jwt.MapClaims, len(claims)+1)
+	for k, v := range claims {
+		marked[k] = v
+	}
+	marked[ClaimVerification] = NotVerified
+	return marked
+}
+
+// WatermarkJSON inserts the _verification member at the top of a pretty-printed JSON
+// document, where it is seen first, whichever order the claims are in.
+func WatermarkJSON(doc []byte) []byte {
+	rest, ok := bytes.CutPrefix(doc, []byte("{"))
+	if !ok {
+		return doc
+	}
+	member := `"` + ClaimVerification + `": "` + NotVerified + `"`
+	var out bytes.Buffer
+	out.Grow(len(doc) + len(member) + 4)
+	out.WriteString("{\n  " + member)
+	if trimmed := bytes.TrimSpace(rest); string(trimmed) == "}" {
+		out.WriteString("\n}")
+		return out.Bytes()
+	}
+	out.WriteByte(',')
+	out.Write(rest)
+	return out.Bytes()
+}
+
+// verificationAttrs removes the _verification claim of watermarked claims, returning it
+// as the attribute of the element holding them.
+func verificationAttrs(claims jwt.MapClaims) (jwt.MapClaims, []xml.Attr) {
+	value, ok := claims[ClaimVerification].(string)
+	if !ok {
+		return claims, nil
+	}
+	unmarked := make(jwt.MapClaims, len(claims))
+	for k, v := range claims {
+		if k != ClaimVerification {
+			unmarked[k] = v
+		}
+	}
+	return unmarked, []xml.Attr{{Name: xml.Name{Local: AttrVerification}, Value: value}}
+}
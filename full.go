@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"jwtdecode/config"
+	"jwtdecode/formatter"
+	"jwtdecode/pkg/jwtdecode"
+)
+
+// timingClaims are the claims shown in the Timing section of the -full report, in order.
+var timingClaims = []string{"iat", "nbf", "auth_time", "exp"}
+
+// printFullReport prints a decoded token as labeled sections (header, payload, signature,
+// timing, and the warnings, findings, and failures found), the jwt.io view of a token in a
+// terminal. Control characters of claim values are removed so they cannot act on the terminal.
+func printFullReport(w io.Writer, appConfig *config.AppConfig, d *jwtdecode.Result, label string) {
+	if label != "" {
+		fmt.Fprintf(w, "\n######## %s ########\n", label)
+	}
+	fmt.Fprintln(w, "\n== Header ==")
+	writeReportJSON(w, formatter.StripControlCharacters(d.Header))
+	fmt.Fprintln(w, "\n== Payload ==")
+	writeReportJSON(w, formatter.StripControlCharacters(d.Claims))
+
+	fmt.Fprintln(w, "\n== Signature ==")
+	alg, _ := d.Header["alg"].(string)
+	fmt.Fprintf(w, "Algorithm: %s\n", alg)
+	if kid, ok := d.Header["kid"].(string); ok {
+		fmt.Fprintf(w, "Key ID:    %q\n", kid)
+	}
+	fmt.Fprintf(w, "Size:      %d bytes\n", d.SignatureSize)
+	switch {
+	case alg == "none":
+		fmt.Fprintln(w, "Status:    NOT SIGNED (alg none)")
+	case d.Verified:
+		fmt.Fprintln(w, "Status:    VERIFIED")
+	default:
+		fmt.Fprintln(w, "Status:    NOT VERIFIED (use -verify-key, -verify-hmac-secret, -jwks-url, -issuer-discovery, -trust, -provider, -resolve-did, -allow-embedded-jwk, or -jku-allowlist)")
+	}
+
+	now := appConfig.Now()
+	fmt.Fprintln(w, "\n== Timing ==")
+	for _, name := range timingClaims {
+		value, ok := d.Claims[name]
+		if !ok {
+			continue
+		}
+		// Epoch claims are strings once converted by -convert-epoch
+		seconds, isNumber := value.(float64)
+		if !isNumber {
+			fmt.Fprintf(w, "%-10s %v\n", name+":", value)
+			continue
+		}
+		at := time.Unix(int64(seconds), 0).In(appConfig.Location())
+		fmt.Fprintf(w, "%-10s %s (%s)\n", name+":", at.Format(time.RFC3339), relativeTime(at, now))
+	}
+	switch {
+	case !d.Expiry.IsZero() && !now.Before(d.Expiry):
+		fmt.Fprintln(w, "Status:    EXPIRED")
+	case d.NotBefore.After(now):
+		fmt.Fprintln(w, "Status:    NOT YET VALID")
+	case d.Expiry.IsZero():
+		fmt.Fprintln(w, "Status:    VALID (no expiry)")
+	default:
+		fmt.Fprintln(w, "Status:    VALID")
+	}
+
+	if len(d.Warnings) > 0 {
+		fmt.Fprintln(w, "\n== Warnings ==")
+		for _, warning := range d.Warnings {
+			fmt.Fprintf(w, "[%s] %s: %s\n", warning.Severity, warning.Code, warning.Message)
+		}
+	}
+	if len(d.Findings) > 0 {
+		fmt.Fprintln(w, "\n== Findings ==")
+		for _, finding := range d.Findings {
+			fmt.Fprintf(w, "[%s] %s: %s\n", finding.Severity, finding.ID, finding.Message)
+		}
+	}
+	if len(d.Failures) > 0 {
+		fmt.Fprintln(w, "\n== Failures ==")
+		for _, failure := range d.Failures {
+			fmt.Fprintln(w, failure)
+		}
+	}
+}
+
+// writeReportJSON writes a section of the -full report as indented JSON.
+func writeReportJSON(w io.Writer, value map[string]interface{}) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		fmt.Fprintf(w, "(cannot be displayed: %v)\n", err)
+		return
+	}
+	fmt.Fprintf(w, "%s\n", data)
+}
+
+// relativeTime describes t relative to now, e.g. "in 2h30m0s" or "3 days ago".
+func relativeTime(t, now time.Time) string {
+	d := t.Sub(now)
+	abs := time.Duration(math.Abs(float64(d))).Truncate(time.Second)
+	text := abs.String()
+	if abs >= 48*time.Hour {
+		text = fmt.Sprintf("%d days", int(abs/(24*time.Hour)))
+	}
+	switch {
+	case abs == 0:
+		return "now"
+	case d > 0:
+		return "in " + text
+	default:
+		return text + " ago"
+	}
+}
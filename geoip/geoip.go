@@ -0,0 +1,140 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// ClaimGeoIP is the output key holding the locations of the IP addresses found in claims.
+const ClaimGeoIP = "geoip"
+
+// DB looks up IP addresses in MaxMind databases (GeoIP2 or GeoLite2 Country, City, or
+// ASN). Several databases are combined, e.g. a Country and an ASN database.
+type DB struct {
+	readers []*maxminddb.Reader
+}
+
+// record is the subset of a MaxMind record that enrichment reads.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	ASN   uint   `maxminddb:"autonomous_system_number"`
+	ASOrg string `maxminddb:"autonomous_system_organization"`
+}
+
+// Location is what the databases know about an IP address.
+type Location struct {
+	IP      string
+	Country string // ISO 3166-1 country code
+	ASN     uint   // Autonomous system number
+	ASOrg   string // Autonomous system organization
+}
+
+// Open loads the databases at paths in memory, so that they are not held open.
+func Open(paths []string) (*DB, error) {
+	db := &DB{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading GeoIP database: %w", err)
+		}
+		reader, err := maxminddb.FromBytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("opening GeoIP database %q: %w", path, err)
+		}
+		db.readers = append(db.readers, reader)
+	}
+	return db, nil
+}
+
+// Lookup returns the location of addr, and false if no database knows it.
+func (db *DB) Lookup(addr netip.Addr) (Location, bool) {
+	loc := Location{IP: addr.String()}
+	found := false
+	for _, reader := range db.readers {
+		var rec record
+		if err := reader.Lookup(net.IP(addr.AsSlice()), &rec); err != nil {
+			continue
+		}
+		if loc.Country == "" && rec.Country.ISOCode != "" {
+			loc.Country = rec.Country.ISOCode
+			found = true
+		}
+		if loc.ASN == 0 && rec.ASN != 0 {
+			loc.ASN, loc.ASOrg = rec.ASN, rec.ASOrg
+			found = true
+		}
+	}
+	return loc, found
+}
+
+// Enrich looks up the IP addresses held by the top-level string claims: a single
+// address (e.g., ipaddr) or a comma-separated list (e.g., an X-Forwarded-For value).
+// It returns the locations by claim name, as generic values that every output formatter
+// can render; nil if no address was located. Private and loopback addresses are skipped.
+func (db *DB) Enrich(claims jwt.MapClaims) map[string]interface{} {
+	names := make([]string, 0, len(claims))
+	for name := range claims {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	enriched := map[string]interface{}{}
+	for _, name := range names {
+		value, ok := claims[name].(string)
+		if !ok {
+			continue
+		}
+		var locations []interface{}
+		for _, addr := range ParseIPs(value) {
+			if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsUnspecified() {
+				continue
+			}
+			if loc, ok := db.Lookup(addr); ok {
+				locations = append(locations, loc.toValue())
+			}
+		}
+		if len(locations) > 0 {
+			enriched[name] = locations
+		}
+	}
+	if len(enriched) == 0 {
+		return nil
+	}
+	return enriched
+}
+
+// ParseIPs returns the addresses of a value made of one IP address or a comma-separated
+// list of them; nil if any part of it is not an IP address.
+func ParseIPs(value string) []netip.Addr {
+	var addrs []netip.Addr
+	for _, part := range strings.Split(value, ",") {
+		addr, err := netip.ParseAddr(strings.TrimSpace(part))
+		if err != nil {
+			return nil
+		}
+		addrs = append(addrs, addr.Unmap())
+	}
+	return addrs
+}
+
+// toValue converts the location into a generic value, omitting what is unknown.
+func (loc Location) toValue() map[string]interface{} {
+	value := map[string]interface{}{"ip": loc.IP}
+	if loc.Country != "" {
+		value["country"] = loc.Country
+	}
+	if loc.ASN != 0 {
+		value["asn"] = loc.ASN
+		value["as_org"] = loc.ASOrg
+	}
+	return value
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// printClaim prints the raw value of a claim for shell substitution: strings as they are,
+// without quotes, and other values (numbers, booleans, objects, arrays, null) as compact
+// JSON, followed by a newline. A missing claim is an error, so scripts can tell it apart
+// from an empty one.
+func printClaim(w io.Writer, claims jwt.MapClaims, name string) error {
+	value, ok := claims[name]
+	if !ok {
+		return fmt.Errorf("getting claim %q: not found in the token", name)
+	}
+	text, ok := value.(string)
+	if !ok {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("formatting claim %q: %w", name, err)
+		}
+		text = string(data)
+	}
+	if _, err := fmt.Fprintln(w, text); err != nil {
+		return fmt.Errorf("printing claim %q: %w", name, err)
+	}
+	return nil
+}
+
+// hasClaim reports whether claims has the named claim. With nonEmpty, a claim that is
+// null, or an empty string, array, or object, counts as missing.
+func hasClaim(claims jwt.MapClaims, name string, nonEmpty bool) bool {
+	value, ok := claims[name]
+	if !ok || !nonEmpty {
+		return ok
+	}
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"jwtdecode/config"
+	"jwtdecode/pkg/jwtdecode"
+	"jwtdecode/secure"
+)
+
+// runEvent is the event of a decoding run on which the -exec command runs.
+// A batch run is invalid if any token is, and expired if any token is expired.
+func runEvent(appConfig *config.AppConfig, results []*jwtdecode.Result, runErr error) string {
+	if runErr != nil {
+		return "invalid"
+	}
+	now := appConfig.Now()
+	event := "success"
+	for _, d := range results {
+		if len(d.Failures) > 0 {
+			return "invalid"
+		}
+		if !d.Expiry.IsZero() && !d.Expiry.After(now) {
+			event = "expired"
+		}
+	}
+	return event
+}
+
+// runExecHook runs the -exec command if the event of the run is one of -exec-on.
+// The claim placeholders are only filled for a single token, and the error is the error
+// that stopped the run or the first deferred failure.
+func runExecHook(appConfig *config.AppConfig, results []*jwtdecode.Result, runErr error) error {
+	if appConfig.Exec == nil {
+		return nil
+	}
+	event := runEvent(appConfig, results, runErr)
+	if !slices.Contains(appConfig.ExecOn, event) {
+		return nil
+	}
+
+	vars := map[string]string{
+		"event": event,
+		"count": strconv.Itoa(len(results)),
+	}
+	if len(appConfig.PartitionBy) == 0 {
+		vars["output_file"] = appConfig.OutputFile
+	}
+	if runErr != nil {
+		vars["error"] = runErr.Error()
+	} else if i := slices.IndexFunc(results, func(d *jwtdecode.Result) bool { return len(d.Failures) > 0 }); i >= 0 {
+		vars["error"] = results[i].Failures[0]
+	}
+	if len(results) == 1 && !appConfig.Batch() {
+		d := results[0]
+		vars["sub"], _ = d.Claims["sub"].(string)
+		vars["iss"], _ = d.Claims["iss"].(string)
+		if !d.Expiry.IsZero() {
+			vars["exp"] = d.Expiry.UTC().Format(time.RFC3339)
+		}
+	}
+	// With hardening, the token must not reach the command through an error message
+	if tokenBuf != nil {
+		vars["error"] = secure.Scrub(vars["error"], tokenBuf.String())
+	}
+	if err := appConfig.Exec.Run(vars, os.Stdout, os.Stderr); err != nil {
+		return fmt.Errorf("running -exec command on %s: %w", event, err)
+	}
+	return nil
+}
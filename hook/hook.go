@@ -0,0 +1,116 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Timeout bounds the run time of a command.
+const Timeout = time.Minute
+
+// placeholderPattern matches a {name} placeholder in a command argument.
+var placeholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// Command is a user command run directly, without a shell. Its {name} placeholders
+// are replaced within each argument, so a value never adds or splits arguments.
+type Command struct {
+	args []string
+}
+
+// Parse splits command into arguments and checks its placeholders against the
+// supported names. Arguments are separated by whitespace; single quotes, double
+// quotes, and backslashes quote characters as in a POSIX shell, but nothing is expanded.
+func Parse(command string, placeholders []string) (*Command, error) {
+	args, err := split(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("command is empty")
+	}
+	for _, arg := range args {
+		for _, m := range placeholderPattern.FindAllStringSubmatch(arg, -1) {
+			if !slices.Contains(placeholders, m[1]) {
+				return nil, fmt.Errorf("unknown placeholder %s in command (supported: {%s})", m[0], strings.Join(placeholders, "}, {"))
+			}
+		}
+	}
+	return &Command{args: args}, nil
+}
+
+// Run runs the command with its placeholders replaced by vars, connecting its output
+// to stdout and stderr, and fails if it exits with a non-zero status or exceeds Timeout.
+func (c *Command) Run(vars map[string]string, stdout, stderr io.Writer) error {
+	args := make([]string, len(c.args))
+	for i, arg := range c.args {
+		args[i] = placeholderPattern.ReplaceAllStringFunc(arg, func(p string) string {
+			return vars[p[1:len(p)-1]]
+		})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	// The command and its arguments come from the user and are not interpreted by a shell
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// split splits a command line into arguments, honoring quotes and backslash escapes.
+func split(command string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf("unterminated quote or escape in command %q", command)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
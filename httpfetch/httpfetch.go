@@ -0,0 +1,122 @@
+package httpfetch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"jwtdecode/lru"
+)
+
+const (
+	// Timeout bounds every request made through this package.
+	Timeout = 10 * time.Second
+	// DefaultMaxSize bounds the size of documents fetched when no explicit limit is given.
+	DefaultMaxSize = 1 << 20
+	// CacheTTL is how long successful responses are reused within the process.
+	CacheTTL = 5 * time.Minute
+	// CacheSize bounds the number of responses cached, as the URLs fetched may come from
+	// the tokens themselves (jku headers, DID and issuer URLs).
+	CacheSize = 256
+	// maxRedirects bounds the redirects followed by a request.
+	maxRedirects = 10
+)
+
+// cacheEntry is a cached response body.
+type cacheEntry struct {
+	body    []byte
+	fetched time.Time
+}
+
+// cache holds successful responses by URL so that key documents shared by several lookups
+// (JWKS, DID documents) are fetched only once.
+var cache = lru.New[cacheEntry](CacheSize)
+
+// client makes the requests of this package. It follows redirects to https:// URLs only,
+// so that a redirect cannot downgrade a key document fetch to plain HTTP.
+var client = &http.Client{
+	Timeout: Timeout,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" {
+			return fmt.Errorf("refusing redirect to non-https URL %s", req.URL.Redacted())
+		}
+		if len(via) >= maxRedirects {
+			return errors.New("stopped after too many redirects")
+		}
+		return nil
+	},
+}
+
+// Get retrieves a small document over HTTP(S), failing on non-200 responses
+// and on bodies larger than maxSize bytes (DefaultMaxSize if maxSize <= 0).
+// Successful responses are cached in memory for CacheTTL.
+func Get(url string, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	entry, ok := cache.Get(url)
+	if ok && time.Since(entry.fetched) < CacheTTL && int64(len(entry.body)) <= maxSize {
+		return entry.body, nil
+	}
+
+	body, err := fetch(url, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	cache.Add(url, cacheEntry{body: body, fetched: time.Now()})
+	return body, nil
+}
+
+// Refresh retrieves a document like Get, but always over the network, so that changes
+// are seen within CacheTTL. The response replaces the cached one.
+func Refresh(url string, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	body, err := fetch(url, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	cache.Add(url, cacheEntry{body: body, fetched: time.Now()})
+	return body, nil
+}
+
+// fetch performs the HTTP request for Get and Refresh.
+func fetch(url string, maxSize int64) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", url, err)
+	}
+	if int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("response from %s exceeds %d bytes", url, maxSize)
+	}
+	return body, nil
+}
+
+// Post sends a JSON document over HTTP(S), failing on non-2xx responses.
+func Post(url string, body []byte) error {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("posting to %s: %w", url, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("posting to %s: unexpected status %s", url, resp.Status)
+	}
+	return nil
+}
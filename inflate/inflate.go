@@ -0,0 +1,115 @@
+package inflate
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"jwtdecode/jwterrors"
+	"jwtdecode/verify"
+)
+
+// DefaultMaxRatio bounds how many times larger than its compressed form a payload
+// may inflate. Legitimate JSON claims rarely exceed a ratio of 20.
+const DefaultMaxRatio = 100
+
+// Limits bounds the output of Deflate so that compressed-payload bombs cannot
+// exhaust memory.
+type Limits struct {
+	MaxSize  int64 // Absolute cap on the inflated size in bytes
+	MaxRatio int64 // Cap on inflated size / compressed size (DefaultMaxRatio if <= 0)
+}
+
+// ErrTooLarge is returned when a payload inflates beyond its limits. It is a
+// jwterrors.ErrTokenTooLarge.
+var ErrTooLarge = jwterrors.Wrap(jwterrors.ErrTokenTooLarge, errors.New("compressed payload exceeds the decompression limit"))
+
+// Deflate inflates a raw DEFLATE (RFC 1951) stream, as used by the "zip": "DEF"
+// header (RFC 7516 §4.1.3). Reading stops as soon as the output exceeds the absolute
+// size cap or the expansion ratio, so at most one byte past the limit is ever inflated.
+func Deflate(data []byte, limits Limits) ([]byte, error) {
+	limit, ratio := limits.bound(int64(len(data)))
+	r := flate.NewReader(bytes.NewReader(data))
+	defer func() {
+		_ = r.Close()
+	}()
+	out, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, jwterrors.Wrap(jwterrors.ErrMalformedToken, fmt.Errorf("inflating payload: %w", err))
+	}
+	if int64(len(out)) > limit {
+		return nil, fmt.Errorf("%w (max %d bytes, ratio %d:1)", ErrTooLarge, limits.MaxSize, ratio)
+	}
+	return out, nil
+}
+
+// Check checks the size of a payload inflated by another decompressor (e.g., the plaintext
+// of a JWE, which go-jose inflates itself) against the limits: an ErrTooLarge if inflated
+// bytes exceed them for compressed bytes.
+func Check(compressed, inflated int64, limits Limits) error {
+	if limit, ratio := limits.bound(compressed); inflated > limit {
+		return fmt.Errorf("%w (max %d bytes, ratio %d:1)", ErrTooLarge, limits.MaxSize, ratio)
+	}
+	return nil
+}
+
+// bound returns the inflated size allowed for compressed bytes, and the ratio applied.
+func (l Limits) bound(compressed int64) (limit, ratio int64) {
+	ratio = l.MaxRatio
+	if ratio <= 0 {
+		ratio = DefaultMaxRatio
+	}
+	limit = l.MaxSize
+	if byRatio := compressed * ratio; byRatio < limit {
+		limit = byRatio
+	}
+	return limit, ratio
+}
+
+// Payload inflates a payload according to the value of the zip header. An empty
+// value returns the payload unchanged; any algorithm other than DEF is rejected.
+func Payload(zip string, data []byte, limits Limits) ([]byte, error) {
+	switch zip {
+	case "":
+		return data, nil
+	case "DEF":
+		return Deflate(data, limits)
+	default:
+		return nil, jwterrors.Wrap(jwterrors.ErrMalformedToken, fmt.Errorf("unsupported zip algorithm %q", zip))
+	}
+}
+
+// Token inflates the payload of a compact JWS whose header carries a zip parameter
+// and returns the token with the inflated payload re-encoded, for decoding only: the
+// signature still covers the original compressed payload, so verification must use
+// the original token. Tokens without a zip header are returned unchanged.
+func Token(raw string, limits Limits) (string, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return raw, nil
+	}
+	headerJSON, err := verify.DecodeSegment(parts[0])
+	if err != nil {
+		return raw, nil // Left for the parser to report
+	}
+	var header struct {
+		Zip string `json:"zip"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Zip == "" {
+		return raw, nil
+	}
+	compressed, err := verify.DecodeSegment(parts[1])
+	if err != nil {
+		return "", jwterrors.Wrap(jwterrors.ErrMalformedToken, fmt.Errorf("decoding compressed payload: %w", err))
+	}
+	payload, err := Payload(header.Zip, compressed, limits)
+	if err != nil {
+		return "", err
+	}
+	return parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2], nil
+}
@@ -0,0 +1,196 @@
+package inputformat
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"jwtdecode/verify"
+)
+
+// CBOR tags of CWT (RFC 8392) and COSE (RFC 9052) structures.
+const (
+	tagCWT      = 61
+	tagSign1    = 18
+	tagMac0     = 17
+	tagEncrypt0 = 16
+	tagSign     = 98
+	tagMac      = 97
+	tagEncrypt  = 96
+)
+
+// cwtClaims are the names of the registered CWT claim keys.
+var cwtClaims = map[int64]string{1: "iss", 2: "sub", 3: "aud", 4: "exp", 5: "nbf", 6: "iat", 7: "cti", 8: "cnf"}
+
+// coseHeaders are the names of the COSE header labels with a JOSE equivalent.
+var coseHeaders = map[int64]string{1: "alg", 2: "crit", 3: "cty", 4: "kid"}
+
+// coseAlgs are the JOSE names of the COSE signature and MAC algorithms.
+var coseAlgs = map[int64]string{
+	-7: "ES256", -35: "ES384", -36: "ES512", -8: "EdDSA",
+	-257: "RS256", -258: "RS384", -259: "RS512",
+	-37: "PS256", -38: "PS384", -39: "PS512",
+	5: "HS256", 6: "HS384", 7: "HS512",
+}
+
+// cborDecoder decodes CBOR maps with integer keys, which JSON cannot hold, into generic maps.
+var cborDecoder, _ = cbor.DecOptions{
+	DefaultMapType: reflect.TypeOf(map[interface{}]interface{}(nil)),
+	IntDec:         cbor.IntDecConvertSigned,
+}.DecMode()
+
+// isCBOR reports whether data starts with the tag of a CWT or of a COSE structure.
+func isCBOR(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+	switch data[0] {
+	case 0xc0 | tagSign1, 0xc0 | tagMac0, 0xc0 | tagEncrypt0:
+		return true
+	case 0xd8: // One-byte tag number
+		return len(data) > 1 && (data[1] == tagCWT || data[1] == tagSign || data[1] == tagMac || data[1] == tagEncrypt)
+	}
+	return false
+}
+
+// parseCWT converts a CWT, as binary CBOR or its hex or base64url encoding, into an
+// unsecured JWT with the COSE header and the claims, named as in JWT where they have a
+// JWT equivalent. The signature is kept for its size only.
+func parseCWT(input string) (*Input, error) {
+	data := []byte(input)
+	if !isCBOR(data) {
+		trimmed := strings.TrimSpace(input)
+		var err error
+		if data, err = hex.DecodeString(trimmed); err != nil {
+			if data, err = verify.DecodeSegment(trimmed); err != nil {
+				return nil, fmt.Errorf("parsing CWT: not CBOR, hex, or base64url")
+			}
+		}
+	}
+	var item interface{}
+	if err := cborDecoder.Unmarshal(data, &item); err != nil {
+		return nil, fmt.Errorf("parsing CWT: %w", err)
+	}
+	if tag, ok := item.(cbor.Tag); ok && tag.Number == tagCWT {
+		item = tag.Content
+	}
+	if tag, ok := item.(cbor.Tag); ok {
+		switch tag.Number {
+		case tagSign1, tagMac0:
+			item = tag.Content
+		case tagEncrypt0, tagEncrypt:
+			return nil, fmt.Errorf("the CWT is encrypted, which cannot be decoded")
+		default:
+			return nil, fmt.Errorf("parsing CWT: COSE structure with tag %d is not supported", tag.Number)
+		}
+	}
+	message, ok := item.([]interface{})
+	if !ok || len(message) != 4 {
+		return nil, fmt.Errorf("parsing CWT: not a COSE_Sign1 or COSE_Mac0 message")
+	}
+	protected, _ := message[0].([]byte)
+	unprotected, _ := message[1].(map[interface{}]interface{})
+	payload, _ := message[2].([]byte)
+	signature, _ := message[3].([]byte)
+	if payload == nil {
+		return nil, fmt.Errorf("parsing CWT: the payload is missing or detached")
+	}
+
+	header := map[string]interface{}{}
+	if len(protected) > 0 {
+		var params map[interface{}]interface{}
+		if err := cborDecoder.Unmarshal(protected, &params); err != nil {
+			return nil, fmt.Errorf("parsing CWT protected header: %w", err)
+		}
+		addCOSEHeader(header, params)
+	}
+	addCOSEHeader(header, unprotected)
+	label, _ := header["alg"].(int64)
+	alg, ok := coseAlgs[label]
+	if !ok {
+		return nil, fmt.Errorf("parsing CWT: COSE algorithm %v is not supported", header["alg"])
+	}
+	header["alg"] = alg
+	header["typ"] = "CWT"
+
+	var claims map[interface{}]interface{}
+	if err := cborDecoder.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("parsing CWT claims: %w", err)
+	}
+	named := make(map[string]interface{}, len(claims))
+	for key, value := range claims {
+		named[mapKey(key, cwtClaims)] = jsonValue(value)
+	}
+	payloadJSON, err := json.Marshal(named)
+	if err != nil {
+		return nil, fmt.Errorf("parsing CWT claims: %w", err)
+	}
+	return &Input{Format: CWT, Compact: unsecured(header, payloadJSON, signature)}, nil
+}
+
+// addCOSEHeader adds COSE header parameters to a JOSE header, without replacing the
+// parameters already there (protected parameters are added first).
+func addCOSEHeader(header map[string]interface{}, params map[interface{}]interface{}) {
+	for key, value := range params {
+		name := mapKey(key, coseHeaders)
+		if _, exists := header[name]; exists {
+			continue
+		}
+		if name == "alg" {
+			header[name] = value
+			continue
+		}
+		header[name] = jsonValue(value)
+	}
+}
+
+// mapKey returns the name of a map key: its registered name, or the key as a string.
+func mapKey(key interface{}, names map[int64]string) string {
+	switch k := key.(type) {
+	case int64:
+		if name, ok := names[k]; ok {
+			return name
+		}
+		return strconv.FormatInt(k, 10)
+	case string:
+		return k
+	default:
+		return fmt.Sprint(k)
+	}
+}
+
+// jsonValue converts a decoded CBOR value into a value JSON can hold: byte strings are
+// text when they are printable UTF-8 and base64url otherwise, and map keys are strings.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		if utf8.Valid(v) && !strings.ContainsFunc(string(v), func(r rune) bool { return !unicode.IsPrint(r) }) {
+			return string(v)
+		}
+		return base64.RawURLEncoding.EncodeToString(v)
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[mapKey(key, nil)] = jsonValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = jsonValue(item)
+		}
+		return out
+	case cbor.Tag:
+		return jsonValue(v.Content)
+	default:
+		return v
+	}
+}
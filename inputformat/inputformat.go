@@ -0,0 +1,153 @@
+package inputformat
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"jwtdecode/jwterrors"
+	"jwtdecode/verify"
+)
+
+// Serializations of a token recognized in the input.
+const (
+	Auto    = "auto"     // Detect the serialization from the input
+	JWS     = "jws"      // Compact JWS: header.payload.signature
+	JWE     = "jwe"      // Compact JWE (five segments) or JWE JSON serialization
+	JWSJSON = "jws-json" // JWS JSON serialization, general or flattened (RFC 7515 §7.2)
+	SDJWT   = "sd-jwt"   // SD-JWT combined format: <jws>~<disclosure>~...~[<kb-jwt>]
+	CWT     = "cwt"      // CBOR Web Token (RFC 8392), binary, hex, or base64url
+	Claims  = "json"     // Plain claims as a JSON object, unsigned
+)
+
+// Formats are the accepted values of -input-format.
+var Formats = []string{Auto, JWS, JWE, JWSJSON, SDJWT, CWT, Claims}
+
+// Input is a token converted for the decoding pipeline, which parses compact JWS.
+type Input struct {
+	Format string
+	// Compact is the compact JWS to decode: the token itself, the JWS JSON serialization
+	// converted, the issuer-signed JWT of an SD-JWT, or an unsecured JWT holding the
+	// claims of a CWT or JSON input.
+	Compact string
+	// Signed reports whether Compact carries the signature of the input, so that it can
+	// be verified like any compact JWS. The COSE signature of a CWT cannot.
+	Signed      bool
+	Encrypted   bool         // Whether the input is a JWE, decrypted by Decrypted
+	Disclosures []Disclosure // Disclosures of an SD-JWT
+	Warnings    []string     // Parts of the input that were not decoded
+}
+
+// Detect returns the serialization of a token, or "" if it is not recognized.
+func Detect(input string) string {
+	// Binary CBOR is checked first, as its bytes may hold any character
+	if isCBOR([]byte(input)) {
+		return CWT
+	}
+	trimmed := strings.TrimSpace(input)
+	switch {
+	case strings.HasPrefix(trimmed, "{"):
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(trimmed), &members); err != nil {
+			return ""
+		}
+		if _, ok := members["ciphertext"]; ok {
+			return JWE
+		}
+		_, general := members["signatures"]
+		_, flattened := members["signature"]
+		if _, ok := members["payload"]; ok && (general || flattened) {
+			return JWSJSON
+		}
+		return Claims
+	case strings.Contains(trimmed, "~"):
+		return SDJWT
+	case strings.Count(trimmed, ".") == 2:
+		return JWS
+	case strings.Count(trimmed, ".") == 4:
+		return JWE
+	}
+	if data, err := hex.DecodeString(trimmed); err == nil && isCBOR(data) {
+		return CWT
+	}
+	if data, err := verify.DecodeSegment(trimmed); err == nil && isCBOR(data) {
+		return CWT
+	}
+	return ""
+}
+
+// Parse converts a token of the given serialization (or of the detected one, for Auto
+// or "") for the decoding pipeline. An input that cannot be converted is a
+// jwterrors.ErrMalformedToken, and a JWE a jwterrors.ErrDecryption, as it is decrypted
+// first.
+func Parse(input, format string) (*Input, error) {
+	if format == "" || format == Auto {
+		format = Detect(input)
+		if format == "" {
+			return nil, jwterrors.Wrap(jwterrors.ErrMalformedToken, fmt.Errorf("unrecognized token format; expected a compact JWS or JWE, JWS JSON, SD-JWT, CWT, or claims JSON (see -input-format)"))
+		}
+	}
+	var in *Input
+	var err error
+	switch format {
+	case JWS:
+		return &Input{Format: JWS, Compact: strings.TrimSpace(input), Signed: true}, nil
+	case JWE:
+		return nil, jwterrors.Wrap(jwterrors.ErrDecryption, fmt.Errorf("the token is an encrypted JWE; decrypt it with -decrypt-key"))
+	case JWSJSON:
+		in, err = parseJWSJSON(input)
+	case SDJWT:
+		in, err = parseSDJWT(input)
+	case CWT:
+		in, err = parseCWT(input)
+	case Claims:
+		in, err = parseClaims(input)
+	default:
+		return nil, fmt.Errorf("unknown input format %q", format)
+	}
+	if err != nil {
+		return nil, jwterrors.Wrap(jwterrors.ErrMalformedToken, err)
+	}
+	return in, nil
+}
+
+// Decrypted converts the plaintext of a decrypted JWE: a nested token (compact JWS, JWS
+// JSON, or SD-JWT), or the claims themselves. Format is the serialization of the plaintext.
+func Decrypted(plaintext []byte) (*Input, error) {
+	format := Detect(string(plaintext))
+	switch format {
+	case "":
+		return nil, jwterrors.Wrap(jwterrors.ErrMalformedToken, fmt.Errorf("the decrypted JWE holds neither a token nor claims JSON"))
+	case JWE:
+		return nil, jwterrors.Wrap(jwterrors.ErrDecryption, fmt.Errorf("the decrypted JWE holds another JWE, which is not decrypted"))
+	}
+	input, err := Parse(string(plaintext), format)
+	if err != nil {
+		return nil, err
+	}
+	input.Encrypted = true
+	return input, nil
+}
+
+// parseClaims converts a JSON object of claims into an unsecured JWT.
+func parseClaims(input string) (*Input, error) {
+	var claims map[string]interface{}
+	if err := json.Unmarshal([]byte(input), &claims); err != nil {
+		return nil, fmt.Errorf("parsing claims JSON: %w", err)
+	}
+	if claims == nil {
+		return nil, fmt.Errorf("parsing claims JSON: not an object")
+	}
+	return &Input{Format: Claims, Compact: unsecured(map[string]interface{}{"alg": "none"}, []byte(strings.TrimSpace(input)), nil)}, nil
+}
+
+// unsecured returns a compact JWT of header and payload, with signature as its
+// signature segment so that the pipeline reports the size of the original signature.
+func unsecured(header map[string]interface{}, payload, signature []byte) string {
+	headerJSON, _ := json.Marshal(header)
+	return base64.RawURLEncoding.EncodeToString(headerJSON) + "." +
+		base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(signature)
+}
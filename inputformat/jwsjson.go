@@ -0,0 +1,52 @@
+package inputformat
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jwsSignature is a signature of the JWS JSON serialization.
+type jwsSignature struct {
+	Protected string                 `json:"protected"`
+	Header    map[string]interface{} `json:"header"`
+	Signature string                 `json:"signature"`
+}
+
+// jwsJSON is the general or flattened JWS JSON serialization.
+type jwsJSON struct {
+	Payload    *string        `json:"payload"`
+	Signatures []jwsSignature `json:"signatures"`
+	jwsSignature
+}
+
+// parseJWSJSON converts a JWS JSON serialization into the compact JWS of its first
+// signature. The compact serialization has no unprotected header, so the header member
+// is left out, and the other signatures are not verified.
+func parseJWSJSON(input string) (*Input, error) {
+	var doc jwsJSON
+	if err := json.Unmarshal([]byte(input), &doc); err != nil {
+		return nil, fmt.Errorf("parsing JWS JSON: %w", err)
+	}
+	if doc.Payload == nil {
+		return nil, fmt.Errorf("parsing JWS JSON: the payload is missing")
+	}
+	if *doc.Payload == "" {
+		return nil, fmt.Errorf("parsing JWS JSON: the payload is detached, which cannot be decoded")
+	}
+	in := &Input{Format: JWSJSON, Signed: true}
+	sig := doc.jwsSignature
+	if len(doc.Signatures) > 0 {
+		sig = doc.Signatures[0]
+		if len(doc.Signatures) > 1 {
+			in.Warnings = append(in.Warnings, fmt.Sprintf("JWS JSON has %d signatures; only the first is decoded and verified", len(doc.Signatures)))
+		}
+	}
+	if sig.Protected == "" {
+		return nil, fmt.Errorf("parsing JWS JSON: the signature has no protected header")
+	}
+	if len(sig.Header) > 0 {
+		in.Warnings = append(in.Warnings, "JWS JSON unprotected header parameters are ignored")
+	}
+	in.Compact = sig.Protected + "." + *doc.Payload + "." + sig.Signature
+	return in, nil
+}
@@ -0,0 +1,198 @@
+package inputformat
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"strings"
+
+	"jwtdecode/verify"
+)
+
+// Claims of SD-JWT payloads holding the digests of the disclosures.
+const (
+	claimSD      = "_sd"     // Digests of the object properties that may be disclosed
+	claimSDAlg   = "_sd_alg" // Hash algorithm of the digests; sha-256 by default
+	arrayElement = "..."     // Key of the digest of an array element that may be disclosed
+)
+
+// Disclosure is a claim disclosed by an SD-JWT: an object property (with a name) or an
+// array element.
+type Disclosure struct {
+	Encoded string      // The disclosure as it appears in the SD-JWT, which the digests hash
+	Name    string      // Name of the property; empty for an array element
+	Value   interface{} // Disclosed value
+	element bool
+}
+
+// parseSDJWT splits an SD-JWT into its issuer-signed JWT and its disclosures. A key
+// binding JWT at the end is not checked.
+func parseSDJWT(input string) (*Input, error) {
+	parts := strings.Split(strings.TrimSpace(input), "~")
+	in := &Input{Format: SDJWT, Compact: parts[0], Signed: true}
+	if strings.Count(in.Compact, ".") != 2 {
+		return nil, fmt.Errorf("parsing SD-JWT: the issuer-signed JWT is not a compact JWS")
+	}
+	for i, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		if i == len(parts)-2 {
+			in.Warnings = append(in.Warnings, "SD-JWT key binding JWT is not checked")
+			break
+		}
+		d, err := parseDisclosure(part)
+		if err != nil {
+			return nil, fmt.Errorf("parsing SD-JWT disclosure %d: %w", i+1, err)
+		}
+		in.Disclosures = append(in.Disclosures, d)
+	}
+	return in, nil
+}
+
+// parseDisclosure decodes a disclosure: the base64url encoding of [salt, name, value]
+// for an object property, or of [salt, value] for an array element.
+func parseDisclosure(encoded string) (Disclosure, error) {
+	data, err := verify.DecodeSegment(encoded)
+	if err != nil {
+		return Disclosure{}, err
+	}
+	var items []interface{}
+	if err := json.Unmarshal(data, &items); err != nil {
+		return Disclosure{}, err
+	}
+	d := Disclosure{Encoded: encoded}
+	switch len(items) {
+	case 2:
+		d.Value, d.element = items[1], true
+	case 3:
+		name, ok := items[1].(string)
+		if !ok {
+			return Disclosure{}, fmt.Errorf("the claim name is not a string")
+		}
+		if name == claimSD || name == arrayElement {
+			return Disclosure{}, fmt.Errorf("the claim name %q is reserved", name)
+		}
+		d.Name, d.Value = name, items[2]
+	default:
+		return Disclosure{}, fmt.Errorf("expected 2 or 3 items, got %d", len(items))
+	}
+	return d, nil
+}
+
+// Disclose replaces the digests of an SD-JWT payload by the claims of the disclosures,
+// and removes the digests of claims that are not disclosed. It returns the disclosures
+// that no digest refers to, which were not issued for this payload.
+func Disclose(claims map[string]interface{}, disclosures []Disclosure) ([]Disclosure, error) {
+	alg := "sha-256"
+	if value, ok := claims[claimSDAlg]; ok {
+		name, _ := value.(string)
+		alg = name
+		delete(claims, claimSDAlg)
+	}
+	var newHash func() hash.Hash
+	switch alg {
+	case "sha-256":
+		newHash = sha256.New
+	case "sha-384":
+		newHash = sha512.New384
+	case "sha-512":
+		newHash = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported %s %q", claimSDAlg, alg)
+	}
+	byDigest := make(map[string]*Disclosure, len(disclosures))
+	for i := range disclosures {
+		h := newHash()
+		h.Write([]byte(disclosures[i].Encoded))
+		byDigest[base64.RawURLEncoding.EncodeToString(h.Sum(nil))] = &disclosures[i]
+	}
+	used := map[*Disclosure]bool{}
+	if _, err := discloseValue(claims, byDigest, used); err != nil {
+		return nil, err
+	}
+	var unused []Disclosure
+	for i := range disclosures {
+		if !used[&disclosures[i]] {
+			unused = append(unused, disclosures[i])
+		}
+	}
+	return unused, nil
+}
+
+// discloseValue processes the digests within a value, recursively, returning the value
+// with its disclosed claims. Each disclosure is used once at most.
+func discloseValue(value interface{}, byDigest map[string]*Disclosure, used map[*Disclosure]bool) (interface{}, error) {
+	lookup := func(digest interface{}, element bool) (*Disclosure, error) {
+		s, _ := digest.(string)
+		d, ok := byDigest[s]
+		if !ok {
+			return nil, nil
+		}
+		if used[d] {
+			return nil, fmt.Errorf("a disclosure is referred to more than once")
+		}
+		if d.element != element {
+			return nil, fmt.Errorf("a disclosure of the wrong kind is referred to by %s", s)
+		}
+		used[d] = true
+		return d, nil
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		digests, _ := v[claimSD].([]interface{})
+		delete(v, claimSD)
+		for key, item := range v {
+			newItem, err := discloseValue(item, byDigest, used)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = newItem
+		}
+		for _, digest := range digests {
+			d, err := lookup(digest, false)
+			if err != nil {
+				return nil, err
+			}
+			if d == nil {
+				continue
+			}
+			if _, exists := v[d.Name]; exists {
+				return nil, fmt.Errorf("disclosed claim %q is already in the payload", d.Name)
+			}
+			disclosed, err := discloseValue(d.Value, byDigest, used)
+			if err != nil {
+				return nil, err
+			}
+			v[d.Name] = disclosed
+		}
+		return v, nil
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			if obj, ok := item.(map[string]interface{}); ok && len(obj) == 1 {
+				if digest, ok := obj[arrayElement]; ok {
+					d, err := lookup(digest, true)
+					if err != nil {
+						return nil, err
+					}
+					if d == nil {
+						continue
+					}
+					item = d.Value
+				}
+			}
+			newItem, err := discloseValue(item, byDigest, used)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, newItem)
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
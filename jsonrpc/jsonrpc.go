@@ -0,0 +1,166 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Error codes defined by JSON-RPC 2.0.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000 // A request that was understood but could not be completed
+)
+
+// ErrExit is returned by a Handler to stop serving after the current message.
+var ErrExit = errors.New("exit requested")
+
+// Error is a JSON-RPC error object. Handlers return it to choose the error code;
+// any other error is reported with CodeServerError.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"` // Details of the error, e.g. the violations of invalid params
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Handler handles one request or notification and returns its result.
+type Handler func(method string, params json.RawMessage) (interface{}, error)
+
+// request is an incoming JSON-RPC 2.0 request, or a notification when ID is absent.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is an outgoing JSON-RPC 2.0 response.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Serve reads messages framed as in the Language Server Protocol base protocol
+// (a Content-Length header, a blank line, and a JSON body) from r, dispatches them to
+// handler, and writes the framed responses to w. Messages larger than maxSize bytes
+// are rejected. Serve returns nil at the end of input or when handler returns ErrExit.
+func Serve(r io.Reader, w io.Writer, handler Handler, maxSize int) error {
+	reader := textproto.NewReader(bufio.NewReader(r))
+	for {
+		body, err := readMessage(reader, maxSize)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			var rpcErr *Error
+			if errors.As(err, &rpcErr) {
+				// The oversized body was not read, so the stream cannot be resynchronized
+				_ = writeMessage(w, response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: rpcErr})
+			}
+			return err
+		}
+
+		resp, stop := dispatch(body, handler)
+		if resp != nil {
+			if err := writeMessage(w, resp); err != nil {
+				return err
+			}
+		}
+		if stop {
+			return nil
+		}
+	}
+}
+
+// dispatch handles one message body and returns the response, or nil for a
+// notification, and whether serving should stop.
+func dispatch(body []byte, handler Handler) (*response, bool) {
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return &response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}}, false
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return &response{JSONRPC: "2.0", ID: idOrNull(req.ID), Error: &Error{Code: CodeInvalidRequest, Message: "invalid JSON-RPC 2.0 request"}}, false
+	}
+
+	result, err := handler(req.Method, req.Params)
+	stop := errors.Is(err, ErrExit)
+	if req.ID == nil {
+		return nil, stop
+	}
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	switch {
+	case err == nil || stop:
+		if result == nil {
+			result = json.RawMessage("null")
+		}
+		resp.Result = result
+	default:
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeServerError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+	}
+	return resp, stop
+}
+
+// idOrNull returns the request ID, or null when the request had none.
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if id == nil {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+// readMessage reads the headers and body of one framed message.
+func readMessage(reader *textproto.Reader, maxSize int) ([]byte, error) {
+	header, err := reader.ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("reading message header: %w", err)
+	}
+	value := strings.TrimSpace(header.Get("Content-Length"))
+	length, err := strconv.Atoi(value)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", value)
+	}
+	if length > maxSize {
+		return nil, &Error{Code: CodeInvalidRequest, Message: fmt.Sprintf("message of %d bytes exceeds the %d byte limit", length, maxSize)}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader.R, body); err != nil {
+		return nil, fmt.Errorf("reading message body: %w", err)
+	}
+	return body, nil
+}
+
+// writeMessage writes one framed message.
+func writeMessage(w io.Writer, resp interface{}) error {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("encoding response: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(body), body); err != nil {
+		return fmt.Errorf("writing response: %w", err)
+	}
+	return nil
+}
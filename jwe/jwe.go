@@ -0,0 +1,146 @@
+// Package jwe decrypts JWE tokens (RFC 7516), in the compact or JSON serialization, with
+// the key of -decrypt-key, so that the claims they carry, or the JWS nested in them, can be
+// decoded like any token.
+package jwe
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	jose "github.com/go-jose/go-jose/v4"
+
+	"jwtdecode/inflate"
+	"jwtdecode/jwterrors"
+)
+
+// KeyAlgorithms are the key management algorithms accepted. RSA1_5 is not, as it is open
+// to padding oracle attacks, nor are the PBES2 algorithms, whose iteration count is chosen
+// by the sender of the token.
+var KeyAlgorithms = []jose.KeyAlgorithm{
+	jose.RSA_OAEP, jose.RSA_OAEP_256,
+	jose.A128KW, jose.A192KW, jose.A256KW,
+	jose.A128GCMKW, jose.A192GCMKW, jose.A256GCMKW,
+	jose.ECDH_ES, jose.ECDH_ES_A128KW, jose.ECDH_ES_A192KW, jose.ECDH_ES_A256KW,
+	jose.DIRECT,
+}
+
+// ContentEncryptions are the content encryption algorithms accepted.
+var ContentEncryptions = []jose.ContentEncryption{
+	jose.A128CBC_HS256, jose.A192CBC_HS384, jose.A256CBC_HS512,
+	jose.A128GCM, jose.A192GCM, jose.A256GCM,
+}
+
+// Decrypter decrypts tokens with one key.
+type Decrypter struct {
+	key    interface{}
+	limits inflate.Limits // Limits of compressed plaintexts
+}
+
+// NewDecrypter parses the key of -decrypt-key: a PEM private key (PKCS #8, PKCS #1 RSA, or
+// SEC 1 EC), a private or symmetric JWK, or else the raw bytes of a symmetric key, without
+// their trailing newline. Compressed plaintexts are held to limits, as compressed JWS
+// payloads are.
+func NewDecrypter(data []byte, limits inflate.Limits) (*Decrypter, error) {
+	d, err := newDecrypter(data)
+	if err != nil {
+		return nil, err
+	}
+	d.limits = limits
+	return d, nil
+}
+
+// newDecrypter parses the key of a Decrypter.
+func newDecrypter(data []byte) (*Decrypter, error) {
+	trimmed := bytes.TrimSpace(data)
+	if block, _ := pem.Decode(trimmed); block != nil {
+		key, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, err
+		}
+		return &Decrypter{key: key}, nil
+	}
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var jwk jose.JSONWebKey
+		if err := json.Unmarshal(trimmed, &jwk); err != nil {
+			return nil, fmt.Errorf("parsing JWK: %w", err)
+		}
+		if jwk.IsPublic() {
+			return nil, fmt.Errorf("the JWK is a public key; decryption requires the private key")
+		}
+		return &Decrypter{key: &jwk}, nil
+	}
+	key := bytes.TrimRight(data, "\r\n")
+	if len(key) == 0 {
+		return nil, fmt.Errorf("the key is empty")
+	}
+	return &Decrypter{key: key}, nil
+}
+
+// parsePrivateKey parses the private key of a PEM block.
+func parsePrivateKey(block *pem.Block) (interface{}, error) {
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing private key: %w", err)
+		}
+		return key, nil
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing RSA private key: %w", err)
+		}
+		return key, nil
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing EC private key: %w", err)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("PEM block %q is not a private key (PRIVATE KEY, RSA PRIVATE KEY, or EC PRIVATE KEY)", block.Type)
+}
+
+// Decrypt returns the plaintext of a token, after checking its integrity. Compressed
+// plaintexts ("zip": "DEF") are inflated by go-jose, within its own bounds, then checked
+// against the limits of the Decrypter, their compressed size being that of the
+// ciphertext. A token that cannot be parsed is a jwterrors.ErrMalformedToken, one that
+// cannot be decrypted a jwterrors.ErrDecryption, and one whose plaintext inflates beyond
+// the limits a jwterrors.ErrTokenTooLarge.
+func (d *Decrypter) Decrypt(token string) ([]byte, error) {
+	obj, err := jose.ParseEncrypted(token, KeyAlgorithms, ContentEncryptions)
+	if err != nil {
+		return nil, jwterrors.Wrap(jwterrors.ErrMalformedToken, fmt.Errorf("parsing JWE: %w", err))
+	}
+	plaintext, err := obj.Decrypt(d.key)
+	if err != nil {
+		return nil, jwterrors.Wrap(jwterrors.ErrDecryption, fmt.Errorf("decrypting JWE: %w", err))
+	}
+	if _, ok := obj.Header.ExtraHeaders["zip"]; ok {
+		if err := inflate.Check(ciphertextSize(token), int64(len(plaintext)), d.limits); err != nil {
+			return nil, fmt.Errorf("decompressing JWE plaintext: %w", err)
+		}
+	}
+	return plaintext, nil
+}
+
+// ciphertextSize returns the size of the ciphertext of a token that parsed, in the compact
+// or JSON serialization.
+func ciphertextSize(token string) int64 {
+	encoded := ""
+	if parts := strings.Split(token, "."); len(parts) == 5 {
+		encoded = parts[3]
+	} else {
+		var serialized struct {
+			Ciphertext string `json:"ciphertext"`
+		}
+		_ = json.Unmarshal([]byte(token), &serialized)
+		encoded = serialized.Ciphertext
+	}
+	return int64(base64.RawURLEncoding.DecodedLen(len(strings.TrimRight(encoded, "="))))
+}
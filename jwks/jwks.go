@@ -0,0 +1,165 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"jwtdecode/httpfetch"
+	"jwtdecode/jwterrors"
+)
+
+// Key is a single JSON Web Key (RFC 7517). Only the members needed to
+// reconstruct public keys are decoded.
+type Key struct {
+	Kty string   `json:"kty"`
+	Kid string   `json:"kid,omitempty"`
+	Use string   `json:"use,omitempty"`
+	Alg string   `json:"alg,omitempty"`
+	N   string   `json:"n,omitempty"`
+	E   string   `json:"e,omitempty"`
+	Crv string   `json:"crv,omitempty"`
+	X   string   `json:"x,omitempty"`
+	Y   string   `json:"y,omitempty"`
+	D   string   `json:"d,omitempty"` // Private key material; must never be present in public key sets
+	X5c []string `json:"x5c,omitempty"`
+}
+
+// Set is a JSON Web Key Set.
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// Parse decodes a JWKS document.
+func Parse(data []byte) (*Set, error) {
+	var set Set
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parsing JWKS: %w", err)
+	}
+	return &set, nil
+}
+
+// Fetch retrieves and decodes the JWKS document at url.
+func Fetch(url string) (*Set, error) {
+	data, err := httpfetch.Get(url, 0)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Find returns the key with the given kid. If kid is empty and the set holds
+// exactly one key, that key is returned.
+func (s *Set) Find(kid string) (*Key, error) {
+	if kid == "" {
+		if len(s.Keys) == 1 {
+			return &s.Keys[0], nil
+		}
+		return nil, fmt.Errorf("token has no kid and JWKS holds %d keys", len(s.Keys))
+	}
+	for i := range s.Keys {
+		if s.Keys[i].Kid == kid {
+			return &s.Keys[i], nil
+		}
+	}
+	return nil, jwterrors.Wrap(jwterrors.ErrKeyNotFound, fmt.Errorf("no key with kid %q in JWKS", kid))
+}
+
+// PublicKey reconstructs the crypto public key described by the JWK.
+func (k *Key) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decoding RSA modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decoding RSA exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("RSA exponent out of range")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decoding EC x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decoding EC y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("EC point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decoding Ed25519 key: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key length %d", len(x))
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// FromPublicKey describes a public key as a JWK with the given kid and alg.
+func FromPublicKey(pub crypto.PublicKey, kid, alg string) (Key, error) {
+	key := Key{Kid: kid, Use: "sig", Alg: alg}
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		key.Kty = "RSA"
+		key.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+		key.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		key.Kty = "EC"
+		key.Crv = k.Curve.Params().Name
+		key.X = base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size)))
+		key.Y = base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		key.Kty = "OKP"
+		key.Crv = "Ed25519"
+		key.X = base64.RawURLEncoding.EncodeToString(k)
+	default:
+		return Key{}, fmt.Errorf("unsupported key type %T", pub)
+	}
+	return key, nil
+}
+
+// decodeBigInt decodes a base64url-encoded unsigned big-endian integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
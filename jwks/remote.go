@@ -0,0 +1,197 @@
+package jwks
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"jwtdecode/httpfetch"
+	"jwtdecode/jwterrors"
+)
+
+// ErrKeyNotFound is returned by Remote.Key when the key set has no key for a kid, even
+// once fetched again. It is a jwterrors.ErrKeyNotFound.
+var ErrKeyNotFound = fmt.Errorf("%w in JWKS", jwterrors.ErrKeyNotFound)
+
+// Remote is a JWKS document fetched over HTTPS, with its own timeout and trusted CAs, and
+// cached on disk so that repeated runs do not fetch it every time.
+type Remote struct {
+	URL      string         // HTTPS URL of the JWKS document
+	Timeout  time.Duration  // Bounds the request; httpfetch.Timeout if zero
+	RootCAs  *x509.CertPool // CAs trusted for the server certificate; the system pool if nil
+	CacheDir string         // Directory of the cached documents; no cache if empty
+	CacheTTL time.Duration  // Age until which a fetched document is used; kept for the run and not cached on disk if zero
+
+	mu      sync.Mutex
+	set     *Set      // Key set already read by this run
+	fetched time.Time // When set was fetched
+}
+
+// NewRemote checks that rawURL is an https URL and returns its remote key set.
+func NewRemote(rawURL string) (*Remote, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid JWKS URL %q; must be an https URL", rawURL)
+	}
+	return &Remote{URL: rawURL}, nil
+}
+
+// LoadCAs reads the PEM certificates of a CA bundle file into a pool.
+func LoadCAs(pemData []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("no PEM certificate found in CA bundle")
+	}
+	return pool, nil
+}
+
+// Key returns the key with the given kid (see Set.Find). When the set, as cached, has no
+// such key, it is fetched again in case the issuer rotated its keys since; ErrKeyNotFound
+// is returned if it still has none.
+func (r *Remote) Key(kid string) (*Key, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	set, fresh, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	key, err := set.Find(kid)
+	if err == nil || fresh {
+		return r.found(key, err)
+	}
+	if set, err = r.fetch(); err != nil {
+		return nil, err
+	}
+	return r.found(set.Find(kid))
+}
+
+// Check fetches the key set, bypassing the key set already read and the cache on disk, and
+// returns an error if it is unreachable or does not parse. The key set read by the run is
+// left as it is.
+func (r *Remote) Check() error {
+	data, err := r.Get(r.URL)
+	if err != nil {
+		return err
+	}
+	if _, err := Parse(data); err != nil {
+		return fmt.Errorf("%s: %w", r.URL, err)
+	}
+	return nil
+}
+
+// Flush drops the key set read by this run and its cache file, so that the next lookup
+// fetches it again.
+func (r *Remote) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.set = nil
+	if path := r.cachePath(); path != "" {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("removing cached JWKS: %w", err)
+		}
+	}
+	return nil
+}
+
+// found wraps the error of a key lookup with ErrKeyNotFound.
+func (r *Remote) found(key *Key, err error) (*Key, error) {
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyNotFound, err)
+	}
+	return key, nil
+}
+
+// load returns the key set read before by this run or the cached one if it is younger than
+// CacheTTL, or a fetched one, reporting whether it was just fetched.
+func (r *Remote) load() (*Set, bool, error) {
+	if r.set != nil && (r.CacheTTL <= 0 || time.Since(r.fetched) < r.CacheTTL) {
+		return r.set, false, nil
+	}
+	if path := r.cachePath(); path != "" {
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < r.CacheTTL {
+			if data, err := os.ReadFile(path); err == nil {
+				if set, err := Parse(data); err == nil {
+					r.set, r.fetched = set, info.ModTime()
+					return set, false, nil
+				}
+			}
+		}
+	}
+	set, err := r.fetch()
+	return set, true, err
+}
+
+// fetch retrieves the key set and caches it on disk. A cache that cannot be written only
+// costs a fetch in the next run, so it is not an error.
+func (r *Remote) fetch() (*Set, error) {
+	data, err := r.Get(r.URL)
+	if err != nil {
+		return nil, err
+	}
+	set, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", r.URL, err)
+	}
+	r.set, r.fetched = set, time.Now()
+	if path := r.cachePath(); path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+			_ = os.WriteFile(path, data, 0600)
+		}
+	}
+	return set, nil
+}
+
+// Get performs an HTTPS request with the timeout and the CAs of the remote key set, and
+// TLS 1.2 or later, e.g. to fetch the discovery document that points to the key set.
+func (r *Remote) Get(rawURL string) ([]byte, error) {
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = httpfetch.Timeout
+	}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: r.RootCAs},
+		},
+	}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		// The error names the URL
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", rawURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpfetch.DefaultMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", rawURL, err)
+	}
+	if len(body) > httpfetch.DefaultMaxSize {
+		return nil, fmt.Errorf("%s exceeds %d bytes", rawURL, httpfetch.DefaultMaxSize)
+	}
+	return body, nil
+}
+
+// cachePath returns the cache file of the URL, or "" when caching is disabled.
+func (r *Remote) cachePath() string {
+	if r.CacheDir == "" || r.CacheTTL <= 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(r.URL))
+	return filepath.Join(r.CacheDir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,59 @@
+// Package jwterrors defines the categories of the errors of decoding a token, shared by
+// the packages of jwtdecode, so that library consumers and the exit status of the command
+// line tell failures apart with errors.Is instead of matching messages. An error keeps
+// the message of where it occurred, and is marked with its category by Wrap.
+package jwterrors
+
+import "errors"
+
+// Categories of errors.
+var (
+	// ErrMalformedToken is a token that cannot be parsed: an unrecognized serialization,
+	// invalid base64url or JSON, or an invalid CWT, SD-JWT, or JWS JSON structure.
+	ErrMalformedToken = errors.New("malformed token")
+	// ErrTokenTooLarge is a token, or its inflated payload, exceeding the size limit.
+	ErrTokenTooLarge = errors.New("token too large")
+	// ErrDecryption is a JWE that cannot be decrypted: no key was given, or the key is
+	// not the key of the token, or the ciphertext was modified.
+	ErrDecryption = errors.New("token cannot be decrypted")
+	// ErrSignatureInvalid is a token whose signature does not verify with the key it was
+	// checked against, or whose alg the key does not allow.
+	ErrSignatureInvalid = errors.New("token signature is invalid")
+	// ErrKeyNotFound is a key set without the key of the token (its kid).
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrExpired is a token past its expiration time (exp claim).
+	ErrExpired = errors.New("token is expired")
+	// ErrNotYetValid is a token before its not-before time (nbf claim), or issued in the
+	// future (iat claim).
+	ErrNotYetValid = errors.New("token is not yet valid")
+	// ErrOutputTooLarge is formatted output exceeding the output size limit.
+	ErrOutputTooLarge = errors.New("output too large")
+	// ErrInvalidConfig is a configuration, or library options, breaking the validation
+	// rules.
+	ErrInvalidConfig = errors.New("invalid configuration")
+)
+
+// categorized is an error marked with a category.
+type categorized struct {
+	category error
+	err      error
+}
+
+// Error returns the message of the error, without its category.
+func (e *categorized) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the category and the error, for errors.Is and errors.As.
+func (e *categorized) Unwrap() []error {
+	return []error{e.category, e.err}
+}
+
+// Wrap marks err with a category, keeping its message, so that errors.Is(err, category)
+// reports true. Wrap returns nil if err is nil.
+func Wrap(category, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &categorized{category: category, err: err}
+}
@@ -0,0 +1,51 @@
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errNotFound is returned by get when no secret is stored for the account.
+var errNotFound = errors.New("not found")
+
+// Get reads the token stored under ref, given as <service>/<account>, from the platform
+// secret store: the macOS Keychain, the Windows Credential Manager, or the Secret Service
+// (GNOME Keyring, KWallet) on Linux and the BSDs.
+func Get(ref string) (string, error) {
+	service, account, err := parseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	secret, err := get(service, account)
+	if errors.Is(err, errNotFound) {
+		return "", fmt.Errorf("no token stored in the keychain for %q", ref)
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %q from the keychain: %w", ref, err)
+	}
+	return secret, nil
+}
+
+// Set stores the token under ref, given as <service>/<account>, in the platform secret
+// store, replacing any token stored there.
+func Set(ref, token string) error {
+	service, account, err := parseRef(ref)
+	if err != nil {
+		return err
+	}
+	if err := set(service, account, token); err != nil {
+		return fmt.Errorf("storing %q in the keychain: %w", ref, err)
+	}
+	return nil
+}
+
+// parseRef splits a <service>/<account> reference at its last slash, so that the
+// service may itself contain slashes (e.g., a URL).
+func parseRef(ref string) (string, string, error) {
+	i := strings.LastIndex(ref, "/")
+	if i <= 0 || i == len(ref)-1 {
+		return "", "", fmt.Errorf("invalid keychain reference %q; expected <service>/<account>", ref)
+	}
+	return ref[:i], ref[i+1:], nil
+}
@@ -0,0 +1,23 @@
+//go:build darwin || windows || linux || freebsd || netbsd || openbsd
+
+package keychain
+
+import (
+	"errors"
+
+	"github.com/zalando/go-keyring"
+)
+
+// get reads the secret of an account of a service from the platform secret store.
+func get(service, account string) (string, error) {
+	secret, err := keyring.Get(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", errNotFound
+	}
+	return secret, err
+}
+
+// set stores the secret of an account of a service in the platform secret store.
+func set(service, account, secret string) error {
+	return keyring.Set(service, account, secret)
+}
@@ -0,0 +1,19 @@
+//go:build !(darwin || windows || linux || freebsd || netbsd || openbsd)
+
+package keychain
+
+import "errors"
+
+// errUnsupported is returned on platforms without a secret store supported by go-keyring,
+// whose Secret Service client does not build there.
+var errUnsupported = errors.New("keychain not supported on this platform")
+
+// get reports that the keychain is not supported.
+func get(_, _ string) (string, error) {
+	return "", errUnsupported
+}
+
+// set reports that the keychain is not supported.
+func set(_, _, _ string) error {
+	return errUnsupported
+}
@@ -0,0 +1,83 @@
+package keys
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"jwtdecode/config"
+	"jwtdecode/inputformat"
+	"jwtdecode/keychain"
+	"jwtdecode/terminal"
+	"jwtdecode/token"
+)
+
+// maxTokenSizeMB bounds the size of a stored token, like the default -max-token-size.
+const maxTokenSizeMB = 1
+
+// Main runs the keys subcommand with its command-line arguments, reporting to w.
+func Main(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: jwtdecode keys store-token [-token-file <file>] <service>/<account> | jwtdecode keys watch -issuer <url>")
+	}
+	switch args[0] {
+	case "store-token":
+		return storeToken(args[1:], w)
+	case "watch":
+		return watch(args[1:], w)
+	default:
+		return fmt.Errorf("unknown keys command %q", args[0])
+	}
+}
+
+// storeToken saves a token in the platform secret store, so that it can be decoded with
+// -token-keychain. The token is read from a file or from stdin, never from the command line,
+// to keep it out of shell history and process listings.
+func storeToken(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("keys store-token", flag.ContinueOnError)
+	tokenFile := fs.String("token-file", "", "File containing the token to store (default: read from stdin)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: jwtdecode keys store-token [-token-file <file>] <service>/<account>")
+	}
+	ref := fs.Arg(0)
+
+	var jwtToken string
+	if *tokenFile != "" {
+		var err error
+		jwtToken, err = token.GetToken(config.TokenTypeFile, *tokenFile, token.Options{
+			Warn:    config.Warn,
+			MaxSize: maxTokenSizeMB * 1024 * 1024,
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		if terminal.IsTerminal(os.Stdin) {
+			fmt.Fprintln(os.Stderr, "Paste the token and press Enter:")
+		}
+		// Read one byte past the limit, so that an oversized token fails validation below
+		reader := bufio.NewReader(io.LimitReader(os.Stdin, maxTokenSizeMB*1024*1024+1))
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("reading token from stdin: %w", err)
+		}
+		jwtToken = strings.TrimSpace(line)
+		if jwtToken == "" {
+			return fmt.Errorf("no token on stdin")
+		}
+	}
+	if err := config.ValidateToken(jwtToken, maxTokenSizeMB, inputformat.JWS); err != nil {
+		return err
+	}
+	if err := keychain.Set(ref, jwtToken); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Stored token in the keychain as %s\n", ref)
+	return nil
+}
@@ -0,0 +1,152 @@
+package keys
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	"jwtdecode/discovery"
+	"jwtdecode/httpfetch"
+	"jwtdecode/jwks"
+)
+
+// keyChange is a key in a rotation event, with its RFC 7638 thumbprint.
+type keyChange struct {
+	Thumbprint string `json:"thumbprint"`
+	jwks.Key
+}
+
+// rotation is the JSON event posted to the webhook when the key set changes.
+type rotation struct {
+	Event   string      `json:"event"` // Always "rotation"
+	Issuer  string      `json:"issuer"`
+	JWKSURI string      `json:"jwks_uri"`
+	Time    time.Time   `json:"time"`
+	Added   []keyChange `json:"added"`
+	Removed []keyChange `json:"removed"`
+}
+
+// watch polls the key set of an issuer and logs the keys added and removed between
+// two polls, posting each rotation to the webhook. It runs until interrupted. Fetch and
+// webhook failures are reported as warnings, so that the watch survives outages.
+func watch(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("keys watch", flag.ContinueOnError)
+	issuer := fs.String("issuer", "", "Issuer URL whose discovery document names the watched JWKS")
+	interval := fs.Duration("interval", 5*time.Minute, "Time between two polls of the JWKS")
+	webhook := fs.String("webhook", "", "URL receiving a JSON event when keys are added or removed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if *issuer == "" {
+		return fmt.Errorf("usage: jwtdecode keys watch -issuer <url> [-interval <duration>] [-webhook <url>]")
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("-interval must be positive")
+	}
+	if *webhook != "" {
+		u, err := url.Parse(*webhook)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid -webhook %q: an http or https URL is required", *webhook)
+		}
+	}
+
+	doc, err := discovery.Fetch(*issuer)
+	if err != nil {
+		return err
+	}
+	jwksURI, _ := doc.Metadata["jwks_uri"].(string)
+	if doc.Keys == nil || jwksURI == "" {
+		return fmt.Errorf("discovery document of %s has no jwks_uri", *issuer)
+	}
+	known, err := thumbprints(doc.Keys)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s watching %d keys at %s\n", time.Now().Format(time.RFC3339), len(known), jwksURI)
+	for _, change := range sorted(known) {
+		fmt.Fprintf(w, "%s key present %s: %s\n", time.Now().Format(time.RFC3339), change.Thumbprint, discovery.Describe(change.Key))
+	}
+
+	for {
+		time.Sleep(*interval)
+		current, err := poll(jwksURI)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		event := rotation{Event: "rotation", Issuer: doc.Issuer(), JWKSURI: jwksURI, Time: time.Now().UTC(), Added: []keyChange{}, Removed: []keyChange{}}
+		for _, change := range sorted(current) {
+			if _, ok := known[change.Thumbprint]; !ok {
+				event.Added = append(event.Added, change)
+			}
+		}
+		for _, change := range sorted(known) {
+			if _, ok := current[change.Thumbprint]; !ok {
+				event.Removed = append(event.Removed, change)
+			}
+		}
+		known = current
+		if len(event.Added) == 0 && len(event.Removed) == 0 {
+			continue
+		}
+		for _, change := range event.Added {
+			fmt.Fprintf(w, "%s key added %s: %s\n", event.Time.Format(time.RFC3339), change.Thumbprint, discovery.Describe(change.Key))
+		}
+		for _, change := range event.Removed {
+			fmt.Fprintf(w, "%s key removed %s: %s\n", event.Time.Format(time.RFC3339), change.Thumbprint, discovery.Describe(change.Key))
+		}
+		if *webhook != "" {
+			body, err := json.Marshal(event)
+			if err == nil {
+				err = httpfetch.Post(*webhook, body)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: sending webhook: %v\n", err)
+			}
+		}
+	}
+}
+
+// poll fetches the current key set, bypassing the fetch cache.
+func poll(jwksURI string) (map[string]keyChange, error) {
+	data, err := httpfetch.Refresh(jwksURI, 0)
+	if err != nil {
+		return nil, err
+	}
+	set, err := jwks.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", jwksURI, err)
+	}
+	return thumbprints(set)
+}
+
+// sorted returns the keys of an index in thumbprint order.
+func sorted(keys map[string]keyChange) []keyChange {
+	list := make([]keyChange, 0, len(keys))
+	for _, change := range keys {
+		list = append(list, change)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Thumbprint < list[j].Thumbprint })
+	return list
+}
+
+// thumbprints indexes the keys of a set by thumbprint.
+func thumbprints(set *jwks.Set) (map[string]keyChange, error) {
+	keys := make(map[string]keyChange, len(set.Keys))
+	for _, key := range set.Keys {
+		thumbprint, err := discovery.Thumbprint(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key.Kid, err)
+		}
+		keys[thumbprint] = keyChange{Thumbprint: thumbprint, Key: key}
+	}
+	return keys, nil
+}
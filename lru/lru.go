@@ -0,0 +1,99 @@
+package lru
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is a fixed-capacity cache evicting the least recently used entry, safe for
+// concurrent use. It counts lookups that hit and missed.
+type Cache[V any] struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List               // Entries, most recently used first
+	entries  map[string]*list.Element // Elements of order, by key
+	hits     uint64
+	misses   uint64
+}
+
+// entry is a cached value with its key, as held in the order list.
+type entry[V any] struct {
+	key   string
+	value V
+}
+
+// Stats are the size and lookup counters of a cache.
+type Stats struct {
+	Size     int    `json:"size"`
+	Capacity int    `json:"capacity"`
+	Hits     uint64 `json:"hits"`
+	Misses   uint64 `json:"misses"`
+}
+
+// New returns an empty cache holding up to capacity entries.
+func New[V any](capacity int) *Cache[V] {
+	return &Cache[V]{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element, capacity),
+	}
+}
+
+// Get returns the value cached for key, marking it as recently used.
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.hits++
+		c.order.MoveToFront(el)
+		return el.Value.(*entry[V]).value, true
+	}
+	c.misses++
+	var zero V
+	return zero, false
+}
+
+// Add caches value for key, evicting the least recently used entry when the cache is full.
+func (c *Cache[V]) Add(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*entry[V]).value = value
+		c.order.MoveToFront(el)
+		return
+	}
+	if c.capacity <= 0 {
+		return
+	}
+	if c.order.Len() >= c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry[V]).key)
+	}
+	c.entries[key] = c.order.PushFront(&entry[V]{key: key, value: value})
+}
+
+// Remove drops the entry of key, if any.
+func (c *Cache[V]) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+// Purge drops every entry, keeping the lookup counters.
+func (c *Cache[V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+}
+
+// Stats returns the current size and lookup counters of the cache.
+func (c *Cache[V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{Size: c.order.Len(), Capacity: c.capacity, Hits: c.hits, Misses: c.misses}
+}
@@ -1,94 +1,614 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 
+	"jwtdecode/bench"
+	"jwtdecode/checkpoint"
+	"jwtdecode/claimdoc"
+	"jwtdecode/claimenv"
 	"jwtdecode/config"
+	"jwtdecode/convert"
+	"jwtdecode/discovery"
+	"jwtdecode/envelope"
+	"jwtdecode/family"
+	"jwtdecode/fixtures"
 	"jwtdecode/formatter"
+	"jwtdecode/jsonrpc"
+	"jwtdecode/jwterrors"
+	"jwtdecode/keys"
+	"jwtdecode/monitor"
 	"jwtdecode/output"
+	"jwtdecode/pager"
+	"jwtdecode/partition"
+	"jwtdecode/pkg/jwtdecode"
+	"jwtdecode/provenance"
+	"jwtdecode/roundtrip"
+	"jwtdecode/secure"
+	"jwtdecode/snapshot"
+	"jwtdecode/telemetry"
+	"jwtdecode/terminal"
+	"jwtdecode/tokendiff"
+	"jwtdecode/usagestats"
+	"jwtdecode/utils"
 )
 
+// exitSignatureInvalid is the exit status of a run whose token does not verify with the key
+// supplied on the command line (-verify-hmac-secret, -verify-key). Other errors exit with status 1.
+const exitSignatureInvalid = 3
+
+// exitClaimMissing is the exit status of a run whose token does not have the -has-claim claim.
+const exitClaimMissing = 4
+
+// exitTokenNotValid is the exit status of a -validate run whose token is expired or not
+// yet valid.
+const exitTokenNotValid = 5
+
 var (
 	// version is set by the build process
 	version = "dev"
+
+	// tokenBuf holds the token in locked memory when hardening is enabled.
+	// It is wiped on every exit path.
+	tokenBuf *secure.Buffer
+
+	// endTelemetry ends the span of the run and flushes the telemetry exporters.
+	// It is called on every exit path once telemetry is set up.
+	endTelemetry = func() {}
+
+	// errorFormat is the -error-format of the run, once the configuration is loaded.
+	errorFormat = config.ErrorFormatText
 )
 
 // main is the entry point of the jwtdecode application.
 // It orchestrates the configuration loading, token parsing, claims preprocessing,
 // formatting, and final output writing.
 func main() {
+	// Subcommands take their own flags and are dispatched before the main flags are parsed
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "bench":
+			if err := bench.Main(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error running benchmark: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "snapshot":
+			if err := snapshot.Main(os.Args[2:], os.Stdout); err != nil {
+				if !errors.Is(err, snapshot.ErrDifferences) {
+					fmt.Fprintf(os.Stderr, "Error comparing snapshots: %v\n", err)
+				}
+				os.Exit(1)
+			}
+			return
+		case "monitor":
+			if err := monitor.Main(os.Args[2:], os.Stdout); err != nil {
+				if !errors.Is(err, monitor.ErrExpiring) {
+					fmt.Fprintf(os.Stderr, "Error monitoring token: %v\n", err)
+				}
+				os.Exit(1)
+			}
+			return
+		case "keys":
+			if err := keys.Main(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error managing keys: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "exec":
+			if err := claimenv.Main(os.Args[2:]); err != nil {
+				// The exit status of the command is passed on where it runs as a child process
+				var exitErr *exec.ExitError
+				if errors.As(err, &exitErr) {
+					os.Exit(exitErr.ExitCode())
+				}
+				fmt.Fprintf(os.Stderr, "Error running command: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "issuers":
+			if err := discovery.Main(os.Args[2:], os.Stdout); err != nil {
+				if !errors.Is(err, discovery.ErrDrift) {
+					fmt.Fprintf(os.Stderr, "Error comparing issuers: %v\n", err)
+				}
+				os.Exit(1)
+			}
+			return
+		case "explain":
+			if err := claimdoc.Main(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error explaining claim: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "convert":
+			if err := convert.Main(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error converting claims: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "families":
+			if err := family.Main(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error correlating token families: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "config":
+			if err := config.Main(os.Args[2:], os.Stdout); err != nil {
+				if !errors.Is(err, config.ErrInvalid) {
+					fmt.Fprintf(os.Stderr, "Error validating configuration: %v\n", err)
+				}
+				os.Exit(1)
+			}
+			return
+		case "diff":
+			if err := tokendiff.Main(os.Args[2:], os.Stdout); err != nil {
+				if !errors.Is(err, tokendiff.ErrDifferences) {
+					fmt.Fprintf(os.Stderr, "Error comparing tokens: %v\n", err)
+				}
+				os.Exit(1)
+			}
+			return
+		case "fixtures":
+			if err := fixtures.Main(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error generating fixtures: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "stats":
+			if err := usagestats.Main(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading usage statistics: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	// 1. Load configuration (flags, config file, or environment)
 	appConfig, err := config.LoadConfig(version)
+	if appConfig != nil {
+		errorFormat = appConfig.ErrorFormat
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
-		os.Exit(1)
+		logAndExit("Error loading configuration: %v", err)
+	}
+
+	// -dry-run prints the plan of the run without reading the token or writing anything
+	if appConfig.DryRun {
+		if err := appConfig.WritePlan(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing the plan: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Move the token into locked memory so it is zeroed after use. The string it was read
+	// into cannot be cleared, as Go strings are immutable, so it is dropped for the string
+	// of the buffer; the token sources clear the bytes they read it from.
+	if appConfig.Harden {
+		raw := []byte(appConfig.JWTToken)
+		tokenBuf, err = secure.NewBuffer(raw)
+		clear(raw)
+		if err != nil {
+			logAndExit("Error enabling hardening: %v", err)
+		}
+		appConfig.JWTToken = tokenBuf.String()
+	}
+
+	// Export traces and metrics when the standard OpenTelemetry variables configure OTLP
+	shutdownTelemetry, err := telemetry.Setup(context.Background(), version, config.Warn)
+	if err != nil {
+		logAndExit("Error setting up telemetry: %v", err)
+	}
+	ctx, runSpan := telemetry.Tracer().Start(context.Background(), "jwtdecode")
+	endTelemetry = func() {
+		runSpan.End()
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTelemetry(flushCtx); err != nil {
+			config.Warn(fmt.Sprintf("flushing telemetry: %v", err))
+		}
+	}
+
+	// Count the run in the local usage statistics when opted in; they are never transmitted
+	if appConfig.UsageStats {
+		recordUsage(appConfig)
 	}
 
 	// 2. Execution logic start
 	if !appConfig.IsSilent {
-		fmt.Println("Decoding JWT token...")
-		// Print a snippet of the token for immediate user confirmation
-		printTokenSnippet(appConfig.JWTToken)
+		if appConfig.Batch() {
+			source := appConfig.TokenList
+			if source == "" {
+				source = appConfig.TokenDir
+			}
+			fmt.Printf("Decoding JWT tokens from %s...\n", source)
+		} else {
+			fmt.Println("Decoding JWT token...")
+			if appConfig.TokenEnvVar != "" {
+				fmt.Printf("Token read from environment variable %s\n", appConfig.TokenEnvVar)
+			}
+			// Identify the token for immediate user confirmation without leaking its content
+			if appConfig.ShowSnippet {
+				printTokenSnippet(appConfig.JWTToken, appConfig.SnippetLength)
+			} else {
+				printTokenFingerprint(appConfig.JWTToken)
+			}
+		}
 	}
 
-	// 3. Parse the JWT token (unverified as we are only decoding claims)
-	token, _, err := new(jwt.Parser).ParseUnverified(appConfig.JWTToken, jwt.MapClaims{})
+	// 3. Resolve the provider and trust configuration shared by all tokens
+	dec, err := jwtdecode.FromConfig(appConfig)
 	if err != nil {
-		logAndExit("Error parsing JWT token: %v", err)
+		logAndExit("Error %v", err)
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		logAndExit("Error: Could not extract claims from token.")
+	// Serve decode and verify requests over stdin and stdout until the client exits
+	if appConfig.JSONRPC {
+		r := newReloader(dec)
+		go r.watch()
+		// Requests are traces of their own rather than children of the session span
+		if err := jsonrpc.Serve(os.Stdin, os.Stdout, r.handler(context.Background()), maxRequestSize(appConfig)); err != nil {
+			logAndExit("Error serving JSON-RPC: %v", err)
+		}
+		endTelemetry()
+		return
 	}
 
-	// 4. Pre-process claims (e.g., handle epoch-to-human-readable conversion)
-	processedClaims := formatter.PreprocessClaims(claims, appConfig.ConvertEpoch, appConfig.EpochUnit)
+	// Serve decode and verify requests over HTTP until the process is interrupted
+	if appConfig.Serve != "" {
+		r := newReloader(dec)
+		go r.watch()
+		fmt.Fprintf(os.Stderr, "Serving decode and verify requests on %s\n", appConfig.Serve)
+		if err := serveHTTP(r, appConfig.Serve); err != nil {
+			logAndExit("Error serving HTTP: %v", err)
+		}
+		endTelemetry()
+		return
+	}
 
-	// 5. Format the claims into the requested output format (JSON, CSV, or XML)
-	var outputData []byte
-	switch appConfig.OutputFormat {
-	case config.OutputFormatJSON:
-		outputData, err = formatter.FormatJSON(processedClaims)
-	case config.OutputFormatCSV:
-		outputData, err = formatter.FormatCSV(processedClaims)
-	case config.OutputFormatXML:
-		outputData, err = formatter.FormatXML(processedClaims)
+	// 4. Decode, verify, and annotate the token, or each token of the list or directory
+	var results []*jwtdecode.Result
+	var skipped []error
+	switch {
+	case appConfig.TokenList != "":
+		results, skipped, err = dec.DecodeList(ctx, appConfig.TokenList)
+	case appConfig.TokenDir != "":
+		results, skipped, err = dec.DecodeDir(ctx, appConfig.TokenDir)
 	default:
-		logAndExit("Error: Unknown output format %q.", appConfig.OutputFormat)
+		var d *jwtdecode.Result
+		d, err = dec.DecodeNamed(ctx, appConfig.JWTToken, appConfig.SnapshotName, "")
+		results = []*jwtdecode.Result{d}
+	}
+	if err != nil {
+		if hookErr := runExecHook(appConfig, nil, err); hookErr != nil {
+			config.Warn(hookErr.Error())
+		}
+		logAndExitCode(exitStatus(err), "Error %v", err)
+	}
+	// With -keep-going, the tokens that could not be decoded are reported, and fail the run
+	// once the output of the others is written
+	for _, err := range skipped {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+	}
+	if appConfig.KeepGoing && !appConfig.IsSilent {
+		fmt.Printf("Batch summary: %d tokens, %d decoded, %d failed\n", len(results)+len(skipped), len(results), len(skipped))
+	}
+
+	// 5. Format and write the output file, or one file per partition of a batch, or print the -get claim
+	switch {
+	case appConfig.QuietOutput:
+		// The exit status is the only result
+	case appConfig.Get != "":
+		claims := results[0].Claims
+		if terminal.IsTerminal(os.Stdout) {
+			claims = formatter.StripControlCharacters(claims)
+		}
+		err = printClaim(os.Stdout, claims, appConfig.Get)
+	case len(appConfig.PartitionBy) > 0:
+		err = writePartitions(appConfig, results)
+	default:
+		err = writeOutput(appConfig, appConfig.OutputFile, results, appConfig.NDJSON)
+	}
+	if err != nil {
+		logAndExit("Error %v", err)
+	}
+	if appConfig.Full {
+		var report bytes.Buffer
+		for i, d := range results {
+			label := ""
+			switch {
+			case d.Source != "":
+				label = d.Source
+			case appConfig.Batch():
+				label = fmt.Sprintf("token %d", i+1)
+			}
+			printFullReport(&report, appConfig, d, label)
+		}
+		if appConfig.NoPager {
+			_, err = os.Stdout.Write(report.Bytes())
+		} else {
+			err = pager.Page(os.Stdout, report.Bytes(), config.Warn)
+		}
+		if err != nil {
+			logAndExit("Error %v", err)
+		}
+	}
+	// The run is complete once its output is written, so it is not resumed again
+	if appConfig.Resume {
+		if err := checkpoint.Remove(appConfig.CheckpointFile); err != nil {
+			config.Warn(err.Error())
+		}
+	}
+
+	// Run the -exec command for the outcome of the run, now that the output file exists
+	if err := runExecHook(appConfig, results, nil); err != nil {
+		logAndExit("Error %v", err)
+	}
+
+	// The output carries the full report and findings, so these failures are reported after it is written
+	var deferredFailures []string
+	if len(skipped) > 0 {
+		deferredFailures = append(deferredFailures, fmt.Sprintf("%d of %d tokens could not be decoded", len(skipped), len(results)+len(skipped)))
+	}
+	for i, d := range results {
+		for _, failure := range d.Failures {
+			switch {
+			case d.Source != "":
+				failure = fmt.Sprintf("%s: %s", d.Source, failure)
+			case appConfig.Batch():
+				failure = fmt.Sprintf("token %d: %s", i+1, failure)
+			}
+			deferredFailures = append(deferredFailures, failure)
+		}
+	}
+	if len(deferredFailures) > 0 {
+		logAndExit("Error: %s", strings.Join(deferredFailures, "; "))
 	}
 
+	// An expired or not yet valid token is a result of -validate, reported by the exit status
+	if appConfig.Validate {
+		var notValid error
+		for i, d := range results {
+			err := d.ValidityError()
+			if err == nil {
+				continue
+			}
+			notValid = err
+			if !appConfig.IsSilent {
+				fmt.Printf("Token %d is not valid: %s\n", i+1, d.NotValid)
+			}
+		}
+		if notValid != nil {
+			tokenBuf.Wipe()
+			endTelemetry()
+			tokenBuf.Destroy()
+			os.Exit(exitStatus(notValid))
+		}
+	}
+
+	// A missing -has-claim claim is a result rather than an error, reported by the exit status alone
+	if appConfig.HasClaim != "" {
+		for i, d := range results {
+			if hasClaim(d.Claims, appConfig.HasClaim, appConfig.NonEmpty) {
+				continue
+			}
+			if !appConfig.IsSilent {
+				fmt.Printf("Token %d does not have claim %q\n", i+1, appConfig.HasClaim)
+			}
+			tokenBuf.Wipe()
+			endTelemetry()
+			tokenBuf.Destroy()
+			os.Exit(exitClaimMissing)
+		}
+	}
+	tokenBuf.Wipe()
+	endTelemetry()
+	tokenBuf.Destroy()
+}
+
+// writeOutput formats the decoded tokens, checks the output size, and writes the output
+// file, followed by the provenance sidecar for JSON output. With ndjson, JSON output is
+// newline-delimited instead of an array.
+func writeOutput(appConfig *config.AppConfig, outputFile string, results []*jwtdecode.Result, ndjson bool) error {
+	outputData, err := jwtdecode.Format(appConfig, results, ndjson)
 	if err != nil {
-		logAndExit("Error formatting output: %v", err)
+		return fmt.Errorf("formatting output: %w", err)
 	}
 
-	// 6. Security Check: Prevent Resource Exhaustion (Output Size)
+	// Lossy conversions fail the run like findings, once the output is written
+	if appConfig.VerifyRoundtrip {
+		if err := checkRoundtrip(appConfig, outputData, results); err != nil {
+			return err
+		}
+	}
+
+	// Security Check: Prevent Resource Exhaustion (Output Size)
 	if len(outputData) > appConfig.MaxOutputSize*1024*1024 {
-		logAndExit("Error: Formatted output size exceeds %dMB limit.", appConfig.MaxOutputSize)
+		return jwterrors.Wrap(jwterrors.ErrOutputTooLarge, fmt.Errorf("checking output size: formatted output exceeds %dMB limit", appConfig.MaxOutputSize))
 	}
 
-	// 7. Persist the output to the specified file
-	if err := output.WriteOutput(outputData, appConfig.OutputFile); err != nil {
-		logAndExit("Error writing output to file: %v", err)
+	if err := output.WriteOutput(outputData, outputFile, output.Options{
+		StrictPermissions: appConfig.StrictPerms,
+		Warn:              config.Warn,
+		Page:              !appConfig.NoPager,
+	}); err != nil {
+		return fmt.Errorf("writing output to file: %w", err)
+	}
+	if !appConfig.IsSilent {
+		fmt.Printf("Successfully wrote output to %s\n", outputFile)
 	}
 
+	// JSON has no room for metadata, so claim sources go to a sidecar file
+	if appConfig.Provenance && appConfig.OutputFormat == config.OutputFormatJSON {
+		var sources interface{} = results[0].Sources
+		if appConfig.Batch() {
+			list := make([]map[string]string, len(results))
+			for i, d := range results {
+				list[i] = d.Sources
+			}
+			sources = list
+		}
+		sidecar, err := json.MarshalIndent(sources, "", "  ")
+		if err != nil {
+			return fmt.Errorf("formatting provenance: %w", err)
+		}
+		sidecarFile := provenance.SidecarPath(outputFile)
+		if err := output.WriteOutput(sidecar, sidecarFile, output.Options{
+			StrictPermissions: appConfig.StrictPerms,
+			Warn:              config.Warn,
+		}); err != nil {
+			return fmt.Errorf("writing provenance to file: %w", err)
+		}
+		if !appConfig.IsSilent {
+			fmt.Printf("Successfully wrote claim provenance to %s\n", sidecarFile)
+		}
+	}
+	return nil
+}
+
+// writePartitions splits the decoded tokens of a token list by the partition template
+// and writes one output file per partition, creating its directories as needed. JSON
+// partitions are newline-delimited. Tokens keep their input order within a partition.
+func writePartitions(appConfig *config.AppConfig, results []*jwtdecode.Result) error {
+	partitioner, err := partition.New(appConfig.PartitionBy, appConfig.PartitionTemplate, config.PartitionExtension(appConfig.OutputFormat))
+	if err != nil {
+		return err
+	}
+	var paths []string
+	groups := make(map[string][]*jwtdecode.Result)
+	for _, d := range results {
+		path := partitioner.Path(d.Claims)
+		if _, ok := groups[path]; !ok {
+			paths = append(paths, path)
+		}
+		groups[path] = append(groups[path], d)
+	}
+	for _, path := range paths {
+		sanitizedPath, err := utils.SanitizeFilePath(path)
+		if err != nil {
+			return fmt.Errorf("sanitizing partition path: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(sanitizedPath), 0700); err != nil {
+			return fmt.Errorf("creating partition directory: %w", err)
+		}
+		if err := writeOutput(appConfig, sanitizedPath, groups[path], true); err != nil {
+			return err
+		}
+	}
 	if !appConfig.IsSilent {
-		fmt.Printf("Successfully wrote output to %s\n", appConfig.OutputFile)
+		fmt.Printf("Wrote %d tokens to %d partition files\n", len(results), len(paths))
+	}
+	return nil
+}
+
+// checkRoundtrip reads the formatted output back and records each claim value it does not
+// preserve as a failure of its token.
+func checkRoundtrip(appConfig *config.AppConfig, outputData []byte, results []*jwtdecode.Result) error {
+	claimsList := make([]jwt.MapClaims, len(results))
+	var sources []map[string]string
+	for i, d := range results {
+		claimsList[i] = d.Claims
+		// The XML watermark is an attribute, which is not read back
+		if d.Watermarked(appConfig) && appConfig.OutputFormat != config.OutputFormatXML {
+			claimsList[i] = formatter.Watermark(d.Claims)
+		}
+		if appConfig.Provenance {
+			sources = append(sources, d.Sources)
+		}
+	}
+	losses, err := roundtrip.Check(appConfig.OutputFormat, outputData, claimsList, sources, appConfig.FormatterOptions())
+	if err != nil {
+		return fmt.Errorf("verifying round trip: %w", err)
+	}
+	for i, d := range results {
+		for _, loss := range losses[i] {
+			d.Failures = append(d.Failures, "lossy output: "+loss)
+		}
+	}
+	return nil
+}
+
+// exitStatus returns the exit status of a run failing with err, by its category (see the
+// jwterrors package): exitSignatureInvalid for a signature that does not verify,
+// exitTokenNotValid for an expired or not yet valid token, and 1 otherwise.
+func exitStatus(err error) int {
+	switch {
+	case errors.Is(err, jwterrors.ErrSignatureInvalid):
+		return exitSignatureInvalid
+	case errors.Is(err, jwterrors.ErrExpired), errors.Is(err, jwterrors.ErrNotYetValid):
+		return exitTokenNotValid
+	default:
+		return 1
+	}
+}
+
+// recordUsage adds the run to the usage statistics file: its output format, provider, and
+// the names of the options given. A statistics file that cannot be written is a warning.
+func recordUsage(appConfig *config.AppConfig) {
+	path, err := usagestats.DefaultPath()
+	if err == nil {
+		err = usagestats.Record(path, usagestats.Run{
+			Format:   appConfig.OutputFormat,
+			Provider: appConfig.Provider,
+			Features: appConfig.Features(),
+		}, time.Now())
+	}
+	if err != nil {
+		config.Warn(fmt.Sprintf("recording usage statistics: %v", err))
 	}
 }
 
 // logAndExit prints a formatted message to stderr and exits with status 1.
+// When hardening is enabled, the token is scrubbed from the message and wiped before exiting.
 func logAndExit(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, format+"\n", args...)
-	os.Exit(1)
+	logAndExitCode(1, format, args...)
+}
+
+// logAndExitCode is logAndExit with a specific exit status. With -error-format json, the
+// message is printed in the error envelope, coded after the first error of args.
+func logAndExitCode(code int, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if tokenBuf != nil {
+		msg = secure.Scrub(msg, tokenBuf.String())
+		tokenBuf.Wipe()
+	}
+	if errorFormat == config.ErrorFormatJSON {
+		var err error
+		for _, arg := range args {
+			if e, ok := arg.(error); ok {
+				err = e
+				break
+			}
+		}
+		if data, marshalErr := json.Marshal(envelope.NewError(err, msg)); marshalErr == nil {
+			msg = string(data)
+		}
+	}
+	fmt.Fprintln(os.Stderr, msg)
+	endTelemetry()
+	tokenBuf.Destroy()
+	os.Exit(code)
+}
+
+// printTokenFingerprint prints the SHA-256 fingerprint of the token for user feedback.
+// Unlike a snippet, the fingerprint does not reveal any part of the header or payload.
+func printTokenFingerprint(token string) {
+	fmt.Printf("Token SHA-256: %s\n", jwtdecode.TokenHash(token))
 }
 
 // printTokenSnippet prints a snippet of the token for user feedback.
-func printTokenSnippet(token string) {
-	snippetLength := 15
+func printTokenSnippet(token string, snippetLength int) {
 	if len(token) > snippetLength*2+3 {
 		fmt.Printf("Token: %s...%s\n", token[:snippetLength], token[len(token)-snippetLength:])
 	} else {
@@ -0,0 +1,195 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/hook"
+	"jwtdecode/httpfetch"
+	"jwtdecode/token"
+)
+
+// ErrExpiring is returned when the monitored token is about to expire or has expired.
+var ErrExpiring = errors.New("token is about to expire")
+
+// Placeholders are the names replaced in the -exec command.
+var Placeholders = []string{"event", "token_file", "exp", "remaining", "sub", "iss"}
+
+// Options configures a monitor run.
+type Options struct {
+	TokenType  string        // "file" or "environment", as for token.GetToken
+	TokenValue string        // Token file path or environment variable name
+	WarnBefore time.Duration // Remaining lifetime at which the token is reported as expiring
+	Interval   time.Duration // Time between two checks
+	Webhook    string        // URL receiving a JSON event when the token is expiring (optional)
+	Exec       *hook.Command // Command run when the token is expiring (optional)
+	Warn       func(string)  // Receives non-fatal warnings about the token source; may be nil
+}
+
+// Status is the expiry state of the token at one check.
+type Status struct {
+	Event     string    `json:"event"` // "ok", "expiring", or "expired"
+	TokenFile string    `json:"token_file,omitempty"`
+	Expiry    time.Time `json:"exp"`
+	Remaining int64     `json:"remaining"` // Seconds until expiry, negative once expired
+	Subject   string    `json:"sub,omitempty"`
+	Issuer    string    `json:"iss,omitempty"`
+}
+
+// Main runs the monitor subcommand with its command-line arguments, reporting to w.
+func Main(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("monitor", flag.ContinueOnError)
+	tokenFile := fs.String("token-file", "", "File containing the monitored JWT token, read again at every check")
+	tokenEnv := fs.Bool("token-env", false, "Monitor the JWT token in the JWT_TOKEN environment variable")
+	warnBefore := fs.Duration("warn-before", 5*time.Minute, "Remaining lifetime at which the token is reported as expiring")
+	interval := fs.Duration("interval", time.Minute, "Time between two checks")
+	webhook := fs.String("webhook", "", "URL receiving a JSON event when the token is expiring")
+	execCommand := fs.String("exec", "", "Command run without a shell when the token is expiring, e.g. 'notify-send {event} {remaining}'")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	// The token is read at every check, so each warning is only printed once
+	warned := map[string]bool{}
+	opts := Options{WarnBefore: *warnBefore, Interval: *interval, Webhook: *webhook, Warn: func(msg string) {
+		if !warned[msg] {
+			warned[msg] = true
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+		}
+	}}
+	switch {
+	case *tokenFile != "" && *tokenEnv:
+		return fmt.Errorf("-token-file and -token-env are mutually exclusive")
+	case *tokenFile != "":
+		opts.TokenType, opts.TokenValue = "file", *tokenFile
+	case *tokenEnv:
+		opts.TokenType = "environment"
+	default:
+		return fmt.Errorf("a token source is required (-token-file or -token-env)")
+	}
+	if *execCommand != "" {
+		cmd, err := hook.Parse(*execCommand, Placeholders)
+		if err != nil {
+			return fmt.Errorf("invalid -exec: %w", err)
+		}
+		opts.Exec = cmd
+	}
+	return Run(opts, w)
+}
+
+// Run checks the token until it is about to expire, reporting its remaining lifetime
+// to w at every check. It then fires the hooks and returns ErrExpiring.
+func Run(opts Options, w io.Writer) error {
+	if opts.WarnBefore < 0 {
+		return fmt.Errorf("-warn-before must not be negative")
+	}
+	if opts.Interval <= 0 {
+		return fmt.Errorf("-interval must be positive")
+	}
+	if opts.Webhook != "" {
+		u, err := url.Parse(opts.Webhook)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid -webhook %q: an http or https URL is required", opts.Webhook)
+		}
+	}
+
+	for {
+		status, err := Check(opts, time.Now())
+		if err != nil {
+			return err
+		}
+		remaining := time.Duration(status.Remaining) * time.Second
+		if status.Event == "expired" {
+			fmt.Fprintf(w, "%s token expired at %s (%s ago)\n", time.Now().Format(time.RFC3339), status.Expiry.Format(time.RFC3339), -remaining)
+		} else {
+			fmt.Fprintf(w, "%s token expires at %s (in %s)\n", time.Now().Format(time.RFC3339), status.Expiry.Format(time.RFC3339), remaining)
+		}
+		if status.Event != "ok" {
+			if err := fire(opts, status, w); err != nil {
+				return err
+			}
+			return fmt.Errorf("%w: %s", ErrExpiring, status.Event)
+		}
+		// Wake up in time to warn, even when that is sooner than the next check
+		time.Sleep(max(time.Second, min(opts.Interval, time.Until(status.Expiry)-opts.WarnBefore)))
+	}
+}
+
+// Check reads the token and returns its expiry state at now. The signature is not
+// verified, since only the expiry is of interest.
+func Check(opts Options, now time.Time) (Status, error) {
+	raw, err := token.GetToken(opts.TokenType, opts.TokenValue, token.Options{
+		Warn:    opts.Warn,
+		MaxSize: 1 << 20,
+	})
+	if err != nil {
+		return Status{}, err
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(raw, claims); err != nil {
+		return Status{}, fmt.Errorf("parsing JWT token: %w", err)
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return Status{}, fmt.Errorf("reading exp claim: %w", err)
+	}
+	if exp == nil {
+		return Status{}, fmt.Errorf("token has no exp claim")
+	}
+
+	status := Status{Expiry: exp.UTC(), Remaining: int64(exp.Sub(now).Truncate(time.Second).Seconds())}
+	if opts.TokenType == "file" {
+		status.TokenFile = opts.TokenValue
+	}
+	status.Subject, _ = claims.GetSubject()
+	status.Issuer, _ = claims.GetIssuer()
+	switch remaining := exp.Sub(now); {
+	case remaining <= 0:
+		status.Event = "expired"
+	case remaining <= opts.WarnBefore:
+		status.Event = "expiring"
+	default:
+		status.Event = "ok"
+	}
+	return status, nil
+}
+
+// fire posts the status to the webhook and runs the command with its output to w, attempting both
+// before reporting their failures.
+func fire(opts Options, status Status, w io.Writer) error {
+	var errs []error
+	if opts.Webhook != "" {
+		body, err := json.Marshal(status)
+		if err == nil {
+			err = httpfetch.Post(opts.Webhook, body)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sending webhook: %w", err))
+		}
+	}
+	if opts.Exec != nil {
+		vars := map[string]string{
+			"event":      status.Event,
+			"token_file": status.TokenFile,
+			"exp":        status.Expiry.Format(time.RFC3339),
+			"remaining":  fmt.Sprint(status.Remaining),
+			"sub":        status.Subject,
+			"iss":        status.Issuer,
+		}
+		if err := opts.Exec.Run(vars, w, os.Stderr); err != nil {
+			errs = append(errs, fmt.Errorf("running -exec command: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
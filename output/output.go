@@ -1,18 +1,58 @@
 package output
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+
+	"jwtdecode/pager"
+	"jwtdecode/utils"
 )
 
-// WriteOutput writes data to the specified file path.
+// Stdout is the output file path that writes to standard output instead of a file.
+const Stdout = "-"
+
+// Options controls how output is written.
+type Options struct {
+	StrictPermissions bool         // Fail instead of warning when an existing output file is world-accessible
+	Warn              func(string) // Receives non-fatal warnings; may be nil
+	Page              bool         // Page output to stdout that does not fit on the terminal (see the pager package)
+}
+
+// WriteOutput writes data to the specified file path, or to stdout for Stdout.
 // It uses restricted permissions (0600) to ensure the output (e.g., JWT claims)
 // is only readable/writable by the owner, mitigating CWE-276 (G306).
-func WriteOutput(data []byte, filePath string) error {
-	// 1. Write the data to the file with owner-only permissions.
+func WriteOutput(data []byte, filePath string, opts Options) error {
+	if filePath == Stdout {
+		if opts.Page {
+			return pager.Page(os.Stdout, data, opts.Warn)
+		}
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("failed to write output to stdout: %w", err)
+		}
+		return nil
+	}
+
+	// 1. An existing file keeps its permissions when overwritten, so check them first.
+	info, err := os.Stat(filePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("inspecting output file %q: %w", filePath, err)
+	}
+	if err == nil {
+		if err := utils.CheckFileMode(filePath, info.Mode()); err != nil {
+			if opts.StrictPermissions {
+				return fmt.Errorf("insecure output file permissions: %w", err)
+			}
+			if opts.Warn != nil {
+				opts.Warn(fmt.Sprintf("insecure output file permissions: %v", err))
+			}
+		}
+	}
+
+	// 2. Write the data to the file with owner-only permissions.
 	// 0600 = Read/Write for owner, no access for others.
-	err := os.WriteFile(filePath, data, 0600)
-	if err != nil {
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
 		return fmt.Errorf("failed to write output to file %q: %w", filePath, err)
 	}
 	return nil
@@ -0,0 +1,72 @@
+package pager
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+
+	"jwtdecode/terminal"
+)
+
+// DefaultCommand is the pager run when $PAGER is not set. -R passes the escape sequences
+// of colored output through instead of showing them.
+const DefaultCommand = "less -R"
+
+// Command returns the pager command: $PAGER, or DefaultCommand when it is not set. An
+// empty $PAGER, or "cat", disables paging, as with git.
+func Command() []string {
+	command, ok := os.LookupEnv("PAGER")
+	if !ok {
+		command = DefaultCommand
+	}
+	fields := strings.Fields(command)
+	if len(fields) == 0 || fields[0] == "cat" {
+		return nil
+	}
+	return fields
+}
+
+// Page writes data to f, through the pager when f is a terminal and data does not fit on
+// its screen. Output that fits, or that goes to a pipe or a file, is written directly.
+// When the pager cannot be started, data is written directly after warning through warn.
+func Page(f *os.File, data []byte, warn func(string)) error {
+	rows, cols, ok := terminal.Size(f)
+	command := Command()
+	if !ok || command == nil || lines(data, cols) < rows {
+		return write(f, data)
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = f
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		if warn != nil {
+			warn(fmt.Sprintf("starting pager %q: %v", command[0], err))
+		}
+		return write(f, data)
+	}
+	// Quitting the pager early is not an error
+	_ = cmd.Wait()
+	return nil
+}
+
+// lines returns the number of screen lines data takes on a terminal cols wide,
+// counting long lines as wrapped.
+func lines(data []byte, cols int) int {
+	count := 0
+	for _, line := range bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) {
+		count += 1 + max(utf8.RuneCount(line)-1, 0)/cols
+	}
+	return count
+}
+
+// write writes data to f directly.
+func write(f *os.File, data []byte) error {
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("writing to stdout: %w", err)
+	}
+	return nil
+}
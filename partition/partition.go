@@ -0,0 +1,169 @@
+package partition
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Missing is the placeholder value of a claim that is absent or empty.
+const Missing = "unknown"
+
+// placeholder matches a template placeholder such as {iss} or {iss_host}.
+var placeholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// dateFields are the placeholders derived from the token's iat claim.
+var dateFields = map[string]string{
+	"date":  "2006-01-02",
+	"year":  "2006",
+	"month": "01",
+	"day":   "02",
+	"hour":  "15",
+}
+
+// Partitioner assigns each token to an output file based on its claims.
+type Partitioner struct {
+	claims   []string
+	template string
+}
+
+// New returns a Partitioner grouping tokens by the given claims. The template is a file
+// path with placeholders: {<claim>} for the value of a partition claim, {<claim>_host}
+// for the host of a URL-valued partition claim, and {date}, {year}, {month}, {day}, and
+// {hour} for the token's issue time (iat) in UTC. An empty template selects a Hive-style
+// layout (see DefaultTemplate).
+func New(claims []string, template, ext string) (*Partitioner, error) {
+	if len(claims) == 0 {
+		return nil, fmt.Errorf("no partition claims given")
+	}
+	if template == "" {
+		template = DefaultTemplate(claims, ext)
+	}
+	p := &Partitioner{claims: claims, template: template}
+	for _, match := range placeholder.FindAllStringSubmatch(template, -1) {
+		if !p.known(match[1]) {
+			return nil, fmt.Errorf("unknown placeholder {%s} in partition template; use a -partition-by claim, <claim>_host, date, year, month, day, or hour", match[1])
+		}
+	}
+	return p, nil
+}
+
+// DefaultTemplate returns a Hive-style template with one key=value directory per claim
+// and per issue date, e.g. "iss={iss}/date={date}/claims.json".
+func DefaultTemplate(claims []string, ext string) string {
+	parts := make([]string, 0, len(claims)+2)
+	for _, claim := range claims {
+		parts = append(parts, claim+"={"+claim+"}")
+	}
+	return strings.Join(append(parts, "date={date}", "claims."+ext), "/")
+}
+
+// known reports whether name is a valid placeholder for this partitioner.
+func (p *Partitioner) known(name string) bool {
+	if _, ok := dateFields[name]; ok {
+		return true
+	}
+	for _, claim := range p.claims {
+		if name == claim || name == claim+"_host" {
+			return true
+		}
+	}
+	return false
+}
+
+// Path returns the file path of the partition the claims belong to. Claim values are
+// sanitized so that they cannot add directories or leave the template's directory.
+func (p *Partitioner) Path(claims jwt.MapClaims) string {
+	rendered := placeholder.ReplaceAllStringFunc(p.template, func(match string) string {
+		return sanitize(p.value(claims, match[1:len(match)-1]))
+	})
+	return filepath.FromSlash(rendered)
+}
+
+// value returns the unsanitized value of a placeholder.
+func (p *Partitioner) value(claims jwt.MapClaims, name string) string {
+	if layout, ok := dateFields[name]; ok {
+		iat, ok := epochSeconds(claims["iat"])
+		if !ok {
+			return Missing
+		}
+		return time.Unix(iat, 0).UTC().Format(layout)
+	}
+	for _, claim := range p.claims {
+		if name == claim {
+			return claimString(claims[claim])
+		}
+	}
+	claim := strings.TrimSuffix(name, "_host")
+	value := claimString(claims[claim])
+	if u, err := url.Parse(value); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return value
+}
+
+// claimString renders a claim value as a partition value. Of an array, the first
+// element is used (e.g., the primary audience).
+func claimString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			return v
+		}
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		if len(v) > 0 {
+			return claimString(v[0])
+		}
+	}
+	return Missing
+}
+
+// epochSeconds extracts an epoch timestamp in seconds from a decoded claim value.
+func epochSeconds(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// sanitize makes a claim value safe as a single path segment: characters other than
+// letters, digits, '.', '-', '_', '@', and '=' are replaced by '_', and the special
+// segments "." and ".." are never produced.
+func sanitize(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '.', r == '-', r == '_', r == '@', r == '=':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	s := b.String()
+	if s == "" {
+		return Missing
+	}
+	if strings.Trim(s, ".") == "" {
+		return strings.Repeat("_", len(s))
+	}
+	return s
+}
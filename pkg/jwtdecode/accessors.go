@@ -0,0 +1,106 @@
+package jwtdecode
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The accessors below read the registered claims of a Result, accepting the variants that
+// issuers use in practice. They read the processed Claims, so a claim that the query or
+// the pipeline removed or renamed is absent, except for the expiration time, which is
+// recorded before the claims are processed.
+
+// ExpiresAt returns the expiration time of the token (exp claim), and whether it has one.
+// A numeric string is accepted as well as a number.
+func (d *Result) ExpiresAt() (time.Time, bool) {
+	if !d.Expiry.IsZero() {
+		return d.Expiry, true
+	}
+	return numericDate(d.Claims["exp"])
+}
+
+// Audience returns the audience of the token (aud claim), a single string or an array of
+// strings, as a list; nil if it has none.
+func (d *Result) Audience() []string {
+	return stringList(d.Claims["aud"], false)
+}
+
+// Scopes returns the scopes granted to the token: the space-separated scope claim (RFC
+// 8693), or else the scp claim, a string or an array of strings as issued by Microsoft
+// Entra ID and Okta; nil if it has neither.
+func (d *Result) Scopes() []string {
+	if scopes := stringList(d.Claims["scope"], true); scopes != nil {
+		return scopes
+	}
+	return stringList(d.Claims["scp"], true)
+}
+
+// Issuer returns the issuer of the token (iss claim), or "" if it has none.
+func (d *Result) Issuer() string {
+	iss, _ := d.Claims["iss"].(string)
+	return iss
+}
+
+// numericDate converts a NumericDate claim, seconds since the epoch as a number or a
+// numeric string, with an optional fraction.
+func numericDate(value interface{}) (time.Time, bool) {
+	var seconds float64
+	switch v := value.(type) {
+	case float64:
+		seconds = v
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		seconds = f
+	case int64:
+		seconds = float64(v)
+	case int:
+		seconds = float64(v)
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		seconds = f
+	default:
+		return time.Time{}, false
+	}
+	if math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return time.Time{}, false
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*1e9)).UTC(), true
+}
+
+// stringList converts a claim holding a string or an array of strings to a list, splitting
+// a string on spaces with split. Empty strings and items that are not strings are left
+// out.
+func stringList(value interface{}, split bool) []string {
+	var items []string
+	switch v := value.(type) {
+	case string:
+		if split {
+			items = strings.Fields(v)
+		} else if v != "" {
+			items = []string{v}
+		}
+	case []string:
+		for _, item := range v {
+			if item != "" {
+				items = append(items, item)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "" {
+				items = append(items, s)
+			}
+		}
+	}
+	return items
+}
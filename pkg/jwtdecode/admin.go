@@ -0,0 +1,109 @@
+package jwtdecode
+
+// TrustSettings are the verification settings of a Decoder, as shown to administrators:
+// the sources of verification keys and the policies applied to them. Secrets are never
+// included, only whether they are set.
+type TrustSettings struct {
+	JWKSURL          string         `json:"jwks_url,omitempty"`         // Key set of -jwks-url, or the one resolved by -issuer-discovery
+	IssuerDiscovery  string         `json:"issuer_discovery,omitempty"` // Issuer of -issuer-discovery
+	JWKSCAFile       string         `json:"jwks_ca_file,omitempty"`     // CA bundle of the JWKS server
+	VerifyKey        string         `json:"verify_key,omitempty"`       // File of -verify-key
+	VerifyAlgs       []string       `json:"verify_algs,omitempty"`      // Algorithms allowed with VerifyKey
+	HMACSecret       bool           `json:"hmac_secret"`                // Whether -verify-hmac-secret is set
+	Provider         string         `json:"provider,omitempty"`         // Provider verifying the tokens
+	ResolveDID       bool           `json:"resolve_did"`                // Whether DID issuers are resolved
+	AllowEmbeddedJWK bool           `json:"allow_embedded_jwk"`         // Whether the jwk header may verify the token
+	JKUAllowlist     []string       `json:"jku_allowlist"`              // URL prefixes of jku key sets
+	PinnedKeys       []string       `json:"pinned_keys"`                // Thumbprints of -pin-key
+	TrustFile        string         `json:"trust_file,omitempty"`       // Trust file of -trust
+	Anchors          []AnchorConfig `json:"anchors"`                    // Trust anchors of the trust file
+
+	Tenants map[string]TrustSettings `json:"tenants,omitempty"` // Settings of the tenants of -serve, by name
+}
+
+// AnchorConfig is a trust anchor of the trust file, as shown in TrustSettings.
+type AnchorConfig struct {
+	Issuer     string   `json:"issuer"`
+	JWKSURI    string   `json:"jwks_uri,omitempty"`
+	JWKSFile   string   `json:"jwks_file,omitempty"`
+	Keys       []string `json:"keys,omitempty"`
+	Algorithms []string `json:"algorithms,omitempty"`
+	Audiences  []string `json:"audiences,omitempty"`
+}
+
+// Trust returns the verification settings of the Decoder.
+func (dec *Decoder) Trust() TrustSettings {
+	cfg := dec.cfg
+	settings := TrustSettings{
+		IssuerDiscovery:  cfg.IssuerDiscovery,
+		JWKSCAFile:       cfg.JWKSCAFile,
+		VerifyKey:        cfg.VerifyKey,
+		VerifyAlgs:       cfg.VerifyAlgs,
+		HMACSecret:       cfg.HMACSecret != nil,
+		ResolveDID:       cfg.ResolveDID,
+		AllowEmbeddedJWK: cfg.AllowEmbeddedJWK,
+		JKUAllowlist:     nonNilStrings(cfg.JKUAllowlist),
+		PinnedKeys:       nonNilStrings(cfg.PinnedKeys),
+		TrustFile:        cfg.TrustFile,
+		Anchors:          []AnchorConfig{},
+	}
+	if dec.jwks != nil {
+		settings.JWKSURL = dec.jwks.URL
+	}
+	if dec.prov != nil && !cfg.SkipVerify {
+		settings.Provider = dec.prov.Name()
+	}
+	if dec.trustCfg != nil {
+		for _, anchor := range dec.trustCfg.Issuers {
+			settings.Anchors = append(settings.Anchors, AnchorConfig{
+				Issuer:     anchor.Issuer,
+				JWKSURI:    anchor.JWKSURI,
+				JWKSFile:   anchor.JWKSFile,
+				Keys:       anchor.Keys,
+				Algorithms: anchor.Algorithms,
+				Audiences:  anchor.Audiences,
+			})
+		}
+	}
+	for name, tenant := range dec.tenants {
+		if settings.Tenants == nil {
+			settings.Tenants = map[string]TrustSettings{}
+		}
+		settings.Tenants[name] = tenant.Trust()
+	}
+	return settings
+}
+
+// FlushCaches empties the claims cache and drops the key set of -jwks-url or
+// -issuer-discovery, in memory and on disk, and those of the trust file issuers, so that
+// tokens are decoded and their keys fetched again. The caches of tenants are flushed as
+// well.
+func (dec *Decoder) FlushCaches() error {
+	if dec.cache != nil {
+		dec.cache.Purge()
+	}
+	if dec.jwks != nil {
+		if err := dec.jwks.Flush(); err != nil {
+			return err
+		}
+	}
+	if dec.trustCfg != nil {
+		if err := dec.trustCfg.Flush(); err != nil {
+			return err
+		}
+	}
+	for _, tenant := range dec.tenants {
+		if err := tenant.FlushCaches(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nonNilStrings returns list, or an empty list for nil, so that it is encoded as [] in JSON.
+func nonNilStrings(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
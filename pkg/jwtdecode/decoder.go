@@ -0,0 +1,1075 @@
+// Package jwtdecode is the library behind the jwtdecode command: it decodes, verifies,
+// and processes tokens with the same options, and formats their claims in the same output
+// formats. A Decoder is built from library Options with New, or from a configuration
+// loaded from the command line with FromConfig.
+package jwtdecode
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+
+	"jwtdecode/binding"
+	"jwtdecode/checkpoint"
+	"jwtdecode/config"
+	"jwtdecode/conformance"
+	"jwtdecode/did"
+	"jwtdecode/discovery"
+	"jwtdecode/envelope"
+	"jwtdecode/events"
+	"jwtdecode/findings"
+	"jwtdecode/formatter"
+	"jwtdecode/geoip"
+	"jwtdecode/inflate"
+	"jwtdecode/inputformat"
+	"jwtdecode/jwe"
+	"jwtdecode/jwks"
+	"jwtdecode/jwterrors"
+	"jwtdecode/lru"
+	"jwtdecode/process"
+	"jwtdecode/provenance"
+	"jwtdecode/provider"
+	"jwtdecode/query"
+	"jwtdecode/snapshot"
+	"jwtdecode/telemetry"
+	"jwtdecode/token"
+	"jwtdecode/trust"
+	"jwtdecode/utils"
+	"jwtdecode/vc"
+	"jwtdecode/verify"
+	"jwtdecode/warnings"
+)
+
+// ClaimSourceFile is the claim recording the file a token of a directory was read from.
+const ClaimSourceFile = "source_file"
+
+// ClaimHeader is the claim holding the decoded JOSE header with -include-header.
+const ClaimHeader = "header"
+
+// ClaimSignatureValid is the claim recording that the signature verified with the supplied key.
+const ClaimSignatureValid = "signature_valid"
+
+// Categories of the errors returned by a Decoder (see the jwterrors package), for
+// errors.Is.
+var (
+	// ErrSignatureInvalid is returned when a token does not verify with the secret or key
+	// supplied by -verify-hmac-secret, -verify-key, -jwks-url, or -issuer-discovery, or
+	// with the keys of the provider, trust anchor, or DID document. The CLI then exits with
+	// status 3, so scripts can tell a forged or tampered token apart from other errors.
+	ErrSignatureInvalid = jwterrors.ErrSignatureInvalid
+	ErrMalformedToken   = jwterrors.ErrMalformedToken
+	ErrTokenTooLarge    = jwterrors.ErrTokenTooLarge
+	ErrDecryption       = jwterrors.ErrDecryption
+	ErrKeyNotFound      = jwterrors.ErrKeyNotFound
+	// ErrExpired and ErrNotYetValid are the errors of Result.ValidityError.
+	ErrExpired     = jwterrors.ErrExpired
+	ErrNotYetValid = jwterrors.ErrNotYetValid
+	// ErrInvalidOptions is the category of the *config.ValidationError of invalid Options.
+	ErrInvalidOptions = jwterrors.ErrInvalidConfig
+)
+
+// Decoder decodes, verifies, and processes tokens with the settings of a configuration.
+// It holds the state shared by every token: the resolved provider, the loaded trust
+// configuration, keys, and key sets, the pinned keys, the claims processing pipeline, and
+// the claims cache. A Decoder is safe for concurrent use once built, so that a server or a
+// batch decodes every token with one Decoder; Results served from the claims cache are
+// shared, and must not be modified.
+type Decoder struct {
+	cfg         *config.AppConfig
+	warn        func(msg string) // Reports the soft issues found while decoding
+	prov        provider.Provider
+	trustCfg    *trust.Config
+	cache       *lru.Cache[cachedResult] // Decoded tokens by SHA-256 of the token; nil if disabled
+	geo         *geoip.DB                // GeoIP databases; nil if none
+	keyVerifier *verify.KeyVerifier      // Verifier of -verify-key; nil if none
+	decrypter   *jwe.Decrypter           // Decrypter of -decrypt-key; nil if none
+	pins        verify.Pins              // Keys of -pin-key that verification keys must be among
+	jwks        *jwks.Remote             // Key set of -jwks-url; nil if none
+	query       *query.Query             // Claims selected by -query; nil for all
+	steps       process.Pipeline         // Claims processing steps, from the config file or the shorthand flags
+	workers     int                      // Tokens decoded at once by DecodeAll
+	tenants     map[string]*Decoder      // Decoders of the tenants of -serve, by name
+}
+
+// cachedResult is a decoded token kept in the claims cache, with the time it was decoded.
+type cachedResult struct {
+	d  *Result
+	at time.Time
+}
+
+// Result is the result of decoding and checking one token.
+type Result struct {
+	Claims        jwt.MapClaims          // Processed claims, ready for formatting
+	Header        map[string]interface{} // Token header
+	Payload       []byte                 // Decoded payload, for JSON output that keeps the issuer's encoding
+	Raw           bool                   // Whether the payload can be printed as issued (no claim was modified)
+	Sources       map[string]string      // Source of each claim, when provenance is recorded
+	Failures      []string               // Failures reported only after the output is written
+	NotValid      string                 // Why the token is expired or not yet valid with Validate; empty if it is valid
+	Source        string                 // File the token was read from, in directory mode
+	Expiry        time.Time              // Expiration time (exp claim); zero if absent
+	NotBefore     time.Time              // Start of validity (nbf claim); zero if absent
+	SignatureSize int                    // Size of the signature in bytes
+	Verified      bool                   // Whether the signature was verified, unless the output is watermarked
+	Hash          string                 // Hex SHA-256 of the token
+	Warnings      []warnings.Warning     // Soft issues found while decoding
+	Findings      []findings.Finding     // Security findings about the token
+}
+
+// FromConfig returns a Decoder decoding with the settings of a loaded configuration,
+// resolving the provider and loading the trust configuration and keys once. Soft issues
+// are reported on stderr, and status messages on stdout unless IsSilent is set.
+func FromConfig(appConfig *config.AppConfig) (*Decoder, error) {
+	return newDecoder(appConfig, config.Warn)
+}
+
+// newDecoder returns a Decoder decoding with appConfig, reporting soft issues to warn.
+func newDecoder(appConfig *config.AppConfig, warn func(msg string)) (*Decoder, error) {
+	dec := &Decoder{cfg: appConfig, warn: warn, workers: runtime.GOMAXPROCS(0)}
+	if len(appConfig.Query) > 0 {
+		q, err := query.Parse(appConfig.Query)
+		if err != nil {
+			return nil, err
+		}
+		dec.query = q
+	}
+	steps, err := process.Parse(appConfig.Steps(), appConfig.DateLayout())
+	if err != nil {
+		return nil, fmt.Errorf("building pipeline: %w", err)
+	}
+	dec.steps = steps
+	if appConfig.Provider != "" {
+		prov, err := provider.Get(appConfig.Provider, provider.Options{
+			Audience: appConfig.Audience,
+			Issuer:   appConfig.Issuer,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("resolving provider: %w", err)
+		}
+		dec.prov = prov
+	}
+	if appConfig.TrustFile != "" {
+		trustCfg, err := trust.Load(appConfig.TrustFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading trust configuration: %w", err)
+		}
+		dec.trustCfg = trustCfg
+	}
+	if appConfig.VerifyKey != "" {
+		pemData, err := utils.ReadFile(appConfig.VerifyKey)
+		if err != nil {
+			return nil, fmt.Errorf("reading verification key: %w", err)
+		}
+		if dec.keyVerifier, err = verify.NewKeyVerifier(pemData, appConfig.VerifyAlgs); err != nil {
+			return nil, fmt.Errorf("loading verification key: %w", err)
+		}
+	}
+	if appConfig.DecryptKey != "" {
+		keyData, err := utils.ReadFile(appConfig.DecryptKey)
+		if err != nil {
+			return nil, fmt.Errorf("reading decryption key: %w", err)
+		}
+		if dec.decrypter, err = jwe.NewDecrypter(keyData, inflate.Limits{MaxSize: int64(appConfig.MaxTokenSize) * 1024 * 1024}); err != nil {
+			return nil, fmt.Errorf("loading decryption key: %w", err)
+		}
+	}
+	if appConfig.JWKSURL != "" || appConfig.IssuerDiscovery != "" {
+		var rootCAs *x509.CertPool
+		if appConfig.JWKSCAFile != "" {
+			pemData, err := utils.ReadFile(appConfig.JWKSCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading JWKS CA bundle: %w", err)
+			}
+			if rootCAs, err = jwks.LoadCAs(pemData); err != nil {
+				return nil, fmt.Errorf("loading JWKS CA bundle: %w", err)
+			}
+		}
+		// The discovery document is fetched with the timeout and CAs of the key set
+		jwksURL := appConfig.JWKSURL
+		if appConfig.IssuerDiscovery != "" {
+			client := &jwks.Remote{Timeout: appConfig.JWKSTimeout, RootCAs: rootCAs}
+			doc, err := discovery.FetchMetadata(appConfig.IssuerDiscovery, client.Get)
+			if err != nil {
+				return nil, fmt.Errorf("resolving JWKS: %w", err)
+			}
+			if jwksURL = doc.JWKSURI(); jwksURL == "" {
+				return nil, fmt.Errorf("resolving JWKS: discovery document of %s has no jwks_uri", appConfig.IssuerDiscovery)
+			}
+			if !sameIssuer(doc.Issuer(), appConfig.IssuerDiscovery) {
+				dec.warn(fmt.Sprintf("discovery document of %s declares the issuer %q", appConfig.IssuerDiscovery, doc.Issuer()))
+			}
+		}
+		remote, err := jwks.NewRemote(jwksURL)
+		if err != nil {
+			return nil, fmt.Errorf("resolving JWKS: %w", err)
+		}
+		remote.Timeout = appConfig.JWKSTimeout
+		remote.RootCAs = rootCAs
+		remote.CacheDir = appConfig.JWKSCacheDir
+		remote.CacheTTL = appConfig.JWKSCacheTTL
+		dec.jwks = remote
+	}
+	if len(appConfig.GeoIPDB) > 0 {
+		geo, err := geoip.Open(appConfig.GeoIPDB)
+		if err != nil {
+			return nil, err
+		}
+		dec.geo = geo
+	}
+	dec.pins = verify.NewPins(appConfig.PinnedKeys...)
+	// Token list snapshots are named after the position of each token, and -validate output
+	// follows the clock unless -validate-at fixes it, so each token is decoded again then
+	if appConfig.CacheSize > 0 && (appConfig.Serves() || (appConfig.TokenList != "" && appConfig.SnapshotDir == "")) && (!appConfig.Validate || !appConfig.ValidateAt.IsZero()) {
+		dec.cache = lru.New[cachedResult](appConfig.CacheSize)
+	}
+	for _, name := range appConfig.TenantNames() {
+		tenant, err := newDecoder(appConfig.ForTenant(name), warn)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %q: %w", name, err)
+		}
+		if dec.tenants == nil {
+			dec.tenants = map[string]*Decoder{}
+		}
+		dec.tenants[name] = tenant
+	}
+	return dec, nil
+}
+
+// Config returns the configuration the Decoder decodes with.
+func (dec *Decoder) Config() *config.AppConfig {
+	return dec.cfg
+}
+
+// Tenant returns the Decoder of the requests of a tenant of -serve, with its own claims
+// cache and keys, and whether the tenant exists.
+func (dec *Decoder) Tenant(name string) (*Decoder, bool) {
+	tenant, ok := dec.tenants[name]
+	return tenant, ok
+}
+
+// CacheStats returns the statistics of the claims cache, and whether it is enabled.
+func (dec *Decoder) CacheStats() (lru.Stats, bool) {
+	if dec.cache == nil {
+		return lru.Stats{}, false
+	}
+	return dec.cache.Stats(), true
+}
+
+// DecodeCached decodes a token like DecodeNamed, reusing the result of an earlier decode of
+// the same token when the claims cache is enabled. Failed decodes are not cached, and a
+// result is decoded again once the token has expired since it was cached, as its checks
+// may no longer pass. Warnings are only reported when a token is decoded.
+func (dec *Decoder) DecodeCached(ctx context.Context, rawToken, snapshotName string) (*Result, error) {
+	if dec.cache == nil {
+		return dec.DecodeNamed(ctx, rawToken, snapshotName, "")
+	}
+	key := TokenHash(rawToken)
+	if cached, ok := dec.cache.Get(key); ok {
+		expiry := cached.d.Expiry
+		if expiry.IsZero() || !cached.at.Before(expiry) || time.Now().Before(expiry) {
+			telemetry.CacheLookups.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "hit")))
+			return cached.d, nil
+		}
+		dec.cache.Remove(key)
+	}
+	telemetry.CacheLookups.Add(ctx, 1, metric.WithAttributes(attribute.String("result", "miss")))
+	d, err := dec.DecodeNamed(ctx, rawToken, snapshotName, "")
+	if err != nil {
+		return nil, err
+	}
+	dec.cache.Add(key, cachedResult{d: d, at: time.Now()})
+	return d, nil
+}
+
+// TokenHash returns the hex SHA-256 of a token, which identifies it in the claims cache
+// and in the output.
+func TokenHash(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// DecodeNamed decodes one token, recording a span and the decode metrics. Its snapshot,
+// with a snapshot directory, is named snapshotName, and a token read from a file of a
+// token directory records sourceFile in the source_file claim.
+func (dec *Decoder) DecodeNamed(ctx context.Context, rawToken, snapshotName, sourceFile string) (*Result, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "decode")
+	defer span.End()
+	start := time.Now()
+	d, err := dec.decodeToken(ctx, rawToken, snapshotName, sourceFile)
+	outcome := telemetry.Outcome(err)
+	telemetry.Decodes.Add(ctx, 1, metric.WithAttributes(outcome))
+	telemetry.DecodeSeconds.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(outcome))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	alg, _ := d.Header["alg"].(string)
+	span.SetAttributes(
+		attribute.String("jwt.alg", alg),
+		attribute.Bool("jwt.verified", d.Verified),
+		attribute.Int("jwt.failures", len(d.Failures)),
+	)
+	if sourceFile != "" {
+		span.SetAttributes(attribute.String("jwt.source_file", sourceFile))
+	}
+	return d, nil
+}
+
+// decodeToken parses, verifies, and annotates one token. The file a token of a directory
+// was read from is recorded in the source_file claim. Errors are worded to follow "Error "
+// in messages; findings that fail the run are returned in Result.Failures instead.
+func (dec *Decoder) decodeToken(ctx context.Context, rawToken, snapshotName, sourceFile string) (*Result, error) {
+	appConfig := dec.cfg
+	prov := dec.prov
+
+	// Convert the input to a compact JWS, whatever its serialization: JWS JSON, the
+	// issuer-signed JWT of an SD-JWT, or the claims of a CWT or claims JSON, once
+	// decrypted if it is a JWE. The token is identified by the input as read.
+	input, err := dec.parseInput(rawToken)
+	if err != nil {
+		return nil, err
+	}
+	format := input.Format
+	if input.Encrypted {
+		format = inputformat.JWE + " (" + input.Format + ")"
+	}
+	if !input.Signed && (appConfig.Verifies() || appConfig.HMACWordlist != "") {
+		return nil, fmt.Errorf("verifying token: the signature of %s input cannot be verified", format)
+	}
+	if format != inputformat.JWS && !appConfig.IsSilent {
+		fmt.Printf("Input format: %s\n", format)
+	}
+	hash := TokenHash(rawToken)
+	rawToken = input.Compact
+
+	// 1. Normalize the encoding quirks of the issuer-specific provider, if any
+	parseInput := rawToken
+	if prov != nil {
+		parseInput, err = prov.Normalize(rawToken)
+		if err != nil {
+			return nil, fmt.Errorf("normalizing %s token: %w", prov.Name(), err)
+		}
+	}
+
+	// Inflate compressed payloads ("zip": "DEF", e.g. SMART Health Cards) within strict limits
+	parseInput, err = inflate.Token(parseInput, inflate.Limits{MaxSize: int64(appConfig.MaxTokenSize) * 1024 * 1024})
+	if err != nil {
+		return nil, fmt.Errorf("decompressing token payload: %w", err)
+	}
+
+	// 2. Parse the JWT token (unverified as we are only decoding claims)
+	token, segments, err := new(jwt.Parser).ParseUnverified(parseInput, jwt.MapClaims{})
+	if err != nil {
+		return nil, fmt.Errorf("parsing JWT token: %w", jwterrors.Wrap(jwterrors.ErrMalformedToken, err))
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, fmt.Errorf("extracting claims: unexpected claims type %T", token.Claims)
+	}
+	// JSON decoding replaces the bytes of invalid UTF-8 sequences, so binary values are
+	// decoded again from the escaped payload to be rendered with -binary-values
+	escapedBytes := false
+	if appConfig.BinaryValues != "" {
+		payload, err := verify.DecodeSegment(segments[1])
+		if err != nil {
+			return nil, fmt.Errorf("decoding payload: %w", err)
+		}
+		if !utf8.Valid(payload) {
+			clear(claims)
+			if err := json.Unmarshal(formatter.EscapeInvalidUTF8(payload), &claims); err != nil {
+				return nil, fmt.Errorf("parsing JWT token: %w", err)
+			}
+			escapedBytes = true
+		}
+	}
+	// Pipeline steps below only add annotations, so an unchanged claim count at formatting
+	// time means the claims are exactly the decoded payload
+	decodedClaims := len(claims)
+	// Track where each claim originates as claims from other sources are merged
+	tracker := provenance.New(claims)
+
+	// Findings and failures that are reported in the output and only fail the run after it is written
+	var tokenFindings []findings.Finding
+	var deferredFailures []string
+	// Soft issues, reported on stderr as they are found and in the output with -warnings
+	warns := warnings.NewCollector(dec.warn)
+	for _, msg := range input.Warnings {
+		warns.Warn(warnings.CodeInputFormat, findings.SeverityLow, msg)
+	}
+	// Whether the signature was verified by any of the configured means
+	verified := false
+
+	// Replace the digests of an SD-JWT by the claims its disclosures disclose
+	if input.Format == inputformat.SDJWT {
+		unused, err := inputformat.Disclose(claims, input.Disclosures)
+		if err != nil {
+			return nil, fmt.Errorf("disclosing SD-JWT claims: %w", err)
+		}
+		for _, disclosure := range unused {
+			msg := "SD-JWT disclosure is not referred to by the payload: " + disclosure.Encoded
+			if disclosure.Name != "" {
+				msg = fmt.Sprintf("SD-JWT disclosure of %s is not referred to by the payload", disclosure.Name)
+			}
+			warns.Warn(warnings.CodeInputFormat, findings.SeverityMedium, msg)
+		}
+		tracker.Record(claims, provenance.SourceDisclosure)
+	}
+
+	// 3. Enforce critical header extensions (RFC 7515 §4.1.11). Tokens naming extensions
+	// that are not implemented must be rejected, unless explicitly downgraded to a warning.
+	unsupportedCrit, err := verify.UnsupportedCrit(token.Header)
+	if err != nil {
+		tokenFindings = append(tokenFindings, findings.Finding{ID: "crit-malformed", Severity: findings.SeverityHigh, Message: err.Error()})
+		warns.Warn(warnings.CodeCritMalformed, findings.SeverityHigh, err.Error())
+		deferredFailures = append(deferredFailures, err.Error())
+	} else if len(unsupportedCrit) > 0 {
+		msg := "crit header names unsupported extensions: " + strings.Join(unsupportedCrit, ", ")
+		list := make([]interface{}, len(unsupportedCrit))
+		for i, name := range unsupportedCrit {
+			list[i] = name
+		}
+		claims["crit_unsupported"] = list
+		tokenFindings = append(tokenFindings, findings.Finding{ID: "crit-unsupported", Severity: findings.SeverityHigh, Message: msg})
+		warns.Warn(warnings.CodeCritUnsupported, findings.SeverityHigh, msg)
+		if !appConfig.AllowUnsupportedCrit {
+			deferredFailures = append(deferredFailures, msg)
+		}
+	}
+
+	// 4. Test an HMAC token for weak secrets (authorized testing only)
+	if appConfig.HMACWordlist != "" {
+		if err := dec.checkHMACWordlist(rawToken, token, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	// Verify the token with the HMAC secret supplied by -verify-hmac-secret
+	if appConfig.HMACSecret != nil {
+		if _, isHMAC := token.Method.(*jwt.SigningMethodHMAC); !isHMAC {
+			return nil, fmt.Errorf("verifying token with HMAC secret: header alg %s is not HS256, HS384, or HS512: %w", token.Method.Alg(), ErrSignatureInvalid)
+		}
+		if err := verify.Signature(rawToken, token, appConfig.HMACSecret, dec.pins); err != nil {
+			if errors.Is(err, jwt.ErrSignatureInvalid) {
+				return nil, fmt.Errorf("verifying token with HMAC secret: %w", ErrSignatureInvalid)
+			}
+			return nil, fmt.Errorf("verifying token with HMAC secret: %w: %w", ErrSignatureInvalid, err)
+		}
+		claims[ClaimSignatureValid] = true
+		verified = true
+		if !appConfig.IsSilent {
+			fmt.Println("Signature verified using HMAC secret")
+		}
+	}
+	// Verify the token with the public key supplied by -verify-key
+	if dec.keyVerifier != nil {
+		if err := dec.keyVerifier.Verify(rawToken, token, dec.pins); err != nil {
+			return nil, fmt.Errorf("verifying token with %s: %w: %w", appConfig.VerifyKey, ErrSignatureInvalid, err)
+		}
+		claims[ClaimSignatureValid] = true
+		verified = true
+		if !appConfig.IsSilent {
+			fmt.Printf("Signature verified using key from %s\n", appConfig.VerifyKey)
+		}
+	}
+
+	// Verify the token with the key of the -jwks-url key set, or of the key set of the
+	// -issuer-discovery issuer, selected by its kid
+	if dec.jwks != nil {
+		if err := dec.verifyJWKS(rawToken, token); err != nil {
+			return nil, err
+		}
+		claims[ClaimSignatureValid] = true
+		verified = true
+		if !appConfig.IsSilent {
+			fmt.Printf("Signature verified using key from %s\n", dec.jwks.URL)
+		}
+		// A key set may be shared by several issuers, so the issuer is checked apart
+		if iss, _ := claims["iss"].(string); appConfig.IssuerDiscovery != "" && !sameIssuer(iss, appConfig.IssuerDiscovery) {
+			warns.Warn(warnings.CodeIssuerMismatch, findings.SeverityHigh, fmt.Sprintf("token iss %q does not match the issuer %s of -issuer-discovery", iss, appConfig.IssuerDiscovery))
+		}
+	}
+
+	// 5. Apply provider verification and conventions
+	if prov != nil {
+		if !appConfig.SkipVerify {
+			if err := prov.Verify(rawToken, token, dec.pins); err != nil {
+				return nil, fmt.Errorf("verifying %s token: %w", prov.Name(), err)
+			}
+			verified = true
+			if !appConfig.IsSilent {
+				fmt.Printf("Signature verified using %s keys\n", prov.Name())
+			}
+		}
+		claims, err = prov.Process(token, claims)
+		if err != nil {
+			return nil, fmt.Errorf("processing %s token: %w", prov.Name(), err)
+		}
+		tracker.Record(claims, "provider:"+prov.Name())
+	}
+
+	// 6. Verify the token against the trust anchor configured for its issuer.
+	// Suspected algorithm confusion is recorded as a finding instead of being verified.
+	if dec.trustCfg != nil {
+		issuer, _ := claims["iss"].(string)
+		anchor, err := dec.trustCfg.Anchor(issuer)
+		if err != nil {
+			return nil, fmt.Errorf("verifying token: %w", err)
+		}
+		if finding := anchor.AlgConfusion(token); finding != nil {
+			tokenFindings = append(tokenFindings, *finding)
+			warns.Warn(warnings.CodeAlgConfusion, finding.Severity, finding.Message)
+			deferredFailures = append(deferredFailures, "token signature was not verified: "+verify.ErrAlgConfusion.Error())
+		} else {
+			if err := anchor.Verify(rawToken, token, dec.pins); err != nil {
+				return nil, fmt.Errorf("verifying token: %w", err)
+			}
+			verified = true
+			if !appConfig.IsSilent {
+				fmt.Printf("Signature verified using trust anchor for %s\n", anchor.Issuer)
+			}
+		}
+	}
+
+	// 7. Keys supplied by the token itself (jwk, jku) are only used as far as the policy permits,
+	// and are always reported as findings
+	verifiedBy, headerFindings, err := verify.HeaderKeys(rawToken, token, verify.HeaderKeyPolicy{
+		AllowEmbeddedJWK: appConfig.AllowEmbeddedJWK,
+		JKUAllowlist:     appConfig.JKUAllowlist,
+		Pins:             dec.pins,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("verifying token: %w", err)
+	}
+	for _, finding := range headerFindings {
+		tokenFindings = append(tokenFindings, finding)
+		if finding.Severity != findings.SeverityLow {
+			warns.Warn(warnings.CodeHeaderKey, finding.Severity, finding.Message)
+		}
+	}
+	verified = verified || len(verifiedBy) > 0
+	if len(verifiedBy) > 0 && !appConfig.IsSilent {
+		fmt.Printf("Signature verified using key from %s header\n", strings.Join(verifiedBy, " and "))
+	}
+
+	// 8. Verify tokens issued by a DID, and the credentials embedded in a presentation,
+	// with keys resolved from the DID documents
+	if appConfig.ResolveDID {
+		if err := did.VerifyToken(rawToken, token, dec.pins); err != nil {
+			return nil, fmt.Errorf("verifying token: %w", err)
+		}
+		for i, credential := range vc.EmbeddedCredentials(claims) {
+			inner, _, err := new(jwt.Parser).ParseUnverified(credential, jwt.MapClaims{})
+			if err != nil {
+				return nil, fmt.Errorf("parsing embedded credential %d: %w", i+1, err)
+			}
+			if err := did.VerifyToken(credential, inner, dec.pins); err != nil {
+				return nil, fmt.Errorf("verifying embedded credential %d: %w", i+1, err)
+			}
+		}
+		verified = true
+		if !appConfig.IsSilent {
+			fmt.Printf("Signature verified using DID document of %v\n", claims["iss"])
+		}
+	}
+
+	// 9. Check the certificate binding (RFC 8705) when a client certificate is supplied
+	if appConfig.ClientCert != "" {
+		pemData, err := utils.ReadFile(appConfig.ClientCert)
+		if err != nil {
+			return nil, fmt.Errorf("reading client certificate: %w", err)
+		}
+		thumbprint, err := binding.CertificateThumbprint(pemData)
+		if err != nil {
+			return nil, fmt.Errorf("reading client certificate: %w", err)
+		}
+		bound, match, err := binding.CheckCertificateBinding(claims, thumbprint)
+		if err != nil {
+			return nil, fmt.Errorf("checking certificate binding: %w", err)
+		}
+		claims["cnf_x5t#S256_match"] = match
+		if !match {
+			warns.Warn(warnings.CodeCertBinding, findings.SeverityHigh, fmt.Sprintf("certificate thumbprint %s does not match cnf x5t#S256 %s", thumbprint, bound))
+		} else if !appConfig.IsSilent {
+			fmt.Printf("Certificate binding verified (x5t#S256 %s)\n", thumbprint)
+		}
+	}
+
+	// 10. Verify OIDC bindings (nonce, at_hash, c_hash) against the supplied values
+	oidcChecks := binding.CheckOIDC(token.Method.Alg(), claims, binding.OIDCInputs{
+		Nonce:             appConfig.Nonce,
+		AccessToken:       appConfig.AccessToken,
+		AuthorizationCode: appConfig.AuthCode,
+	})
+	for _, check := range oidcChecks {
+		claims[check.Claim+"_valid"] = check.Passed
+		if !check.Passed {
+			warns.Warn(warnings.CodeOIDCBinding, findings.SeverityHigh, check.Message)
+		} else if !appConfig.IsSilent {
+			fmt.Printf("OIDC %s verified\n", check.Claim)
+		}
+	}
+
+	// 11. Describe and validate security events (logout tokens, SET/CAEP/RISC)
+	annotation, problems := events.Annotate(claims)
+	if annotation != nil {
+		claims[events.ClaimEvents+"_annotation"] = annotation
+	}
+	for _, problem := range problems {
+		warns.Warn(warnings.CodeEvent, findings.SeverityMedium, problem)
+	}
+
+	// 12. Expand verifiable credentials and presentations (W3C VC JWT encoding)
+	vcAnnotations, problems := vc.Annotate(claims)
+	for key, value := range vcAnnotations {
+		claims[key] = value
+	}
+	for _, problem := range problems {
+		warns.Warn(warnings.CodeCredential, findings.SeverityMedium, problem)
+	}
+
+	// 13. Check the token against the requested conformance profile
+	if appConfig.Conformance != "" {
+		report, err := conformance.Check(appConfig.Conformance, token.Header, claims)
+		if err != nil {
+			return nil, fmt.Errorf("checking conformance: %w", err)
+		}
+		claims["conformance_report"] = report.ToMap()
+		if !appConfig.IsSilent {
+			printConformanceReport(report)
+		}
+		if !report.Conformant {
+			deferredFailures = append(deferredFailures, fmt.Sprintf("token does not conform to %s (%d failed checks)", report.Profile, report.Failures()))
+		}
+	}
+	// Locate the IP addresses held by claims in the GeoIP databases
+	if dec.geo != nil {
+		if locations := dec.geo.Enrich(claims); locations != nil {
+			claims[geoip.ClaimGeoIP] = locations
+		}
+	}
+	switch {
+	case token.Method.Alg() == "none":
+		warns.Add(warnings.CodeAlgNone, findings.SeverityHigh, "token is not signed (alg none)")
+	case !verified:
+		warns.Add(warnings.CodeUnverified, findings.SeverityMedium, "token signature was not verified")
+	}
+	// Envelopes hold the findings and the source file apart from the claims
+	if len(tokenFindings) > 0 && !appConfig.Envelope {
+		claims[findings.ClaimFindings] = findings.ToValue(tokenFindings)
+	}
+	// -validate checks the time claims of the payload, whatever part of it is output
+	var validity jwt.MapClaims
+	var notValid string
+	if appConfig.Validate {
+		validity, notValid = checkValidity(claims, appConfig.Now(), appConfig.ClockSkew)
+	}
+	// The expiry is read from the payload before the header can replace it, -query can
+	// select claims without exp, or the pipeline steps can rename or remove the exp claim
+	var expiry, notBefore time.Time
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		expiry = exp.Time
+	}
+	if nbf, err := claims.GetNotBefore(); err == nil && nbf != nil {
+		notBefore = nbf.Time
+	}
+	// The header is output with the claims, or in their place
+	switch {
+	case appConfig.HeaderOnly:
+		claims = jwt.MapClaims(maps.Clone(token.Header))
+		tracker = provenance.New(nil)
+		tracker.Record(claims, provenance.SourceHeader)
+	case appConfig.IncludeHeader:
+		if _, ok := claims[ClaimHeader]; ok {
+			return nil, fmt.Errorf("adding header: the payload already has a %s claim", ClaimHeader)
+		}
+		claims[ClaimHeader] = maps.Clone(token.Header)
+		tracker.Record(claims, provenance.SourceHeader)
+	}
+	// -query keeps the selected claims only, before the annotations of the run are added
+	if dec.query != nil {
+		selected, unmatched := dec.query.Apply(claims)
+		for _, expr := range unmatched {
+			warns.Warn(warnings.CodeQueryNoMatch, findings.SeverityLow, fmt.Sprintf("query %q matched no claim", expr))
+		}
+		claims = jwt.MapClaims(selected)
+	}
+	if sourceFile != "" && !appConfig.Envelope {
+		claims[ClaimSourceFile] = sourceFile
+	}
+	for name, value := range validity {
+		if _, ok := claims[name]; ok {
+			return nil, fmt.Errorf("adding validity: the payload already has a %s claim", name)
+		}
+		claims[name] = value
+	}
+
+	// 14. Process claims: render binary values, run the steps of the pipeline (e.g.,
+	// collapse namespaced keys, epoch-to-human-readable conversion), and truncate values
+	claims = formatter.RenderBinaryValues(claims, appConfig.BinaryValues, escapedBytes)
+	claims, err = dec.steps.Run(claims, process.Token{Context: ctx, Header: token.Header}, func(code, message string) {
+		warns.Add(code, findings.SeverityLow, message)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("processing claims: %w", err)
+	}
+	if appConfig.TruncateValues > 0 {
+		var truncated []string
+		claims, truncated = formatter.TruncateValues(claims, appConfig.TruncateValues)
+		for _, name := range truncated {
+			warns.Add(warnings.CodeTruncated, findings.SeverityLow, fmt.Sprintf("values of claim %s were truncated to %d characters", name, appConfig.TruncateValues))
+		}
+	}
+	for key, value := range claims {
+		if original, ok := value.(string); ok && strings.HasSuffix(key, "_original_key") {
+			tracker.Renamed(original, strings.TrimSuffix(key, "_original_key"))
+		}
+	}
+	if appConfig.SnapshotDir != "" {
+		if err := snapshot.Write(appConfig.SnapshotDir, snapshotName, claims, appConfig.ValidateAt); err != nil {
+			return nil, fmt.Errorf("writing snapshot: %w", err)
+		}
+	}
+	result := &Result{
+		Claims:        claims,
+		Header:        token.Header,
+		Failures:      deferredFailures,
+		NotValid:      notValid,
+		Source:        sourceFile,
+		Expiry:        expiry,
+		Verified:      verified,
+		NotBefore:     notBefore,
+		SignatureSize: base64.RawURLEncoding.DecodedLen(len(strings.TrimRight(segments[2], "="))),
+		Hash:          hash,
+		Findings:      tokenFindings,
+	}
+	result.Warnings = warns.List()
+	if appConfig.Warnings && !appConfig.Envelope && len(result.Warnings) > 0 {
+		result.Claims[warnings.ClaimWarnings] = warnings.ToValue(warns.List())
+	}
+	if appConfig.Provenance {
+		result.Sources = tracker.Sources(result.Claims)
+	}
+
+	// 15. Keep the payload for JSON output that is printed as issued or in payload order
+	result.Raw = prov == nil && dec.query == nil && input.Format != inputformat.SDJWT && len(dec.steps) == 0 && appConfig.BinaryValues == "" && appConfig.TruncateValues == 0 && !appConfig.HeaderOnly && len(result.Claims) == decodedClaims
+	if appConfig.OutputFormat == config.OutputFormatJSON && (result.Raw || appConfig.PreserveOrder) {
+		result.Payload, err = verify.DecodeSegment(segments[1])
+		if err != nil {
+			return nil, fmt.Errorf("decoding payload: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// DecodeList decodes every token of a token list in order. Snapshots are named after
+// the list and the token's position in it. The first token that cannot be decoded
+// stops the run, unless -keep-going is set: the tokens that cannot be decoded are then
+// left out and returned as errors. With -resume, each decoded token is recorded in the
+// checkpoint file, and a run continues after the last token recorded there.
+func (dec *Decoder) DecodeList(ctx context.Context, path string) ([]*Result, []error, error) {
+	list, err := token.OpenList(path, dec.cfg.MaxTokenSize*1024*1024, token.Options{
+		StrictPermissions: dec.cfg.StrictPerms,
+		Warn:              dec.warn,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = list.Close()
+	}()
+
+	var results []*Result
+	var skipped []error
+	var cp *checkpoint.Writer
+	if dec.cfg.Resume {
+		state, err := checkpoint.Load(dec.cfg.CheckpointFile, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, data := range state.Results {
+			var r checkpointResult
+			if err := json.Unmarshal(data, &r); err != nil {
+				return nil, nil, fmt.Errorf("reading checkpoint record %d: %w", i+1, err)
+			}
+			results = append(results, r.result())
+		}
+		if state.Line > 0 {
+			if err := list.Resume(state.Offset, state.Line); err != nil {
+				return nil, nil, fmt.Errorf("resuming token list: %w", err)
+			}
+			if !dec.cfg.IsSilent {
+				fmt.Printf("Resuming after line %d (%d tokens already decoded)\n", state.Line, len(results))
+			}
+		}
+		if cp, err = checkpoint.Open(dec.cfg.CheckpointFile, path, state); err != nil {
+			return nil, nil, err
+		}
+		defer func() {
+			_ = cp.Close()
+		}()
+	}
+
+	for {
+		rawToken, ok := list.Next()
+		if !ok {
+			break
+		}
+		index := len(results) + len(skipped) + 1
+		d, err := dec.decodeListed(ctx, rawToken, fmt.Sprintf("%s_%d", dec.cfg.SnapshotName, index))
+		if err != nil {
+			err = fmt.Errorf("decoding token %d (line %d): %w", index, list.Line(), err)
+			if !dec.cfg.KeepGoing {
+				return nil, nil, err
+			}
+			skipped = append(skipped, err)
+			continue
+		}
+		results = append(results, d)
+		if cp != nil {
+			if err := cp.Record(list.Line(), list.Offset(), newCheckpointResult(d)); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if err := list.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(results) == 0 && len(skipped) > 0 {
+		return nil, nil, fmt.Errorf("none of the %d tokens of %q could be decoded: %w", len(skipped), path, errors.Join(skipped...))
+	}
+	if len(results) == 0 {
+		return nil, nil, fmt.Errorf("token list %q contains no tokens", path)
+	}
+	if dec.cache != nil && !dec.cfg.IsSilent {
+		stats := dec.cache.Stats()
+		fmt.Printf("Claims cache: %d hits, %d misses\n", stats.Hits, stats.Misses)
+	}
+	return results, skipped, nil
+}
+
+// decodeListed validates and decodes a token of a token list.
+func (dec *Decoder) decodeListed(ctx context.Context, rawToken, snapshotName string) (*Result, error) {
+	if err := config.ValidateToken(rawToken, dec.cfg.MaxTokenSize, dec.cfg.InputFormat); err != nil {
+		return nil, err
+	}
+	return dec.DecodeCached(ctx, rawToken, snapshotName)
+}
+
+// DecodeDir decodes every token file of a directory tree whose name matches the
+// configured pattern, in lexical order, including matching members of archives. Each
+// result records its file, relative to the directory, in the source_file claim, and
+// snapshots are named after it. The first file that cannot be decoded stops the run,
+// unless -keep-going is set, as for DecodeList.
+func (dec *Decoder) DecodeDir(ctx context.Context, dir string) ([]*Result, []error, error) {
+	var results []*Result
+	var skipped []error
+	opts := token.Options{
+		StrictPermissions: dec.cfg.StrictPerms,
+		Warn:              dec.warn,
+		MaxSize:           int64(dec.cfg.MaxTokenSize) * 1024 * 1024,
+	}
+	snapshotName := strings.NewReplacer("/", "_", token.ArchiveSeparator, "_")
+	err := token.Walk(dir, dec.cfg.TokenPattern, opts, func(name, rawToken string) error {
+		d, err := dec.decodeFile(ctx, rawToken, snapshotName.Replace(strings.TrimSuffix(name, path.Ext(name))), name)
+		if err != nil {
+			err = fmt.Errorf("decoding %s: %w", name, err)
+			if !dec.cfg.KeepGoing {
+				return err
+			}
+			skipped = append(skipped, err)
+			return nil
+		}
+		results = append(results, d)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(results) == 0 && len(skipped) > 0 {
+		return nil, nil, fmt.Errorf("none of the %d token files of %q could be decoded: %w", len(skipped), dir, errors.Join(skipped...))
+	}
+	if len(results) == 0 {
+		return nil, nil, fmt.Errorf("no files matching %q in token directory %q", dec.cfg.TokenPattern, dir)
+	}
+	return results, skipped, nil
+}
+
+// decodeFile validates and decodes a token file of a token directory.
+func (dec *Decoder) decodeFile(ctx context.Context, rawToken, snapshotName, name string) (*Result, error) {
+	if err := config.ValidateToken(rawToken, dec.cfg.MaxTokenSize, dec.cfg.InputFormat); err != nil {
+		return nil, err
+	}
+	return dec.DecodeNamed(ctx, rawToken, snapshotName, name)
+}
+
+// checkpointResult is a decoded token as recorded in a checkpoint file.
+type checkpointResult struct {
+	Claims        jwt.MapClaims          `json:"claims"`
+	Payload       []byte                 `json:"payload,omitempty"`
+	Raw           bool                   `json:"raw,omitempty"`
+	Sources       map[string]string      `json:"sources,omitempty"`
+	Failures      []string               `json:"failures,omitempty"`
+	NotValid      string                 `json:"not_valid,omitempty"`
+	Expiry        time.Time              `json:"expiry,omitzero"`
+	Verified      bool                   `json:"verified,omitempty"`
+	Header        map[string]interface{} `json:"header,omitempty"`
+	Hash          string                 `json:"hash,omitempty"`
+	Warnings      []warnings.Warning     `json:"warnings,omitempty"`
+	Findings      []findings.Finding     `json:"findings,omitempty"`
+	NotBefore     time.Time              `json:"not_before,omitzero"`
+	SignatureSize int                    `json:"signature_size,omitempty"`
+}
+
+// newCheckpointResult returns the checkpoint record of a decoded token.
+func newCheckpointResult(d *Result) checkpointResult {
+	return checkpointResult{Claims: d.Claims, Payload: d.Payload, Raw: d.Raw, Sources: d.Sources, Failures: d.Failures, NotValid: d.NotValid, Expiry: d.Expiry, Verified: d.Verified,
+		Header: d.Header, Hash: d.Hash, Warnings: d.Warnings, Findings: d.Findings,
+		NotBefore: d.NotBefore, SignatureSize: d.SignatureSize}
+}
+
+// result restores the decoded token of a checkpoint record.
+func (r checkpointResult) result() *Result {
+	return &Result{Claims: r.Claims, Payload: r.Payload, Raw: r.Raw, Sources: r.Sources, Failures: r.Failures, NotValid: r.NotValid, Expiry: r.Expiry, Verified: r.Verified,
+		Header: r.Header, Hash: r.Hash, Warnings: r.Warnings, Findings: r.Findings,
+		NotBefore: r.NotBefore, SignatureSize: r.SignatureSize}
+}
+
+// parseInput converts a token for the decoding pipeline (see inputformat.Parse). A JWE is
+// decrypted with the key of -decrypt-key, and its plaintext converted instead.
+func (dec *Decoder) parseInput(rawToken string) (*inputformat.Input, error) {
+	format := dec.cfg.InputFormat
+	if format == "" || format == inputformat.Auto {
+		format = inputformat.Detect(rawToken)
+	}
+	if format != inputformat.JWE || dec.decrypter == nil {
+		input, err := inputformat.Parse(rawToken, dec.cfg.InputFormat)
+		if err != nil {
+			return nil, fmt.Errorf("reading token: %w", err)
+		}
+		return input, nil
+	}
+	plaintext, err := dec.decrypter.Decrypt(rawToken)
+	if err != nil {
+		return nil, fmt.Errorf("reading token: %w", err)
+	}
+	input, err := inputformat.Decrypted(plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("reading token: %w", err)
+	}
+	return input, nil
+}
+
+// Watermarked reports whether the output of a decoded token is stamped as not verified.
+func (d *Result) Watermarked(appConfig *config.AppConfig) bool {
+	return !d.Verified && !appConfig.NoWatermark
+}
+
+// verifyJWKS verifies a token with the key of the -jwks-url or -issuer-discovery key set
+// matching its kid header, with the algorithms of the key type, or the alg of the key if
+// it has one.
+// A key set that cannot be fetched is an error; a token that does not verify with it,
+// including one with an unknown kid, wraps ErrSignatureInvalid.
+func (dec *Decoder) verifyJWKS(rawToken string, token *jwt.Token) error {
+	kid, _ := token.Header["kid"].(string)
+	jwk, err := dec.jwks.Key(kid)
+	if errors.Is(err, jwks.ErrKeyNotFound) {
+		return fmt.Errorf("verifying token with JWKS: %w: %w", ErrSignatureInvalid, err)
+	}
+	if err != nil {
+		return fmt.Errorf("verifying token with JWKS: %w", err)
+	}
+	key, err := jwk.PublicKey()
+	if err != nil {
+		return fmt.Errorf("verifying token with JWKS: decoding key %q: %w", jwk.Kid, err)
+	}
+	var algs []string
+	if jwk.Alg != "" {
+		algs = []string{jwk.Alg}
+	}
+	verifier, err := verify.NewPublicKeyVerifier(key, algs)
+	if err != nil {
+		return fmt.Errorf("verifying token with JWKS: key %q: %w", jwk.Kid, err)
+	}
+	if err := verifier.Verify(rawToken, token, dec.pins); err != nil {
+		return fmt.Errorf("verifying token with JWKS key %q: %w: %w", jwk.Kid, ErrSignatureInvalid, err)
+	}
+	return nil
+}
+
+// sameIssuer reports whether two issuer identifiers are the same, ignoring a trailing
+// slash, which some issuers include (e.g., Auth0) and users often leave out.
+func sameIssuer(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
+
+// Envelope returns the envelope of a decoded token, with its claims as formatted.
+func (d *Result) Envelope(claims jwt.MapClaims) *envelope.Envelope {
+	return envelope.New(envelope.Token{SHA256: d.Hash, Source: d.Source, ExpiresAt: d.Expiry},
+		d.Header, claims, d.Verified, d.Warnings, d.Findings, d.Failures)
+}
+
+// FormatJSON formats a decoded token as JSON, printing the payload as issued when no
+// claim was modified (fast path) and keeping its key order when requested.
+func (d *Result) FormatJSON(preserveOrder bool) ([]byte, error) {
+	switch {
+	case d.Raw && d.Payload != nil:
+		return formatter.FormatRawJSON(d.Payload)
+	case preserveOrder:
+		return formatter.FormatOrderedJSON(d.Claims, d.Payload)
+	default:
+		return formatter.FormatJSON(d.Claims)
+	}
+}
+
+// checkHMACWordlist tries the candidate secrets of the configured wordlist against the token
+// and records the finding in the claims. A weak secret is always reported as a warning.
+func (dec *Decoder) checkHMACWordlist(rawToken string, token *jwt.Token, claims jwt.MapClaims) error {
+	appConfig := dec.cfg
+	wordlist, err := utils.OpenFile(appConfig.HMACWordlist)
+	if err != nil {
+		return fmt.Errorf("opening wordlist: %w", err)
+	}
+	defer func() {
+		_ = wordlist.Close()
+	}()
+	result, err := verify.CheckHMACWordlist(rawToken, token, wordlist, appConfig.MaxAttempts)
+	if err != nil {
+		return fmt.Errorf("checking HMAC wordlist: %w", err)
+	}
+	claims["hmac_weak_secret_found"] = result.Found
+	claims["hmac_wordlist_attempts"] = result.Attempts
+	if result.Found {
+		dec.warn(fmt.Sprintf("token is signed with weak HMAC secret %q (found after %d attempts)", result.Secret, result.Attempts))
+	} else if !appConfig.IsSilent {
+		fmt.Printf("No weak HMAC secret found in %d attempts\n", result.Attempts)
+	}
+	return nil
+}
+
+// printConformanceReport prints a summary of a conformance report with its failed checks.
+func printConformanceReport(report *conformance.Report) {
+	fmt.Printf("Conformance (%s): %d of %d checks passed\n", report.Profile, len(report.Results)-report.Failures(), len(report.Results))
+	for _, result := range report.Results {
+		if !result.Passed {
+			fmt.Printf("  FAIL %s: %s\n", result.Rule, result.Message)
+		}
+	}
+}
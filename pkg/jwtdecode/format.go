@@ -0,0 +1,88 @@
+package jwtdecode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/config"
+	"jwtdecode/formatter"
+)
+
+// Format formats the decoded tokens with the formatter of the configured output
+// format and its format options. A token list becomes a JSON array (or newline-delimited
+// JSON), one CSV row per token, or a <JWTClaimsSet> (or, with -xml-multidoc, one XML
+// document per token).
+func Format(appConfig *config.AppConfig, results []*Result, ndjson bool) ([]byte, error) {
+	claimsList := make([]jwt.MapClaims, len(results))
+	var sources []map[string]string
+	for i, d := range results {
+		claimsList[i] = d.Claims
+		if appConfig.StripControl {
+			claimsList[i] = formatter.StripControlCharacters(d.Claims)
+		}
+		if d.Watermarked(appConfig) && appConfig.OutputFormat != config.OutputFormatJSON {
+			claimsList[i] = formatter.Watermark(claimsList[i])
+		}
+		if appConfig.Provenance {
+			sources = append(sources, d.Sources)
+		}
+	}
+	b := formatter.Batch{Claims: claimsList, Sources: sources, Multiple: appConfig.Batch()}
+
+	switch appConfig.OutputFormat {
+	case config.OutputFormatJSON:
+		// JSON documents are formatted from each token's payload, keeping its key order
+		b.Documents = make([][]byte, len(results))
+		for i, d := range results {
+			if appConfig.Envelope {
+				doc, err := json.MarshalIndent(d.Envelope(claimsList[i]), "", "  ")
+				if err != nil {
+					return nil, fmt.Errorf("formatting envelope: %w", err)
+				}
+				b.Documents[i] = doc
+				continue
+			}
+			doc, err := d.FormatJSON(appConfig.PreserveOrder)
+			if err != nil {
+				return nil, err
+			}
+			if d.Watermarked(appConfig) {
+				doc = formatter.WatermarkJSON(doc)
+			}
+			b.Documents[i] = doc
+		}
+	case config.OutputFormatXML:
+		if !b.Multiple {
+			b.Sources = []map[string]string{results[0].Sources}
+		}
+	case config.OutputFormatTree, config.OutputFormatTable:
+		// Batch trees and tables are labeled with the token's file, or its position in the list
+		b.Labels = make([]string, len(results))
+		for i, d := range results {
+			switch {
+			case d.Source != "":
+				b.Labels[i] = d.Source
+			case b.Multiple:
+				b.Labels[i] = fmt.Sprintf("token %d", i+1)
+			default:
+				b.Labels[i] = "claims"
+			}
+		}
+	}
+
+	format, err := formatter.Get(appConfig.OutputFormat)
+	if err != nil {
+		return nil, err
+	}
+	opts := appConfig.FormatterOptions()
+	opts.JSON.NDJSON = ndjson
+	return format(b, opts)
+}
+
+// Format formats decoded tokens in the output format of the Decoder, as a batch when its
+// configuration reads a token list or directory.
+func (dec *Decoder) Format(results []*Result) ([]byte, error) {
+	return Format(dec.cfg, results, dec.cfg.NDJSON)
+}
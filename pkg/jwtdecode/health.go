@@ -0,0 +1,54 @@
+package jwtdecode
+
+import (
+	"jwtdecode/jwks"
+	"jwtdecode/trust"
+	"jwtdecode/utils"
+)
+
+// Check is the result of a readiness check of a dependency of a Decoder.
+type Check struct {
+	Name   string `json:"name"`             // What was checked: jwks, jwks-ca-file, trust-file, or trust-anchor
+	Target string `json:"target"`           // URL, file, or issuer checked
+	Tenant string `json:"tenant,omitempty"` // Tenant whose dependency was checked; empty for the configuration
+	Error  string `json:"error,omitempty"`  // Why the check failed; empty if it passed
+}
+
+// CheckDependencies checks that the dependencies of the Decoder are available: the key set
+// of -jwks-url or -issuer-discovery is reachable, and the JWKS CA bundle, the trust file,
+// and the keys of every trust anchor load. Key sets are fetched again, bypassing their
+// caches. The dependencies of tenants follow. A Decoder without dependencies has no checks.
+func (dec *Decoder) CheckDependencies() []Check {
+	var checks []Check
+	add := func(name, target string, err error) {
+		check := Check{Name: name, Target: target, Tenant: dec.cfg.Tenant}
+		if err != nil {
+			check.Error = err.Error()
+		}
+		checks = append(checks, check)
+	}
+	if path := dec.cfg.JWKSCAFile; path != "" {
+		pemData, err := utils.ReadFile(path)
+		if err == nil {
+			_, err = jwks.LoadCAs(pemData)
+		}
+		add("jwks-ca-file", path, err)
+	}
+	if dec.jwks != nil {
+		add("jwks", dec.jwks.URL, dec.jwks.Check())
+	}
+	if path := dec.cfg.TrustFile; path != "" {
+		_, err := trust.Load(path)
+		add("trust-file", path, err)
+	}
+	if dec.trustCfg != nil {
+		for i := range dec.trustCfg.Issuers {
+			anchor := &dec.trustCfg.Issuers[i]
+			add("trust-anchor", anchor.Issuer, anchor.Check())
+		}
+	}
+	for _, name := range dec.cfg.TenantNames() {
+		checks = append(checks, dec.tenants[name].CheckDependencies()...)
+	}
+	return checks
+}
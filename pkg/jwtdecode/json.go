@@ -0,0 +1,124 @@
+package jwtdecode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"jwtdecode/config"
+	"jwtdecode/findings"
+	"jwtdecode/warnings"
+)
+
+// ParseOptions decodes Options from JSON, for callers outside Go such as the WebAssembly
+// build. The fields have the names of the config file fields; hmacSecret is the secret
+// itself, clockSkew a duration (e.g., "30s"), and validateAt a reference time (RFC 3339 or
+// epoch seconds). Unknown fields are rejected.
+func ParseOptions(data []byte) (Options, error) {
+	var opts struct {
+		Options
+		HMACSecret string `json:"hmacSecret"`
+		ClockSkew  string `json:"clockSkew"`
+		ValidateAt string `json:"validateAt"`
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&opts); err != nil {
+			return Options{}, fmt.Errorf("parsing options: %w", err)
+		}
+	}
+	if opts.HMACSecret != "" {
+		opts.Options.HMACSecret = []byte(opts.HMACSecret)
+	}
+	if opts.ClockSkew != "" {
+		d, err := time.ParseDuration(opts.ClockSkew)
+		if err != nil {
+			return Options{}, fmt.Errorf("parsing options: invalid clockSkew %q: %w", opts.ClockSkew, err)
+		}
+		opts.Options.ClockSkew = d
+	}
+	if opts.ValidateAt != "" {
+		t, err := config.ParseTime(opts.ValidateAt)
+		if err != nil {
+			return Options{}, fmt.Errorf("parsing options: validateAt: %w", err)
+		}
+		opts.Now = t
+	}
+	return opts.Options, nil
+}
+
+// Response is the outcome of DecodeJSON: the decoded token and its output, or the error
+// that stopped decoding.
+type Response struct {
+	Header   map[string]interface{} `json:"header,omitempty"`
+	Claims   map[string]interface{} `json:"claims,omitempty"`
+	Verified bool                   `json:"verified"`
+	Failures []string               `json:"failures,omitempty"`
+	NotValid string                 `json:"notValid,omitempty"` // Why the token is expired or not yet valid, with validate
+	Warnings []warnings.Warning     `json:"warnings,omitempty"`
+	Findings []findings.Finding     `json:"findings,omitempty"`
+	Output   string                 `json:"output,omitempty"` // Claims formatted in the output format, as the command line prints them
+	Error    string                 `json:"error,omitempty"`
+}
+
+// DecodeJSON decodes a token with options given as JSON (see ParseOptions) and returns the
+// Response as JSON. Errors are reported in the error member, so that callers outside Go
+// only have to parse the response.
+func DecodeJSON(token string, optionsJSON []byte) []byte {
+	opts, err := ParseOptions(optionsJSON)
+	if err != nil {
+		return ErrorJSON(err)
+	}
+	dec, err := New(opts)
+	if err != nil {
+		return ErrorJSON(err)
+	}
+	return dec.DecodeJSON(token)
+}
+
+// ErrorJSON returns the JSON Response reporting err, for callers that build their Decoder
+// from JSON options themselves.
+func ErrorJSON(err error) []byte {
+	return marshalResponse(Response{Error: err.Error()})
+}
+
+// DecodeJSON decodes a token and returns the Response as JSON, as the DecodeJSON function
+// does with the options of the Decoder.
+func (dec *Decoder) DecodeJSON(token string) []byte {
+	return marshalResponse(dec.response(token))
+}
+
+// response decodes a token into a Response.
+func (dec *Decoder) response(token string) Response {
+	res, err := dec.Decode(context.Background(), strings.TrimSpace(token))
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	output, err := dec.Format([]*Result{res})
+	if err != nil {
+		return Response{Error: err.Error()}
+	}
+	return Response{
+		Header:   res.Header,
+		Claims:   res.Claims,
+		Verified: res.Verified,
+		Failures: res.Failures,
+		NotValid: res.NotValid,
+		Warnings: res.Warnings,
+		Findings: res.Findings,
+		Output:   string(output),
+	}
+}
+
+// marshalResponse returns a Response as JSON.
+func marshalResponse(resp Response) []byte {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(Response{Error: fmt.Sprintf("formatting response: %v", err)})
+	}
+	return data
+}
@@ -0,0 +1,171 @@
+package jwtdecode
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"jwtdecode/config"
+	"jwtdecode/process"
+)
+
+// Options are the settings of a Decoder built by New, the library counterparts of the
+// command-line options. The zero value decodes a token without verifying its signature
+// or processing its claims, as the command line does without options. In JSON (see
+// ParseOptions), the fields have the names of the config file fields.
+type Options struct {
+	InputFormat string `json:"inputFormat"` // Serialization of the tokens (see the inputformat package); detected when empty
+	DecryptKey  string `json:"decryptKey"`  // Private key (PEM or JWK) or symmetric key file decrypting JWE tokens
+
+	// Signature verification; the signature is not verified when none is set
+	Provider        string   `json:"provider"`        // Issuer-specific provider (see the provider package)
+	SkipVerify      bool     `json:"skipVerify"`      // Apply the conventions of Provider without verifying with its keys
+	Audience        string   `json:"audience"`        // Expected audience, checked by Provider
+	Issuer          string   `json:"issuer"`          // Expected issuer, checked by Provider
+	VerifyKey       string   `json:"verifyKey"`       // PEM file of the public key or certificate the tokens must be signed with
+	VerifyAlgs      []string `json:"verifyAlgs"`      // Algorithms allowed with VerifyKey; every algorithm of the key type when empty
+	HMACSecret      []byte   `json:"-"`               // Secret that HS256/384/512 tokens must be signed with
+	JWKSURL         string   `json:"jwksUrl"`         // HTTPS URL of the key set whose key, selected by kid, verifies the tokens
+	IssuerDiscovery string   `json:"issuerDiscovery"` // HTTPS issuer URL whose discovery document gives the key set
+	TrustFile       string   `json:"trustFile"`       // Multi-issuer trust configuration (trust.yaml)
+	ResolveDID      bool     `json:"resolveDid"`      // Verify tokens issued by a DID with the keys of its DID document
+	PinnedKeys      []string `json:"pinnedKeys"`      // RFC 7638 thumbprints that verification keys must match
+
+	// Claims processing, in the order of the command line: Query, then Pipeline
+	Query         []string             `json:"query"`         // Path expressions selecting the claims (gjson-style or JSONPath); every claim when empty
+	Pipeline      []process.Definition `json:"pipeline"`      // Claims processing steps, in order (e.g., redact, convert-epoch)
+	IncludeHeader bool                 `json:"includeHeader"` // Add the JOSE header to the claims, as the header claim
+	Conformance   string               `json:"conformance"`   // Profile the tokens are checked against (see the conformance package)
+	Validate      bool                 `json:"validate"`      // Check exp, nbf, and iat, adding the outcome to the claims (see Result.NotValid)
+	ClockSkew     time.Duration        `json:"-"`             // Tolerance of Validate for clock differences with the issuer
+	Now           time.Time            `json:"-"`             // Reference time of Validate; the current time when zero
+	Warnings      bool                 `json:"warnings"`      // Add the soft issues found to the claims, under warnings
+
+	// Output of Decoder.Format
+	OutputFormat  string               `json:"outputFormat"`  // JSON (default), CSV, XML, TREE, or TABLE
+	FormatOptions config.FormatOptions `json:"formatOptions"` // Settings of single output formats, as the formatOptions section of the config file
+	PreserveOrder bool                 `json:"preserveOrder"` // Keep claims in their payload order in JSON output
+	NoWatermark   bool                 `json:"noWatermark"`   // Do not stamp the output of tokens whose signature was not verified
+
+	MaxTokenSize int              `json:"maxTokenSizeMB"` // Size limit of a token in MB (default 1)
+	Workers      int              `json:"workers"`        // Tokens decoded at once by Decoder.DecodeAll (default GOMAXPROCS)
+	Warn         func(msg string) `json:"-"`              // Receives the soft issues as they are found, possibly concurrently; nil to only return them in Result.Warnings
+}
+
+// config returns the configuration of a run with the options, checked against the
+// validation rules of the command line. Problems name the equivalent command-line flags.
+func (o Options) config() (*config.AppConfig, error) {
+	cfg := config.Defaults()
+	if o.InputFormat != "" {
+		cfg.InputFormat = strings.ToLower(o.InputFormat)
+	}
+	cfg.DecryptKey = o.DecryptKey
+	cfg.Provider = o.Provider
+	cfg.SkipVerify = o.SkipVerify
+	cfg.Audience = o.Audience
+	cfg.Issuer = o.Issuer
+	cfg.VerifyKey = o.VerifyKey
+	cfg.VerifyAlgs = o.VerifyAlgs
+	cfg.HMACSecret = o.HMACSecret
+	cfg.JWKSURL = o.JWKSURL
+	cfg.IssuerDiscovery = o.IssuerDiscovery
+	cfg.TrustFile = o.TrustFile
+	cfg.ResolveDID = o.ResolveDID
+	cfg.PinnedKeys = o.PinnedKeys
+	cfg.Query = o.Query
+	cfg.Pipeline = o.Pipeline
+	cfg.IncludeHeader = o.IncludeHeader
+	cfg.Conformance = o.Conformance
+	cfg.Validate = o.Validate
+	cfg.ClockSkew = o.ClockSkew
+	cfg.ValidateAt = o.Now
+	cfg.Warnings = o.Warnings
+	if o.OutputFormat != "" {
+		cfg.OutputFormat = strings.ToUpper(o.OutputFormat)
+	}
+	cfg.FormatOptions = o.FormatOptions
+	cfg.PreserveOrder = o.PreserveOrder
+	cfg.NoWatermark = o.NoWatermark
+	if o.MaxTokenSize > 0 {
+		cfg.MaxTokenSize = o.MaxTokenSize
+	}
+	if err := config.Validate(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// New returns a Decoder decoding with opts. The provider, keys, key sets, and trust
+// configuration are resolved once, for every token decoded, from any number of
+// goroutines. Invalid options are reported as a *config.ValidationError.
+func New(opts Options) (*Decoder, error) {
+	if opts.Workers < 0 {
+		return nil, fmt.Errorf("workers must not be negative")
+	}
+	cfg, err := opts.config()
+	if err != nil {
+		return nil, err
+	}
+	warn := opts.Warn
+	if warn == nil {
+		warn = func(string) {}
+	}
+	dec, err := newDecoder(cfg, warn)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Workers > 0 {
+		dec.workers = opts.Workers
+	}
+	return dec, nil
+}
+
+// Decode decodes one token. The size and serialization of the token are checked first, as
+// on the command line.
+func (dec *Decoder) Decode(ctx context.Context, rawToken string) (*Result, error) {
+	if err := config.ValidateToken(rawToken, dec.cfg.MaxTokenSize, dec.cfg.InputFormat); err != nil {
+		return nil, err
+	}
+	return dec.DecodeNamed(ctx, rawToken, "", "")
+}
+
+// DecodeAll decodes tokens concurrently, with a pool of Options.Workers goroutines, and
+// returns the result or the error of each token at its index. Tokens not yet decoded when
+// ctx is done fail with the error of ctx.
+func (dec *Decoder) DecodeAll(ctx context.Context, rawTokens []string) ([]*Result, []error) {
+	results := make([]*Result, len(rawTokens))
+	errs := make([]error, len(rawTokens))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(dec.workers, len(rawTokens)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = dec.Decode(ctx, strings.TrimSpace(rawTokens[i]))
+			}
+		}()
+	}
+	for i := range rawTokens {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results, errs
+}
+
+// Decode decodes one token with opts. Programs decoding many tokens with the same options
+// should build a Decoder with New instead, which resolves the keys once.
+func Decode(token string, opts Options) (*Result, error) {
+	dec, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	return dec.Decode(context.Background(), strings.TrimSpace(token))
+}
@@ -0,0 +1,91 @@
+package jwtdecode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/process"
+	"jwtdecode/warnings"
+)
+
+// ClaimProcessor is a claims processing step of a program embedding the decoder, such as an
+// enrichment from a directory or an organization-specific redaction. Once registered with
+// RegisterClaimProcessor, it is a step like the built-in ones (see the process package):
+// pipelines of Options, of the config file, and of the server reference it by name.
+type ClaimProcessor interface {
+	// Name is the step name of the processor in pipelines.
+	Name() string
+	// Process returns the processed claims. Claims may be modified in place. An error
+	// fails the decoding of the token.
+	Process(claims map[string]interface{}, ctx *ProcessContext) (map[string]interface{}, error)
+}
+
+// OptionsChecker is implemented by the ClaimProcessors that take options, which are then
+// checked when a pipeline is built rather than when a token is processed. The options of
+// processors that do not implement it are not checked.
+type OptionsChecker interface {
+	CheckOptions(options json.RawMessage) error
+}
+
+// ProcessContext is what a ClaimProcessor knows of the token whose claims it processes,
+// and of the step it runs as.
+type ProcessContext struct {
+	Context context.Context        // Context of the decoding, e.g. for the deadline of lookups
+	Header  map[string]interface{} // JOSE header of the token; not to be modified
+	Options json.RawMessage        // Options of the step in the pipeline, as declared; nil if none
+	Warn    func(msg string)       // Reports a soft issue, as a pipeline warning of the result
+}
+
+// RegisterClaimProcessor adds p to the steps of pipelines, under its name, which must not
+// be the name of a built-in or registered step. Processors are registered before the
+// decoders referencing them are built, typically from an init function, and are called
+// concurrently when tokens are.
+func RegisterClaimProcessor(p ClaimProcessor) error {
+	return process.Register(p.Name(), func(options json.RawMessage, _ string) (process.Step, error) {
+		if checker, ok := p.(OptionsChecker); ok {
+			if err := checker.CheckOptions(options); err != nil {
+				return nil, err
+			}
+		}
+		return &processorStep{processor: p, options: options}, nil
+	})
+}
+
+// processorStep runs a ClaimProcessor as a step of a pipeline.
+type processorStep struct {
+	processor ClaimProcessor
+	options   json.RawMessage
+}
+
+// Apply runs the processor without a token, as Pipeline.Apply does not give one, reporting
+// an error as a warning and leaving the claims unchanged.
+func (s *processorStep) Apply(claims jwt.MapClaims, warn process.WarnFunc) jwt.MapClaims {
+	processed, err := s.ApplyToken(claims, process.Token{Context: context.Background()}, warn)
+	if err != nil {
+		warn(warnings.CodePipeline, err.Error())
+		return claims
+	}
+	return processed
+}
+
+// ApplyToken runs the processor on the claims of the token.
+func (s *processorStep) ApplyToken(claims jwt.MapClaims, token process.Token, warn process.WarnFunc) (jwt.MapClaims, error) {
+	processed, err := s.processor.Process(claims, &ProcessContext{
+		Context: token.Context,
+		Header:  token.Header,
+		Options: s.options,
+		Warn: func(msg string) {
+			warn(warnings.CodePipeline, msg)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.processor.Name(), err)
+	}
+	if processed == nil {
+		processed = map[string]interface{}{}
+	}
+	return processed, nil
+}
@@ -0,0 +1,46 @@
+package jwtdecode
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// quickDecoder is the Decoder without options of Quick and QuickJSON, built at first use.
+var quickDecoder = sync.OnceValues(func() (*Decoder, error) {
+	return New(Options{})
+})
+
+// Quick decodes a token as the command line does without options, and returns its claims,
+// for scripts and tests that need no more. The signature is not verified, and the token may
+// be in any serialization detected by -input-format auto.
+func Quick(token string) (map[string]interface{}, error) {
+	d, err := quickDecode(token)
+	if err != nil {
+		return nil, err
+	}
+	return d.Claims, nil
+}
+
+// QuickJSON decodes a token as Quick does, and returns its claims as the JSON document the
+// command line writes without options, watermarked as not verified.
+func QuickJSON(token string) ([]byte, error) {
+	dec, err := quickDecoder()
+	if err != nil {
+		return nil, err
+	}
+	d, err := quickDecode(token)
+	if err != nil {
+		return nil, err
+	}
+	return dec.Format([]*Result{d})
+}
+
+// quickDecode decodes a token with the Decoder of Quick.
+func quickDecode(token string) (*Result, error) {
+	dec, err := quickDecoder()
+	if err != nil {
+		return nil, err
+	}
+	return dec.Decode(context.Background(), strings.TrimSpace(token))
+}
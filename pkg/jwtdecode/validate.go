@@ -0,0 +1,81 @@
+package jwtdecode
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/jwterrors"
+)
+
+// Claims added to the output by -validate.
+const (
+	ClaimExpired     = "expired"       // Whether the token has expired
+	ClaimExpiresIn   = "expires_in"    // Time until the token expires, negative once expired, e.g. "-3600s"
+	ClaimNotYetValid = "not_yet_valid" // Whether the token is not valid yet (nbf, or iat in the future)
+)
+
+// Beginnings of the reasons of checkValidity, by which ValidityError tells them apart.
+const (
+	reasonExpired      = "expired at "
+	reasonNotBefore    = "not valid before "
+	reasonIssuedFuture = "issued in the future at "
+)
+
+// ValidityError returns why the token is not valid with Validate (see NotValid) as an
+// error wrapping ErrExpired or ErrNotYetValid, or ErrMalformedToken for a time claim that
+// is not a number; nil if it is valid.
+func (d *Result) ValidityError() error {
+	switch {
+	case d.NotValid == "":
+		return nil
+	case strings.HasPrefix(d.NotValid, reasonExpired):
+		return jwterrors.Wrap(ErrExpired, fmt.Errorf("token %s", d.NotValid))
+	case strings.HasPrefix(d.NotValid, reasonNotBefore), strings.HasPrefix(d.NotValid, reasonIssuedFuture):
+		return jwterrors.Wrap(ErrNotYetValid, fmt.Errorf("token %s", d.NotValid))
+	default:
+		return jwterrors.Wrap(ErrMalformedToken, fmt.Errorf("token is not valid: %s", d.NotValid))
+	}
+}
+
+// checkValidity checks the exp, nbf, and iat claims against now, tolerating clock
+// differences up to skew. It returns the claims describing the outcome, and why the token
+// is not valid, or "" if it is. A time claim that is not a number makes the token invalid.
+func checkValidity(claims jwt.MapClaims, now time.Time, skew time.Duration) (jwt.MapClaims, string) {
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return nil, "exp claim is not a valid time"
+	}
+	nbf, err := claims.GetNotBefore()
+	if err != nil {
+		return nil, "nbf claim is not a valid time"
+	}
+	iat, err := claims.GetIssuedAt()
+	if err != nil {
+		return nil, "iat claim is not a valid time"
+	}
+
+	outcome := jwt.MapClaims{}
+	var reason string
+	expired := exp != nil && !now.Before(exp.Add(skew))
+	if exp != nil {
+		outcome[ClaimExpiresIn] = fmt.Sprintf("%ds", int64(exp.Sub(now).Truncate(time.Second)/time.Second))
+	}
+	if expired {
+		reason = fmt.Sprintf(reasonExpired+"%s", exp.UTC().Format(time.RFC3339))
+	}
+	notYetValid := false
+	switch {
+	case nbf != nil && now.Add(skew).Before(nbf.Time):
+		notYetValid = true
+		reason = fmt.Sprintf(reasonNotBefore+"%s", nbf.UTC().Format(time.RFC3339))
+	case iat != nil && now.Add(skew).Before(iat.Time):
+		notYetValid = true
+		reason = fmt.Sprintf(reasonIssuedFuture+"%s", iat.UTC().Format(time.RFC3339))
+	}
+	outcome[ClaimExpired] = expired
+	outcome[ClaimNotYetValid] = notYetValid
+	return outcome, reason
+}
@@ -0,0 +1,129 @@
+package process
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/warnings"
+)
+
+// defaultNestedDepth is the number of levels of tokens embedded in tokens decoded by
+// default.
+const defaultNestedDepth = 4
+
+// Members of the object replacing a nested token.
+const (
+	NestedHeader = "header"
+	NestedClaims = "claims"
+)
+
+// DecodeNested replaces the string values that are JWTs, such as an id_token or
+// access_token claim, or a token in an act claim, by an object holding their decoded
+// header and claims, as -decode-nested. Values in nested objects and arrays are decoded
+// too, and so are the tokens embedded in decoded tokens, up to MaxDepth levels. The
+// signatures of nested tokens are not verified.
+type DecodeNested struct {
+	Claims   []string `json:"claims,omitempty"`   // Top-level claims searched for tokens; every claim when empty
+	MaxDepth int      `json:"maxDepth,omitempty"` // Levels of tokens embedded in tokens decoded (default 4)
+}
+
+func newDecodeNested(options json.RawMessage, _ string) (Step, error) {
+	s := &DecodeNested{}
+	if err := decodeOptions(options, s); err != nil {
+		return nil, err
+	}
+	if s.MaxDepth < 0 {
+		return nil, fmt.Errorf("maxDepth must not be negative")
+	}
+	if s.MaxDepth == 0 {
+		s.MaxDepth = defaultNestedDepth
+	}
+	return s, nil
+}
+
+// Apply decodes the nested tokens, warning about tokens left encoded beyond MaxDepth.
+func (s *DecodeNested) Apply(claims jwt.MapClaims, warn WarnFunc) jwt.MapClaims {
+	decoded := make(jwt.MapClaims, len(claims))
+	for key, value := range claims {
+		decoded[key] = value
+	}
+	names := s.Claims
+	if len(names) == 0 {
+		names = make([]string, 0, len(claims))
+		for key := range claims {
+			names = append(names, key)
+		}
+	}
+	for _, name := range names {
+		if value, ok := decoded[name]; ok {
+			decoded[name] = s.decode(value, name, 1, warn)
+		}
+	}
+	return decoded
+}
+
+// decode returns value with the tokens it holds decoded, at a depth of nested tokens.
+func (s *DecodeNested) decode(value interface{}, path string, depth int, warn WarnFunc) interface{} {
+	switch v := value.(type) {
+	case string:
+		header, claims, ok := parseNested(v)
+		if !ok {
+			return v
+		}
+		if depth > s.MaxDepth {
+			warn(warnings.CodePipeline, fmt.Sprintf("token in claim %s was not decoded, as it is nested deeper than %d levels", path, s.MaxDepth))
+			return v
+		}
+		for key, member := range claims {
+			claims[key] = s.decode(member, path+"."+NestedClaims+"."+key, depth+1, warn)
+		}
+		return map[string]interface{}{NestedHeader: header, NestedClaims: claims}
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, member := range v {
+			copied[key] = s.decode(member, path+"."+key, depth, warn)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = s.decode(item, fmt.Sprintf("%s.%d", path, i), depth, warn)
+		}
+		return copied
+	}
+	return value
+}
+
+// parseNested decodes a value with the shape of a compact JWS (three base64url segments)
+// whose header names an algorithm and whose payload is a JSON object. Values that merely
+// hold dots, such as host names or version numbers, are not tokens.
+func parseNested(value string) (map[string]interface{}, map[string]interface{}, bool) {
+	segments := strings.Split(value, ".")
+	if len(segments) != 3 || segments[0] == "" || segments[1] == "" {
+		return nil, nil, false
+	}
+	var header, claims map[string]interface{}
+	if !decodeNestedSegment(segments[0], &header) || !decodeNestedSegment(segments[1], &claims) {
+		return nil, nil, false
+	}
+	if alg, ok := header["alg"].(string); !ok || alg == "" {
+		return nil, nil, false
+	}
+	if _, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segments[2], "=")); err != nil {
+		return nil, nil, false
+	}
+	return header, claims, true
+}
+
+// decodeNestedSegment decodes a base64url segment holding a JSON object into v.
+func decodeNestedSegment(segment string, v *map[string]interface{}) bool {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil && *v != nil
+}
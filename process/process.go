@@ -0,0 +1,182 @@
+// Package process implements the claims processing pipeline: an ordered list of steps,
+// each rewriting the claims of a token before they are formatted. A pipeline is declared
+// in the config file, or built from the shorthand flags (-decode-nested, -convert-epoch,
+// -omit-null, -redact, -strip-claim-prefix). Programs embedding the decoder add their own steps
+// with Register.
+package process
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Names of the steps.
+const (
+	StepConvertEpoch = "convert-epoch"
+	StepFlatten      = "flatten"
+	StepRename       = "rename"
+	StepRedact       = "redact"
+	StepFilter       = "filter"
+	StepAnnotate     = "annotate"
+	StepDecodeNested = "decode-nested"
+)
+
+// Definition is a step of a pipeline as declared in the config file: its name and its
+// options, e.g. {"step": "rename", "options": {"claims": {"preferred_username": "user"}}}.
+type Definition struct {
+	Step    string          `json:"step"`
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// Define returns the definition of a step with options, which are marshaled to JSON.
+func Define(step string, options interface{}) Definition {
+	raw, _ := json.Marshal(options)
+	return Definition{Step: step, Options: raw}
+}
+
+// WarnFunc reports a soft issue found by a step, with its warning code.
+type WarnFunc func(code, message string)
+
+// Step is one step of a pipeline.
+type Step interface {
+	// Apply returns the processed claims. Claims may be modified in place.
+	Apply(claims jwt.MapClaims, warn WarnFunc) jwt.MapClaims
+}
+
+// Token is what a TokenStep knows of the token whose claims it processes.
+type Token struct {
+	Context context.Context        // Context of the decoding
+	Header  map[string]interface{} // JOSE header of the token
+}
+
+// TokenStep is a step that needs the token besides its claims, and may fail, such as the
+// steps registered by programs embedding the decoder. Pipeline.Run calls ApplyToken
+// instead of Apply.
+type TokenStep interface {
+	Step
+	// ApplyToken returns the processed claims, or an error failing the decoding of the
+	// token. Claims may be modified in place.
+	ApplyToken(claims jwt.MapClaims, token Token, warn WarnFunc) (jwt.MapClaims, error)
+}
+
+// Pipeline is an ordered list of steps.
+type Pipeline []Step
+
+// Apply runs the claims through every step in order.
+func (p Pipeline) Apply(claims jwt.MapClaims, warn WarnFunc) jwt.MapClaims {
+	for _, step := range p {
+		claims = step.Apply(claims, warn)
+	}
+	return claims
+}
+
+// Run runs the claims through every step in order, as Apply, giving the token to the
+// steps that are TokenSteps. The first error of a step stops the pipeline.
+func (p Pipeline) Run(claims jwt.MapClaims, token Token, warn WarnFunc) (jwt.MapClaims, error) {
+	for i, step := range p {
+		tokenStep, ok := step.(TokenStep)
+		if !ok {
+			claims = step.Apply(claims, warn)
+			continue
+		}
+		var err error
+		if claims, err = tokenStep.ApplyToken(claims, token, warn); err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return claims, nil
+}
+
+// Constructor builds a step from its options, decoded from JSON. dateLayout is the layout
+// of datestamps of steps that do not set one.
+type Constructor func(options json.RawMessage, dateLayout string) (Step, error)
+
+// constructorsMu guards constructors, which Register adds to.
+var constructorsMu sync.RWMutex
+
+// constructors build the steps by name: the built-in steps and the registered ones.
+var constructors = map[string]Constructor{
+	StepConvertEpoch: newConvertEpoch,
+	StepFlatten:      newFlatten,
+	StepRename:       newRename,
+	StepRedact:       newRedact,
+	StepFilter:       newFilter,
+	StepAnnotate:     newAnnotate,
+	StepDecodeNested: newDecodeNested,
+}
+
+// Register adds a step named name to the steps of pipelines, built by constructor. The
+// name of a built-in or registered step cannot be registered again.
+func Register(name string, constructor Constructor) error {
+	if name == "" {
+		return fmt.Errorf("registering step: empty name")
+	}
+	if constructor == nil {
+		return fmt.Errorf("registering step %s: nil constructor", name)
+	}
+	constructorsMu.Lock()
+	defer constructorsMu.Unlock()
+	if _, exists := constructors[name]; exists {
+		return fmt.Errorf("registering step %s: a step has this name", name)
+	}
+	constructors[name] = constructor
+	return nil
+}
+
+// Steps returns the names of the steps in sorted order.
+func Steps() []string {
+	constructorsMu.RLock()
+	defer constructorsMu.RUnlock()
+	names := make([]string, 0, len(constructors))
+	for name := range constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New builds the step of a definition. dateLayout is the time.Format layout of the
+// datestamps added by a convert-epoch step that does not set its own.
+func New(def Definition, dateLayout string) (Step, error) {
+	constructorsMu.RLock()
+	constructor, ok := constructors[def.Step]
+	constructorsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown step %q; must be one of: %s", def.Step, strings.Join(Steps(), ", "))
+	}
+	step, err := constructor(def.Options, dateLayout)
+	if err != nil {
+		return nil, fmt.Errorf("%s options: %w", def.Step, err)
+	}
+	return step, nil
+}
+
+// Parse builds the pipeline of a list of definitions.
+func Parse(defs []Definition, dateLayout string) (Pipeline, error) {
+	pipeline := make(Pipeline, 0, len(defs))
+	for i, def := range defs {
+		step, err := New(def, dateLayout)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		pipeline = append(pipeline, step)
+	}
+	return pipeline, nil
+}
+
+// decodeOptions decodes the options of a step, rejecting unknown options.
+func decodeOptions(options json.RawMessage, v interface{}) error {
+	if len(bytes.TrimSpace(options)) == 0 || bytes.Equal(bytes.TrimSpace(options), []byte("null")) {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(options))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
@@ -0,0 +1,457 @@
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/formatter"
+	"jwtdecode/warnings"
+)
+
+// EpochUnits are the accepted units of epoch timestamps.
+var EpochUnits = []string{"s", "ms", "us", "ns", "seconds", "milliseconds", "microseconds", "nanoseconds"}
+
+// ConvertEpoch adds a human-readable "<claim>_datestamp" next to the epoch claims (iat,
+// exp, nbf, auth_time, and those of Claims), as -convert-epoch.
+type ConvertEpoch struct {
+	Unit     string   `json:"unit,omitempty"`     // Unit of the timestamps (s, ms, us, ns); empty for the heuristic
+	Layout   string   `json:"layout,omitempty"`   // time.Format layout of the datestamps, or the name of a layout of the time package (e.g., RFC3339)
+	Claims   []string `json:"claims,omitempty"`   // Names or glob patterns of custom timestamp claims, as -epoch-claims
+	Timezone string   `json:"timezone,omitempty"` // Time zone of the datestamps, as -timezone; UTC when empty
+
+	location *time.Location
+}
+
+func newConvertEpoch(options json.RawMessage, dateLayout string) (Step, error) {
+	s := &ConvertEpoch{}
+	if err := decodeOptions(options, s); err != nil {
+		return nil, err
+	}
+	if s.Unit != "" && !slices.Contains(EpochUnits, strings.ToLower(s.Unit)) {
+		return nil, fmt.Errorf("invalid unit %q; must be one of: %s", s.Unit, strings.Join(EpochUnits, ", "))
+	}
+	if err := formatter.ValidEpochPatterns(s.Claims); err != nil {
+		return nil, err
+	}
+	if s.Layout == "" {
+		s.Layout = valueOr(dateLayout, formatter.DefaultDateLayout)
+	}
+	s.Layout = formatter.ResolveLayout(s.Layout)
+	if time.Unix(0, 0).UTC().Format(s.Layout) == s.Layout {
+		return nil, fmt.Errorf("layout %q holds no date or time element", s.Layout)
+	}
+	location, err := formatter.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	s.location = location
+	return s, nil
+}
+
+// Apply adds the datestamps, warning about the claims whose unit is guessed.
+func (s *ConvertEpoch) Apply(claims jwt.MapClaims, warn WarnFunc) jwt.MapClaims {
+	for _, guess := range formatter.GuessedEpochUnitsMatching(claims, s.Claims, s.Unit) {
+		warn(warnings.CodeEpochHeuristic, "epoch unit of "+guess+" was guessed; set -epoch-unit to confirm it")
+	}
+	return formatter.AddDatestampsIn(claims, s.Claims, s.Unit, s.Layout, s.location)
+}
+
+// Flatten replaces nested objects by their members, under keys joining the names of the
+// path with the separator, e.g. "address.country". With Arrays, array items are
+// flattened too, under their index, e.g. "roles.0".
+type Flatten struct {
+	Separator string `json:"separator,omitempty"` // Separator of the names of a path (default ".")
+	Arrays    bool   `json:"arrays,omitempty"`    // Flatten arrays into indexed keys as well
+}
+
+func newFlatten(options json.RawMessage, _ string) (Step, error) {
+	s := &Flatten{}
+	if err := decodeOptions(options, s); err != nil {
+		return nil, err
+	}
+	if s.Separator == "" {
+		s.Separator = "."
+	}
+	return s, nil
+}
+
+// Apply flattens the claims. A flattened key that is already a claim is left nested, with
+// a warning.
+func (s *Flatten) Apply(claims jwt.MapClaims, warn WarnFunc) jwt.MapClaims {
+	flat := make(jwt.MapClaims, len(claims))
+	for key, value := range claims {
+		if _, nested := s.nested(value); !nested {
+			flat[key] = value
+		}
+	}
+	for key, value := range claims {
+		members, nested := s.nested(value)
+		if !nested {
+			continue
+		}
+		flattened := map[string]interface{}{}
+		s.flatten(flattened, key, members)
+		collision := ""
+		for name := range flattened {
+			if _, exists := flat[name]; exists {
+				collision = name
+				break
+			}
+		}
+		if collision != "" {
+			warn(warnings.CodePipeline, fmt.Sprintf("claim %s was not flattened, as %s is already a claim", key, collision))
+			flat[key] = value
+			continue
+		}
+		for name, member := range flattened {
+			flat[name] = member
+		}
+	}
+	return flat
+}
+
+// nested returns the members of an object, or of an array with Arrays, by name.
+func (s *Flatten) nested(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, len(v) > 0
+	case []interface{}:
+		if !s.Arrays || len(v) == 0 {
+			return nil, false
+		}
+		members := make(map[string]interface{}, len(v))
+		for i, item := range v {
+			members[strconv.Itoa(i)] = item
+		}
+		return members, true
+	}
+	return nil, false
+}
+
+// flatten adds the members of an object under prefix to out, recursively.
+func (s *Flatten) flatten(out map[string]interface{}, prefix string, members map[string]interface{}) {
+	for name, value := range members {
+		key := prefix + s.Separator + name
+		if nested, ok := s.nested(value); ok {
+			s.flatten(out, key, nested)
+			continue
+		}
+		out[key] = value
+	}
+}
+
+// Rename collapses namespaced claims to their short names by removing the first matching
+// prefix, as -strip-claim-prefix, and renames claims.
+type Rename struct {
+	Prefixes []string          `json:"prefixes,omitempty"` // Namespace prefixes removed from claim keys
+	Claims   map[string]string `json:"claims,omitempty"`   // New name of each renamed claim
+}
+
+func newRename(options json.RawMessage, _ string) (Step, error) {
+	s := &Rename{}
+	if err := decodeOptions(options, s); err != nil {
+		return nil, err
+	}
+	if len(s.Prefixes) == 0 && len(s.Claims) == 0 {
+		return nil, fmt.Errorf("requires prefixes or claims")
+	}
+	for from, to := range s.Claims {
+		if to == "" {
+			return nil, fmt.Errorf("claim %s is renamed to an empty name", from)
+		}
+	}
+	return s, nil
+}
+
+// Apply renames the claims. A claim is not renamed to the name of another claim, with a
+// warning.
+func (s *Rename) Apply(claims jwt.MapClaims, warn WarnFunc) jwt.MapClaims {
+	claims = formatter.StripClaimPrefixes(claims, s.Prefixes)
+	if len(s.Claims) == 0 {
+		return claims
+	}
+	renamed := make(jwt.MapClaims, len(claims))
+	for key, value := range claims {
+		renamed[key] = value
+	}
+	for from, to := range s.Claims {
+		value, ok := claims[from]
+		if !ok || from == to {
+			continue
+		}
+		if _, exists := renamed[to]; exists {
+			warn(warnings.CodePipeline, fmt.Sprintf("claim %s was not renamed, as %s is already a claim", from, to))
+			continue
+		}
+		delete(renamed, from)
+		renamed[to] = value
+	}
+	return renamed
+}
+
+// RedactProfilePII is the profile of the Redact step redacting personal data.
+const RedactProfilePII = "pii"
+
+// RedactProfiles are the built-in sets of sensitive claims of the Redact step, by name.
+var RedactProfiles = map[string][]string{
+	RedactProfilePII: {"email", "phone_number", "ssn", "address"},
+}
+
+// Hints kept by masked values: the last digits of a number of at least twice as many
+// digits, e.g. of a phone number, and the first character of text of at least
+// minHintLength characters.
+const (
+	visibleDigits = 4
+	minHintLength = 4
+)
+
+// Redact replaces the values of claims by a placeholder, so that the output can be shared.
+type Redact struct {
+	Claims      []string `json:"claims,omitempty"`      // Claims redacted: names, or dotted paths into nested objects (e.g., "address.street")
+	Profile     string   `json:"profile,omitempty"`     // Built-in set of sensitive claims redacted too, one of RedactProfiles (e.g., pii)
+	Mask        bool     `json:"mask,omitempty"`        // Mask values, keeping a hint of them (e.g., j***@example.com), instead of replacing them
+	Replacement string   `json:"replacement,omitempty"` // Placeholder of redacted values (default "REDACTED")
+
+	claims []string
+}
+
+func newRedact(options json.RawMessage, _ string) (Step, error) {
+	s := &Redact{}
+	if err := decodeOptions(options, s); err != nil {
+		return nil, err
+	}
+	s.claims = s.Claims
+	if s.Profile != "" {
+		profile, ok := RedactProfiles[s.Profile]
+		if !ok {
+			return nil, fmt.Errorf("unknown profile %q; must be one of: %s", s.Profile, strings.Join(redactProfileNames(), ", "))
+		}
+		s.claims = append(slices.Clip(s.Claims), profile...)
+	}
+	if len(s.claims) == 0 {
+		return nil, fmt.Errorf("requires claims or a profile")
+	}
+	if s.Mask && s.Replacement != "" {
+		return nil, fmt.Errorf("replacement cannot be combined with mask")
+	}
+	if s.Replacement == "" {
+		s.Replacement = "REDACTED"
+	}
+	return s, nil
+}
+
+// redactProfileNames returns the names of RedactProfiles, sorted.
+func redactProfileNames() []string {
+	names := make([]string, 0, len(RedactProfiles))
+	for name := range RedactProfiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Apply redacts the claims. A name is a claim name if the token has such a claim, as
+// namespaced claims are URLs holding dots, and a path otherwise.
+func (s *Redact) Apply(claims jwt.MapClaims, _ WarnFunc) jwt.MapClaims {
+	redacted := make(jwt.MapClaims, len(claims))
+	for key, value := range claims {
+		redacted[key] = value
+	}
+	for _, name := range s.claims {
+		if value, ok := redacted[name]; ok {
+			redacted[name] = s.redact(value)
+			continue
+		}
+		redactPath(redacted, strings.Split(name, "."), s.redact)
+	}
+	return redacted
+}
+
+// redact returns the redacted value of a claim: masked with Mask, the replacement otherwise.
+func (s *Redact) redact(value interface{}) interface{} {
+	if s.Mask {
+		return MaskValue(value)
+	}
+	return s.Replacement
+}
+
+// redactPath replaces the value at a path into nested objects by its redacted value,
+// copying the objects on the way so that the decoded claims are not modified.
+func redactPath(object map[string]interface{}, path []string, redact func(interface{}) interface{}) {
+	value, ok := object[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		object[path[0]] = redact(value)
+		return
+	}
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	copied := make(map[string]interface{}, len(nested))
+	for key, v := range nested {
+		copied[key] = v
+	}
+	redactPath(copied, path[1:], redact)
+	object[path[0]] = copied
+}
+
+// MaskValue masks a claim value, keeping a hint of it for whoever knows the value: the
+// first character of text (e.g., "J***"), and of the local part of an email address, whose
+// domain is kept (e.g., "j***@example.com"), or the last digits of a long number written
+// as text (e.g., "***-**-6789" for an SSN, with its separators). Short values are masked
+// whole. The members of objects, such as an OIDC address, and the items of arrays are
+// masked one by one. Other values become "***", and null stays null.
+func MaskValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return maskString(v)
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for key, member := range v {
+			masked[key] = MaskValue(member)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = MaskValue(item)
+		}
+		return masked
+	default:
+		return "***"
+	}
+}
+
+// maskString masks a string value (see MaskValue).
+func maskString(value string) string {
+	if value == "" {
+		return ""
+	}
+	if at := strings.LastIndex(value, "@"); at > 0 {
+		return maskString(value[:at]) + value[at:]
+	}
+	digits, length := 0, 0
+	for _, r := range value {
+		length++
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+	if digits > 0 && digits*2 >= length {
+		// Most of the value is a number: mask its digits, but the last ones of long numbers
+		visible := 0
+		if digits >= 2*visibleDigits {
+			visible = visibleDigits
+		}
+		var masked strings.Builder
+		seen := 0
+		for _, r := range value {
+			if unicode.IsDigit(r) {
+				if seen++; seen <= digits-visible {
+					r = '*'
+				}
+			}
+			masked.WriteRune(r)
+		}
+		return masked.String()
+	}
+	if length < minHintLength {
+		return "***"
+	}
+	first, _ := utf8.DecodeRuneInString(value)
+	return string(first) + "***"
+}
+
+// Filter keeps or removes claims.
+type Filter struct {
+	Include  []string `json:"include,omitempty"`  // Claims kept, dropping the others; every claim when empty
+	Exclude  []string `json:"exclude,omitempty"`  // Claims removed
+	OmitNull bool     `json:"omitNull,omitempty"` // Remove null claims, and null members of nested objects, as -omit-null
+}
+
+func newFilter(options json.RawMessage, _ string) (Step, error) {
+	s := &Filter{}
+	if err := decodeOptions(options, s); err != nil {
+		return nil, err
+	}
+	if len(s.Include) == 0 && len(s.Exclude) == 0 && !s.OmitNull {
+		return nil, fmt.Errorf("requires include, exclude, or omitNull")
+	}
+	return s, nil
+}
+
+// Apply filters the claims.
+func (s *Filter) Apply(claims jwt.MapClaims, _ WarnFunc) jwt.MapClaims {
+	filtered := make(jwt.MapClaims, len(claims))
+	if len(s.Include) > 0 {
+		for _, name := range s.Include {
+			if value, ok := claims[name]; ok {
+				filtered[name] = value
+			}
+		}
+	} else {
+		for key, value := range claims {
+			filtered[key] = value
+		}
+	}
+	for _, name := range s.Exclude {
+		delete(filtered, name)
+	}
+	if s.OmitNull {
+		filtered = formatter.OmitNull(filtered)
+	}
+	return filtered
+}
+
+// Annotate adds fixed claims, e.g. the environment the token was captured in.
+type Annotate struct {
+	Claims map[string]interface{} `json:"claims,omitempty"` // Claims added, by name
+}
+
+func newAnnotate(options json.RawMessage, _ string) (Step, error) {
+	s := &Annotate{}
+	if err := decodeOptions(options, s); err != nil {
+		return nil, err
+	}
+	if len(s.Claims) == 0 {
+		return nil, fmt.Errorf("requires claims")
+	}
+	return s, nil
+}
+
+// Apply adds the claims. A claim of the token is kept, with a warning.
+func (s *Annotate) Apply(claims jwt.MapClaims, warn WarnFunc) jwt.MapClaims {
+	annotated := make(jwt.MapClaims, len(claims)+len(s.Claims))
+	for key, value := range claims {
+		annotated[key] = value
+	}
+	for name, value := range s.Claims {
+		if _, exists := annotated[name]; exists {
+			warn(warnings.CodePipeline, fmt.Sprintf("annotation %s was not added, as the token has such a claim", name))
+			continue
+		}
+		annotated[name] = value
+	}
+	return annotated
+}
+
+// valueOr returns value, or fallback if it is empty.
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,65 @@
+package provenance
+
+import (
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Sources of claims. Sources of claims fetched from elsewhere are named after their
+// origin, e.g. "provider:auth0".
+const (
+	SourcePayload    = "payload"    // Decoded from the token payload
+	SourceDerived    = "derived"    // Computed by jwtdecode (annotations, findings, reports)
+	SourceHeader     = "header"     // Decoded from the token header (-include-header, -header-only)
+	SourceDisclosure = "disclosure" // Disclosed by an SD-JWT disclosure
+)
+
+// Tracker records where each claim originated as claims from several sources are merged.
+type Tracker struct {
+	sources map[string]string
+}
+
+// New returns a tracker attributing all current claims to the token payload.
+func New(claims jwt.MapClaims) *Tracker {
+	t := &Tracker{sources: make(map[string]string, len(claims))}
+	t.Record(claims, SourcePayload)
+	return t
+}
+
+// Record attributes every claim that is not tracked yet to source. It is called after
+// each step that merges claims from a new source.
+func (t *Tracker) Record(claims jwt.MapClaims, source string) {
+	for key := range claims {
+		if _, ok := t.sources[key]; !ok {
+			t.sources[key] = source
+		}
+	}
+}
+
+// Renamed attributes a claim that was renamed (e.g., by prefix stripping) to the
+// source of its original name.
+func (t *Tracker) Renamed(from, to string) {
+	if source, ok := t.sources[from]; ok {
+		t.sources[to] = source
+	}
+}
+
+// Sources returns the source of every claim in claims. Claims that were never recorded
+// were added by jwtdecode itself and are attributed to SourceDerived.
+func (t *Tracker) Sources(claims jwt.MapClaims) map[string]string {
+	sources := make(map[string]string, len(claims))
+	for key := range claims {
+		source, ok := t.sources[key]
+		if !ok {
+			source = SourceDerived
+		}
+		sources[key] = source
+	}
+	return sources
+}
+
+// SidecarPath returns the path of the provenance sidecar for an output file.
+func SidecarPath(outputFile string) string {
+	return strings.TrimSuffix(outputFile, ".json") + ".provenance.json"
+}
@@ -0,0 +1,248 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/jwks"
+	"jwtdecode/verify"
+)
+
+const (
+	appleIDIssuer           = "https://appleid.apple.com"
+	appleJWKSURL            = "https://appleid.apple.com/auth/keys"
+	appStoreConnectAudience = "appstoreconnect-v1"
+
+	// appleRootCAG3SHA256 is the SHA-256 fingerprint of Apple Root CA - G3, which anchors
+	// the x5c chains of App Store signed payloads (notifications, transactions, renewals).
+	appleRootCAG3SHA256 = "63343abfb89a6a03ebb57e9b3f5fa7be7c4f5c756f3017b3a8c488c3653e9179"
+)
+
+// Apple token kinds, reported in the apple_token_type annotation.
+const (
+	appleKindSignIn        = "sign-in-with-apple"
+	appleKindConnectAPI    = "app-store-connect-api"
+	appleKindServerAPI     = "app-store-server-api"
+	appleKindSignedPayload = "app-store-signed-payload"
+)
+
+// appleRealUserStatus describes the values of the real_user_status claim.
+var appleRealUserStatus = map[int64]string{
+	0: "Unsupported: real user detection is not available on this platform",
+	1: "Unknown: the system could not determine whether the user is real",
+	2: "LikelyReal: the user is very likely a real person",
+}
+
+// appleClaimNotes documents the conventions of Apple tokens, by claim.
+var appleClaimNotes = map[string]string{
+	"iss":              "https://appleid.apple.com for Sign in with Apple; the issuer ID (or, for individual keys, the key ID) for App Store API tokens",
+	"aud":              "Client ID (bundle ID or Services ID) for Sign in with Apple; appstoreconnect-v1 for App Store API tokens",
+	"sub":              "Stable user identifier, unique to the developer team: the same Apple ID has a different sub for another team",
+	"exp":              "App Store API tokens may live at most 20 minutes after iat",
+	"bid":              "Bundle ID of the app; distinguishes App Store Server API tokens from App Store Connect API tokens",
+	"email":            "Only issued on the first authorization; may be a private relay address (@privaterelay.appleid.com)",
+	"email_verified":   "Issued as the string \"true\" or a boolean; Apple only issues verified addresses",
+	"is_private_email": "Whether email is an Apple private relay address forwarding to the user's real address",
+	"real_user_status": "Likelihood that the user is a real person: " + describeRealUserStatus(),
+	"transfer_sub":     "User identifier from the previous team, after the app was transferred; map it to the user's sub from that team",
+	"nonce_supported":  "Whether the platform supports the nonce claim",
+}
+
+// describeRealUserStatus lists the values of the real_user_status claim.
+func describeRealUserStatus() string {
+	values := make([]string, 0, len(appleRealUserStatus))
+	for status := int64(0); status < int64(len(appleRealUserStatus)); status++ {
+		values = append(values, fmt.Sprintf("%d = %s", status, appleRealUserStatus[status]))
+	}
+	return strings.Join(values, "; ")
+}
+
+// apple handles Sign in with Apple identity tokens, App Store Connect and App Store Server
+// API authentication tokens, and App Store signed payloads (JWS with an x5c chain).
+type apple struct {
+	opts Options
+}
+
+func init() {
+	register("apple", func(opts Options) Provider { return apple{opts: opts} })
+}
+
+// Name returns the provider identifier.
+func (apple) Name() string {
+	return "apple"
+}
+
+// claimNotes returns the notes on the claims of Apple tokens.
+func (apple) claimNotes() map[string]string {
+	return appleClaimNotes
+}
+
+// Normalize returns the token unchanged; Apple tokens use standard encoding.
+func (apple) Normalize(raw string) (string, error) {
+	return raw, nil
+}
+
+// Verify selects the verification method matching the token kind: Apple's JWKS for
+// identity tokens and the x5c certificate chain for App Store signed payloads.
+func (apple) Verify(raw string, token *jwt.Token, pins verify.Pins) error {
+	switch appleKind(token) {
+	case appleKindSignedPayload:
+		key, err := appleChainKey(token)
+		if err != nil {
+			return err
+		}
+		return verify.Signature(raw, token, key, pins)
+	case appleKindSignIn:
+		set, err := jwks.Fetch(appleJWKSURL)
+		if err != nil {
+			return fmt.Errorf("retrieving Apple JWKS: %w", err)
+		}
+		kid, _ := token.Header["kid"].(string)
+		jwk, err := set.Find(kid)
+		if err != nil {
+			return err
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return fmt.Errorf("decoding Apple key %q: %w", kid, err)
+		}
+		return verify.Signature(raw, token, key, pins)
+	case appleKindConnectAPI, appleKindServerAPI:
+		return fmt.Errorf("API authentication tokens are signed with your own private key and cannot be verified with Apple keys; use -skip-verify")
+	default:
+		return fmt.Errorf("token is not a recognized Apple token")
+	}
+}
+
+// Process validates Apple's iss/aud conventions for the token kind and annotates
+// cryptic claims with their meaning.
+func (p apple) Process(token *jwt.Token, claims jwt.MapClaims) (jwt.MapClaims, error) {
+	kind := appleKind(token)
+	switch kind {
+	case appleKindSignIn:
+		if err := checkAudience(claims, p.opts.Audience); err != nil {
+			return nil, err
+		}
+	case appleKindConnectAPI, appleKindServerAPI:
+		if iss, _ := claims["iss"].(string); iss == "" {
+			return nil, fmt.Errorf("API token is missing the iss claim (issuer ID or key ID)")
+		}
+		iat, _ := claims.GetIssuedAt()
+		exp, _ := claims.GetExpirationTime()
+		if iat == nil || exp == nil {
+			return nil, fmt.Errorf("API token must carry both iat and exp claims")
+		}
+		if exp.Sub(iat.Time).Minutes() > 20 {
+			return nil, fmt.Errorf("API token lifetime exceeds Apple's 20 minute limit")
+		}
+	case appleKindSignedPayload:
+		// Structure is enforced by the certificate chain; no claim conventions to check
+	default:
+		return nil, fmt.Errorf("token is not a recognized Apple token (iss %v, aud %v)", claims["iss"], claims["aud"])
+	}
+
+	annotated := make(jwt.MapClaims, len(claims)+4)
+	for k, v := range claims {
+		annotated[k] = v
+	}
+	annotated["apple_token_type"] = kind
+	if status, ok := claimInt(claims["real_user_status"]); ok {
+		if desc, known := appleRealUserStatus[status]; known {
+			annotated["real_user_status_description"] = desc
+		}
+	}
+	if _, ok := claims["transfer_sub"]; ok {
+		annotated["transfer_sub_description"] = "User was transferred from another team; map this identifier to the user's sub from the previous team"
+	}
+	if isTrue(claims["is_private_email"]) {
+		annotated["is_private_email_description"] = "Email is an Apple private relay address"
+	}
+	return annotated, nil
+}
+
+// appleKind classifies an Apple token from its header and claims.
+func appleKind(token *jwt.Token) string {
+	if _, ok := token.Header["x5c"]; ok {
+		return appleKindSignedPayload
+	}
+	claims, _ := token.Claims.(jwt.MapClaims)
+	if iss, _ := claims["iss"].(string); iss == appleIDIssuer {
+		return appleKindSignIn
+	}
+	if aud, _ := claims.GetAudience(); len(aud) == 1 && aud[0] == appStoreConnectAudience {
+		// App Store Server API tokens carry the app's bundle ID
+		if _, ok := claims["bid"]; ok {
+			return appleKindServerAPI
+		}
+		return appleKindConnectAPI
+	}
+	return ""
+}
+
+// appleChainKey validates the x5c certificate chain against the pinned Apple root
+// and returns the leaf certificate's public key.
+func appleChainKey(token *jwt.Token) (interface{}, error) {
+	rawChain, _ := token.Header["x5c"].([]interface{})
+	if len(rawChain) < 2 {
+		return nil, fmt.Errorf("x5c header must contain a certificate chain")
+	}
+	certs := make([]*x509.Certificate, 0, len(rawChain))
+	for i, entry := range rawChain {
+		s, _ := entry.(string)
+		der, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("decoding x5c certificate %d: %w", i+1, err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("parsing x5c certificate %d: %w", i+1, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	root := certs[len(certs)-1]
+	fingerprint := sha256.Sum256(root.Raw)
+	if hex.EncodeToString(fingerprint[:]) != appleRootCAG3SHA256 {
+		return nil, fmt.Errorf("x5c chain is not anchored in Apple Root CA - G3")
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1 : len(certs)-1] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, fmt.Errorf("verifying x5c chain: %w", err)
+	}
+	return certs[0].PublicKey, nil
+}
+
+// claimInt extracts an integer from a numeric claim value.
+func claimInt(v interface{}) (int64, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// isTrue reports whether a claim is true, accepting Apple's string-encoded booleans.
+func isTrue(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	}
+	return false
+}
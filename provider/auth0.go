@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/formatter"
+	"jwtdecode/jwks"
+	"jwtdecode/verify"
+)
+
+// auth0Domain is the suffix of Auth0-hosted tenant domains, whose keys are trusted
+// without an explicitly configured issuer.
+const auth0Domain = ".auth0.com"
+
+// auth0ClaimNotes documents the conventions of Auth0 tokens, by claim.
+var auth0ClaimNotes = map[string]string{
+	"iss":         "Tenant URL with a trailing slash, e.g. https://example.eu.auth0.com/, or the tenant's custom domain",
+	"sub":         "<connection>|<user ID>, e.g. auth0|5f7c8ec7c33c6c004bbafe82 or google-oauth2|1234; <client ID>@clients for client credentials tokens",
+	"aud":         "API identifier for access tokens (with the /userinfo endpoint when openid is requested); client ID for ID tokens",
+	"azp":         "Client ID of the application the token was issued to",
+	"gty":         "Grant type of non-interactive tokens, e.g. client-credentials or password",
+	"scope":       "Scopes granted for the API, space-separated",
+	"permissions": "Permissions of the user for the API, added when RBAC and \"Add Permissions in the Access Token\" are enabled",
+	"org_id":      "ID of the organization the user logged in through",
+	"org_name":    "Name of the organization the user logged in through, if enabled for the tenant",
+}
+
+// auth0 handles Auth0 tokens: keys are fetched from the tenant's JWKS and URL-namespaced
+// custom claims (e.g., "https://myapp.example.com/roles") are collapsed to short names.
+type auth0 struct {
+	opts Options
+}
+
+func init() {
+	register("auth0", func(opts Options) Provider { return auth0{opts: opts} })
+}
+
+// Name returns the provider identifier.
+func (auth0) Name() string {
+	return "auth0"
+}
+
+// claimNotes returns the notes on the claims of Auth0 tokens. Custom claims are URL-namespaced
+// (e.g., https://myapp.example.com/roles), unless the tenant allows unnamespaced claims.
+func (auth0) claimNotes() map[string]string {
+	return auth0ClaimNotes
+}
+
+// Normalize returns the token unchanged; Auth0 tokens use standard encoding.
+func (auth0) Normalize(raw string) (string, error) {
+	return raw, nil
+}
+
+// Verify fetches the tenant's JWKS and verifies the signature. The tenant is the expected
+// issuer when configured; otherwise the token's iss is only trusted on Auth0-hosted domains.
+func (p auth0) Verify(raw string, token *jwt.Token, pins verify.Pins) error {
+	claims, _ := token.Claims.(jwt.MapClaims)
+	iss, _ := claims["iss"].(string)
+	if p.opts.Issuer != "" && iss != p.opts.Issuer {
+		return fmt.Errorf("iss %q does not match expected issuer %q", iss, p.opts.Issuer)
+	}
+	issuerURL, err := url.Parse(iss)
+	if err != nil || issuerURL.Scheme != "https" || issuerURL.Host == "" {
+		return fmt.Errorf("iss %q is not an HTTPS issuer URL", iss)
+	}
+	if p.opts.Issuer == "" && !strings.HasSuffix(issuerURL.Hostname(), auth0Domain) {
+		return fmt.Errorf("iss %q is not an Auth0-hosted domain; set -issuer to trust a custom domain", iss)
+	}
+
+	set, err := jwks.Fetch(strings.TrimSuffix(iss, "/") + "/.well-known/jwks.json")
+	if err != nil {
+		return fmt.Errorf("retrieving Auth0 JWKS: %w", err)
+	}
+	kid, _ := token.Header["kid"].(string)
+	jwk, err := set.Find(kid)
+	if err != nil {
+		return err
+	}
+	key, err := jwk.PublicKey()
+	if err != nil {
+		return fmt.Errorf("decoding Auth0 key %q: %w", kid, err)
+	}
+	return verify.Signature(raw, token, key, pins)
+}
+
+// Process checks the expected audience and collapses namespaced custom claims.
+func (p auth0) Process(_ *jwt.Token, claims jwt.MapClaims) (jwt.MapClaims, error) {
+	if err := checkAudience(claims, p.opts.Audience); err != nil {
+		return nil, err
+	}
+	return formatter.StripClaimPrefixes(claims, auth0Namespaces(claims)), nil
+}
+
+// auth0Namespaces returns the namespace prefixes of URL-keyed claims, i.e. everything
+// up to and including the last slash of keys such as "https://myapp.example.com/roles".
+func auth0Namespaces(claims jwt.MapClaims) []string {
+	seen := make(map[string]bool)
+	var namespaces []string
+	for key := range claims {
+		if !strings.HasPrefix(key, "https://") && !strings.HasPrefix(key, "http://") {
+			continue
+		}
+		idx := strings.LastIndex(key, "/")
+		namespace := key[:idx+1]
+		if !seen[namespace] {
+			seen[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/httpfetch"
+	"jwtdecode/verify"
+)
+
+var (
+	// albSafeValue restricts header values that are interpolated into key URLs.
+	albSafeValue = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+)
+
+// albClaimNotes documents the conventions of ALB tokens, by claim.
+var albClaimNotes = map[string]string{
+	"iss": "Issuer of the identity provider configured on the listener rule, not of the load balancer",
+	"sub": "Subject returned by the identity provider's user info endpoint",
+	"exp": "ALB signs the claims for each request with a short lifetime; captured tokens are usually expired",
+	"aud": "Not issued; the OIDC client ID is carried in the client header and checked against -audience",
+}
+
+// awsALB handles the x-amzn-oidc-data tokens issued by Amazon Application Load Balancers.
+// These tokens use padded base64 and are signed with ES256 keys published per region.
+type awsALB struct {
+	opts Options
+}
+
+func init() {
+	register("aws-alb", func(opts Options) Provider { return awsALB{opts: opts} })
+}
+
+// Name returns the provider identifier.
+func (awsALB) Name() string {
+	return "aws-alb"
+}
+
+// claimNotes returns the notes on the claims of ALB tokens. The payload holds the user
+// claims from the identity provider's user info endpoint.
+func (awsALB) claimNotes() map[string]string {
+	return albClaimNotes
+}
+
+// Normalize strips the padding ALB adds to each segment and converts to base64url.
+func (awsALB) Normalize(raw string) (string, error) {
+	return canonicalize(raw)
+}
+
+// Verify fetches the regional ELB public key identified by the header's kid and
+// verifies the signature. The region is taken from the load balancer ARN in the signer header.
+func (awsALB) Verify(raw string, token *jwt.Token, pins verify.Pins) error {
+	if token.Method.Alg() != jwt.SigningMethodES256.Alg() {
+		return fmt.Errorf("unexpected ALB signing algorithm %q; expected ES256", token.Method.Alg())
+	}
+	kid, _ := token.Header["kid"].(string)
+	if !albSafeValue.MatchString(kid) {
+		return fmt.Errorf("missing or invalid kid header %q", kid)
+	}
+	signer, _ := token.Header["signer"].(string)
+	region, err := albRegion(signer)
+	if err != nil {
+		return err
+	}
+
+	pemData, err := httpfetch.Get(albKeyURL(region, kid), 0)
+	if err != nil {
+		return fmt.Errorf("retrieving ALB public key: %w", err)
+	}
+	key, err := jwt.ParseECPublicKeyFromPEM(pemData)
+	if err != nil {
+		return fmt.Errorf("parsing ALB public key: %w", err)
+	}
+	return verify.Signature(raw, token, key, pins)
+}
+
+// Process checks the expected audience, which for ALB tokens is the OIDC client ID
+// carried in the client header rather than in the payload.
+func (p awsALB) Process(token *jwt.Token, claims jwt.MapClaims) (jwt.MapClaims, error) {
+	if p.opts.Audience != "" {
+		if client, _ := token.Header["client"].(string); client != p.opts.Audience {
+			return nil, fmt.Errorf("client header %q does not match expected audience %q", client, p.opts.Audience)
+		}
+	}
+	return claims, nil
+}
+
+// albRegion extracts the region from a load balancer ARN such as
+// arn:aws:elasticloadbalancing:us-east-2:123456789012:loadbalancer/app/name/id.
+func albRegion(signer string) (string, error) {
+	fields := strings.Split(signer, ":")
+	if len(fields) < 6 || fields[0] != "arn" || fields[2] != "elasticloadbalancing" {
+		return "", fmt.Errorf("signer header %q is not a load balancer ARN", signer)
+	}
+	region := fields[3]
+	if !albSafeValue.MatchString(region) {
+		return "", fmt.Errorf("invalid region %q in signer header", region)
+	}
+	return region, nil
+}
+
+// albKeyURL returns the public key endpoint for the given region and key ID.
+// GovCloud regions publish their keys in S3 rather than on the ELB key endpoint.
+func albKeyURL(region, kid string) string {
+	if strings.HasPrefix(region, "us-gov-") {
+		return fmt.Sprintf("https://s3-%s.amazonaws.com/aws-elb-public-keys-prod-%s/%s", region, region, kid)
+	}
+	return fmt.Sprintf("https://public-keys.auth.elb.%s.amazonaws.com/%s", region, kid)
+}
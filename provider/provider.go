@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/verify"
+)
+
+// Options carries user-supplied expectations that providers validate against.
+type Options struct {
+	Audience string // Expected audience (e.g., client ID or bundle ID); empty to skip the check
+	Issuer   string // Expected issuer (e.g., a custom tenant domain); empty to use provider defaults
+}
+
+// Provider adapts decoding and verification to the conventions of a specific token issuer.
+type Provider interface {
+	// Name returns the identifier used with the -provider flag.
+	Name() string
+	// Normalize rewrites the raw token so that a standard JWT parser can decode it.
+	Normalize(raw string) (string, error)
+	// Verify checks the signature of the original raw token using the issuer's published
+	// keys, which must be among pins.
+	Verify(raw string, token *jwt.Token, pins verify.Pins) error
+	// Process validates issuer conventions and returns the claims to output,
+	// possibly annotated with provider-specific information.
+	Process(token *jwt.Token, claims jwt.MapClaims) (jwt.MapClaims, error)
+}
+
+// claimNoter is implemented by providers that document the claims they issue or interpret.
+type claimNoter interface {
+	claimNotes() map[string]string
+}
+
+// ClaimNote returns the provider's note on a claim, or "" if it has none.
+func ClaimNote(p Provider, claim string) string {
+	if noter, ok := p.(claimNoter); ok {
+		return noter.claimNotes()[claim]
+	}
+	return ""
+}
+
+// registry maps provider names to their constructors.
+var registry = map[string]func(Options) Provider{}
+
+// register adds a provider constructor to the registry. It is called from each provider's init function.
+func register(name string, constructor func(Options) Provider) {
+	registry[name] = constructor
+}
+
+// Get returns the provider registered under name, configured with opts.
+func Get(name string, opts Options) (Provider, error) {
+	constructor, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q; must be one of: %s", name, strings.Join(Names(), ", "))
+	}
+	return constructor(opts), nil
+}
+
+// Names returns the sorted names of all registered providers.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// canonicalize re-encodes each segment of a token as unpadded base64url.
+func canonicalize(raw string) (string, error) {
+	parts := strings.Split(raw, ".")
+	for i, part := range parts {
+		decoded, err := verify.DecodeSegment(part)
+		if err != nil {
+			return "", fmt.Errorf("decoding token segment %d: %w", i+1, err)
+		}
+		parts[i] = base64.RawURLEncoding.EncodeToString(decoded)
+	}
+	return strings.Join(parts, "."), nil
+}
+
+// checkAudience reports an error if expected is set and not among the token's audiences.
+func checkAudience(claims jwt.MapClaims, expected string) error {
+	if expected == "" {
+		return nil
+	}
+	aud, err := claims.GetAudience()
+	if err != nil {
+		return fmt.Errorf("invalid aud claim: %w", err)
+	}
+	for _, a := range aud {
+		if a == expected {
+			return nil
+		}
+	}
+	return fmt.Errorf("aud %v does not contain expected audience %q", []string(aud), expected)
+}
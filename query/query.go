@@ -0,0 +1,256 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kinds of path segments.
+const (
+	segKey   = iota // Object member, or array index when the name is a number
+	segAny          // Every member of an object, or every item of an array (*)
+	segItems        // Every item of an array (#, [*])
+	segIndex        // Array item by index ([n])
+)
+
+// segment is one step of a path into the claims.
+type segment struct {
+	kind  int
+	name  string
+	index int
+}
+
+// Query selects a subset of the claims with path expressions, in the dotted style of
+// gjson (realm_access.roles, items.#.id, resource_access.*.roles) or in JSONPath
+// ($.realm_access.roles, $.items[*].id, $['https://example.com/roles']).
+type Query struct {
+	exprs []string
+	paths [][]segment
+}
+
+// Parse parses path expressions into a query selecting what any of them matches.
+func Parse(exprs []string) (*Query, error) {
+	q := &Query{exprs: exprs}
+	for _, expr := range exprs {
+		var path []segment
+		var err error
+		if strings.HasPrefix(expr, "$") {
+			path, err = parseJSONPath(expr)
+		} else {
+			path, err = parseDotted(expr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing query %q: %w", expr, err)
+		}
+		q.paths = append(q.paths, path)
+	}
+	return q, nil
+}
+
+// parseDotted parses a gjson-style path: names separated by dots, where \. is a dot
+// within a name, * matches every member or item, and # every item of an array.
+func parseDotted(expr string) ([]segment, error) {
+	if expr == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+	var path []segment
+	var name strings.Builder
+	escaped := false
+	flush := func() error {
+		s := name.String()
+		name.Reset()
+		switch s {
+		case "":
+			return fmt.Errorf("empty name")
+		case "*":
+			path = append(path, segment{kind: segAny})
+		case "#":
+			path = append(path, segment{kind: segItems})
+		default:
+			path = append(path, segment{kind: segKey, name: s})
+		}
+		return nil
+	}
+	for _, r := range expr {
+		switch {
+		case escaped:
+			name.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '.':
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		default:
+			name.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+// parseJSONPath parses the subset of JSONPath selecting by name and index: $.name,
+// $['name'], $["name"], $.*, [n], and [*].
+func parseJSONPath(expr string) ([]segment, error) {
+	rest := strings.TrimPrefix(expr, "$")
+	var path []segment
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			return nil, fmt.Errorf("recursive descent (..) is not supported")
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			name := rest[:end]
+			rest = rest[end:]
+			switch name {
+			case "":
+				return nil, fmt.Errorf("empty name")
+			case "*":
+				path = append(path, segment{kind: segAny})
+			default:
+				path = append(path, segment{kind: segKey, name: name})
+			}
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("missing ]")
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			if inner == "*" {
+				path = append(path, segment{kind: segItems})
+				continue
+			}
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				path = append(path, segment{kind: segKey, name: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid selector [%s]; expected a quoted name, an index, or *", inner)
+			}
+			path = append(path, segment{kind: segIndex, index: index})
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	return path, nil
+}
+
+// Apply returns the subset of claims matched by the query, keeping the nesting of the
+// matched values, and the expressions that matched nothing. Arrays keep the matched
+// items only, in order.
+func (q *Query) Apply(claims map[string]interface{}) (map[string]interface{}, []string) {
+	out := map[string]interface{}{}
+	var unmatched []string
+	for i, path := range q.paths {
+		value, ok := extract(claims, path)
+		if !ok {
+			unmatched = append(unmatched, q.exprs[i])
+			continue
+		}
+		merge(out, value.(map[string]interface{}))
+	}
+	return out, unmatched
+}
+
+// extract returns the parts of value matched by path, and whether anything matched.
+func extract(value interface{}, path []segment) (interface{}, bool) {
+	if len(path) == 0 {
+		return value, true
+	}
+	seg, rest := path[0], path[1:]
+	switch v := value.(type) {
+	case map[string]interface{}:
+		switch seg.kind {
+		case segKey:
+			child, ok := v[seg.name]
+			if !ok {
+				return nil, false
+			}
+			sub, ok := extract(child, rest)
+			if !ok {
+				return nil, false
+			}
+			return map[string]interface{}{seg.name: sub}, true
+		case segAny:
+			out := map[string]interface{}{}
+			for key, child := range v {
+				if sub, ok := extract(child, rest); ok {
+					out[key] = sub
+				}
+			}
+			return out, len(out) > 0
+		}
+	case []interface{}:
+		switch seg.kind {
+		case segAny, segItems:
+			var out []interface{}
+			for _, item := range v {
+				if sub, ok := extract(item, rest); ok {
+					out = append(out, sub)
+				}
+			}
+			return out, len(out) > 0
+		case segKey, segIndex:
+			index := seg.index
+			if seg.kind == segKey {
+				n, err := strconv.Atoi(seg.name)
+				if err != nil {
+					return nil, false
+				}
+				index = n
+			}
+			if index < 0 || index >= len(v) {
+				return nil, false
+			}
+			sub, ok := extract(v[index], rest)
+			if !ok {
+				return nil, false
+			}
+			return []interface{}{sub}, true
+		}
+	}
+	return nil, false
+}
+
+// merge adds the members of src to dst, merging the objects both hold, and the arrays
+// of the same length item by item. Other values of src replace those of dst.
+func merge(dst, src map[string]interface{}) {
+	for key, value := range src {
+		dst[key] = mergeValue(dst[key], value)
+	}
+}
+
+// mergeValue returns the merge of two values selected by different expressions.
+func mergeValue(a, b interface{}) interface{} {
+	switch bv := b.(type) {
+	case map[string]interface{}:
+		if av, ok := a.(map[string]interface{}); ok {
+			merge(av, bv)
+			return av
+		}
+	case []interface{}:
+		if av, ok := a.([]interface{}); ok && len(av) == len(bv) {
+			for i := range av {
+				av[i] = mergeValue(av[i], bv[i])
+			}
+			return av
+		}
+	}
+	return b
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sync/atomic"
+
+	"jwtdecode/config"
+	"jwtdecode/pkg/jwtdecode"
+)
+
+// queue bounds the -serve decode and verify requests in progress: as many as there are
+// workers are served at once, and up to the queue size more wait for a worker. Once the
+// queue is full, requests wait for room in it, or are refused with the drop policy, so that
+// a burst of requests, or slow clients, cannot grow the memory of the server without bound.
+type queue struct {
+	admitted chan struct{} // Requests being served or waiting for a worker
+	workers  chan struct{} // Requests being served
+	policy   string        // config.QueuePolicyBlock or config.QueuePolicyDrop
+	dropped  atomic.Uint64 // Requests refused
+}
+
+// newQueue returns the queue of the -queue-size and -queue-policy of cfg, with a worker
+// for each CPU, as Decoder.DecodeAll.
+func newQueue(cfg *config.AppConfig) *queue {
+	workers := runtime.GOMAXPROCS(0)
+	return &queue{
+		admitted: make(chan struct{}, workers+cfg.QueueSize),
+		workers:  make(chan struct{}, workers),
+		policy:   cfg.QueuePolicy,
+	}
+}
+
+// serve returns the serveFunc serving requests with serve once a worker is free. A request
+// that does not fit in the queue is answered with 503 under the drop policy, and one whose
+// client goes away while it waits fails with the error of its context.
+func (q *queue) serve(serve serveFunc) serveFunc {
+	return func(ctx context.Context, dec *jwtdecode.Decoder, req *http.Request) (int, string, []byte, error) {
+		if q.policy == config.QueuePolicyDrop {
+			select {
+			case q.admitted <- struct{}{}:
+			default:
+				q.dropped.Add(1)
+				return 0, "", nil, &httpError{status: http.StatusServiceUnavailable, msg: fmt.Sprintf("the server is busy and its queue of %d requests is full; retry later", cap(q.admitted)-cap(q.workers))}
+			}
+		} else {
+			select {
+			case q.admitted <- struct{}{}:
+			case <-ctx.Done():
+				return 0, "", nil, ctx.Err()
+			}
+		}
+		defer func() { <-q.admitted }()
+		select {
+		case q.workers <- struct{}{}:
+		case <-ctx.Done():
+			return 0, "", nil, ctx.Err()
+		}
+		defer func() { <-q.workers }()
+		return serve(ctx, dec, req)
+	}
+}
+
+// stats returns the state of the queue for the admin stats route.
+func (q *queue) stats() map[string]interface{} {
+	return map[string]interface{}{
+		"workers": cap(q.workers),
+		"size":    cap(q.admitted) - cap(q.workers),
+		"policy":  q.policy,
+		"busy":    len(q.workers),
+		"waiting": max(len(q.admitted)-len(q.workers), 0),
+		"dropped": q.dropped.Load(),
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"jwtdecode/config"
+	"jwtdecode/jsonrpc"
+	"jwtdecode/pkg/jwtdecode"
+	"jwtdecode/telemetry"
+)
+
+// reloadInterval is the time between two checks of the config and trust files for changes.
+const reloadInterval = 2 * time.Second
+
+// reloader holds the decoder serving -jsonrpc or -serve requests and replaces it when the
+// configuration is reloaded, on SIGHUP, when the config or trust file changes, or on an
+// admin request. A configuration that does not load is rejected, and the previous one
+// stays active.
+type reloader struct {
+	current atomic.Pointer[jwtdecode.Decoder]
+	started time.Time     // Time the server started
+	served  atomic.Uint64 // HTTP requests served
+	queue   *queue        // Queue of the -serve decode and verify requests
+
+	mu       sync.Mutex           // Serializes reloads, which parse the command line again
+	watched  map[string]fileStamp // Stamps of the config and trust files of the current decoder
+	reloads  int                  // Configurations reloaded
+	loadedAt time.Time            // Time the current configuration was loaded
+}
+
+// fileStamp identifies a version of a watched file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// newReloader returns a reloader serving with dec.
+func newReloader(dec *jwtdecode.Decoder) *reloader {
+	r := &reloader{started: time.Now()}
+	r.current.Store(dec)
+	r.watched = stampFiles(dec.Config())
+	r.loadedAt = r.started
+	return r
+}
+
+// handler returns the JSON-RPC handler, which serves each request with the current
+// decoder, recording a span and the request metrics.
+func (r *reloader) handler(ctx context.Context) jsonrpc.Handler {
+	return func(method string, params json.RawMessage) (interface{}, error) {
+		ctx, span := telemetry.Tracer().Start(ctx, "jsonrpc "+method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("rpc.system", "jsonrpc"),
+			attribute.String("rpc.method", method),
+		))
+		defer span.End()
+		start := time.Now()
+		result, err := serveRPC(ctx, r.current.Load(), method, params)
+		attrs := metric.WithAttributes(attribute.String("rpc.method", method), telemetry.Outcome(err))
+		telemetry.Requests.Add(ctx, 1, attrs)
+		telemetry.RequestTime.Record(ctx, time.Since(start).Seconds(), attrs)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return result, err
+	}
+}
+
+// watch reloads the configuration on SIGHUP, where the platform has it, and when a watched
+// file changes, until the process exits.
+func (r *reloader) watch() {
+	hup := make(chan os.Signal, 1)
+	if hangup != nil {
+		signal.Notify(hup, hangup)
+	}
+	ticker := time.NewTicker(reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-hup:
+			_ = r.reload("SIGHUP")
+		case <-ticker.C:
+			if path := r.changedFile(); path != "" {
+				_ = r.reload(path + " changed")
+			}
+		}
+	}
+}
+
+// changedFile returns a watched file that changed since it was stamped; "" if none did.
+func (r *reloader) changedFile() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for path, stamp := range r.watched {
+		if stampFile(path) != stamp {
+			return path
+		}
+	}
+	return ""
+}
+
+// reload loads the configuration again and swaps in a new decoder built from it, returning
+// why the configuration was rejected, if it was. The claims cache starts empty, as cached
+// results may not hold under the new configuration.
+func (r *reloader) reload(reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.current.Load()
+	// The files are stamped again even if the new version is rejected, so that the
+	// rejection is only reported once per change
+	defer func() {
+		r.watched = stampFiles(r.current.Load().Config())
+	}()
+
+	cfg, err := config.ReloadConfig(version)
+	if err == nil {
+		switch {
+		case cfg.JSONRPC != old.Config().JSONRPC:
+			err = fmt.Errorf("-jsonrpc cannot be turned off without restarting")
+		case cfg.Serve != old.Config().Serve:
+			err = fmt.Errorf("the -serve address cannot change without restarting")
+		case cfg.MaxTokenSize != old.Config().MaxTokenSize:
+			err = fmt.Errorf("the maximum token size cannot change without restarting")
+		}
+	}
+	var dec *jwtdecode.Decoder
+	if err == nil {
+		dec, err = jwtdecode.FromConfig(cfg)
+	}
+	if err != nil {
+		config.Warn(fmt.Sprintf("rejected configuration reloaded after %s, keeping the previous one: %v", reason, err))
+		return err
+	}
+	r.current.Store(dec)
+	r.reloads++
+	r.loadedAt = time.Now()
+	fmt.Fprintf(os.Stderr, "Configuration reloaded after %s\n", reason)
+	return nil
+}
+
+// loaded returns the number of configurations reloaded, and the time the current one was
+// loaded.
+func (r *reloader) loaded() (int, time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reloads, r.loadedAt
+}
+
+// stampFiles returns the current stamps of the config and trust files of a configuration,
+// including the trust files of its tenants.
+func stampFiles(cfg *config.AppConfig) map[string]fileStamp {
+	stamps := map[string]fileStamp{}
+	paths := []string{cfg.ConfigFile, cfg.TrustFile}
+	for _, tenant := range cfg.Tenants {
+		paths = append(paths, tenant.TrustFile)
+	}
+	for _, path := range paths {
+		if path != "" {
+			stamps[path] = stampFile(path)
+		}
+	}
+	return stamps
+}
+
+// stampFile returns the stamp of a file; the zero stamp if it cannot be read.
+func stampFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
@@ -0,0 +1,292 @@
+package roundtrip
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/formatter"
+)
+
+// Check reads formatted output back into claims and compares them with the claims it was
+// formatted from, returning the lossy conversions found for each claims set, in order.
+// Values read from CSV and XML text are typed like JSON values: true and false are
+// booleans, JSON numbers are numbers, and text starting with [ or { holding valid JSON
+// is an array or object; XML elements holding item_N elements are arrays. The source
+// columns and attributes added for provenance are ignored, and CSV cells holding the
+// missing value of opts (when not empty) are absent claims. The output is read with the
+// CSV delimiter and XML root elements of opts.
+func Check(format string, data []byte, claimsList []jwt.MapClaims, sources []map[string]string, opts formatter.Options) ([][]string, error) {
+	var decoded []map[string]interface{}
+	var err error
+	switch format {
+	case "JSON":
+		decoded, err = readJSON(data)
+	case "CSV":
+		decoded, err = readCSV(data, claimsList, sources, opts.CSV)
+	case "XML":
+		decoded, err = readXML(data, opts.XML)
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s output back: %w", format, err)
+	}
+	if len(decoded) != len(claimsList) {
+		return nil, fmt.Errorf("reading %s output back: found %d claims sets instead of %d", format, len(decoded), len(claimsList))
+	}
+
+	losses := make([][]string, len(claimsList))
+	for i, claims := range claimsList {
+		// The original claims are compared in their JSON form, as read by the decoder
+		var original map[string]interface{}
+		raw, err := json.Marshal(claims)
+		if err == nil {
+			err = json.Unmarshal(raw, &original)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("normalizing claims: %w", err)
+		}
+		losses[i] = compare("", original, decoded[i], format)
+	}
+	return losses, nil
+}
+
+// compare describes the differences between an original value and the value read back,
+// by claim path.
+func compare(path string, original, read interface{}, format string) []string {
+	om, oIsMap := original.(map[string]interface{})
+	rm, rIsMap := read.(map[string]interface{})
+	if oIsMap && rIsMap {
+		keys := map[string]bool{}
+		for k := range om {
+			keys[k] = true
+		}
+		for k := range rm {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		var losses []string
+		for _, k := range sorted {
+			child := k
+			if path != "" {
+				child = path + "." + k
+			}
+			ov, inOriginal := om[k]
+			rv, inRead := rm[k]
+			switch {
+			case !inRead:
+				losses = append(losses, fmt.Sprintf("%s: %s is missing from the %s output", child, render(ov), format))
+			case !inOriginal:
+				losses = append(losses, fmt.Sprintf("%s: %s output adds %s", child, format, render(rv)))
+			default:
+				losses = append(losses, compare(child, ov, rv, format)...)
+			}
+		}
+		return losses
+	}
+	oa, oIsArray := original.([]interface{})
+	ra, rIsArray := read.([]interface{})
+	if oIsArray && rIsArray && len(oa) == len(ra) {
+		var losses []string
+		for i := range oa {
+			losses = append(losses, compare(fmt.Sprintf("%s[%d]", path, i), oa[i], ra[i], format)...)
+		}
+		return losses
+	}
+	if reflect.DeepEqual(original, read) {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s: %s reads back from the %s output as %s", path, render(original), format, render(read))}
+}
+
+// readJSON reads a JSON document, array of documents, or newline-delimited documents.
+func readJSON(data []byte) ([]map[string]interface{}, error) {
+	data = bytes.TrimSpace(data)
+	var list []map[string]interface{}
+	if len(data) > 0 && data[0] == '[' {
+		err := json.Unmarshal(data, &list)
+		return list, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var claims map[string]interface{}
+		err := dec.Decode(&claims)
+		if errors.Is(err, io.EOF) {
+			return list, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, claims)
+	}
+}
+
+// readCSV reads one claims set per CSV row. Cells holding the missing value, or empty
+// cells without one, are absent claims, and the <claim>_source columns added for
+// provenance are dropped.
+func readCSV(data []byte, claimsList []jwt.MapClaims, sources []map[string]string, opts formatter.CSVOptions) ([]map[string]interface{}, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	if opts.Delimiter != 0 {
+		reader.Comma = opts.Delimiter
+	}
+	// The missing value is compared as written, with CSV injection protection
+	missingValue := formatter.EscapeCSVValue(opts.MissingValue)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("missing header row")
+	}
+	header := records[0]
+	list := make([]map[string]interface{}, 0, len(records)-1)
+	for i, record := range records[1:] {
+		claims := make(map[string]interface{}, len(header))
+		for j, column := range header {
+			if j >= len(record) || record[j] == missingValue {
+				continue
+			}
+			if claim, ok := strings.CutSuffix(column, "_source"); ok && i < len(sources) && i < len(claimsList) {
+				_, recorded := sources[i][claim]
+				_, claimed := claimsList[i][column]
+				if recorded && !claimed {
+					continue
+				}
+			}
+			claims[column] = typed(record[j])
+		}
+		list = append(list, claims)
+	}
+	return list, nil
+}
+
+// element is an XML element read back from the output.
+type element struct {
+	name     string
+	text     strings.Builder
+	children []*element
+}
+
+// readXML reads a <JWTClaims> document, a <JWTClaimsSet> of <Token> elements, or a
+// stream of <JWTClaims> documents, under the root elements of opts.
+func readXML(data []byte, opts formatter.XMLOptions) ([]map[string]interface{}, error) {
+	root, setRoot := formatter.DefaultXMLRoot, formatter.DefaultXMLSetRoot
+	if opts.Root != "" {
+		root = opts.Root
+	}
+	if opts.SetRoot != "" {
+		setRoot = opts.SetRoot
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var roots []*element
+	var stack []*element
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &element{name: t.Name.Local}
+			if len(stack) == 0 {
+				roots = append(roots, e)
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+
+	var list []map[string]interface{}
+	for _, e := range roots {
+		switch e.name {
+		case root:
+			list = append(list, e.object())
+		case setRoot:
+			for _, token := range e.children {
+				list = append(list, token.object())
+			}
+		default:
+			return nil, fmt.Errorf("unexpected root element <%s>", e.name)
+		}
+	}
+	return list, nil
+}
+
+// object returns the child elements of e as claims.
+func (e *element) object() map[string]interface{} {
+	claims := make(map[string]interface{}, len(e.children))
+	for _, child := range e.children {
+		claims[child.name] = child.value()
+	}
+	return claims
+}
+
+// value returns the value of e: an array if its children are item_N elements, an object
+// if it has other children, and its typed text otherwise.
+func (e *element) value() interface{} {
+	if len(e.children) == 0 {
+		return typed(e.text.String())
+	}
+	for i, child := range e.children {
+		if child.name != fmt.Sprintf("item_%d", i+1) {
+			return e.object()
+		}
+	}
+	items := make([]interface{}, len(e.children))
+	for i, child := range e.children {
+		items[i] = child.value()
+	}
+	return items
+}
+
+// typed reads a text value as the JSON value it spells, or else as a string.
+func typed(text string) interface{} {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	switch trimmed[0] {
+	case '[', '{', 't', 'f', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		var v interface{}
+		if trimmed == text && json.Unmarshal([]byte(text), &v) == nil && v != nil {
+			return v
+		}
+	}
+	return text
+}
+
+// render formats a value compactly for a loss report.
+func render(value interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"jwtdecode/config"
+	"jwtdecode/jsonrpc"
+	"jwtdecode/pkg/jwtdecode"
+)
+
+// rpcMethods are the JSON-RPC methods served with -jsonrpc, besides the lifecycle
+// methods initialize, shutdown, and exit.
+var rpcMethods = []string{"decode", "verify", "stats"}
+
+// rpcParams are the parameters of the decode and verify methods.
+type rpcParams struct {
+	Token string `json:"token"`
+}
+
+// serveRPC serves a JSON-RPC request with the decoder. Requests are decoded with the
+// options given on the command line.
+func serveRPC(ctx context.Context, dec *jwtdecode.Decoder, method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case "initialize":
+		return map[string]interface{}{
+			"serverInfo":   map[string]interface{}{"name": "jwtdecode", "version": version},
+			"capabilities": map[string]interface{}{"methods": rpcMethods},
+		}, nil
+	case "initialized", "shutdown":
+		return nil, nil
+	case "exit":
+		return nil, jsonrpc.ErrExit
+	case "decode":
+		d, err := rpcDecode(ctx, dec, params)
+		if err != nil {
+			return nil, err
+		}
+		if dec.Config().Envelope {
+			return d.Envelope(d.Claims), nil
+		}
+		return map[string]interface{}{
+			"header":   d.Header,
+			"claims":   d.Claims,
+			"verified": d.Verified,
+			"failures": nonNil(d.Failures),
+		}, nil
+	case "verify":
+		if !dec.Config().Verifies() {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeServerError, Message: "no signature verification configured (-verify-key, -verify-hmac-secret, -jwks-url, -issuer-discovery, -trust, -resolve-did, -provider, -allow-embedded-jwk, or -jku-allowlist)"}
+		}
+		d, err := rpcDecode(ctx, dec, params)
+		if err != nil {
+			// Protocol errors are returned as such, anything else means the token is not valid
+			var rpcErr *jsonrpc.Error
+			if errors.As(err, &rpcErr) {
+				return nil, err
+			}
+			return map[string]interface{}{"valid": false, "error": err.Error(), "failures": []string{}}, nil
+		}
+		return map[string]interface{}{"valid": len(d.Failures) == 0, "failures": nonNil(d.Failures)}, nil
+	case "stats":
+		stats := map[string]interface{}{"cache": nil}
+		if cache, ok := dec.CacheStats(); ok {
+			stats["cache"] = cache
+		}
+		return stats, nil
+	default:
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound, Message: "method not found: " + method}
+	}
+}
+
+// rpcDecode decodes the token of a decode or verify request.
+func rpcDecode(ctx context.Context, dec *jwtdecode.Decoder, params json.RawMessage) (*jwtdecode.Result, error) {
+	var req rpcParams
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+	}
+	if err := validateParams(req); err != nil {
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: "invalid params: " + err.Error(), Data: err.Violations}
+	}
+	rawToken := strings.TrimSpace(req.Token)
+	if err := config.ValidateToken(rawToken, dec.Config().MaxTokenSize, dec.Config().InputFormat); err != nil {
+		return nil, err
+	}
+	return dec.DecodeCached(ctx, rawToken, dec.Config().SnapshotName)
+}
+
+// validateParams checks the parameters of a decode or verify request, returning their
+// violations like those of the configuration, with the parameter paths as fields.
+func validateParams(req rpcParams) *config.ValidationError {
+	var violations []config.Violation
+	if strings.TrimSpace(req.Token) == "" {
+		violations = append(violations, config.Violation{Field: "params.token", Message: "is required", Fix: "send the token to decode as a string"})
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return &config.ValidationError{Violations: violations}
+}
+
+// nonNil returns list, or an empty list for nil, so that it is encoded as [] in JSON.
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -0,0 +1,116 @@
+package secretref
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Timeout bounds a password manager CLI call, leaving time to unlock the vault interactively.
+const Timeout = 2 * time.Minute
+
+// Resolver fetches the secret named by a reference of its scheme.
+type Resolver func(ref string) (string, error)
+
+// resolvers maps reference schemes to their resolvers.
+var resolvers = map[string]Resolver{
+	"op": onePassword,
+	"bw": bitwarden,
+}
+
+// Schemes returns the supported reference schemes in sorted order.
+func Schemes() []string {
+	schemes := make([]string, 0, len(resolvers))
+	for scheme := range resolvers {
+		schemes = append(schemes, scheme)
+	}
+	slices.Sort(schemes)
+	return schemes
+}
+
+// Resolve fetches the secret named by a reference such as op://vault/item/field
+// or bw://item/field from the password manager of its scheme.
+func Resolve(ref string) (string, error) {
+	scheme, _, ok := strings.Cut(ref, "://")
+	resolve, known := resolvers[scheme]
+	if !ok || !known {
+		return "", fmt.Errorf("invalid secret reference %q; expected %s://...", ref, strings.Join(Schemes(), "://... or "))
+	}
+	secret, err := resolve(ref)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(secret), nil
+}
+
+// onePassword reads a 1Password secret reference (op://vault/item/[section/]field)
+// with the 1Password CLI, which resolves the reference syntax itself.
+func onePassword(ref string) (string, error) {
+	return run("op", "read", "--no-newline", ref)
+}
+
+// bitwarden reads bw://item[/field] with the Bitwarden CLI. The item is an ID or a name;
+// the field is "password" (default), "notes", or the name of a custom field. The vault
+// must be unlocked, with the session in BW_SESSION.
+func bitwarden(ref string) (string, error) {
+	item, field, _ := strings.Cut(strings.TrimPrefix(ref, "bw://"), "/")
+	if item == "" {
+		return "", fmt.Errorf("invalid secret reference %q; expected bw://item[/field]", ref)
+	}
+	switch field {
+	case "", "password":
+		return run("bw", "get", "password", item)
+	case "notes":
+		return run("bw", "get", "notes", item)
+	}
+	out, err := run("bw", "get", "item", item)
+	if err != nil {
+		return "", err
+	}
+	var entry struct {
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(out), &entry); err != nil {
+		return "", fmt.Errorf("parsing Bitwarden item %q: %w", item, err)
+	}
+	for _, f := range entry.Fields {
+		if f.Name == field {
+			return f.Value, nil
+		}
+	}
+	return "", fmt.Errorf("item %q in Bitwarden has no field %q", item, field)
+}
+
+// run runs a password manager CLI and returns its output. Stdin and stderr stay connected
+// to the terminal, so that the CLI can prompt to unlock the vault.
+func run(name string, args ...string) (string, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s CLI not found in PATH: %w", name, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%s %s failed with exit status %d", name, args[0], exitErr.ExitCode())
+		}
+		return "", fmt.Errorf("running %s: %w", name, err)
+	}
+	return stdout.String(), nil
+}
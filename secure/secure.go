@@ -0,0 +1,50 @@
+package secure
+
+import "strings"
+
+// redactedPlaceholder replaces secret material scrubbed from messages.
+const redactedPlaceholder = "[REDACTED]"
+
+// Buffer holds secret bytes in memory that is locked against swapping (where supported)
+// and zeroed when Wipe or Destroy is called. The zero value is an empty, already-wiped
+// buffer.
+type Buffer struct {
+	data    []byte
+	mapping []byte // Memory mapping holding data, kept after Wipe until Destroy
+	locked  bool
+}
+
+// Bytes returns the secret content. The returned slice is only valid until Wipe or Destroy
+// is called.
+func (b *Buffer) Bytes() []byte {
+	return b.data
+}
+
+// Len returns the length of the secret content.
+func (b *Buffer) Len() int {
+	return len(b.data)
+}
+
+// Scrub replaces every occurrence of the secret, and of each of its dot-separated
+// segments, in msg with a placeholder. This prevents a token (or its header, payload,
+// or signature) from leaking through error messages that quote their input.
+func Scrub(msg, secret string) string {
+	if secret == "" {
+		return msg
+	}
+	msg = strings.ReplaceAll(msg, secret, redactedPlaceholder)
+	for _, segment := range strings.Split(secret, ".") {
+		// Very short segments would cause false positives on ordinary text
+		if len(segment) >= 8 {
+			msg = strings.ReplaceAll(msg, segment, redactedPlaceholder)
+		}
+	}
+	return msg
+}
+
+// zero overwrites the slice with zeros.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
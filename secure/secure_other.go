@@ -0,0 +1,35 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package secure
+
+// NewBuffer copies secret into a buffer that is zeroed on Wipe.
+// Memory locking is not available on this platform.
+func NewBuffer(secret []byte) (*Buffer, error) {
+	data := make([]byte, len(secret))
+	copy(data, secret)
+	return &Buffer{data: data}, nil
+}
+
+// String returns a copy of the secret as a string.
+func (b *Buffer) String() string {
+	return string(b.data)
+}
+
+// Wipe zeroes the secret. It is safe to call more than once.
+func (b *Buffer) Wipe() {
+	if b == nil || b.data == nil {
+		return
+	}
+	zero(b.data)
+	b.data = nil
+}
+
+// Destroy zeroes the secret. It is safe to call more than once, and after Wipe.
+func (b *Buffer) Destroy() {
+	b.Wipe()
+}
+
+// DisableCoreDumps is a no-op on platforms without core dump resource limits.
+func DisableCoreDumps() error {
+	return nil
+}
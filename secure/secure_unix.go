@@ -0,0 +1,75 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package secure
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// NewBuffer copies secret into a dedicated anonymous memory mapping outside the Go heap
+// and locks it in RAM so it is never written to swap. The caller should zero its own copy.
+func NewBuffer(secret []byte) (*Buffer, error) {
+	if len(secret) == 0 {
+		return &Buffer{}, nil
+	}
+	data, err := unix.Mmap(-1, 0, len(secret), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return nil, fmt.Errorf("allocating secure buffer: %w", err)
+	}
+	if err := unix.Mlock(data); err != nil {
+		_ = unix.Munmap(data)
+		return nil, fmt.Errorf("locking secure buffer in memory: %w", err)
+	}
+	copy(data, secret)
+	return &Buffer{data: data, mapping: data, locked: true}, nil
+}
+
+// String returns the secret as a string sharing the buffer's memory, without copying it
+// onto the Go heap. Once Wipe is called, the string, and every substring of it, reads as
+// zeros: the memory stays mapped so that a reference kept past Wipe cannot fault. The
+// string must not be used after Destroy.
+func (b *Buffer) String() string {
+	if len(b.data) == 0 {
+		return ""
+	}
+	return unsafe.String(&b.data[0], len(b.data))
+}
+
+// Wipe zeroes the secret and unlocks its memory. The zeroed memory is not unmapped, as
+// strings returned by String may still refer to it; it is released when the process exits.
+// It is safe to call more than once.
+func (b *Buffer) Wipe() {
+	if b == nil || b.data == nil {
+		return
+	}
+	zero(b.data)
+	if b.locked {
+		_ = unix.Munlock(b.data)
+	}
+	b.data = nil
+	b.locked = false
+}
+
+// Destroy zeroes the secret, unlocks its memory, and unmaps it. Strings returned by String
+// must no longer be used, as reading them faults. It is safe to call more than once, and
+// after Wipe.
+func (b *Buffer) Destroy() {
+	if b == nil || b.mapping == nil {
+		return
+	}
+	b.Wipe()
+	_ = unix.Munmap(b.mapping)
+	b.mapping = nil
+}
+
+// DisableCoreDumps sets the core file size limit to zero so a crash cannot write
+// process memory (including secrets) to disk.
+func DisableCoreDumps() error {
+	if err := unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{Cur: 0, Max: 0}); err != nil {
+		return fmt.Errorf("disabling core dumps: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,537 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"jwtdecode/config"
+	"jwtdecode/envelope"
+	"jwtdecode/pkg/jwtdecode"
+	"jwtdecode/telemetry"
+)
+
+// Timeouts of the HTTP server, so that slow clients cannot hold connections open.
+const (
+	serveHeaderTimeout = 10 * time.Second
+	serveTimeout       = 30 * time.Second
+	serveIdleTimeout   = 2 * time.Minute
+	serveShutdown      = 10 * time.Second
+)
+
+// apiKeyHeader is the request header carrying the key of -serve-api-key.
+const apiKeyHeader = "X-API-Key"
+
+// mediaTypes are the output formats of the media types of an Accept header, and
+// formatMediaTypes the media type of each output format in responses.
+var (
+	mediaTypes = map[string]string{
+		"application/json": config.OutputFormatJSON,
+		"text/csv":         config.OutputFormatCSV,
+		"application/xml":  config.OutputFormatXML,
+		"text/xml":         config.OutputFormatXML,
+		"text/plain":       config.OutputFormatTree,
+	}
+	formatMediaTypes = map[string]string{
+		config.OutputFormatJSON:  "application/json",
+		config.OutputFormatCSV:   "text/csv; charset=utf-8",
+		config.OutputFormatXML:   "application/xml",
+		config.OutputFormatTree:  "text/plain; charset=utf-8",
+		config.OutputFormatTable: "text/plain; charset=utf-8",
+	}
+)
+
+// Media types of request bodies: a raw token, sent as application/jwt (RFC 7519) or as
+// text, or a JSON body holding the token member.
+const (
+	mediaTypeJWT  = "application/jwt"
+	mediaTypeJSON = "application/json"
+	mediaTypeText = "text/plain"
+)
+
+// serveFunc serves a request of a route with a decoder, returning the status, content
+// type, and body of the response.
+type serveFunc func(context.Context, *jwtdecode.Decoder, *http.Request) (int, string, []byte, error)
+
+// httpError is an error answered with an HTTP status.
+type httpError struct {
+	status int
+	msg    string
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+// errUnauthorized answers requests without a valid API key.
+var errUnauthorized = &httpError{status: http.StatusUnauthorized, msg: "missing or invalid API key in the " + apiKeyHeader + " header"}
+
+// tenantContextKey is the context key of the tenant selected by the path prefix of a
+// request.
+type tenantContextKey struct{}
+
+// serveHTTP serves decode, verify, health, and admin requests over HTTP on the address of
+// -serve, with the current decoder of r, or that of the tenant a request selects, until the
+// process is interrupted. Requests in progress are given time to complete.
+func serveHTTP(r *reloader, addr string) error {
+	r.queue = newQueue(r.current.Load().Config())
+	mux := http.NewServeMux()
+	mux.Handle("POST /decode", r.httpHandler("/decode", r.queue.serve(serveDecode)))
+	mux.Handle("POST /verify", r.httpHandler("/verify", r.queue.serve(serveVerify)))
+	mux.Handle("GET /healthz", r.httpHandler("/healthz", serveHealth))
+	mux.Handle("POST /admin/cache/flush", r.httpHandler("/admin/cache/flush", adminOnly(serveFlush)))
+	mux.Handle("POST /admin/reload", r.httpHandler("/admin/reload", adminOnly(r.serveReload)))
+	mux.Handle("GET /admin/trust", r.httpHandler("/admin/trust", adminOnly(serveTrust)))
+	mux.Handle("GET /admin/stats", r.httpHandler("/admin/stats", adminOnly(r.serveStats)))
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           r.tenantRoutes(mux),
+		ReadHeaderTimeout: serveHeaderTimeout,
+		ReadTimeout:       serveTimeout,
+		WriteTimeout:      serveTimeout,
+		IdleTimeout:       serveIdleTimeout,
+		// A token may be sent in the Authorization header
+		MaxHeaderBytes: maxRequestSize(r.current.Load().Config()),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), stopSignals...)
+	defer stop()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdown)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// maxRequestSize is the size limit of a request: a token of the maximum token size, with
+// room for the rest of the request.
+func maxRequestSize(cfg *config.AppConfig) int {
+	return cfg.MaxTokenSize*1024*1024 + 64*1024
+}
+
+// tenantRoutes serves the routes of tenants under their path prefix: a request to
+// <prefix>/decode or <prefix>/verify is served as /decode or /verify for the tenant.
+func (r *reloader) tenantRoutes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		cfg := r.current.Load().Config()
+		for _, name := range cfg.TenantNames() {
+			prefix := cfg.Tenants[name].PathPrefix
+			if rest, ok := strings.CutPrefix(req.URL.Path, prefix); prefix != "" && ok && (rest == "/decode" || rest == "/verify") {
+				req = req.Clone(context.WithValue(req.Context(), tenantContextKey{}, name))
+				req.URL.Path, req.URL.RawPath = rest, ""
+				break
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// httpHandler returns the handler of a route, which authenticates the request (unless it
+// is public), limits its size, and serves it with the current decoder, or that of the
+// tenant it selects, recording a span and the request metrics. Errors are answered as a
+// JSON object with an error member, or as the error envelope (see errorBody).
+func (r *reloader) httpHandler(route string, serve serveFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, span := telemetry.Tracer().Start(req.Context(), req.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("http.route", route),
+		))
+		defer span.End()
+		start := time.Now()
+		r.served.Add(1)
+
+		var (
+			status      int
+			contentType string
+			body        []byte
+		)
+		// The maximum token size is the same for every tenant
+		limit := int64(maxRequestSize(r.current.Load().Config()))
+		dec, err := requestDecoder(r.current.Load(), route, req)
+		switch {
+		case err != nil:
+		case req.ContentLength > limit:
+			// Rejected before any of the body is read; bodies of unknown length are cut at the limit
+			err = &httpError{status: http.StatusRequestEntityTooLarge, msg: fmt.Sprintf("request body of %d bytes exceeds the limit of %d bytes", req.ContentLength, limit)}
+		default:
+			if tenant := dec.Config().Tenant; tenant != "" {
+				span.SetAttributes(attribute.String("jwtdecode.tenant", tenant))
+			}
+			req.Body = http.MaxBytesReader(w, req.Body, limit)
+			status, contentType, body, err = serve(ctx, dec, req)
+		}
+		if err != nil {
+			status = http.StatusBadRequest
+			var httpErr *httpError
+			var tooLarge *http.MaxBytesError
+			request := false
+			switch {
+			case errors.As(err, &httpErr):
+				status = httpErr.status
+				request = status < http.StatusInternalServerError
+			case errors.As(err, &tooLarge):
+				status = http.StatusRequestEntityTooLarge
+				request = true
+			case errors.Is(err, jwtdecode.ErrTokenTooLarge):
+				status = http.StatusRequestEntityTooLarge
+			case errors.Is(err, jwtdecode.ErrSignatureInvalid):
+				status = http.StatusUnprocessableEntity
+			}
+			contentType = "application/json"
+			body = errorBody(r.current.Load().Config().ErrorFormat, err, status, request)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		if status == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", "1")
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		attrs := metric.WithAttributes(attribute.String("http.route", route), telemetry.Outcome(err))
+		telemetry.Requests.Add(ctx, 1, attrs)
+		telemetry.RequestTime.Record(ctx, time.Since(start).Seconds(), attrs)
+	})
+}
+
+// errorBody returns the body of an error response: a JSON object with an error member, or
+// with -error-format json the error envelope, coded after the status for errors of the
+// request itself.
+func errorBody(format string, err error, status int, request bool) []byte {
+	if format != config.ErrorFormatJSON {
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return body
+	}
+	e := envelope.NewError(err, err.Error())
+	if request {
+		e.Error.Code = strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+		e.Error.Category = envelope.CategoryRequest
+	}
+	body, _ := json.Marshal(e)
+	return body
+}
+
+// requestDecoder authenticates a request and returns the decoder serving it. Decode and
+// verify requests are served by the decoder of the tenant they select, by their path
+// prefix, their X-Tenant header, or the API key of the tenant they send; the other routes
+// are served by dec. The key of -serve-api-key authenticates every request, and the key of
+// a tenant the decode and verify requests of the tenant. Requests are not authenticated
+// when no key applies to them.
+func requestDecoder(dec *jwtdecode.Decoder, route string, req *http.Request) (*jwtdecode.Decoder, error) {
+	cfg := dec.Config()
+	sent := []byte(req.Header.Get(apiKeyHeader))
+	global := cfg.ServeAPIKey != nil && subtle.ConstantTimeCompare(sent, cfg.ServeAPIKey) == 1
+	if route != "/decode" && route != "/verify" {
+		if cfg.ServeAPIKey != nil && !global && !public(route, req) {
+			return nil, errUnauthorized
+		}
+		return dec, nil
+	}
+
+	name, _ := req.Context().Value(tenantContextKey{}).(string)
+	if header := req.Header.Get(config.TenantHeader); header != "" {
+		if name != "" && header != name {
+			return nil, &httpError{status: http.StatusBadRequest, msg: fmt.Sprintf("the %s header names tenant %q, but the path is that of tenant %q", config.TenantHeader, header, name)}
+		}
+		name = header
+	}
+	keyTenant := ""
+	for tenant, key := range cfg.TenantKeys {
+		if subtle.ConstantTimeCompare(sent, key) == 1 {
+			keyTenant = tenant
+		}
+	}
+	switch {
+	case keyTenant != "" && name != "" && name != keyTenant:
+		return nil, &httpError{status: http.StatusForbidden, msg: fmt.Sprintf("the API key is not that of tenant %q", name)}
+	case keyTenant != "":
+		name = keyTenant
+	case global:
+	case cfg.ServeAPIKey != nil || cfg.TenantKeys[name] != nil:
+		return nil, errUnauthorized
+	}
+	if name == "" {
+		return dec, nil
+	}
+	tenant, ok := dec.Tenant(name)
+	if !ok {
+		return nil, &httpError{status: http.StatusNotFound, msg: fmt.Sprintf("unknown tenant %q", name)}
+	}
+	return tenant, nil
+}
+
+// public reports whether a request is served without the API key: the plain health check,
+// so that liveness probes need no key. The verbose health report, which names the key sets
+// and files checked, is not public.
+func public(route string, req *http.Request) bool {
+	return route == "/healthz" && !healthVerbose(req)
+}
+
+// healthVerbose reports whether a health request asks for the report of the checks, with
+// a true verbose query parameter (1, true).
+func healthVerbose(req *http.Request) bool {
+	verbose, _ := strconv.ParseBool(req.URL.Query().Get("verbose"))
+	return verbose
+}
+
+// serveHealth answers a health request with the status of the server: ok while it serves
+// requests, or with verbose, the result of the checks of its dependencies (see
+// Decoder.CheckDependencies), answered with 503 when one of them fails, so that the
+// verbose report serves as a readiness probe.
+func serveHealth(_ context.Context, dec *jwtdecode.Decoder, req *http.Request) (int, string, []byte, error) {
+	status := http.StatusOK
+	result := map[string]interface{}{"status": "ok"}
+	if healthVerbose(req) {
+		checks := dec.CheckDependencies()
+		for _, check := range checks {
+			if check.Error != "" {
+				status = http.StatusServiceUnavailable
+				result["status"] = "unavailable"
+			}
+		}
+		if checks == nil {
+			checks = []jwtdecode.Check{}
+		}
+		result["checks"] = checks
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	return status, "application/json", body, nil
+}
+
+// adminOnly serves an admin route only when -serve-api-key is set, so that admin requests
+// are always authenticated; they are answered with 403 otherwise.
+func adminOnly(serve serveFunc) serveFunc {
+	return func(ctx context.Context, dec *jwtdecode.Decoder, req *http.Request) (int, string, []byte, error) {
+		if dec.Config().ServeAPIKey == nil {
+			return 0, "", nil, &httpError{status: http.StatusForbidden, msg: "admin routes are only served with -serve-api-key"}
+		}
+		return serve(ctx, dec, req)
+	}
+}
+
+// serveFlush answers a cache flush request, emptying the claims cache and the JWKS cache
+// of the current decoder.
+func serveFlush(_ context.Context, dec *jwtdecode.Decoder, _ *http.Request) (int, string, []byte, error) {
+	if err := dec.FlushCaches(); err != nil {
+		return 0, "", nil, &httpError{status: http.StatusInternalServerError, msg: err.Error()}
+	}
+	return jsonResponse(map[string]interface{}{"flushed": true})
+}
+
+// serveReload answers a reload request, loading the configuration again as SIGHUP does.
+// A configuration that does not load is answered with 422, keeping the previous one.
+func (r *reloader) serveReload(_ context.Context, _ *jwtdecode.Decoder, _ *http.Request) (int, string, []byte, error) {
+	if err := r.reload("admin request"); err != nil {
+		return 0, "", nil, &httpError{status: http.StatusUnprocessableEntity, msg: fmt.Sprintf("configuration rejected, keeping the previous one: %v", err)}
+	}
+	return jsonResponse(map[string]interface{}{"reloaded": true})
+}
+
+// serveTrust answers a trust request with the verification settings of the current
+// decoder.
+func serveTrust(_ context.Context, dec *jwtdecode.Decoder, _ *http.Request) (int, string, []byte, error) {
+	return jsonResponse(dec.Trust())
+}
+
+// serveStats answers a stats request with the runtime statistics of the server: its
+// uptime, configuration reloads, requests served, claims cache, and memory.
+func (r *reloader) serveStats(_ context.Context, dec *jwtdecode.Decoder, _ *http.Request) (int, string, []byte, error) {
+	reloads, loadedAt := r.loaded()
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	stats := map[string]interface{}{
+		"version":        version,
+		"started":        r.started.UTC(),
+		"uptime_seconds": int64(time.Since(r.started).Seconds()),
+		"config_loaded":  loadedAt.UTC(),
+		"reloads":        reloads,
+		"requests":       r.served.Load(),
+		"cache":          nil,
+		"queue":          r.queue.stats(),
+		"runtime": map[string]interface{}{
+			"go_version":       runtime.Version(),
+			"goroutines":       runtime.NumGoroutine(),
+			"heap_alloc_bytes": mem.HeapAlloc,
+			"sys_bytes":        mem.Sys,
+			"gc_cycles":        mem.NumGC,
+		},
+	}
+	if cache, ok := dec.CacheStats(); ok {
+		stats["cache"] = cache
+	}
+	return jsonResponse(stats)
+}
+
+// jsonResponse answers with value as a JSON body and the 200 status.
+func jsonResponse(value interface{}) (int, string, []byte, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	return http.StatusOK, "application/json", body, nil
+}
+
+// serveDecode answers a decode request with the claims of the token, in the output format
+// of the format query parameter, or of the Accept header, or the configured one.
+func serveDecode(ctx context.Context, dec *jwtdecode.Decoder, req *http.Request) (int, string, []byte, error) {
+	format, err := requestFormat(req, dec.Config().OutputFormat)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	d, err := httpDecode(ctx, dec, req)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	cfg := *dec.Config()
+	cfg.OutputFormat = format
+	out, err := jwtdecode.Format(&cfg, []*jwtdecode.Result{d}, false)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	return http.StatusOK, formatMediaTypes[format], out, nil
+}
+
+// serveVerify answers a verify request with whether the token is valid, and its signature
+// failures. A token that cannot be verified is answered as not valid rather than as an
+// error.
+func serveVerify(ctx context.Context, dec *jwtdecode.Decoder, req *http.Request) (int, string, []byte, error) {
+	if !dec.Config().Verifies() {
+		return 0, "", nil, &httpError{status: http.StatusNotImplemented, msg: "no signature verification configured (-verify-key, -verify-hmac-secret, -jwks-url, -issuer-discovery, -trust, -resolve-did, -provider, -allow-embedded-jwk, or -jku-allowlist)"}
+	}
+	result := map[string]interface{}{"valid": false, "failures": []string{}}
+	d, err := httpDecode(ctx, dec, req)
+	var httpErr *httpError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &httpErr), errors.As(err, &tooLarge):
+		return 0, "", nil, err
+	case err != nil:
+		result["error"] = err.Error()
+	default:
+		result["valid"] = len(d.Failures) == 0
+		result["failures"] = nonNil(d.Failures)
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	return http.StatusOK, "application/json", body, nil
+}
+
+// httpDecode decodes the token of a request, sent as the body, as the token member of a
+// JSON body, or in the Authorization header as a bearer token.
+func httpDecode(ctx context.Context, dec *jwtdecode.Decoder, req *http.Request) (*jwtdecode.Result, error) {
+	rawToken, err := requestToken(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.ValidateToken(rawToken, dec.Config().MaxTokenSize, dec.Config().InputFormat); err != nil {
+		return nil, err
+	}
+	return dec.DecodeCached(ctx, rawToken, dec.Config().SnapshotName)
+}
+
+// requestToken returns the token of a request, from either its body or its Authorization
+// header. Bodies are application/jwt or text/plain raw tokens, or application/json bodies
+// holding the token member; bodies of other media types are answered with 415 before
+// they are read.
+func requestToken(req *http.Request) (string, error) {
+	mediaType := ""
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+			return "", &httpError{status: http.StatusUnsupportedMediaType, msg: fmt.Sprintf("invalid Content-Type %q: %v", contentType, err)}
+		}
+	}
+	switch mediaType {
+	case "", mediaTypeJWT, mediaTypeJSON, mediaTypeText:
+	default:
+		return "", &httpError{status: http.StatusUnsupportedMediaType, msg: fmt.Sprintf("unsupported Content-Type %q; send %s, %s, or %s", mediaType, mediaTypeJWT, mediaTypeJSON, mediaTypeText)}
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(body))
+	if mediaType == mediaTypeJSON && token != "" {
+		var params rpcParams
+		if err := json.Unmarshal(body, &params); err != nil {
+			return "", &httpError{status: http.StatusBadRequest, msg: fmt.Sprintf("invalid JSON body: %v", err)}
+		}
+		token = strings.TrimSpace(params.Token)
+	}
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		scheme, bearer, ok := strings.Cut(auth, " ")
+		switch {
+		case !ok || !strings.EqualFold(scheme, "Bearer"):
+			return "", &httpError{status: http.StatusBadRequest, msg: "the Authorization header must hold a bearer token"}
+		case token != "":
+			return "", &httpError{status: http.StatusBadRequest, msg: "send the token in the body or in the Authorization header, not both"}
+		}
+		token = strings.TrimSpace(bearer)
+	}
+	if token == "" {
+		return "", &httpError{status: http.StatusBadRequest, msg: "no token; send it as the body, as the token member of a JSON body, or as a bearer token in the Authorization header"}
+	}
+	return token, nil
+}
+
+// requestFormat returns the output format of a decode request: the format query
+// parameter, or the first media type of the Accept header that has an output format, or
+// fallback when neither is given or the client accepts any media type.
+func requestFormat(req *http.Request, fallback string) (string, error) {
+	if format := req.URL.Query().Get("format"); format != "" {
+		format = strings.ToUpper(format)
+		if _, ok := formatMediaTypes[format]; !ok {
+			return "", &httpError{status: http.StatusBadRequest, msg: fmt.Sprintf("invalid format %q; must be one of: %s", format, strings.Join(config.OutputFormats, ", "))}
+		}
+		return format, nil
+	}
+	accept := req.Header.Get("Accept")
+	if accept == "" {
+		return fallback, nil
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if format, ok := mediaTypes[mediaType]; ok {
+			return format, nil
+		}
+		if mediaType == "*/*" {
+			return fallback, nil
+		}
+	}
+	return "", &httpError{status: http.StatusNotAcceptable, msg: "no supported media type accepted; accept application/json, text/csv, application/xml, or text/plain"}
+}
@@ -0,0 +1,14 @@
+//go:build !js
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// hangup is the signal reloading the configuration of a long-running mode.
+var hangup os.Signal = syscall.SIGHUP
+
+// stopSignals are the signals stopping a server gracefully.
+var stopSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
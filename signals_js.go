@@ -0,0 +1,9 @@
+package main
+
+import "os"
+
+// hangup is nil, as a JavaScript host sends no signals.
+var hangup os.Signal
+
+// stopSignals are the signals stopping a server gracefully.
+var stopSignals = []os.Signal{os.Interrupt}
@@ -0,0 +1,49 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrDifferences is returned by Main when the compared snapshots differ.
+var ErrDifferences = errors.New("snapshots differ")
+
+// Main runs the snapshot subcommand with its command-line arguments, reporting to w.
+// The only action is "compare <old-dir> <new-dir>".
+func Main(args []string, w io.Writer) error {
+	if len(args) != 3 || args[0] != "compare" {
+		return fmt.Errorf("usage: jwtdecode snapshot compare <old-dir> <new-dir>")
+	}
+	diffs, err := Compare(args[1], args[2])
+	if err != nil {
+		return err
+	}
+	for _, d := range diffs {
+		switch {
+		case d.Claim == "":
+			fmt.Fprintf(w, "%s: snapshot %s\n", d.File, d.Change)
+		case d.Change == "added":
+			fmt.Fprintf(w, "%s: + %s = %s\n", d.File, d.Claim, valueJSON(d.New))
+		case d.Change == "removed":
+			fmt.Fprintf(w, "%s: - %s = %s\n", d.File, d.Claim, valueJSON(d.Old))
+		default:
+			fmt.Fprintf(w, "%s: ~ %s: %s -> %s\n", d.File, d.Claim, valueJSON(d.Old), valueJSON(d.New))
+		}
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("%w (%d differences)", ErrDifferences, len(diffs))
+	}
+	fmt.Fprintln(w, "Snapshots are identical")
+	return nil
+}
+
+// valueJSON renders a claim value compactly for the comparison report.
+func valueJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
@@ -0,0 +1,200 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/formatter"
+	"jwtdecode/utils"
+)
+
+// Volatile is the placeholder for claim values that change with every issued token.
+const Volatile = "[volatile]"
+
+// timeClaims are replaced by their offset from the reference time.
+var timeClaims = []string{formatter.ClaimIAT, formatter.ClaimEXP, formatter.ClaimNBF, formatter.ClaimAuthTime}
+
+// volatileClaims are replaced by the Volatile placeholder.
+var volatileClaims = []string{"jti", "nonce", "at_hash", "c_hash", "s_hash", "sid", "uti", "rh"}
+
+// Canonicalize returns a copy of claims in which values that differ between otherwise
+// identical tokens are made stable: time claims become offsets from the reference time
+// (e.g., "T+3600s"), as do their -convert-epoch datestamps, and identifiers and hashes
+// become a placeholder. When reference is
+// zero, the token's own iat is used, so lifetimes are compared instead of issue times.
+func Canonicalize(claims jwt.MapClaims, reference time.Time) jwt.MapClaims {
+	canonical := make(jwt.MapClaims, len(claims))
+	for key, value := range claims {
+		canonical[key] = value
+	}
+	if reference.IsZero() {
+		if iat, ok := epochSeconds(claims[formatter.ClaimIAT]); ok {
+			reference = time.Unix(iat, 0)
+		}
+	}
+	for _, key := range timeClaims {
+		seconds, ok := epochSeconds(claims[key])
+		if !ok || reference.IsZero() {
+			continue
+		}
+		canonical[key] = fmt.Sprintf("T%+ds", seconds-reference.Unix())
+		if _, ok := canonical[key+"_datestamp"]; ok {
+			canonical[key+"_datestamp"] = canonical[key]
+		}
+	}
+	for _, key := range volatileClaims {
+		if _, ok := canonical[key]; ok {
+			canonical[key] = Volatile
+		}
+	}
+	return canonical
+}
+
+// epochSeconds extracts an epoch timestamp in seconds from a decoded claim value.
+func epochSeconds(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// Write writes the canonical claims as sorted, indented JSON to <name>.json in dir,
+// creating dir if needed.
+func Write(dir, name string, claims jwt.MapClaims, reference time.Time) error {
+	data, err := formatter.FormatJSON(Canonicalize(claims, reference))
+	if err != nil {
+		return fmt.Errorf("formatting snapshot: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("creating snapshot directory: %w", err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return fmt.Errorf("opening snapshot directory: %w", err)
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+	// Terminate with a newline so snapshots diff cleanly with line-based tools
+	if err := root.WriteFile(name+".json", append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	return nil
+}
+
+// Name derives a stable snapshot name from a token file path: its base name without
+// extension, or "token" when the token was not read from a file.
+func Name(tokenFile string) string {
+	if tokenFile == "" {
+		return "token"
+	}
+	base := filepath.Base(tokenFile)
+	if name := strings.TrimSuffix(base, filepath.Ext(base)); name != "" {
+		return name
+	}
+	return "token"
+}
+
+// Difference is one difference between two snapshot directories.
+type Difference struct {
+	File   string
+	Claim  string // Empty for differences of whole files
+	Change string // "added", "removed", or "changed"
+	Old    interface{}
+	New    interface{}
+}
+
+// Compare compares the snapshots in two directories and returns their differences,
+// sorted by file and claim.
+func Compare(oldDir, newDir string) ([]Difference, error) {
+	oldFiles, err := readSnapshots(oldDir)
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := readSnapshots(newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []Difference
+	for file, oldClaims := range oldFiles {
+		newClaims, ok := newFiles[file]
+		if !ok {
+			diffs = append(diffs, Difference{File: file, Change: "removed"})
+			continue
+		}
+		diffs = append(diffs, compareClaims(file, oldClaims, newClaims)...)
+	}
+	for file := range newFiles {
+		if _, ok := oldFiles[file]; !ok {
+			diffs = append(diffs, Difference{File: file, Change: "added"})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].File != diffs[j].File {
+			return diffs[i].File < diffs[j].File
+		}
+		return diffs[i].Claim < diffs[j].Claim
+	})
+	return diffs, nil
+}
+
+// compareClaims compares the top-level claims of two snapshots. Nested values are
+// compared as a whole.
+func compareClaims(file string, oldClaims, newClaims map[string]interface{}) []Difference {
+	var diffs []Difference
+	for key, oldValue := range oldClaims {
+		newValue, ok := newClaims[key]
+		if !ok {
+			diffs = append(diffs, Difference{File: file, Claim: key, Change: "removed", Old: oldValue})
+			continue
+		}
+		oldJSON, _ := json.Marshal(oldValue)
+		newJSON, _ := json.Marshal(newValue)
+		if string(oldJSON) != string(newJSON) {
+			diffs = append(diffs, Difference{File: file, Claim: key, Change: "changed", Old: oldValue, New: newValue})
+		}
+	}
+	for key, newValue := range newClaims {
+		if _, ok := oldClaims[key]; !ok {
+			diffs = append(diffs, Difference{File: file, Claim: key, Change: "added", New: newValue})
+		}
+	}
+	return diffs
+}
+
+// readSnapshots reads every .json snapshot in dir, keyed by file name.
+func readSnapshots(dir string) (map[string]map[string]interface{}, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot directory: %w", err)
+	}
+	snapshots := make(map[string]map[string]interface{})
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := utils.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading snapshot: %w", err)
+		}
+		var claims map[string]interface{}
+		if err := json.Unmarshal(data, &claims); err != nil {
+			return nil, fmt.Errorf("parsing snapshot %q: %w", entry.Name(), err)
+		}
+		snapshots[entry.Name()] = claims
+	}
+	return snapshots, nil
+}
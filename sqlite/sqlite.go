@@ -0,0 +1,369 @@
+package sqlite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// MaxSize bounds the size of a database file and its write-ahead log.
+const MaxSize = 512 << 20
+
+// headerMagic starts every SQLite 3 database file.
+var headerMagic = []byte("SQLite format 3\x00")
+
+// DB is a read-only SQLite database held in memory. Only table b-trees are read,
+// which is all that is needed to scan the rows of a table.
+type DB struct {
+	data     []byte
+	pageSize int
+	usable   int            // Page size less the reserved bytes at the end of each page
+	wal      map[int][]byte // Latest committed image of the pages changed in the write-ahead log
+}
+
+// Open reads the database file at path and the committed pages of its write-ahead log
+// (path + "-wal"), if any, so that recent changes of a running application are seen.
+func Open(path string) (*DB, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 100 || !bytes.Equal(data[:16], headerMagic) {
+		return nil, fmt.Errorf("%s is not an SQLite 3 database", path)
+	}
+	db := &DB{data: data, pageSize: int(binary.BigEndian.Uint16(data[16:18]))}
+	if db.pageSize == 1 {
+		db.pageSize = 65536
+	}
+	if db.pageSize < 512 || db.pageSize&(db.pageSize-1) != 0 {
+		return nil, fmt.Errorf("%s has an invalid page size %d", path, db.pageSize)
+	}
+	db.usable = db.pageSize - int(data[20])
+	if db.usable < 480 {
+		return nil, fmt.Errorf("%s has an invalid reserved size", path)
+	}
+
+	wal, err := readFile(path + "-wal")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	db.wal = db.walPages(wal)
+	return db, nil
+}
+
+// readFile reads a file of at most MaxSize bytes.
+func readFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > MaxSize {
+		return nil, fmt.Errorf("%s exceeds %d MB", path, MaxSize>>20)
+	}
+	return os.ReadFile(path)
+}
+
+// walPages returns the page images of the committed transactions of a write-ahead log.
+// Frames after the last commit frame, and frames of an earlier log (other salts), are ignored.
+func (db *DB) walPages(wal []byte) map[int][]byte {
+	pages := map[int][]byte{}
+	if len(wal) < 32 {
+		return pages
+	}
+	magic := binary.BigEndian.Uint32(wal[0:4])
+	if magic != 0x377f0682 && magic != 0x377f0683 || int(binary.BigEndian.Uint32(wal[8:12])) != db.pageSize {
+		return pages
+	}
+	salt := wal[16:24]
+	pending := map[int][]byte{}
+	for off := 32; off+24+db.pageSize <= len(wal); off += 24 + db.pageSize {
+		frame := wal[off : off+24]
+		if !bytes.Equal(frame[8:16], salt) {
+			break
+		}
+		pending[int(binary.BigEndian.Uint32(frame[0:4]))] = wal[off+24 : off+24+db.pageSize]
+		if binary.BigEndian.Uint32(frame[4:8]) != 0 {
+			for n, page := range pending {
+				pages[n] = page
+			}
+			clear(pending)
+		}
+	}
+	return pages
+}
+
+// page returns page n (1-based), as last committed to the write-ahead log or the file.
+func (db *DB) page(n int) ([]byte, error) {
+	if page, ok := db.wal[n]; ok {
+		return page, nil
+	}
+	start := (n - 1) * db.pageSize
+	if n < 1 || start+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("page %d is out of range", n)
+	}
+	return db.data[start : start+db.pageSize], nil
+}
+
+// Rows calls fn with the values of the named columns of each row of a table. Values are
+// nil, int64, float64, string (TEXT), or []byte (BLOB); columns added after a row was
+// written are nil in that row.
+func (db *DB) Rows(table string, columns []string, fn func(values []interface{}) error) error {
+	var root int
+	var schema string
+	err := db.scan(1, func(record []interface{}) error {
+		if len(record) >= 5 && record[0] == "table" && strings.EqualFold(fmt.Sprint(record[1]), table) {
+			n, _ := record[3].(int64)
+			root = int(n)
+			schema, _ = record[4].(string)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reading schema: %w", err)
+	}
+	if root == 0 {
+		return fmt.Errorf("table %q not found", table)
+	}
+	positions, err := columnPositions(schema, columns)
+	if err != nil {
+		return err
+	}
+	return db.scan(root, func(record []interface{}) error {
+		values := make([]interface{}, len(columns))
+		for i, pos := range positions {
+			if pos < len(record) {
+				values[i] = record[pos]
+			}
+		}
+		return fn(values)
+	})
+}
+
+// scan walks the table b-tree rooted at page root and calls fn with each record.
+func (db *DB) scan(root int, fn func([]interface{}) error) error {
+	return db.walk(root, 0, fn)
+}
+
+// walk visits a b-tree page and its children, bounding the depth against cyclic pages.
+func (db *DB) walk(n, depth int, fn func([]interface{}) error) error {
+	if depth > 64 {
+		return fmt.Errorf("b-tree is too deep at page %d", n)
+	}
+	page, err := db.page(n)
+	if err != nil {
+		return err
+	}
+	hdr := 0
+	if n == 1 {
+		hdr = 100
+	}
+	if hdr+8 > len(page) {
+		return fmt.Errorf("page %d is truncated", n)
+	}
+	kind := page[hdr]
+	cells := int(binary.BigEndian.Uint16(page[hdr+3 : hdr+5]))
+	ptrs := hdr + 8
+	if kind == 0x05 {
+		ptrs = hdr + 12
+	}
+	if ptrs+2*cells > len(page) {
+		return fmt.Errorf("page %d is truncated", n)
+	}
+	for i := range cells {
+		off := int(binary.BigEndian.Uint16(page[ptrs+2*i:]))
+		if off >= len(page) {
+			return fmt.Errorf("page %d has an invalid cell offset", n)
+		}
+		cell := page[off:]
+		switch kind {
+		case 0x05: // Interior table page: left child pointer followed by the key
+			if len(cell) < 4 {
+				return fmt.Errorf("page %d has a truncated cell", n)
+			}
+			if err := db.walk(int(binary.BigEndian.Uint32(cell)), depth+1, fn); err != nil {
+				return err
+			}
+		case 0x0d: // Leaf table page: payload size, rowid, payload
+			size, k := varint(cell)
+			_, k2 := varint(cell[k:])
+			payload, err := db.payload(cell[k+k2:], int(size))
+			if err != nil {
+				return fmt.Errorf("page %d: %w", n, err)
+			}
+			record, err := parseRecord(payload)
+			if err != nil {
+				return fmt.Errorf("page %d: %w", n, err)
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("page %d is not a table b-tree page", n)
+		}
+	}
+	if kind == 0x05 {
+		return db.walk(int(binary.BigEndian.Uint32(page[hdr+8:])), depth+1, fn)
+	}
+	return nil
+}
+
+// payload returns the payload of a leaf cell, following its overflow pages.
+func (db *DB) payload(local []byte, size int) ([]byte, error) {
+	maxLocal := db.usable - 35
+	if size <= maxLocal {
+		if size > len(local) {
+			return nil, fmt.Errorf("truncated cell payload")
+		}
+		return local[:size], nil
+	}
+	minLocal := (db.usable-12)*32/255 - 23
+	n := minLocal + (size-minLocal)%(db.usable-4)
+	if n > maxLocal {
+		n = minLocal
+	}
+	if n+4 > len(local) {
+		return nil, fmt.Errorf("truncated cell payload")
+	}
+	out := make([]byte, 0, size)
+	out = append(out, local[:n]...)
+	next := int(binary.BigEndian.Uint32(local[n:]))
+	for pages := 0; len(out) < size; pages++ {
+		if next == 0 || pages > len(db.data)/db.pageSize+len(db.wal) {
+			return nil, fmt.Errorf("broken overflow chain")
+		}
+		page, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		chunk := page[4:db.usable]
+		if rest := size - len(out); len(chunk) > rest {
+			chunk = chunk[:rest]
+		}
+		out = append(out, chunk...)
+		next = int(binary.BigEndian.Uint32(page))
+	}
+	return out, nil
+}
+
+// parseRecord decodes a record: a header of serial types followed by the values.
+func parseRecord(data []byte) ([]interface{}, error) {
+	hdrSize, k := varint(data)
+	if k == 0 || int(hdrSize) > len(data) || int(hdrSize) < k {
+		return nil, fmt.Errorf("invalid record header")
+	}
+	var record []interface{}
+	body := data[hdrSize:]
+	for pos := k; pos < int(hdrSize); {
+		serial, n := varint(data[pos:int(hdrSize)])
+		if n == 0 {
+			return nil, fmt.Errorf("invalid record header")
+		}
+		pos += n
+		var value interface{}
+		var size int
+		switch {
+		case serial == 0:
+		case serial <= 6:
+			size = []int{0, 1, 2, 3, 4, 6, 8}[serial]
+			if size > len(body) {
+				return nil, fmt.Errorf("truncated record")
+			}
+			var v int64
+			for _, b := range body[:size] {
+				v = v<<8 | int64(b)
+			}
+			// Sign-extend the big-endian two's complement integer
+			shift := 64 - 8*size
+			value = v << shift >> shift
+		case serial == 7:
+			size = 8
+			if size > len(body) {
+				return nil, fmt.Errorf("truncated record")
+			}
+			value = math.Float64frombits(binary.BigEndian.Uint64(body))
+		case serial == 8:
+			value = int64(0)
+		case serial == 9:
+			value = int64(1)
+		case serial >= 12:
+			size = int((serial - 12) / 2)
+			if size > len(body) {
+				return nil, fmt.Errorf("truncated record")
+			}
+			if serial%2 == 0 {
+				value = body[:size:size]
+			} else {
+				value = string(body[:size])
+			}
+		default:
+			return nil, fmt.Errorf("invalid serial type %d", serial)
+		}
+		body = body[size:]
+		record = append(record, value)
+	}
+	return record, nil
+}
+
+// varint decodes a SQLite variable-length integer, returning it and its length (0 if truncated).
+func varint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if i >= len(b) {
+			return 0, 0
+		}
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return v, 9
+}
+
+// columnPositions maps column names to their positions in the CREATE TABLE statement.
+func columnPositions(schema string, columns []string) ([]int, error) {
+	open, end := strings.Index(schema, "("), strings.LastIndex(schema, ")")
+	if open < 0 || end < open {
+		return nil, fmt.Errorf("unsupported table schema %q", schema)
+	}
+	var names []string
+	depth, start := 0, open+1
+	for i := open + 1; i <= end; i++ {
+		switch {
+		case schema[i] == '(':
+			depth++
+		case schema[i] == ')' && i < end:
+			depth--
+		case (schema[i] == ',' && depth == 0) || i == end:
+			fields := strings.Fields(schema[start:i])
+			start = i + 1
+			if len(fields) == 0 {
+				continue
+			}
+			switch strings.ToUpper(fields[0]) {
+			case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+				continue
+			}
+			names = append(names, strings.Trim(fields[0], "\"`[]"))
+		}
+	}
+	positions := make([]int, len(columns))
+	for i, column := range columns {
+		positions[i] = -1
+		for j, name := range names {
+			if strings.EqualFold(name, column) {
+				positions[i] = j
+			}
+		}
+		if positions[i] < 0 {
+			return nil, fmt.Errorf("column %q not found", column)
+		}
+	}
+	return positions, nil
+}
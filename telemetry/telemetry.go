@@ -0,0 +1,132 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer and meter of the application.
+const instrumentationName = "jwtdecode"
+
+// Instruments recording the decode pipeline and the requests served. They record nothing
+// unless Setup installs a meter provider, to which the global OpenTelemetry API delegates.
+var (
+	Decodes       metric.Int64Counter     // Tokens decoded, by outcome
+	DecodeSeconds metric.Float64Histogram // Duration of decoding one token
+	CacheLookups  metric.Int64Counter     // Claims cache lookups, by result (hit or miss)
+	Requests      metric.Int64Counter     // Requests served, by method and outcome
+	RequestTime   metric.Float64Histogram // Duration of serving one request
+)
+
+func init() {
+	meter := otel.Meter(instrumentationName)
+	Decodes, _ = meter.Int64Counter("jwtdecode.decodes", metric.WithDescription("Tokens decoded, by outcome"))
+	DecodeSeconds, _ = meter.Float64Histogram("jwtdecode.decode.duration", metric.WithUnit("s"), metric.WithDescription("Duration of decoding one token"))
+	CacheLookups, _ = meter.Int64Counter("jwtdecode.cache.lookups", metric.WithDescription("Claims cache lookups, by result"))
+	Requests, _ = meter.Int64Counter("jwtdecode.requests", metric.WithDescription("Requests served, by method and outcome"))
+	RequestTime, _ = meter.Float64Histogram("jwtdecode.request.duration", metric.WithUnit("s"), metric.WithDescription("Duration of serving one request"))
+}
+
+// Tracer returns the tracer of the application.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Outcome returns the outcome attribute of an operation that returned err.
+func Outcome(err error) attribute.KeyValue {
+	if err != nil {
+		return attribute.String("outcome", "error")
+	}
+	return attribute.String("outcome", "ok")
+}
+
+// Enabled reports whether the standard OpenTelemetry environment variables configure an
+// OTLP endpoint for traces or metrics, and the SDK is not disabled with OTEL_SDK_DISABLED.
+func Enabled() bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return false
+	}
+	return tracesEnabled() || metricsEnabled()
+}
+
+// tracesEnabled reports whether traces are exported, as configured by the environment.
+func tracesEnabled() bool {
+	if os.Getenv("OTEL_TRACES_EXPORTER") == "none" {
+		return false
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// metricsEnabled reports whether metrics are exported, as configured by the environment.
+func metricsEnabled() bool {
+	if os.Getenv("OTEL_METRICS_EXPORTER") == "none" {
+		return false
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT") != ""
+}
+
+// Setup installs OTLP/HTTP exporters for traces and metrics when Enabled. The exporters
+// read the endpoint, headers, timeout, and TLS settings from the standard
+// OTEL_EXPORTER_OTLP_* environment variables, and the resource from OTEL_SERVICE_NAME and
+// OTEL_RESOURCE_ATTRIBUTES. Export errors are reported to warn. The returned function
+// flushes and stops the exporters; it must be called before exiting.
+func Setup(ctx context.Context, version string, warn func(string)) (func(context.Context) error, error) {
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		warn("telemetry: " + err.Error())
+	}))
+	// Attributes from the environment override the defaults
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(
+			attribute.String("service.name", instrumentationName),
+			attribute.String("service.version", version),
+		),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating telemetry resource: %w", err)
+	}
+
+	var shutdowns []func(context.Context) error
+	if tracesEnabled() {
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("creating OTLP trace exporter: %w", err)
+		}
+		provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+		otel.SetTracerProvider(provider)
+		shutdowns = append(shutdowns, provider.Shutdown)
+	}
+	if metricsEnabled() {
+		exporter, err := otlpmetrichttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("creating OTLP metric exporter: %w", err)
+		}
+		provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)), sdkmetric.WithResource(res))
+		otel.SetMeterProvider(provider)
+		shutdowns = append(shutdowns, provider.Shutdown)
+	}
+	return func(ctx context.Context) error {
+		var errs []error
+		for _, shutdown := range shutdowns {
+			errs = append(errs, shutdown(ctx))
+		}
+		return errors.Join(errs...)
+	}, nil
+}
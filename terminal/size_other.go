@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package terminal
+
+import (
+	"os"
+)
+
+// Size returns the number of rows and columns of the terminal attached to f. The size of
+// terminals is not read on this platform, so it is always reported as unknown.
+func Size(f *os.File) (rows, cols int, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,20 @@
+package terminal
+
+import (
+	"os"
+)
+
+// IsTerminal reports whether the given file is attached to an interactive terminal.
+// It is used to adapt human-facing behavior (banners, colors) when output is piped
+// or redirected. A nil file or a file that cannot be inspected is treated as non-interactive.
+func IsTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	// Character devices are terminals; pipes and regular files are not.
+	return info.Mode()&os.ModeCharDevice != 0
+}
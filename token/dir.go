@@ -0,0 +1,173 @@
+package token
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"jwtdecode/decompress"
+	"jwtdecode/utils"
+)
+
+// ArchiveSeparator separates an archive from the name of a member in token names,
+// e.g. "captures.zip!req/1.jwt".
+const ArchiveSeparator = "!"
+
+// archiveExtensions are the file name suffixes of the archives read by Walk.
+var archiveExtensions = []string{".zip", ".tar", ".tar.gz", ".tgz", ".tar.zst", ".tzst"}
+
+// Walk walks dir in lexical order and calls fn for each regular file whose base name
+// matches pattern (see filepath.Match), with its path relative to dir and its token.
+// Archives (.zip, .tar, .tar.gz, .tgz, .tar.zst) are read without extraction, calling fn
+// for each matching member. Token files and members are read like a token file, with
+// compressed files decompressed up to opts.MaxSize. Symbolic links are not followed.
+// An error returned by fn stops the walk and is returned unchanged.
+func Walk(dir, pattern string, opts Options, fn func(name, token string) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("walking token directory: %w", err)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if isArchive(d.Name()) {
+			return walkArchive(path, rel, pattern, opts, fn)
+		}
+		if matched, _ := filepath.Match(pattern, d.Name()); !matched {
+			return nil
+		}
+		token, err := GetToken("file", path, opts)
+		if err != nil {
+			return err
+		}
+		return fn(rel, token)
+	})
+}
+
+// isArchive reports whether a file name has an archive extension.
+func isArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkArchive calls fn for each regular member of an archive whose base name matches
+// pattern, in archive order. Members are named "<archive>!<member>".
+func walkArchive(path, name, pattern string, opts Options, fn func(name, token string) error) error {
+	f, err := utils.OpenFile(path)
+	if err != nil {
+		return fmt.Errorf("opening archive: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("inspecting archive %q: %w", path, err)
+	}
+	if err := utils.CheckFileMode(path, info.Mode()); err != nil {
+		if opts.StrictPermissions {
+			return fmt.Errorf("insecure archive permissions: %w", err)
+		}
+		if opts.Warn != nil {
+			opts.Warn(fmt.Sprintf("insecure archive permissions: %v", err))
+		}
+	}
+
+	member := func(memberName string, r io.Reader) error {
+		if matched, _ := filepath.Match(pattern, filepath.Base(memberName)); !matched {
+			return nil
+		}
+		fullName := name + ArchiveSeparator + memberName
+		token, err := readMember(r, opts.MaxSize)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", fullName, err)
+		}
+		return fn(fullName, token)
+	}
+
+	if strings.HasSuffix(strings.ToLower(path), ".zip") {
+		zr, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return fmt.Errorf("reading zip archive %q: %w", path, err)
+		}
+		for _, zf := range zr.File {
+			if !zf.Mode().IsRegular() {
+				continue
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", name+ArchiveSeparator+zf.Name, err)
+			}
+			err = member(zf.Name, rc)
+			_ = rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	magic := make([]byte, 4)
+	n, err := f.ReadAt(magic, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading archive %q: %w", path, err)
+	}
+	r, err := decompress.NewReader(f, decompress.Detect(magic[:n]))
+	if err != nil {
+		return fmt.Errorf("reading archive %q: %w", path, err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading tar archive %q: %w", path, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := member(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// readMember reads one archive member as a token, decompressing it if needed. Reading
+// stops one byte past maxSize, so oversized or bomb members cannot exhaust memory.
+func readMember(r io.Reader, maxSize int64) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > maxSize {
+		return "", fmt.Errorf("member exceeds %d bytes", maxSize)
+	}
+	if data, err = decompress.Bytes(data, maxSize); err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("member is empty")
+	}
+	return token, nil
+}
@@ -0,0 +1,138 @@
+package token
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"jwtdecode/decompress"
+	"jwtdecode/utils"
+)
+
+// List reads the tokens of a token list file, one token per line. Blank lines and
+// lines starting with '#' are skipped. Gzip and zstd compressed lists are decompressed
+// transparently.
+type List struct {
+	file    *os.File
+	reader  io.ReadCloser // Decompressing reader of file, or file itself
+	format  string        // Compression format of the file
+	scanner *bufio.Scanner
+	maxSize int
+	line    int
+	offset  int64 // Bytes consumed up to the end of the last scanned line
+}
+
+// OpenList opens a token list file for streaming. Lines longer than maxSize bytes are
+// rejected. The file permissions are checked like those of a token file.
+func OpenList(path string, maxSize int, opts Options) (*List, error) {
+	f, err := utils.OpenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening token list: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("inspecting token list %q: %w", path, err)
+	}
+	if err := utils.CheckFileMode(path, info.Mode()); err != nil {
+		if opts.StrictPermissions {
+			_ = f.Close()
+			return nil, fmt.Errorf("insecure token list permissions: %w", err)
+		}
+		if opts.Warn != nil {
+			opts.Warn(fmt.Sprintf("insecure token list permissions: %v", err))
+		}
+	}
+	// Detect compression without consuming input, so an uncompressed list stays seekable
+	magic := make([]byte, 4)
+	n, err := f.ReadAt(magic, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		_ = f.Close()
+		return nil, fmt.Errorf("reading token list %q: %w", path, err)
+	}
+	l := &List{file: f, maxSize: maxSize, format: decompress.Detect(magic[:n])}
+	if l.reader, err = decompress.NewReader(f, l.format); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("reading token list %q: %w", path, err)
+	}
+	l.scanner = bufio.NewScanner(l.reader)
+	// Allow for the line terminator, so a token of exactly maxSize bytes is accepted
+	l.scanner.Buffer(make([]byte, 0, 64*1024), maxSize+2)
+	l.scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, line, err := bufio.ScanLines(data, atEOF)
+		l.offset += int64(advance)
+		return advance, line, err
+	})
+	return l, nil
+}
+
+// Resume continues the list at a byte offset and line number previously returned by
+// Offset and Line, e.g. from a checkpoint. It must be called before the first Next.
+// Offsets of a compressed list refer to the decompressed data, which is skipped.
+func (l *List) Resume(offset int64, line int) error {
+	if l.format != decompress.FormatNone {
+		skipped, err := io.CopyN(io.Discard, l.reader, offset)
+		if err != nil {
+			return fmt.Errorf("offset %d is outside the token list (%d bytes); was the list replaced?", offset, skipped)
+		}
+		l.offset = offset
+		l.line = line
+		return nil
+	}
+	info, err := l.file.Stat()
+	if err != nil {
+		return fmt.Errorf("inspecting token list: %w", err)
+	}
+	if offset < 0 || offset > info.Size() {
+		return fmt.Errorf("offset %d is outside the token list (%d bytes); was the list replaced?", offset, info.Size())
+	}
+	if _, err := l.file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seeking token list: %w", err)
+	}
+	l.offset = offset
+	l.line = line
+	return nil
+}
+
+// Next returns the next token and true, or false at the end of the list or on error.
+func (l *List) Next() (string, bool) {
+	for l.scanner.Scan() {
+		l.line++
+		tok := strings.TrimSpace(l.scanner.Text())
+		if tok == "" || strings.HasPrefix(tok, "#") {
+			continue
+		}
+		return tok, true
+	}
+	return "", false
+}
+
+// Line returns the line number of the token last returned by Next.
+func (l *List) Line() int {
+	return l.line
+}
+
+// Offset returns the byte offset just past the line of the token last returned by Next.
+func (l *List) Offset() int64 {
+	return l.offset
+}
+
+// Err returns the error that stopped Next, if any.
+func (l *List) Err() error {
+	if err := l.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fmt.Errorf("line %d of token list exceeds %d bytes", l.line+1, l.maxSize)
+		}
+		return fmt.Errorf("reading token list: %w", err)
+	}
+	return nil
+}
+
+// Close closes the decompressing reader and the underlying file.
+func (l *List) Close() error {
+	_ = l.reader.Close()
+	return l.file.Close()
+}
@@ -2,14 +2,26 @@ package token
 
 import (
 	"fmt"
+	"io"
+	"jwtdecode/cookie"
+	"jwtdecode/decompress"
+	"jwtdecode/keychain"
+	"jwtdecode/secretref"
 	"jwtdecode/utils"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+// Options controls how token sources are read.
+type Options struct {
+	StrictPermissions bool         // Fail instead of warning when a token file is world-accessible
+	Warn              func(string) // Receives non-fatal warnings; may be nil
+	MaxSize           int64        // Maximum decompressed size of a compressed token file in bytes
+}
+
 // GetToken reads the JWT token based on the specified type and source value.
-func GetToken(tokenType string, tokenSourceValue string) (string, error) {
+func GetToken(tokenType string, tokenSourceValue string, opts Options) (string, error) {
 	var jwtToken string
 	var err error
 
@@ -43,15 +55,43 @@ func GetToken(tokenType string, tokenSourceValue string) (string, error) {
 			_ = root.Close()
 		}()
 
+		// Check the file permissions before reading, as a world-readable token file is already exposed
+		info, err := root.Stat(base)
+		if err != nil {
+			return "", fmt.Errorf("inspecting token file %q: %w", tokenSourceValue, err)
+		}
+		if err := utils.CheckFileMode(tokenSourceValue, info.Mode()); err != nil {
+			if opts.StrictPermissions {
+				return "", fmt.Errorf("insecure token file permissions: %w", err)
+			}
+			if opts.Warn != nil {
+				opts.Warn(fmt.Sprintf("insecure token file permissions: %v", err))
+			}
+		}
+
 		// Read the file content from the secure root
 		fileContent, err := root.ReadFile(base)
 		if err != nil {
 			return "", fmt.Errorf("reading token file %q: %w", tokenSourceValue, err)
 		}
-		jwtToken = strings.TrimSpace(string(fileContent))
+		// Token files compressed with gzip or zstd are decompressed transparently
+		content, err := decompress.Bytes(fileContent, opts.MaxSize)
+		// The token is held by jwtToken from now on, so the bytes read do not linger
+		defer clear(fileContent)
+		if err != nil {
+			return "", fmt.Errorf("reading token file %q: %w", tokenSourceValue, err)
+		}
+		jwtToken = strings.TrimSpace(string(content))
+		clear(content)
 		if jwtToken == "" {
 			return "", fmt.Errorf("token file %q is empty", tokenSourceValue)
 		}
+	case "stdin":
+		// Read the token piped in, e.g. kubectl get secret ... | jwtdecode -token-stdin
+		jwtToken, err = readStdin(opts.MaxSize)
+		if err != nil {
+			return "", err
+		}
 	case "environment":
 		// Fetch token from an environment variable
 		envVarName := tokenSourceValue
@@ -62,9 +102,91 @@ func GetToken(tokenType string, tokenSourceValue string) (string, error) {
 		if jwtToken == "" {
 			return "", fmt.Errorf("environment variable %q is not set", envVarName)
 		}
+	case "environment-chain":
+		// Fetch token from the first set of a comma-separated list of environment variables
+		jwtToken, _, err = FromEnvChain(strings.Split(tokenSourceValue, ","))
+		if err != nil {
+			return "", err
+		}
+	case "keychain":
+		// Fetch token from the platform secret store, referenced as <service>/<account>
+		jwtToken, err = keychain.Get(tokenSourceValue)
+		if err != nil {
+			return "", err
+		}
+		jwtToken = strings.TrimSpace(jwtToken)
+		if jwtToken == "" {
+			return "", fmt.Errorf("keychain entry %q is empty", tokenSourceValue)
+		}
+	case "reference":
+		// Fetch token from a password manager CLI, e.g. op://vault/item/field or bw://item/field
+		jwtToken, err = secretref.Resolve(tokenSourceValue)
+		if err != nil {
+			return "", err
+		}
+		if jwtToken == "" {
+			return "", fmt.Errorf("secret reference %q is empty", tokenSourceValue)
+		}
+	case "browser-cookie":
+		// Extract token from a cookie of the local browser, e.g. chrome:session_token@app.example.com
+		jwtToken, err = cookie.Token(tokenSourceValue)
+		if err != nil {
+			return "", err
+		}
 	default:
 		return "", fmt.Errorf("unknown token type: %s", tokenType)
 	}
 
 	return jwtToken, nil
 }
+
+// bearerPrefix is the authorization scheme prefix removed from tokens read with FromEnvChain.
+const bearerPrefix = "Bearer "
+
+// FromEnvChain returns the token of the first of the named environment variables that is
+// set and not empty, with the name of that variable. A "Bearer " prefix (in any case) is
+// removed, as some pipelines store the Authorization header value rather than the token.
+func FromEnvChain(names []string) (string, string, error) {
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		value := strings.TrimSpace(os.Getenv(name))
+		if len(value) >= len(bearerPrefix) && strings.EqualFold(value[:len(bearerPrefix)], bearerPrefix) {
+			value = strings.TrimSpace(value[len(bearerPrefix):])
+		}
+		if value != "" {
+			return value, name, nil
+		}
+	}
+	return "", "", fmt.Errorf("none of the environment variables %s is set", strings.Join(names, ", "))
+}
+
+// readStdin reads the token from standard input like a token file: compressed input is
+// decompressed and surrounding whitespace is trimmed. Input larger than maxSize bytes is
+// rejected (no limit if maxSize is 0).
+func readStdin(maxSize int64) (string, error) {
+	var r io.Reader = os.Stdin
+	if maxSize > 0 {
+		r = io.LimitReader(os.Stdin, maxSize+1)
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("reading token from stdin: %w", err)
+	}
+	if maxSize > 0 && int64(len(content)) > maxSize {
+		return "", fmt.Errorf("token read from stdin exceeds %d bytes", maxSize)
+	}
+	defer clear(content)
+	decompressed, err := decompress.Bytes(content, maxSize)
+	if err != nil {
+		return "", fmt.Errorf("reading token from stdin: %w", err)
+	}
+	jwtToken := strings.TrimSpace(string(decompressed))
+	clear(decompressed)
+	if jwtToken == "" {
+		return "", fmt.Errorf("no token read from stdin")
+	}
+	return jwtToken, nil
+}
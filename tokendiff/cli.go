@@ -0,0 +1,151 @@
+package tokendiff
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"jwtdecode/pkg/jwtdecode"
+	"jwtdecode/terminal"
+	"jwtdecode/utils"
+)
+
+// ErrDifferences is returned by Main when the compared tokens differ.
+var ErrDifferences = errors.New("tokens differ")
+
+// Colors of the text report: removed, added, and changed lines.
+const (
+	colorRemoved = "\x1b[31m"
+	colorAdded   = "\x1b[32m"
+	colorChanged = "\x1b[33m"
+	colorReset   = "\x1b[0m"
+)
+
+// Main runs the diff subcommand with its command-line arguments, reporting to w. Each of
+// the two tokens is given as the token itself, as @<file>, or as - for standard input.
+func Main(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "Write the differences as a JSON array")
+	color := fs.String("color", "auto", "Color the text report: auto (when writing to a terminal and NO_COLOR is not set), always, or never")
+	ignore := fs.String("ignore", "", "Comma-separated claims and header parameters not compared, e.g. iat,exp,jti")
+	decryptKey := fs.String("decrypt-key", "", "Private or symmetric key file decrypting JWE tokens (see the main -decrypt-key)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: jwtdecode diff [-json] [-color auto|always|never] [-ignore <claims>] [-decrypt-key <file>] <token-a> <token-b>")
+	}
+	if fs.Arg(0) == "-" && fs.Arg(1) == "-" {
+		return fmt.Errorf("only one token can be read from standard input")
+	}
+	colored, err := useColor(*color, w)
+	if err != nil {
+		return err
+	}
+	var ignored []string
+	for _, name := range strings.Split(*ignore, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ignored = append(ignored, name)
+		}
+	}
+	opts := jwtdecode.Options{DecryptKey: *decryptKey}
+	a, err := decode(fs.Arg(0), opts)
+	if err != nil {
+		return fmt.Errorf("token A: %w", err)
+	}
+	b, err := decode(fs.Arg(1), opts)
+	if err != nil {
+		return fmt.Errorf("token B: %w", err)
+	}
+
+	diffs := Diff(a.Header, a.Claims, b.Header, b.Claims, ignored)
+	if *jsonOut {
+		if diffs == nil {
+			diffs = []Difference{}
+		}
+		data, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, string(data)); err != nil {
+			return err
+		}
+	} else {
+		for _, d := range diffs {
+			fmt.Fprintln(w, d.line(colored))
+		}
+		if len(diffs) == 0 {
+			fmt.Fprintln(w, "Tokens are identical")
+		}
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("%w (%d differences)", ErrDifferences, len(diffs))
+	}
+	return nil
+}
+
+// decode reads and decodes one token argument, without verifying its signature.
+func decode(arg string, opts jwtdecode.Options) (*jwtdecode.Result, error) {
+	var raw string
+	switch {
+	case arg == "-":
+		data, err := io.ReadAll(io.LimitReader(os.Stdin, 64<<20))
+		if err != nil {
+			return nil, fmt.Errorf("reading standard input: %w", err)
+		}
+		raw = string(data)
+	case strings.HasPrefix(arg, "@"):
+		data, err := utils.ReadFile(strings.TrimPrefix(arg, "@"))
+		if err != nil {
+			return nil, err
+		}
+		raw = string(data)
+	default:
+		raw = arg
+	}
+	return jwtdecode.Decode(raw, opts)
+}
+
+// useColor reports whether the text report is colored, for the value of -color.
+func useColor(mode string, w io.Writer) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		f, ok := w.(*os.File)
+		return ok && terminal.IsTerminal(f) && os.Getenv("NO_COLOR") == "", nil
+	}
+	return false, fmt.Errorf("invalid -color %q; must be one of: auto, always, never", mode)
+}
+
+// line renders the difference as a report line, in the color of its change with colored.
+func (d Difference) line(colored bool) string {
+	var line, color string
+	switch d.Change {
+	case Added:
+		line, color = fmt.Sprintf("%s: + %s = %s", d.Section, d.Path, valueJSON(d.New)), colorAdded
+	case Removed:
+		line, color = fmt.Sprintf("%s: - %s = %s", d.Section, d.Path, valueJSON(d.Old)), colorRemoved
+	default:
+		line, color = fmt.Sprintf("%s: ~ %s: %s -> %s", d.Section, d.Path, valueJSON(d.Old), valueJSON(d.New)), colorChanged
+	}
+	if !colored {
+		return line
+	}
+	return color + line + colorReset
+}
+
+// valueJSON renders a value compactly for the report.
+func valueJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
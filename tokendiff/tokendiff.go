@@ -0,0 +1,139 @@
+// Package tokendiff compares two tokens, reporting the header parameters and claims added,
+// removed, and changed from one to the other, e.g. to find why a refreshed token lost
+// scopes.
+package tokendiff
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Sections of a token compared.
+const (
+	SectionHeader = "header"
+	SectionClaims = "claims"
+)
+
+// Changes of a Difference.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// spaceSeparated are the claims holding space-separated lists, compared as sets.
+var spaceSeparated = []string{"scope"}
+
+// Difference is one difference from token A to token B.
+type Difference struct {
+	Section string      `json:"section"`       // SectionHeader or SectionClaims
+	Path    string      `json:"path"`          // Name, or dotted path into nested objects (e.g., "address.country")
+	Change  string      `json:"change"`        // Added, Removed, or Changed
+	Old     interface{} `json:"old,omitempty"` // Value in A; nil if added
+	New     interface{} `json:"new,omitempty"` // Value in B; nil if removed
+}
+
+// Diff compares the headers and claims of two tokens, ignoring the claims and header
+// parameters named in ignore. Nested objects are compared member by member, and lists of
+// strings, such as the scp or aud arrays or the space-separated scope claim, as sets,
+// reporting the items added or removed, so that their order does not matter. Differences
+// are sorted by section and path.
+func Diff(headerA, claimsA, headerB, claimsB map[string]interface{}, ignore []string) []Difference {
+	var diffs []Difference
+	diffs = append(diffs, compare(SectionHeader, "", headerA, headerB, ignore)...)
+	diffs = append(diffs, compare(SectionClaims, "", claimsA, claimsB, ignore)...)
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].Section != diffs[j].Section {
+			return diffs[i].Section == SectionHeader
+		}
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs
+}
+
+// compare compares the members of two objects at a path.
+func compare(section, prefix string, a, b map[string]interface{}, ignore []string) []Difference {
+	var diffs []Difference
+	for name, va := range a {
+		path := prefix + name
+		if prefix == "" && slices.Contains(ignore, name) {
+			continue
+		}
+		vb, ok := b[name]
+		if !ok {
+			diffs = append(diffs, Difference{Section: section, Path: path, Change: Removed, Old: va})
+			continue
+		}
+		diffs = append(diffs, compareValues(section, path, va, vb, ignore)...)
+	}
+	for name, vb := range b {
+		if prefix == "" && slices.Contains(ignore, name) {
+			continue
+		}
+		if _, ok := a[name]; !ok {
+			diffs = append(diffs, Difference{Section: section, Path: prefix + name, Change: Added, New: vb})
+		}
+	}
+	return diffs
+}
+
+// compareValues compares the values of a member present in both objects.
+func compareValues(section, path string, va, vb interface{}, ignore []string) []Difference {
+	if equal(va, vb) {
+		return nil
+	}
+	if oa, ok := va.(map[string]interface{}); ok {
+		if ob, ok := vb.(map[string]interface{}); ok {
+			return compare(section, path+".", oa, ob, ignore)
+		}
+	}
+	la, okA := stringSet(path, va)
+	lb, okB := stringSet(path, vb)
+	if !okA || !okB {
+		return []Difference{{Section: section, Path: path, Change: Changed, Old: va, New: vb}}
+	}
+	var diffs []Difference
+	for _, item := range la {
+		if !slices.Contains(lb, item) {
+			diffs = append(diffs, Difference{Section: section, Path: path, Change: Removed, Old: item})
+		}
+	}
+	for _, item := range lb {
+		if !slices.Contains(la, item) {
+			diffs = append(diffs, Difference{Section: section, Path: path, Change: Added, New: item})
+		}
+	}
+	// The same items in another order are the same set
+	return diffs
+}
+
+// stringSet returns the items of a list of strings: an array of strings, or the string of
+// a space-separated claim.
+func stringSet(path string, value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		if slices.Contains(spaceSeparated, path) {
+			return strings.Fields(v), true
+		}
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			items = append(items, s)
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+// equal reports whether two decoded JSON values are equal.
+func equal(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
@@ -0,0 +1,288 @@
+package trust
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gopkg.in/yaml.v3"
+
+	"jwtdecode/findings"
+	"jwtdecode/httpfetch"
+	"jwtdecode/jwks"
+	"jwtdecode/utils"
+	"jwtdecode/verify"
+)
+
+// Issuer is the trust anchor for a single token issuer.
+type Issuer struct {
+	Issuer     string   `yaml:"issuer"`     // Exact iss value this anchor applies to
+	JWKSURI    string   `yaml:"jwks_uri"`   // Remote JWKS holding the issuer's keys
+	JWKSFile   string   `yaml:"jwks_file"`  // Local JWKS holding the issuer's keys
+	Keys       []string `yaml:"keys"`       // Pinned PEM public keys or certificates
+	Algorithms []string `yaml:"algorithms"` // Allowed signing algorithms; empty allows any except none
+	Audiences  []string `yaml:"audiences"`  // Accepted audiences; empty skips the check
+
+	// baseDir resolves relative file paths against the trust file's directory
+	baseDir string
+	// remote caches the key set of jwks_uri, fetching it again when it is older than
+	// httpfetch.CacheTTL or has no key for the kid of a token
+	remote *jwks.Remote
+}
+
+// Config is a multi-issuer trust configuration.
+type Config struct {
+	Issuers []Issuer `yaml:"issuers"`
+}
+
+// Load reads and validates a trust configuration file (YAML or JSON).
+func Load(path string) (*Config, error) {
+	data, err := utils.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading trust file: %w", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing trust file %q: %w", path, err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path for trust file: %w", err)
+	}
+	seen := make(map[string]bool)
+	for i := range cfg.Issuers {
+		anchor := &cfg.Issuers[i]
+		anchor.baseDir = filepath.Dir(absPath)
+		if anchor.Issuer == "" {
+			return nil, fmt.Errorf("trust file %q: issuer %d has no issuer value", path, i+1)
+		}
+		if seen[anchor.Issuer] {
+			return nil, fmt.Errorf("trust file %q: issuer %q is defined more than once", path, anchor.Issuer)
+		}
+		seen[anchor.Issuer] = true
+		if anchor.JWKSURI == "" && anchor.JWKSFile == "" && len(anchor.Keys) == 0 {
+			return nil, fmt.Errorf("trust file %q: issuer %q has no jwks_uri, jwks_file, or keys", path, anchor.Issuer)
+		}
+		for _, alg := range anchor.Algorithms {
+			if strings.EqualFold(alg, "none") {
+				return nil, fmt.Errorf("trust file %q: issuer %q must not allow alg none", path, anchor.Issuer)
+			}
+		}
+		if anchor.JWKSURI != "" {
+			anchor.remote = &jwks.Remote{URL: anchor.JWKSURI, CacheTTL: httpfetch.CacheTTL}
+		}
+	}
+	return &cfg, nil
+}
+
+// Flush drops the key sets fetched from the jwks_uri of the issuers, so that the next
+// lookups fetch them again.
+func (c *Config) Flush() error {
+	for i := range c.Issuers {
+		if c.Issuers[i].remote != nil {
+			if err := c.Issuers[i].remote.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Anchor returns the trust anchor for the given iss value.
+func (c *Config) Anchor(iss string) (*Issuer, error) {
+	for i := range c.Issuers {
+		if c.Issuers[i].Issuer == iss {
+			return &c.Issuers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("issuer %q is not trusted", iss)
+}
+
+// Verify selects the anchor for the token's iss and verifies the token against it, with
+// keys among pins.
+func (c *Config) Verify(raw string, token *jwt.Token, pins verify.Pins) (*Issuer, error) {
+	claims, _ := token.Claims.(jwt.MapClaims)
+	iss, _ := claims["iss"].(string)
+	anchor, err := c.Anchor(iss)
+	if err != nil {
+		return nil, err
+	}
+	return anchor, anchor.Verify(raw, token, pins)
+}
+
+// AlgConfusion reports a finding when the token claims a symmetric HS* algorithm although
+// the issuer is anchored to asymmetric keys, the pattern of an RS256→HS256 confusion attack
+// where the issuer's public key is used as the HMAC secret. It returns nil otherwise.
+func (a *Issuer) AlgConfusion(token *jwt.Token) *findings.Finding {
+	if _, isHMAC := token.Method.(*jwt.SigningMethodHMAC); !isHMAC {
+		return nil
+	}
+	expected := "asymmetric keys"
+	if len(a.Algorithms) > 0 {
+		expected = strings.Join(a.Algorithms, ", ")
+	}
+	return &findings.Finding{
+		ID:       "alg-confusion",
+		Severity: findings.SeverityCritical,
+		Message: fmt.Sprintf("header alg %s is symmetric, but issuer %q is trusted with %s; this matches an RS256→HS256 "+
+			"algorithm confusion attempt in which the issuer's public key is used as the HMAC secret", token.Method.Alg(), a.Issuer, expected),
+	}
+}
+
+// Verify checks the token's algorithm and audience against the anchor's policy and
+// verifies its signature with the anchor's keys, which must be among pins.
+func (a *Issuer) Verify(raw string, token *jwt.Token, pins verify.Pins) error {
+	alg := token.Method.Alg()
+	if strings.EqualFold(alg, "none") {
+		return fmt.Errorf("unsecured tokens (alg none) are never trusted")
+	}
+	if a.AlgConfusion(token) != nil {
+		return verify.ErrAlgConfusion
+	}
+	if len(a.Algorithms) > 0 && !containsFold(a.Algorithms, alg) {
+		return fmt.Errorf("alg %q is not allowed for issuer %q (allowed: %s)", alg, a.Issuer, strings.Join(a.Algorithms, ", "))
+	}
+	if err := a.checkAudience(token); err != nil {
+		return err
+	}
+
+	keys, err := a.candidateKeys(token)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, key := range keys {
+		err := verify.Signature(raw, token, key, pins)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("no key of issuer %q verifies the token: %w", a.Issuer, errors.Join(errs...))
+}
+
+// checkAudience requires one of the anchor's audiences to be present in the token.
+func (a *Issuer) checkAudience(token *jwt.Token) error {
+	if len(a.Audiences) == 0 {
+		return nil
+	}
+	claims, _ := token.Claims.(jwt.MapClaims)
+	aud, err := claims.GetAudience()
+	if err != nil {
+		return fmt.Errorf("invalid aud claim: %w", err)
+	}
+	for _, got := range aud {
+		for _, want := range a.Audiences {
+			if got == want {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("aud %v does not contain an audience accepted for issuer %q", []string(aud), a.Issuer)
+}
+
+// candidateKeys returns the keys that may have signed the token: the JWKS key matching
+// the kid header, or every pinned key when no JWKS is configured.
+func (a *Issuer) candidateKeys(token *jwt.Token) ([]crypto.PublicKey, error) {
+	kid, _ := token.Header["kid"].(string)
+	var keys []crypto.PublicKey
+
+	if a.JWKSURI != "" || a.JWKSFile != "" {
+		jwk, err := a.key(kid)
+		if err != nil {
+			return nil, err
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("decoding key %q of issuer %q: %w", jwk.Kid, a.Issuer, err)
+		}
+		keys = append(keys, key)
+	}
+	pinned, err := a.pinnedKeys()
+	if err != nil {
+		return nil, err
+	}
+	return append(keys, pinned...), nil
+}
+
+// pinnedKeys reads the pinned keys of the anchor.
+func (a *Issuer) pinnedKeys() ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for _, path := range a.Keys {
+		data, err := utils.ReadFile(a.resolve(path))
+		if err != nil {
+			return nil, fmt.Errorf("reading pinned key of issuer %q: %w", a.Issuer, err)
+		}
+		key, err := verify.ParsePublicKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing pinned key %q of issuer %q: %w", path, a.Issuer, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Check loads the keys of the anchor, fetching its remote JWKS, so that a key set that is
+// unreachable or a key that does not parse is found before a token needs it.
+func (a *Issuer) Check() error {
+	switch {
+	case a.JWKSFile != "":
+		if _, err := a.fileKeySet(); err != nil {
+			return err
+		}
+	case a.remote != nil:
+		if err := a.remote.Check(); err != nil {
+			return fmt.Errorf("retrieving JWKS of issuer %q: %w", a.Issuer, err)
+		}
+	}
+	_, err := a.pinnedKeys()
+	return err
+}
+
+// key returns the key of the anchor's JWKS with the given kid, from its file, or from its
+// URI through the cached key set.
+func (a *Issuer) key(kid string) (*jwks.Key, error) {
+	if a.JWKSFile != "" {
+		set, err := a.fileKeySet()
+		if err != nil {
+			return nil, err
+		}
+		return set.Find(kid)
+	}
+	key, err := a.remote.Key(kid)
+	if err != nil && !errors.Is(err, jwks.ErrKeyNotFound) {
+		return nil, fmt.Errorf("retrieving JWKS of issuer %q: %w", a.Issuer, err)
+	}
+	return key, err
+}
+
+// fileKeySet reads the anchor's JWKS file.
+func (a *Issuer) fileKeySet() (*jwks.Set, error) {
+	data, err := utils.ReadFile(a.resolve(a.JWKSFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading JWKS of issuer %q: %w", a.Issuer, err)
+	}
+	return jwks.Parse(data)
+}
+
+// resolve makes a path relative to the trust file's directory.
+func (a *Issuer) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(a.baseDir, path)
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
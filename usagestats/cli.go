@@ -0,0 +1,86 @@
+package usagestats
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Main runs the stats subcommand with its command-line arguments, printing the usage
+// statistics to w.
+func Main(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	jsonOut := fs.Bool("json", false, "Print the statistics file as JSON")
+	file := fs.String("file", "", "Statistics file (default: usage-stats.json in the jwtdecode directory of the user configuration directory)")
+	reset := fs.Bool("reset", false, "Delete the statistics recorded")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("usage: jwtdecode stats [-json] [-reset] [-file <file>]")
+	}
+	path := *file
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return err
+		}
+	}
+	if *reset {
+		if err := Reset(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Usage statistics deleted (%s)\n", path)
+		return nil
+	}
+
+	stats, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if *jsonOut {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+	if stats.Runs == 0 {
+		fmt.Fprintf(w, "No usage statistics recorded in %s\n", path)
+		fmt.Fprintf(w, "They are recorded, locally only, by runs with -usage-stats, or with %s=1 set\n", EnvVar)
+		return nil
+	}
+	fmt.Fprintf(w, "Usage statistics of %d runs from %s to %s (%s)\n", stats.Runs,
+		stats.Since.Format("2006-01-02"), stats.Updated.Format("2006-01-02"), path)
+	writeCounts(w, "Output formats", stats.Formats)
+	writeCounts(w, "Providers", stats.Providers)
+	writeCounts(w, "Features", stats.Features)
+	return nil
+}
+
+// writeCounts prints a section of counts, the most used first.
+func writeCounts(w io.Writer, title string, counts map[string]int) {
+	fmt.Fprintf(w, "\n%s:\n", title)
+	if len(counts) == 0 {
+		fmt.Fprintln(w, "  none")
+		return
+	}
+	names := make([]string, 0, len(counts))
+	width := 0
+	for name := range counts {
+		names = append(names, name)
+		width = max(width, len(name))
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-*s  %d\n", width, name, counts[name])
+	}
+}
@@ -0,0 +1,143 @@
+// Package usagestats keeps the opt-in usage statistics of jwtdecode: counts of the output
+// formats, providers, and features (flags) used by its runs, in a local file that is never
+// transmitted, so that teams can see which features their users rely on before deprecating
+// any. No token, claim, or option value is recorded, only names.
+package usagestats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"jwtdecode/utils"
+)
+
+// EnvVar is the environment variable enabling the statistics of every run when set to a
+// true value (1, true), as the -usage-stats flag does for one run.
+const EnvVar = "JWTDECODE_USAGE_STATS"
+
+// version identifies the statistics file format.
+const version = 1
+
+// Stats are the usage statistics recorded in the statistics file.
+type Stats struct {
+	Version   int            `json:"version"`
+	Since     time.Time      `json:"since"`     // Time of the first run recorded
+	Updated   time.Time      `json:"updated"`   // Time of the last run recorded
+	Runs      int            `json:"runs"`      // Runs recorded
+	Formats   map[string]int `json:"formats"`   // Runs by output format
+	Providers map[string]int `json:"providers"` // Runs by provider (-provider)
+	Features  map[string]int `json:"features"`  // Runs by flag given, or config file field present, named by its flag
+}
+
+// Run is what a run records.
+type Run struct {
+	Format   string   // Output format
+	Provider string   // Provider; empty for none
+	Features []string // Flags given, without their values
+}
+
+// Enabled reports whether EnvVar enables the statistics.
+func Enabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(EnvVar))
+	return enabled
+}
+
+// DefaultPath returns the statistics file: usage-stats.json in the jwtdecode directory of
+// the user configuration directory (e.g., ~/.config/jwtdecode on Linux).
+func DefaultPath() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating the usage statistics file: %w", err)
+	}
+	return filepath.Join(base, "jwtdecode", "usage-stats.json"), nil
+}
+
+// Load reads the statistics file. A missing file yields empty statistics.
+func Load(path string) (*Stats, error) {
+	stats := &Stats{Version: version, Formats: map[string]int{}, Providers: map[string]int{}, Features: map[string]int{}}
+	data, err := utils.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading usage statistics: %w", err)
+	}
+	if err := json.Unmarshal(data, stats); err != nil || stats.Version != version {
+		return nil, fmt.Errorf("usage statistics %q is not a version %d statistics file", path, version)
+	}
+	for _, counts := range []*map[string]int{&stats.Formats, &stats.Providers, &stats.Features} {
+		if *counts == nil {
+			*counts = map[string]int{}
+		}
+	}
+	return stats, nil
+}
+
+// Record adds a run at now to the statistics file, creating it (readable by the user only)
+// if needed. The file is replaced at once, so that it is never left half written; runs
+// recording at the same time may lose one of their counts.
+func Record(path string, run Run, now time.Time) error {
+	stats, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if stats.Runs == 0 {
+		stats.Since = now.UTC()
+	}
+	stats.Updated = now.UTC()
+	stats.Runs++
+	if run.Format != "" {
+		stats.Formats[run.Format]++
+	}
+	if run.Provider != "" {
+		stats.Providers[run.Provider]++
+	}
+	for _, feature := range run.Features {
+		stats.Features[feature]++
+	}
+	return write(path, stats)
+}
+
+// Reset deletes the statistics file. A missing file is not an error.
+func Reset(path string) error {
+	if err := os.Remove(filepath.Clean(path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing usage statistics: %w", err)
+	}
+	return nil
+}
+
+// write replaces the statistics file by stats, through a temporary file renamed over it.
+func write(path string, stats *Stats) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding usage statistics: %w", err)
+	}
+	dir := filepath.Dir(filepath.Clean(path))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("creating usage statistics directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".usage-stats-*")
+	if err != nil {
+		return fmt.Errorf("writing usage statistics: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing usage statistics: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing usage statistics: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Clean(path)); err != nil {
+		return fmt.Errorf("writing usage statistics: %w", err)
+	}
+	return nil
+}
@@ -2,8 +2,10 @@ package utils
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 )
 
 var (
@@ -33,3 +35,71 @@ func SanitizeFilePath(p string) (string, error) {
 
 	return cleanedPath, nil
 }
+
+// CheckFileMode reports whether a file's permission bits grant access to other users.
+// It returns a descriptive error for world-readable or world-writable files, since
+// such files can expose tokens and decoded claims to anyone on a shared host.
+// Windows does not expose meaningful Unix permission bits, so the check is skipped there.
+func CheckFileMode(p string, mode os.FileMode) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	perm := mode.Perm()
+	switch {
+	case perm&0o002 != 0:
+		return fmt.Errorf("file %q is world-writable (mode %04o)", p, perm)
+	case perm&0o004 != 0:
+		return fmt.Errorf("file %q is world-readable (mode %04o)", p, perm)
+	}
+	return nil
+}
+
+// ReadFile reads a file through an os.Root scoped to its directory, preventing
+// directory traversal (G304) in the same way as the token and config readers.
+func ReadFile(p string) ([]byte, error) {
+	cleaned, err := SanitizeFilePath(p)
+	if err != nil {
+		return nil, err
+	}
+	absPath, err := filepath.Abs(cleaned)
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path for %q: %w", p, err)
+	}
+	root, err := os.OpenRoot(filepath.Dir(absPath))
+	if err != nil {
+		return nil, fmt.Errorf("opening root for %q: %w", p, err)
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+	data, err := root.ReadFile(filepath.Base(absPath))
+	if err != nil {
+		return nil, fmt.Errorf("reading %q: %w", p, err)
+	}
+	return data, nil
+}
+
+// OpenFile opens a file for streaming reads through an os.Root scoped to its directory,
+// with the same traversal protection as ReadFile. The caller must close the file.
+func OpenFile(p string) (*os.File, error) {
+	cleaned, err := SanitizeFilePath(p)
+	if err != nil {
+		return nil, err
+	}
+	absPath, err := filepath.Abs(cleaned)
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path for %q: %w", p, err)
+	}
+	root, err := os.OpenRoot(filepath.Dir(absPath))
+	if err != nil {
+		return nil, fmt.Errorf("opening root for %q: %w", p, err)
+	}
+	defer func() {
+		_ = root.Close()
+	}()
+	f, err := root.Open(filepath.Base(absPath))
+	if err != nil {
+		return nil, fmt.Errorf("opening %q: %w", p, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,223 @@
+package vc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims carrying W3C Verifiable Credentials and Presentations in the JWT encoding.
+const (
+	ClaimCredential   = "vc"
+	ClaimPresentation = "vp"
+)
+
+// Base contexts of the W3C Verifiable Credentials Data Model (v1.1 and v2.0).
+var baseContexts = map[string]bool{
+	"https://www.w3.org/2018/credentials/v1": true,
+	"https://www.w3.org/ns/credentials/v2":   true,
+}
+
+// Annotate expands the vc and vp claims into the credential and presentation they encode
+// (VC Data Model §6.3.2, JWT decoding) and validates their structure. It returns the
+// annotations to add to the output, keyed by output claim name ("vc_annotation",
+// "vp_annotation"), and the structural problems found.
+func Annotate(claims jwt.MapClaims) (map[string]interface{}, []string) {
+	annotations := map[string]interface{}{}
+	var problems []string
+
+	if raw, ok := claims[ClaimCredential]; ok {
+		credential, credProblems := expandCredential(raw, claims)
+		annotations[ClaimCredential+"_annotation"] = annotation("credential", credential, credProblems)
+		for _, p := range credProblems {
+			problems = append(problems, "vc: "+p)
+		}
+	}
+	if raw, ok := claims[ClaimPresentation]; ok {
+		presentation, presProblems := expandPresentation(raw, claims)
+		annotations[ClaimPresentation+"_annotation"] = annotation("presentation", presentation, presProblems)
+		for _, p := range presProblems {
+			problems = append(problems, "vp: "+p)
+		}
+	}
+	if len(annotations) == 0 {
+		return nil, nil
+	}
+	return annotations, problems
+}
+
+// EmbeddedCredentials returns the credential JWTs embedded in the vp claim.
+func EmbeddedCredentials(claims jwt.MapClaims) []string {
+	vpObj, ok := claims[ClaimPresentation].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	items, ok := vpObj["verifiableCredential"].([]interface{})
+	if !ok {
+		items = []interface{}{vpObj["verifiableCredential"]}
+	}
+	var jwts []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			jwts = append(jwts, s)
+		}
+	}
+	return jwts
+}
+
+// annotation builds the output structure for an expanded credential or presentation.
+func annotation(kind string, expanded map[string]interface{}, problems []string) map[string]interface{} {
+	result := map[string]interface{}{
+		kind:    expanded,
+		"valid": len(problems) == 0,
+	}
+	if len(problems) > 0 {
+		list := make([]interface{}, len(problems))
+		for i, p := range problems {
+			list[i] = p
+		}
+		result["problems"] = list
+	}
+	return result
+}
+
+// expandCredential reconstructs a credential from the vc claim and the registered claims
+// that replace its properties: iss (issuer), nbf (issuanceDate), exp (expirationDate),
+// jti (id), and sub (credentialSubject.id).
+func expandCredential(raw interface{}, claims jwt.MapClaims) (map[string]interface{}, []string) {
+	vcObj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, []string{"claim must be a JSON object"}
+	}
+	problems := checkContextAndType(vcObj, "VerifiableCredential")
+
+	credential := copyObject(vcObj)
+	if iss, ok := claims["iss"].(string); ok {
+		credential["issuer"] = iss
+	} else if _, ok := vcObj["issuer"]; !ok {
+		problems = append(problems, "issuer is missing (no iss claim)")
+	}
+	setDate(credential, "issuanceDate", claims["nbf"])
+	setDate(credential, "expirationDate", claims["exp"])
+	if jti, ok := claims["jti"].(string); ok {
+		credential["id"] = jti
+	}
+
+	switch subject := vcObj["credentialSubject"].(type) {
+	case map[string]interface{}:
+		expanded := copyObject(subject)
+		if sub, ok := claims["sub"].(string); ok {
+			expanded["id"] = sub
+		}
+		credential["credentialSubject"] = expanded
+	case []interface{}:
+		for _, item := range subject {
+			if _, ok := item.(map[string]interface{}); !ok {
+				problems = append(problems, "credentialSubject entries must be JSON objects")
+				break
+			}
+		}
+	case nil:
+		problems = append(problems, "credentialSubject is missing")
+	default:
+		problems = append(problems, "credentialSubject must be a JSON object or array of objects")
+	}
+	return credential, problems
+}
+
+// expandPresentation reconstructs a presentation from the vp claim, decoding embedded
+// credential JWTs (without verifying them) into their expanded credentials.
+func expandPresentation(raw interface{}, claims jwt.MapClaims) (map[string]interface{}, []string) {
+	vpObj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, []string{"claim must be a JSON object"}
+	}
+	problems := checkContextAndType(vpObj, "VerifiablePresentation")
+
+	presentation := copyObject(vpObj)
+	if iss, ok := claims["iss"].(string); ok {
+		presentation["holder"] = iss
+	}
+	if jti, ok := claims["jti"].(string); ok {
+		presentation["id"] = jti
+	}
+
+	embedded, ok := vpObj["verifiableCredential"]
+	if !ok {
+		return presentation, problems
+	}
+	items, ok := embedded.([]interface{})
+	if !ok {
+		items = []interface{}{embedded}
+	}
+	expanded := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		switch v := item.(type) {
+		case string:
+			// Embedded credentials are usually JWTs themselves
+			inner, _, err := jwt.NewParser().ParseUnverified(v, jwt.MapClaims{})
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("verifiableCredential %d is not a decodable JWT: %v", i+1, err))
+				expanded = append(expanded, v)
+				continue
+			}
+			innerClaims, _ := inner.Claims.(jwt.MapClaims)
+			credential, credProblems := expandCredential(innerClaims[ClaimCredential], innerClaims)
+			for _, p := range credProblems {
+				problems = append(problems, fmt.Sprintf("verifiableCredential %d: %s", i+1, p))
+			}
+			expanded = append(expanded, credential)
+		case map[string]interface{}:
+			expanded = append(expanded, v)
+		default:
+			problems = append(problems, fmt.Sprintf("verifiableCredential %d must be a JWT or JSON object", i+1))
+		}
+	}
+	presentation["verifiableCredential"] = expanded
+	return presentation, problems
+}
+
+// checkContextAndType validates the @context and type properties shared by credentials
+// and presentations.
+func checkContextAndType(obj map[string]interface{}, requiredType string) []string {
+	var problems []string
+	contexts, ok := obj["@context"].([]interface{})
+	if !ok || len(contexts) == 0 {
+		problems = append(problems, "@context must be a non-empty array")
+	} else if first, _ := contexts[0].(string); !baseContexts[first] {
+		problems = append(problems, fmt.Sprintf("first @context %q is not a W3C credentials base context", first))
+	}
+
+	found := false
+	switch t := obj["type"].(type) {
+	case string:
+		found = t == requiredType
+	case []interface{}:
+		for _, item := range t {
+			if item == requiredType {
+				found = true
+			}
+		}
+	}
+	if !found {
+		problems = append(problems, fmt.Sprintf("type must include %q", requiredType))
+	}
+	return problems
+}
+
+// setDate sets a date property from a NumericDate claim, formatted as RFC 3339.
+func setDate(obj map[string]interface{}, property string, value interface{}) {
+	if seconds, ok := value.(float64); ok {
+		obj[property] = time.Unix(int64(seconds), 0).UTC().Format(time.RFC3339)
+	}
+}
+
+// copyObject returns a shallow copy of a JSON object.
+func copyObject(obj map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,49 @@
+package verify
+
+import (
+	"fmt"
+)
+
+// supportedCrit lists the header extensions this tool implements and may therefore
+// accept when they are marked critical. None are implemented yet.
+var supportedCrit = map[string]bool{}
+
+// registeredHeaders are the header parameters defined by JWS and JWE, which must not
+// appear in crit.
+var registeredHeaders = map[string]bool{
+	"alg": true, "jku": true, "jwk": true, "kid": true, "x5u": true, "x5c": true,
+	"x5t": true, "x5t#S256": true, "typ": true, "cty": true, "crit": true,
+	"enc": true, "zip": true,
+}
+
+// UnsupportedCrit checks the crit header (RFC 7515 §4.1.11) and returns the critical
+// extensions it names that this tool does not implement. A token naming any of them
+// must be rejected. An error is returned when crit is malformed: not a non-empty list
+// of strings, naming registered header parameters, or naming parameters that are absent.
+func UnsupportedCrit(header map[string]interface{}) ([]string, error) {
+	value, ok := header["crit"]
+	if !ok {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("crit header must be a non-empty array")
+	}
+	var unsupported []string
+	for _, item := range list {
+		name, ok := item.(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("crit header must only contain header parameter names")
+		}
+		if registeredHeaders[name] {
+			return nil, fmt.Errorf("crit header must not name registered parameter %q", name)
+		}
+		if _, present := header[name]; !present {
+			return nil, fmt.Errorf("crit header names %q, which is not present in the header", name)
+		}
+		if !supportedCrit[name] {
+			unsupported = append(unsupported, name)
+		}
+	}
+	return unsupported, nil
+}
@@ -0,0 +1,138 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/findings"
+	"jwtdecode/jwks"
+)
+
+// HeaderKeyPolicy governs whether keys supplied by the token itself, through the jwk
+// and jku headers, may be used for verification. The zero value trusts neither.
+type HeaderKeyPolicy struct {
+	AllowEmbeddedJWK bool     // Verify with the public key embedded in the jwk header
+	JKUAllowlist     []string // HTTPS URL prefixes from which jku key sets may be fetched
+	Pins             Pins     // Keys that the header keys must be among
+}
+
+// HeaderKeys verifies the token with the keys referenced by its jwk and jku headers,
+// as far as the policy permits. Every such header produces a finding, whether it was
+// used or ignored, so header-supplied keys are never trusted silently. It returns the
+// headers that verified the signature.
+func HeaderKeys(raw string, token *jwt.Token, policy HeaderKeyPolicy) ([]string, []findings.Finding, error) {
+	var verified []string
+	var found []findings.Finding
+
+	if value, ok := token.Header["jwk"]; ok {
+		if !policy.AllowEmbeddedJWK {
+			found = append(found, findings.Finding{
+				ID:       "embedded-jwk-ignored",
+				Severity: findings.SeverityMedium,
+				Message:  "token carries a jwk header; the embedded key was not used for verification (see -allow-embedded-jwk)",
+			})
+		} else {
+			if err := verifyEmbeddedJWK(raw, token, value, policy.Pins); err != nil {
+				return nil, nil, err
+			}
+			verified = append(verified, "jwk")
+			found = append(found, findings.Finding{
+				ID:       "embedded-jwk-used",
+				Severity: findings.SeverityLow,
+				Message:  "signature was verified with the key embedded in the jwk header, which proves possession of that key but not the identity of the issuer",
+			})
+		}
+	}
+
+	if value, ok := token.Header["jku"]; ok {
+		jku, _ := value.(string)
+		if !jkuAllowed(jku, policy.JKUAllowlist) {
+			found = append(found, findings.Finding{
+				ID:       "jku-not-allowed",
+				Severity: findings.SeverityHigh,
+				Message:  fmt.Sprintf("token carries a jku header pointing to %q, which is not in the allowlist; the key set was not fetched (see -jku-allowlist)", jku),
+			})
+		} else {
+			if err := verifyJKU(raw, token, jku, policy.Pins); err != nil {
+				return nil, nil, err
+			}
+			verified = append(verified, "jku")
+			found = append(found, findings.Finding{
+				ID:       "jku-used",
+				Severity: findings.SeverityLow,
+				Message:  fmt.Sprintf("signature was verified with a key fetched from the allowlisted jku %q", jku),
+			})
+		}
+	}
+	return verified, found, nil
+}
+
+// verifyEmbeddedJWK verifies the token with the public key in its jwk header.
+func verifyEmbeddedJWK(raw string, token *jwt.Token, value interface{}, pins Pins) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding jwk header: %w", err)
+	}
+	var key jwks.Key
+	if err := json.Unmarshal(data, &key); err != nil {
+		return fmt.Errorf("jwk header is not a JSON Web Key: %w", err)
+	}
+	if key.D != "" {
+		return fmt.Errorf("jwk header contains private key material")
+	}
+	pub, err := key.PublicKey()
+	if err != nil {
+		return fmt.Errorf("jwk header: %w", err)
+	}
+	if err := Signature(raw, token, pub, pins); err != nil {
+		return fmt.Errorf("verifying with jwk header: %w", err)
+	}
+	return nil
+}
+
+// verifyJKU verifies the token with the key matching its kid in the key set at jku.
+func verifyJKU(raw string, token *jwt.Token, jku string, pins Pins) error {
+	set, err := jwks.Fetch(jku)
+	if err != nil {
+		return fmt.Errorf("fetching jku key set: %w", err)
+	}
+	kid, _ := token.Header["kid"].(string)
+	key, err := set.Find(kid)
+	if err != nil {
+		return fmt.Errorf("jku key set: %w", err)
+	}
+	pub, err := key.PublicKey()
+	if err != nil {
+		return fmt.Errorf("jku key set: %w", err)
+	}
+	if err := Signature(raw, token, pub, pins); err != nil {
+		return fmt.Errorf("verifying with jku key set: %w", err)
+	}
+	return nil
+}
+
+// jkuAllowed reports whether jku is an HTTPS URL under one of the allowlisted prefixes.
+// Scheme and host must match exactly and the path must match on a segment boundary, so
+// an entry for https://example.com/keys admits neither https://example.com.evil.test
+// nor https://example.com/keys-evil.
+func jkuAllowed(jku string, allowlist []string) bool {
+	target, err := url.Parse(jku)
+	if err != nil || target.Scheme != "https" || target.User != nil || target.Host == "" {
+		return false
+	}
+	for _, entry := range allowlist {
+		prefix, err := url.Parse(entry)
+		if err != nil || prefix.Scheme != "https" || !strings.EqualFold(prefix.Host, target.Host) {
+			continue
+		}
+		base := strings.TrimSuffix(prefix.Path, "/")
+		if target.Path == base || strings.HasPrefix(target.Path, base+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package verify
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// WordlistResult is the outcome of a weak HMAC secret check.
+type WordlistResult struct {
+	Found    bool   // Whether a candidate secret verified the token
+	Secret   string // The verifying secret, if found
+	Attempts int    // Number of candidates tried
+}
+
+// CheckHMACWordlist tries each line of candidates as the HMAC secret of an HS256/384/512
+// token, stopping at the first secret that verifies or after maxAttempts candidates.
+// It is intended for authorized testing of one's own services for weak secrets.
+func CheckHMACWordlist(raw string, token *jwt.Token, candidates io.Reader, maxAttempts int) (*WordlistResult, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		return nil, fmt.Errorf("wordlist check requires an HS256/384/512 token, got alg %q", token.Method.Alg())
+	}
+	idx := strings.LastIndex(raw, ".")
+	if idx < 0 {
+		return nil, fmt.Errorf("token has no signature segment")
+	}
+	sig, err := DecodeSegment(raw[idx+1:])
+	if err != nil {
+		return nil, fmt.Errorf("decoding signature: %w", err)
+	}
+	signingInput := raw[:idx]
+
+	result := &WordlistResult{}
+	scanner := bufio.NewScanner(candidates)
+	for scanner.Scan() {
+		if result.Attempts >= maxAttempts {
+			break
+		}
+		result.Attempts++
+		candidate := strings.TrimRight(scanner.Text(), "\r")
+		if token.Method.Verify(signingInput, sig, []byte(candidate)) == nil {
+			result.Found = true
+			result.Secret = candidate
+			return result, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading wordlist: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package verify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyVerifier verifies tokens with a public key supplied by the user, restricted to the
+// algorithms allowed for it. The algorithm is taken from the token header, so a key can
+// verify any of the algorithms of its type (e.g., RS256 and PS512 for an RSA key).
+type KeyVerifier struct {
+	key  crypto.PublicKey
+	algs []string
+}
+
+// NewKeyVerifier parses the public key in PEM data (see ParsePublicKeyPEM) and allows the
+// algorithms of algs, or every algorithm of the key type when algs is empty. Algorithms
+// that cannot be verified with the key are rejected.
+func NewKeyVerifier(pemData []byte, algs []string) (*KeyVerifier, error) {
+	key, err := ParsePublicKeyPEM(pemData)
+	if err != nil {
+		return nil, err
+	}
+	return NewPublicKeyVerifier(key, algs)
+}
+
+// NewPublicKeyVerifier is NewKeyVerifier for a parsed public key, e.g. from a JWKS.
+func NewPublicKeyVerifier(key crypto.PublicKey, algs []string) (*KeyVerifier, error) {
+	keyAlgs, err := KeyAlgs(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(algs) == 0 {
+		return &KeyVerifier{key: key, algs: keyAlgs}, nil
+	}
+	for _, alg := range algs {
+		if !slices.Contains(keyAlgs, alg) {
+			return nil, fmt.Errorf("algorithm %q cannot be verified with this key; must be one of: %s", alg, strings.Join(keyAlgs, ", "))
+		}
+	}
+	return &KeyVerifier{key: key, algs: slices.Clone(algs)}, nil
+}
+
+// Algs returns the allowed algorithms.
+func (v *KeyVerifier) Algs() []string {
+	return v.algs
+}
+
+// Verify checks that the algorithm of the token header is allowed and verifies the
+// signature of the raw token with the key, which must be among pins.
+func (v *KeyVerifier) Verify(raw string, token *jwt.Token, pins Pins) error {
+	alg := token.Method.Alg()
+	if !slices.Contains(v.algs, alg) {
+		return fmt.Errorf("header alg %s is not allowed; must be one of: %s", alg, strings.Join(v.algs, ", "))
+	}
+	return Signature(raw, token, v.key, pins)
+}
+
+// KeyAlgs returns the JWS algorithms a public key verifies: RS256/384/512 and PS256/384/512
+// for RSA keys, the ES algorithm of the curve for ECDSA keys, and EdDSA for Ed25519 keys.
+func KeyAlgs(key crypto.PublicKey) ([]string, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve.Params().BitSize {
+		case 256:
+			return []string{"ES256"}, nil
+		case 384:
+			return []string{"ES384"}, nil
+		case 521:
+			return []string{"ES512"}, nil
+		}
+		return nil, fmt.Errorf("unsupported ECDSA curve %s", k.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return []string{"EdDSA"}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
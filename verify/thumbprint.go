@@ -0,0 +1,71 @@
+package verify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Pins are the RFC 7638 thumbprints that verification keys must match, for every
+// verification source (providers, trust anchors, DID documents). No pins accept any key.
+// Pins are not modified once built, so they are safe for concurrent use.
+type Pins map[string]bool
+
+// NewPins returns the pins of the given thumbprints, with or without base64 padding.
+func NewPins(thumbprints ...string) Pins {
+	pins := make(Pins, len(thumbprints))
+	for _, t := range thumbprints {
+		pins[strings.TrimRight(t, "=")] = true
+	}
+	return pins
+}
+
+// Check returns an error if keys are pinned and key is not one of them.
+func (pins Pins) Check(key crypto.PublicKey) error {
+	if len(pins) == 0 {
+		return nil
+	}
+	thumbprint, err := Thumbprint(key)
+	if err != nil {
+		return fmt.Errorf("computing key thumbprint for pinning: %w", err)
+	}
+	if !pins[thumbprint] {
+		return fmt.Errorf("key thumbprint %s is not pinned", thumbprint)
+	}
+	return nil
+}
+
+// Thumbprint computes the base64url-encoded SHA-256 JWK thumbprint (RFC 7638) of a public key,
+// or of an HMAC secret (raw bytes, as an "oct" key). The hash input is the JSON object of the
+// key's required members in lexicographic order.
+func Thumbprint(key crypto.PublicKey) (string, error) {
+	var canonical string
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`,
+			b64(big.NewInt(int64(k.E)).Bytes()), b64(k.N.Bytes()))
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		canonical = fmt.Sprintf(`{"crv":"%s","kty":"EC","x":"%s","y":"%s"}`,
+			k.Curve.Params().Name, b64(k.X.FillBytes(make([]byte, size))), b64(k.Y.FillBytes(make([]byte, size))))
+	case ed25519.PublicKey:
+		canonical = fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, b64(k))
+	case []byte:
+		canonical = fmt.Sprintf(`{"k":"%s","kty":"oct"}`, b64(k))
+	default:
+		return "", fmt.Errorf("unsupported key type %T", key)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return b64(sum[:]), nil
+}
+
+// b64 encodes bytes as unpadded base64url.
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
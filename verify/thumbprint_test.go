@@ -0,0 +1,140 @@
+package verify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// b64Decode decodes an unpadded base64url test vector.
+func b64Decode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decoding %q: %v", s, err)
+	}
+	return b
+}
+
+func TestThumbprint(t *testing.T) {
+	tests := []struct {
+		name string
+		key  func(t *testing.T) crypto.PublicKey
+		want string
+	}{
+		{
+			// RFC 7638, section 3.1
+			name: "RSA",
+			key: func(t *testing.T) crypto.PublicKey {
+				n := b64Decode(t, "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECP"+
+					"ebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZ"+
+					"gnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF"+
+					"44-csFCur-kEgU8awapJzKnqDKgw")
+				return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+			},
+			want: "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs",
+		},
+		{
+			// The P-256 key of RFC 7517, appendix A.1
+			name: "EC",
+			key: func(t *testing.T) crypto.PublicKey {
+				return &ecdsa.PublicKey{
+					Curve: elliptic.P256(),
+					X:     new(big.Int).SetBytes(b64Decode(t, "MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4")),
+					Y:     new(big.Int).SetBytes(b64Decode(t, "4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM")),
+				}
+			},
+			want: "cn-I_WNMClehiVp51i_0VpOENW1upEerA8sEam5hn-s",
+		},
+		{
+			// RFC 8037, appendix A.3
+			name: "OKP",
+			key: func(t *testing.T) crypto.PublicKey {
+				return ed25519.PublicKey(b64Decode(t, "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"))
+			},
+			want: "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k",
+		},
+		{
+			// The symmetric key of RFC 7517, appendix A.3
+			name: "oct",
+			key: func(t *testing.T) crypto.PublicKey {
+				return b64Decode(t, "GawgguFyGrWKav7AX4VKUg")
+			},
+			want: "k1JnWRfC-5zzmL72vXIuBgTLfVROXBakS4OmGcrMCoc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Thumbprint(tt.key(t))
+			if err != nil {
+				t.Fatalf("Thumbprint: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Thumbprint = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignatureHMAC(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	secret := []byte("a shared secret of the issuer and the audience")
+	secretThumbprint, err := Thumbprint(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		signKey []byte
+		key     []byte
+		pins    Pins
+		wantErr error
+		wantOK  bool
+	}{
+		{name: "secret", signKey: secret, key: secret, wantOK: true},
+		{name: "pinned secret", signKey: secret, key: secret, pins: NewPins(secretThumbprint), wantOK: true},
+		{name: "secret not pinned", signKey: secret, key: secret, pins: NewPins("k1JnWRfC-5zzmL72vXIuBgTLfVROXBakS4OmGcrMCoc")},
+		{name: "public key as secret", signKey: publicPEM, key: publicPEM, wantErr: ErrAlgConfusion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "test"}).SignedString(tt.signKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, _, err := jwt.NewParser().ParseUnverified(raw, jwt.MapClaims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = Signature(raw, token, tt.key, tt.pins)
+			switch {
+			case tt.wantOK && err != nil:
+				t.Errorf("Signature: %v", err)
+			case !tt.wantOK && err == nil:
+				t.Errorf("Signature succeeded, want an error")
+			case tt.wantErr != nil && !errors.Is(err, tt.wantErr):
+				t.Errorf("Signature: %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
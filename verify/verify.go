@@ -0,0 +1,96 @@
+package verify
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"jwtdecode/jwterrors"
+)
+
+// ErrAlgConfusion is returned when an HMAC token would be verified with public key
+// material as the secret, the signature of an RS256→HS256 algorithm confusion attack.
+var ErrAlgConfusion = errors.New("refusing to verify an HMAC token with a public key as the secret (suspected algorithm confusion)")
+
+// DecodeSegment decodes a token segment leniently, accepting standard or URL-safe
+// base64 alphabets with or without padding.
+func DecodeSegment(seg string) ([]byte, error) {
+	seg = strings.TrimRight(seg, "=")
+	seg = strings.NewReplacer("+", "-", "/", "_").Replace(seg)
+	return base64.RawURLEncoding.DecodeString(seg)
+}
+
+// Signature verifies the signature of the original raw token with the given key,
+// using the algorithm of the parsed token. The signing input is taken verbatim from
+// the raw token, so non-canonical encodings are verified exactly as the issuer signed them.
+// Keys that are not among pins are rejected before the signature is checked.
+func Signature(raw string, token *jwt.Token, key interface{}, pins Pins) error {
+	if _, isHMAC := token.Method.(*jwt.SigningMethodHMAC); isHMAC && !isSymmetricSecret(key) {
+		return ErrAlgConfusion
+	}
+	if err := pins.Check(key); err != nil {
+		return err
+	}
+	idx := strings.LastIndex(raw, ".")
+	if idx < 0 {
+		return fmt.Errorf("token has no signature segment")
+	}
+	sig, err := DecodeSegment(raw[idx+1:])
+	if err != nil {
+		return fmt.Errorf("decoding signature: %w", err)
+	}
+	if err := token.Method.Verify(raw[:idx], sig, key); err != nil {
+		return fmt.Errorf("signature verification failed: %w", jwterrors.Wrap(jwterrors.ErrSignatureInvalid, err))
+	}
+	return nil
+}
+
+// isSymmetricSecret reports whether key is usable as an HMAC secret: raw bytes that are
+// not PEM-encoded public key material.
+func isSymmetricSecret(key interface{}) bool {
+	secret, ok := key.([]byte)
+	if !ok {
+		return false
+	}
+	_, err := ParsePublicKeyPEM(secret)
+	return err != nil
+}
+
+// ParsePublicKeyPEM parses the first public key in PEM data. PKIX public keys
+// ("PUBLIC KEY"), PKCS#1 RSA public keys ("RSA PUBLIC KEY"), and certificates
+// ("CERTIFICATE") are accepted.
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM public key or certificate found")
+		}
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("parsing public key: %w", err)
+			}
+			return key, nil
+		case "RSA PUBLIC KEY":
+			key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("parsing RSA public key: %w", err)
+			}
+			return key, nil
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("parsing certificate: %w", err)
+			}
+			return cert.PublicKey, nil
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package warnings
+
+// Codes of the warnings raised while decoding a token.
+const (
+	CodeCritMalformed   = "crit-malformed"       // The crit header is not a list of extension names
+	CodeCritUnsupported = "crit-unsupported"     // The crit header names extensions that are not implemented
+	CodeAlgConfusion    = "alg-confusion"        // Verification was skipped for a suspected algorithm confusion
+	CodeHeaderKey       = "header-key"           // A key supplied by the token header (jwk, jku)
+	CodeAlgNone         = "alg-none"             // The token is not signed
+	CodeUnverified      = "unverified-signature" // No signature verification was configured for the token
+	CodeCertBinding     = "cert-binding"         // The client certificate does not match the cnf claim
+	CodeOIDCBinding     = "oidc-binding"         // A nonce, at_hash, or c_hash check failed
+	CodeEvent           = "event"                // A security event does not validate
+	CodeCredential      = "credential"           // A verifiable credential does not validate
+	CodeEpochHeuristic  = "epoch-heuristic"      // The unit of an epoch claim was guessed
+	CodeTruncated       = "claim-truncated"      // Long values of a claim were truncated by -truncate-values
+	CodeInputFormat     = "input-format"         // Parts of the input were not decoded (e.g., extra JWS JSON signatures)
+	CodeIssuerMismatch  = "issuer-mismatch"      // The iss claim is not the issuer of -issuer-discovery
+	CodeQueryNoMatch    = "query-no-match"       // A -query expression matched no claim
+	CodePipeline        = "pipeline"             // A pipeline step left claims unchanged (e.g., a rename onto an existing claim)
+)
+
+// ClaimWarnings is the output key holding the list of warnings.
+const ClaimWarnings = "warnings"
+
+// Warning is a soft issue found while decoding a token, which does not fail the run but
+// may matter to downstream automation. Severities are those of the findings package.
+type Warning struct {
+	Code     string `json:"code"`     // One of the Code constants
+	Severity string `json:"severity"` // One of the findings Severity constants
+	Message  string `json:"message"`  // Explanation of the warning
+}
+
+// Collector gathers the warnings of one token as it goes through the decode pipeline.
+type Collector struct {
+	print func(string) // Reports a warning on stderr
+	list  []Warning
+}
+
+// NewCollector returns a collector reporting the warnings it is asked to print with print.
+func NewCollector(print func(string)) *Collector {
+	return &Collector{print: print}
+}
+
+// Warn records a warning and reports it on stderr.
+func (c *Collector) Warn(code, severity, message string) {
+	c.Add(code, severity, message)
+	if c.print != nil {
+		c.print(message)
+	}
+}
+
+// Add records a warning without reporting it on stderr, for soft issues that are only
+// worth noting in the output (e.g., an unverified signature, the common case of decoding).
+func (c *Collector) Add(code, severity, message string) {
+	c.list = append(c.list, Warning{Code: code, Severity: severity, Message: message})
+}
+
+// List returns the warnings recorded so far, in order.
+func (c *Collector) List() []Warning {
+	return c.list
+}
+
+// ToValue converts warnings into generic values that every output formatter can render.
+func ToValue(list []Warning) []interface{} {
+	values := make([]interface{}, 0, len(list))
+	for _, w := range list {
+		values = append(values, map[string]interface{}{
+			"code":     w.Code,
+			"severity": w.Severity,
+			"message":  w.Message,
+		})
+	}
+	return values
+}
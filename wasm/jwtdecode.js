@@ -0,0 +1,63 @@
+// jwtdecode.js loads the WebAssembly build of jwtdecode (GOOS=js GOARCH=wasm) and exposes
+// its decoder to JavaScript. wasm_exec.js, shipped with Go in $(go env GOROOT)/lib/wasm,
+// must be loaded first: it defines the Go class that runs the module.
+//
+//   import { load } from "./jwtdecode.js";
+//   const jwtdecode = await load("jwtdecode.wasm");
+//   const result = jwtdecode.decode(token, { pipeline: [{ step: "convert-epoch" }] });
+//   console.log(result.claims, result.verified, result.output);
+
+// JWTDecodeError is thrown when a token cannot be decoded; response holds the full response
+// of the decoder, including the header and claims decoded before the error, if any.
+export class JWTDecodeError extends Error {
+  constructor(response) {
+    super(response.error);
+    this.name = "JWTDecodeError";
+    this.response = response;
+  }
+}
+
+let loading;
+
+// load instantiates the module once, from a URL or path (fetched), a byte buffer, or a
+// compiled WebAssembly.Module, and resolves to the decoder.
+export function load(source = "jwtdecode.wasm") {
+  if (!loading) {
+    loading = instantiate(source).catch((err) => {
+      loading = undefined;
+      throw err;
+    });
+  }
+  return loading;
+}
+
+async function instantiate(source) {
+  if (typeof globalThis.Go !== "function") {
+    throw new Error("wasm_exec.js must be loaded before jwtdecode.js");
+  }
+  const go = new globalThis.Go();
+  let instance;
+  if (source instanceof WebAssembly.Module) {
+    instance = await WebAssembly.instantiate(source, go.importObject);
+  } else if (source instanceof ArrayBuffer || ArrayBuffer.isView(source)) {
+    ({ instance } = await WebAssembly.instantiate(source, go.importObject));
+  } else {
+    ({ instance } = await WebAssembly.instantiateStreaming(fetch(source), go.importObject));
+  }
+  // run returns when the module exits, which it does not do; the decoder is registered
+  // before it blocks
+  go.run(instance);
+  return { decode };
+}
+
+// decode decodes a token with options named as the fields of the jwtdecode config file
+// (e.g., outputFormat, hmacSecret, validate, pipeline). It returns the header, claims,
+// verified status, warnings, findings, and formatted output of the token, and throws a
+// JWTDecodeError if the token cannot be decoded or does not verify.
+function decode(token, options = {}) {
+  const response = JSON.parse(globalThis.jwtdecodeDecode(String(token), JSON.stringify(options)));
+  if (response.error) {
+    throw new JWTDecodeError(response);
+  }
+  return response;
+}
@@ -0,0 +1,30 @@
+// Command wasm is the WebAssembly build of the jwtdecode library, so that web tools and
+// WASI hosts run the decoding, verification, and claims processing of the command line.
+//
+// Built with GOOS=js GOARCH=wasm, it registers the jwtdecodeDecode(token, optionsJSON)
+// JavaScript function, which returns the JSON response of jwtdecode.DecodeJSON; the
+// jwtdecode.js wrapper loads the module and exposes it as decode(token, options). Built
+// with GOOS=wasip1 GOARCH=wasm, it reads the token from standard input and the options
+// from its first argument, and writes the response to standard output.
+package main
+
+import (
+	"syscall/js"
+
+	"jwtdecode/pkg/jwtdecode"
+)
+
+func main() {
+	js.Global().Set("jwtdecodeDecode", js.FuncOf(func(_ js.Value, args []js.Value) any {
+		if len(args) == 0 || args[0].Type() != js.TypeString {
+			return `{"verified":false,"error":"jwtdecodeDecode expects a token string"}`
+		}
+		var options []byte
+		if len(args) > 1 && args[1].Type() == js.TypeString {
+			options = []byte(args[1].String())
+		}
+		return string(jwtdecode.DecodeJSON(args[0].String(), options))
+	}))
+	// The function is called from JavaScript for as long as the page runs
+	select {}
+}
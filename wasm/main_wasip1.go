@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"jwtdecode/pkg/jwtdecode"
+)
+
+// maxInput bounds the token read from standard input; Options.MaxTokenSize applies after.
+const maxInput = 64 << 20
+
+func main() {
+	token, err := io.ReadAll(io.LimitReader(os.Stdin, maxInput))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: reading token: %v\n", err)
+		os.Exit(1)
+	}
+	var options []byte
+	if len(os.Args) > 1 {
+		options = []byte(os.Args[1])
+	}
+	resp := jwtdecode.DecodeJSON(string(token), options)
+	fmt.Println(string(resp))
+
+	var failed struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(resp, &failed) == nil && failed.Error != "" {
+		os.Exit(1)
+	}
+}